@@ -1,9 +1,13 @@
+//go:build ignore
+
 /*
 Copyright 2021 IBM All Rights Reserved.
 /wini testing git in visal code ide
 SPDX-License-Identifier: Apache-2.0
 */
 //wini testing line
+
+// assetTransfer.go is a program of its own, built only when named: go run assetTransfer.go
 package main
 
 import (
@@ -3,23 +3,55 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
 	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
+	"unicode"
 
 	"github.com/hyperledger/fabric-gateway/pkg/client"
 	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
 	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/msp"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	_ "modernc.org/sqlite"
 )
 
 const (
@@ -32,295 +64,5422 @@ const (
 	gatewayPeer  = "peer0.org1.example.com"
 )
 
-type GitCommit struct {
-	CommitHash    string `json:"CommitHash"`
+// submitTracker records the transactions that have been proposed but have not returned their commit status,
+// so that an interrupted run can wait for them and report the ones left in an unknown state.
+type submitTracker struct {
+	mu       sync.Mutex
+	pending  map[string]string
+	draining bool
+	done     chan struct{}
+}
+
+// inFlightSubmits is used by submitTransaction
+var inFlightSubmits = &submitTracker{pending: map[string]string{}}
+
+// start registers a transaction, or fails once draining has begun so that no new work is started.
+func (t *submitTracker) start(transactionID, name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.draining {
+		return fmt.Errorf("shutting down, %s was not submitted", name)
+	}
+	t.pending[transactionID] = name
+	return nil
+}
+
+func (t *submitTracker) finish(transactionID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.pending, transactionID)
+	if t.draining && len(t.pending) == 0 && t.done != nil {
+		close(t.done)
+		t.done = nil
+	}
+}
+
+// drain stops new submits and waits up to timeout for the pending ones to return their commit status.
+// It returns the transactions that were still pending, sorted by transaction ID.
+func (t *submitTracker) drain(timeout time.Duration) []string {
+	t.mu.Lock()
+	t.draining = true
+	done := make(chan struct{})
+	if len(t.pending) == 0 {
+		close(done)
+	} else {
+		t.done = done
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var pending []string
+	for transactionID, name := range t.pending {
+		pending = append(pending, fmt.Sprintf("%s (%s)", transactionID, name))
+	}
+	sort.Strings(pending)
+	return pending
+}
+
+// repositoryQueue submits the transactions of one repository one at a time, in the order they were queued,
+// while those of different repositories run in parallel. Two concurrent submits for one repository read and
+// write its version record at once, so one of them would fail MVCC validation at commit.
+type repositoryQueue struct {
+	mu       sync.Mutex
+	maxDepth int
+	depth    int
+	pending  map[string][]*queuedSubmit
+}
+
+// queuedSubmit is one transaction waiting in a repositoryQueue; done receives its result
+type queuedSubmit struct {
+	run  func() error
+	done chan error
+}
+
+// submitQueue is used by submitRepositoryTransaction; -queueDepth sets its maxDepth
+var submitQueue = newRepositoryQueue(0)
+
+// newRepositoryQueue returns a queue holding at most maxDepth queued and running transactions across all
+// repositories; zero means no limit.
+func newRepositoryQueue(maxDepth int) *repositoryQueue {
+	return &repositoryQueue{maxDepth: maxDepth, pending: map[string][]*queuedSubmit{}}
+}
+
+// enqueue adds run to the queue of a repository and returns the channel its result is sent on. It fails
+// without queueing when the queue is full. Each repository with pending work has one goroutine, which runs
+// its transactions in order and ends when none are left.
+func (q *repositoryQueue) enqueue(repository string, run func() error) (<-chan error, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.maxDepth > 0 && q.depth >= q.maxDepth {
+		return nil, fmt.Errorf("the submit queue is full (%d transactions queued or running)", q.depth)
+	}
+
+	submit := &queuedSubmit{run: run, done: make(chan error, 1)}
+	q.depth++
+	q.pending[repository] = append(q.pending[repository], submit)
+	if len(q.pending[repository]) == 1 {
+		go q.drainRepository(repository)
+	}
+	return submit.done, nil
+}
+
+// drainRepository runs the queued transactions of a repository until its queue is empty.
+func (q *repositoryQueue) drainRepository(repository string) {
+	for {
+		q.mu.Lock()
+		submit := q.pending[repository][0]
+		q.mu.Unlock()
+
+		submit.done <- submit.run()
+
+		q.mu.Lock()
+		q.depth--
+		q.pending[repository] = q.pending[repository][1:]
+		if len(q.pending[repository]) == 0 {
+			delete(q.pending, repository)
+			q.mu.Unlock()
+			return
+		}
+		q.mu.Unlock()
+	}
+}
+
+// do queues run for a repository and waits for its result.
+func (q *repositoryQueue) do(repository string, run func() error) error {
+	done, err := q.enqueue(repository, run)
+	if err != nil {
+		return err
+	}
+	return <-done
+}
+
+// depths returns the number of queued and running transactions of each repository with pending work.
+func (q *repositoryQueue) depths() map[string]int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	depths := make(map[string]int, len(q.pending))
+	for repository, pending := range q.pending {
+		depths[repository] = len(pending)
+	}
+	return depths
+}
+
+// submitRepositoryTransaction submits a transaction that writes to a repository through submitQueue, so that
+// it never runs concurrently with another submit for the same repository.
+func submitRepositoryTransaction(ctx context.Context, contract *client.Contract, repository, name string, args ...string) (result []byte, err error) {
+	err = submitQueue.do(repository, func() error {
+		result, err = submitTransaction(ctx, contract, name, args...)
+		return err
+	})
+	return result, err
+}
+
+// drainOnSignal waits for in-flight submits when the process is interrupted, then exits.
+func drainOnSignal(timeout time.Duration) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		fmt.Printf("Received %v, waiting up to %v for in-flight transactions to commit\n", sig, timeout)
+		pending := inFlightSubmits.drain(timeout)
+		if len(pending) == 0 {
+			fmt.Println("All in-flight transactions returned their commit status")
+		} else {
+			fmt.Println("Transactions still pending, their commit status is unknown:")
+			for _, transaction := range pending {
+				fmt.Printf("  %s\n", transaction)
+			}
+		}
+		os.Exit(1)
+	}()
+}
+
+// transientFlag collects the repeatable -transient key=value flag into a transient map.
+type transientFlag map[string][]byte
+
+func (t transientFlag) String() string {
+	keys := make([]string, 0, len(t))
+	for key := range t {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// Set adds one key=value pair. A value starting with "base64:" is decoded, so binary data can be passed.
+func (t transientFlag) Set(pair string) error {
+	key, value, found := strings.Cut(pair, "=")
+	if !found || key == "" {
+		return fmt.Errorf("invalid transient data %q, must be key=value", pair)
+	}
+	if _, ok := t[key]; ok {
+		return fmt.Errorf("duplicate transient key %q", key)
+	}
+	if encoded, ok := strings.CutPrefix(value, "base64:"); ok {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("invalid base64 value of transient key %q: %w", key, err)
+		}
+		t[key] = decoded
+		return nil
+	}
+	t[key] = []byte(value)
+	return nil
+}
+
+// transientData is sent as the transient map of every submit. Transient data reaches the endorsing peers
+// only; it is not part of the transaction written to the ledger, unless the chaincode stores it itself.
+var transientData = transientFlag{}
+
+// mspIDPattern matches well-formed MSP IDs such as Org1MSP or org-2.example.com
+var mspIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// endorsingOrgsFlag is the comma separated -endorsingOrgs list of MSP IDs.
+type endorsingOrgsFlag []string
+
+func (f *endorsingOrgsFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return strings.Join(*f, ",")
+}
+
+// Set replaces the list. Every MSP ID must be well-formed and appear once.
+func (f *endorsingOrgsFlag) Set(list string) error {
+	var mspIDs []string
+	seen := make(map[string]bool)
+	for _, mspID := range strings.Split(list, ",") {
+		mspID = strings.TrimSpace(mspID)
+		if !mspIDPattern.MatchString(mspID) {
+			return fmt.Errorf("invalid MSP ID %q, must be letters, digits, '.', '_' or '-'", mspID)
+		}
+		if seen[mspID] {
+			return fmt.Errorf("duplicate MSP ID %q", mspID)
+		}
+		seen[mspID] = true
+		mspIDs = append(mspIDs, mspID)
+	}
+	*f = mspIDs
+	return nil
+}
+
+// endorsingOrgs, when set, are the only organizations asked to endorse submits, instead of the set the gateway
+// discovers from the endorsement policies. It saves the gateway asking more peers than needed, but the set must
+// still satisfy the chaincode and any collection endorsement policy, or the transaction fails validation.
+var endorsingOrgs endorsingOrgsFlag
+
+// maxCountFlag caps count flags such as -limit, -keep and -queueDepth well above any sensible value
+const maxCountFlag = 1000000
+
+// intFlag is an integer flag that only accepts values from min to max, so that a negative count or an absurd
+// limit is rejected while the flags are parsed instead of failing, or misbehaving, later.
+type intFlag[T int | int64] struct {
+	value    *T
+	min, max T
+}
+
+// newIntFlag sets value to its default and returns a flag value that parses into it within the given bounds.
+// The default itself may lie outside them, for example -1 meaning not set.
+func newIntFlag[T int | int64](value *T, defaultValue, min, max T) *intFlag[T] {
+	*value = defaultValue
+	return &intFlag[T]{value: value, min: min, max: max}
+}
+
+func (f *intFlag[T]) String() string {
+	// The flag package calls String on a zero value to detect default values
+	if f == nil || f.value == nil {
+		return "0"
+	}
+	return strconv.FormatInt(int64(*f.value), 10)
+}
+
+// Set parses a decimal integer, rejecting empty, malformed, overflowing and out of range values.
+func (f *intFlag[T]) Set(value string) error {
+	if value == "" {
+		return fmt.Errorf("must not be empty")
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		if strings.HasPrefix(value, "-") {
+			return fmt.Errorf("must be at least %d", f.min)
+		}
+		return fmt.Errorf("must be at most %d", f.max)
+	}
+	if err != nil {
+		return fmt.Errorf("must be a whole number")
+	}
+	if parsed < int64(f.min) {
+		return fmt.Errorf("must be at least %d", f.min)
+	}
+	if parsed > int64(f.max) {
+		return fmt.Errorf("must be at most %d", f.max)
+	}
+	*f.value = T(parsed)
+	return nil
+}
+
+// evaluateTarget, when set by -targetPeer, receives the read queries of evaluateTransaction in place of
+// the contract it is given. Submits are unaffected.
+var evaluateTarget *client.Contract
+
+// latencyBuckets are the upper bounds, in seconds, of the transaction latency histogram
+var latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// clientMetrics counts transactions by kind, function and outcome and keeps a latency histogram per kind and
+// function, served in the Prometheus text format by -metrics.
+type clientMetrics struct {
+	mu        sync.Mutex
+	counts    map[metricKey]int
+	latencies map[metricKey]*latencyHistogram
+}
+
+// metricKey labels a metric; outcome is empty for latency histograms
+type metricKey struct {
+	kind, function, outcome string
+}
+
+// latencyHistogram holds cumulative bucket counts, as Prometheus expects them
+type latencyHistogram struct {
+	buckets []int
+	count   int
+	sum     float64
+}
+
+// metrics is used by evaluateTransaction and submitTransaction; it stays nil, and costs nothing, unless
+// -metrics is set
+var metrics *clientMetrics
+
+// observe records one transaction. It does nothing on a nil receiver.
+func (m *clientMetrics) observe(kind, function string, err error, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[metricKey{kind, function, outcome}]++
+	histogram, ok := m.latencies[metricKey{kind, function, ""}]
+	if !ok {
+		histogram = &latencyHistogram{buckets: make([]int, len(latencyBuckets))}
+		m.latencies[metricKey{kind, function, ""}] = histogram
+	}
+	seconds := elapsed.Seconds()
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			histogram.buckets[i]++
+		}
+	}
+	histogram.count++
+	histogram.sum += seconds
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (m *clientMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sortedKeys := func(keys []metricKey) []metricKey {
+		sort.Slice(keys, func(i, j int) bool {
+			a, b := keys[i], keys[j]
+			if a.kind != b.kind {
+				return a.kind < b.kind
+			}
+			if a.function != b.function {
+				return a.function < b.function
+			}
+			return a.outcome < b.outcome
+		})
+		return keys
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	var out strings.Builder
+	out.WriteString("# HELP gittransfer_transactions_total Transactions sent to the gateway by kind, function and outcome.\n")
+	out.WriteString("# TYPE gittransfer_transactions_total counter\n")
+	var countKeys []metricKey
+	for key := range m.counts {
+		countKeys = append(countKeys, key)
+	}
+	for _, key := range sortedKeys(countKeys) {
+		fmt.Fprintf(&out, "gittransfer_transactions_total{kind=%q,function=%q,outcome=%q} %d\n", key.kind, key.function, key.outcome, m.counts[key])
+	}
+
+	out.WriteString("# HELP gittransfer_transaction_duration_seconds Time from sending a transaction to its result, including the commit wait of submits.\n")
+	out.WriteString("# TYPE gittransfer_transaction_duration_seconds histogram\n")
+	var latencyKeys []metricKey
+	for key := range m.latencies {
+		latencyKeys = append(latencyKeys, key)
+	}
+	for _, key := range sortedKeys(latencyKeys) {
+		histogram := m.latencies[key]
+		for i, bound := range latencyBuckets {
+			fmt.Fprintf(&out, "gittransfer_transaction_duration_seconds_bucket{kind=%q,function=%q,le=%q} %d\n", key.kind, key.function, strconv.FormatFloat(bound, 'f', -1, 64), histogram.buckets[i])
+		}
+		fmt.Fprintf(&out, "gittransfer_transaction_duration_seconds_bucket{kind=%q,function=%q,le=\"+Inf\"} %d\n", key.kind, key.function, histogram.count)
+		fmt.Fprintf(&out, "gittransfer_transaction_duration_seconds_sum{kind=%q,function=%q} %g\n", key.kind, key.function, histogram.sum)
+		fmt.Fprintf(&out, "gittransfer_transaction_duration_seconds_count{kind=%q,function=%q} %d\n", key.kind, key.function, histogram.count)
+	}
+
+	out.WriteString("# HELP gittransfer_submit_queue_depth Transactions queued or running per repository, see -queueDepth.\n")
+	out.WriteString("# TYPE gittransfer_submit_queue_depth gauge\n")
+	depths := submitQueue.depths()
+	repositories := make([]string, 0, len(depths))
+	for repository := range depths {
+		repositories = append(repositories, repository)
+	}
+	sort.Strings(repositories)
+	for _, repository := range repositories {
+		fmt.Fprintf(&out, "gittransfer_submit_queue_depth{repository=%q} %d\n", repository, depths[repository])
+	}
+
+	fmt.Fprint(w, out.String())
+}
+
+// serveMetrics enables metrics collection and serves it at /metrics on addr in the background.
+func serveMetrics(addr string) {
+	metrics = &clientMetrics{counts: map[metricKey]int{}, latencies: map[metricKey]*latencyHistogram{}}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	go func() {
+		err := http.ListenAndServe(addr, mux)
+		if err != nil {
+			fmt.Printf("*** Metrics endpoint on %s stopped: %v\n", addr, err)
+		}
+	}()
+	fmt.Printf("*** Serving metrics at http://%s/metrics\n", addr)
+}
+
+// envelopeOutput wraps printed results in a resultEnvelope. outputOperation names the last evaluated
+// transaction and outputChannel and outputChaincode where it ran.
+var (
+	envelopeOutput  bool
+	outputOperation string
+	outputChannel   string
+	outputChaincode string
+)
+
+// gitTimeout bounds every git command the client runs; zero waits forever
+var gitTimeout time.Duration
+
+// compactOutput selects compact JSON for printed results instead of the default indented form
+var compactOutput bool
+
+// RepositoryVersion struct to match the smart contract definition
+type RepositoryVersion struct {
 	Repository    string `json:"Repository"`
-	CommitMessage string `json:"CommitMessage"`
-	Author        string `json:"Author"`
 	VersionNumber int    `json:"VersionNumber"`
-	Timestamp     string `json:"Timestamp"`
-	//RemoteURL     string `json:"RemoteURL"`
+	Archived      bool   `json:"Archived"`
+	ForkedFrom    string `json:"ForkedFrom,omitempty"`
+	DisplayName   string `json:"DisplayName,omitempty"`
+}
+
+type GitCommit struct {
+	CommitHash           string              `json:"commitHash"`
+	Repository           string              `json:"repository"`
+	CommitMessage        string              `json:"commitMessage"`
+	Author               string              `json:"author"`
+	VersionNumber        int                 `json:"versionNumber"`
+	Timestamp            string              `json:"timestamp"`
+	BuildStatus          string              `json:"buildStatus,omitempty"`
+	ParentHashes         []string            `json:"parentHashes,omitempty"`
+	IsMerge              bool                `json:"isMerge,omitempty"`
+	Trailers             map[string][]string `json:"trailers,omitempty"`
+	Insertions           int                 `json:"insertions,omitempty"`
+	Deletions            int                 `json:"deletions,omitempty"`
+	FilesChanged         int                 `json:"filesChanged,omitempty"`
+	AuthorTimestamp      string              `json:"authorTimestamp,omitempty"`
+	CommitTimestamp      string              `json:"commitTimestamp,omitempty"`
+	RecordedTimestamp    string              `json:"recordedTimestamp,omitempty"`
+	SchemaVersion        int                 `json:"schemaVersion,omitempty"`
+	SecurityLevel        string              `json:"securityLevel,omitempty"`
+	AdvisoryURL          string              `json:"advisoryURL,omitempty"`
+	SubmittedBy          string              `json:"submittedBy,omitempty"`
+	SubmitterCertificate string              `json:"submitterCertificate,omitempty"`
+	SubmitterSignature   string              `json:"submitterSignature,omitempty"`
+	IssueRefs            []string            `json:"issueRefs,omitempty"`
+	MessageBlob          string              `json:"messageBlob,omitempty"`
+	Pinned               bool                `json:"pinned,omitempty"`
+	HashAttestations     []*HashAttestation  `json:"hashAttestations,omitempty"`
+	ReviewApprovals      []*ReviewApproval   `json:"reviewApprovals,omitempty"`
+	//RemoteURL     string `json:"remoteURL"`
+}
+
+// HashAttestation records that an organization checked a commit hash against a real git object
+type HashAttestation struct {
+	MSPID      string `json:"MSPID"`
+	VerifiedAt string `json:"VerifiedAt"`
+}
+
+// ReviewApproval records that an organization approved a commit, until ExpiresAt if set
+type ReviewApproval struct {
+	MSPID      string `json:"MSPID"`
+	ApprovedAt string `json:"ApprovedAt"`
+	ExpiresAt  string `json:"ExpiresAt,omitempty"`
+}
+
+// VersionMilestone is one version of a repository in the timeline returned by GetRepositoryTimeline
+type VersionMilestone struct {
+	Version    int              `json:"Version"`
+	Push       *PushTransaction `json:"Push,omitempty"`
+	CommitHash string           `json:"CommitHash,omitempty"`
+	Timestamp  string           `json:"Timestamp,omitempty"`
+	Initial    bool             `json:"Initial,omitempty"`
+	Gap        bool             `json:"Gap,omitempty"`
+}
+
+// PushTransaction struct to match the smart contract definition
+type PushTransaction struct {
+	Repository  string `json:"repository"`
+	RemoteURL   string `json:"remoteURL"`
+	Timestamp   string `json:"timestamp"`
+	Version     int    `json:"version"`
+	CommitHash  string `json:"commitHash"` // Add this field
+	Message     string `json:"message,omitempty"`
+	ApprovedBy  string `json:"approvedBy,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	TxID        string `json:"txID,omitempty"`
+}
+
+func main() {
+
+	// Define and parse command-line flags
+	var (
+		createFlag              bool
+		pushFlag                bool
+		approvedBy              string
+		environment             string
+		currentFlag             bool
+		readFlag                bool
+		existsFlag              bool
+		readManyFlag            bool
+		hashes                  string
+		getAllFlag              bool
+		commitHash              string
+		repository              string
+		commitMessage           string
+		author                  string
+		remoteURL               string
+		getPushTransactionsFlag bool
+		unpushedFlag            bool
+		getConfigFlag           bool
+		setConfig               string
+		archiveFlag             bool
+		unarchiveFlag           bool
+		listReposFlag           bool
+		buildStatusFlag         bool
+		patchJSON               string
+		updateFlag              bool
+		buildStatus             string
+		mergeFlag               bool
+		getMergesFlag           bool
+		parents                 string
+		fields                  string
+		strictFieldsFlag        bool
+		migratePushKeysFlag     bool
+		registerRepoFlag        bool
+		listRegisteredFlag      bool
+		peerDiffFlag            bool
+		otherEndpoint           string
+		otherTLSCertPath        string
+		asOf                    string
+		byTrailerFlag           bool
+		trailerKey              string
+		trailerValue            string
+		drainTimeout            time.Duration
+		gitStatsFlag            bool
+		churnFlag               bool
+		authorsFlag             bool
+		churnStart              string
+		churnEnd                string
+		verifyTxFlag            bool
+		getBlockFlag            bool
+		blockNumber             int64
+		txStatusFlag            bool
+		txID                    string
+		migrateScopedFlag       bool
+		deleteFlag              bool
+		forceFlag               bool
+		cascadeFlag             bool
+		tombstoneFlag           bool
+		tombstonesFlag          bool
+		deleteReason            string
+		referencesFlag          bool
+		historyFlag             bool
+		targetPeer              string
+		targetTLSCertPath       string
+		grantAccessFlag         bool
+		revokeAccessFlag        bool
+		getAccessFlag           bool
+		accessMSPID             string
+		invokeFunction          string
+		autoRecordFlag          bool
+		syncAllFlag             bool
+		syncRoot                string
+		verifyHashFlag          bool
+		attestFlag              bool
+		reviewFlag              bool
+		reviewTTL               time.Duration
+		waitForFlag             bool
+		waitTimeout             time.Duration
+		autoRecordPath          string
+		autoRecordInterval      time.Duration
+		autoRecordStateFile     string
+		autoPushFlag            bool
+		queueDepth              int
+		signFlag                bool
+		verifySignatureFlag     bool
+		timelineFlag            bool
+		validateDAGFlag         bool
+		topoFlag                bool
+		versionGapsFlag         bool
+		scoreFlag               bool
+		topCommitsFlag          bool
+		topLimit                int
+		pruneFlag               bool
+		pruneHistoryFlag        bool
+		pruneBefore             string
+		pruneKeep               int
+		flagSecurityFlag        bool
+		bySecurityFlag          bool
+		byRemoteFlag            bool
+		byIssueFlag             bool
+		byTypeFlag              bool
+		byRepoFlag              bool
+		richQuery               string
+		commitType              string
+		summaryFlag             bool
+		forkFlag                bool
+		duplicatesFlag          bool
+		pinFlag                 bool
+		unpinFlag               bool
+		pinnedFlag              bool
+		incompleteFlag          bool
+		exportCSVFlag           bool
+		provenanceFlag          bool
+		changelogFlag           bool
+		feedFlag                bool
+		fromVersion             int
+		toVersion               int
+		changelogFormat         string
+		syncFlag                bool
+		listenFlag              bool
+		syncDB                  string
+		exportOut               string
+		reconcileVersionFlag    bool
+		repairFlag              bool
+		forksFlag               bool
+		compareReposFlag        bool
+		importTagsFlag          bool
+		createTrainFlag         bool
+		addToTrainFlag          bool
+		trainName               string
+		trainDescription        string
+		snapshotFlag            bool
+		compareSnapshotsFlag    bool
+		snapshotName            string
+		otherSnapshot           string
+		otherRepository         string
+		mergeReposFlag          bool
+		forkInto                string
+		issueRefs               string
+		incrementVersionFlag    bool
+		securityLevel           string
+		advisoryURL             string
+		prettyFlag              bool
+		compactFlag             bool
+		metricsAddr             string
+		completionShell         string
+		diagnoseFlag            bool
+		payloadFile             string
+		stdinFlag               bool
+		importRepoFlag          bool
+		strictFlag              bool
+		versionNumber           int
+	)
+
+	flag.BoolVar(&createFlag, "create", false, "Create a new Git commit")
+	flag.StringVar(&payloadFile, "file", "", "With -create, read the commit from this JSON file instead of -hash, -repo, -message and -author; with -importTags, the tag mapping; with -importRepo, the commits")
+	flag.BoolVar(&stdinFlag, "stdin", false, "Read what -create or -importRepo would read from -file from standard input instead, for use in pipelines")
+	flag.BoolVar(&importRepoFlag, "importRepo", false, "Record the commits of -file or -stdin in -repo: a JSON array of commit objects with the fields of a commit file, or one commit per line as printed by git log --reverse --format='%H%x09%an%x09%aI%x09%cI%x09%s'")
+	flag.BoolVar(&strictFlag, "strict", false, "Reject unknown fields in the -file or -stdin payload instead of ignoring them")
+	flag.BoolVar(&pushFlag, "push", false, "Handle git push")
+	flag.BoolVar(&readFlag, "read", false, "Read a Git commit by its hash, within -repo if given")
+	flag.BoolVar(&readManyFlag, "readMany", false, "Read the Git commits given as a comma-separated -hashes in one call")
+	flag.StringVar(&hashes, "hashes", "", "Comma-separated commit hashes for -readMany")
+	flag.BoolVar(&existsFlag, "exists", false, "Check if a Git commit exists, or several given as a comma-separated -hash")
+	flag.BoolVar(&getAllFlag, "getAll", false, "Get the Git commits of -repo, or of every repository without it; the unscoped listing scans the whole ledger and is much slower")
+	flag.StringVar(&commitHash, "hash", "", "The hash of the Git commit")
+	flag.StringVar(&repository, "repo", "", "The repository of the Git commit")
+	flag.StringVar(&commitMessage, "message", "", "The commit message, or with -push a message recorded on the push, e.g. the build target")
+	flag.StringVar(&approvedBy, "approvedBy", "", "The organization that approved the push for -push")
+	flag.BoolVar(&currentFlag, "current", false, "Show the push of -repo currently deployed to -env")
+	flag.StringVar(&environment, "env", "", "The environment a -push was deployed to, such as dev, staging or prod; with -getPushTransactions -repo, list the pushes deployed there")
+	flag.StringVar(&author, "author", "", "The author of the Git commit")
+	flag.StringVar(&remoteURL, "url", "", "The remote repository URL")
+	flag.BoolVar(&getPushTransactionsFlag, "getPushTransactions", false, "Get all push transactions, or those of -repo in version order")
+	flag.BoolVar(&unpushedFlag, "unpushed", false, "Get the commits of a repository that were never pushed")
+	flag.BoolVar(&getConfigFlag, "getConfig", false, "Get the contract configuration")
+	flag.StringVar(&setConfig, "setConfig", "", "Replace the contract configuration with the given JSON")
+	flag.BoolVar(&archiveFlag, "archive", false, "Archive a repository so it rejects new commits and pushes (admin only)")
+	flag.BoolVar(&unarchiveFlag, "unarchive", false, "Make an archived repository writable again (admin only)")
+	flag.BoolVar(&listReposFlag, "listRepos", false, "List all repositories with their version and archived status")
+	flag.BoolVar(&buildStatusFlag, "buildStatus", false, "Update the CI build status of a Git commit")
+	flag.StringVar(&patchJSON, "patch", "", `Change fields of the commit -hash with a JSON merge patch, e.g. '{"commitMessage": "Fix typo", "filesChanged": null}'`)
+	flag.BoolVar(&updateFlag, "update", false, "Correct the message and author of the commit -hash to -message and -author")
+	flag.StringVar(&buildStatus, "status", "", "The build status: pending, building, passed or failed")
+	flag.BoolVar(&mergeFlag, "merge", false, "Create a merge commit of the two commits given by -parents")
+	flag.BoolVar(&getMergesFlag, "getMerges", false, "Get the merge commits of a repository")
+	flag.StringVar(&parents, "parents", "", "Comma-separated parent commit hashes of a merge commit")
+	flag.StringVar(&fields, "fields", "", "Comma-separated commit fields to return from -getAll, e.g. commitHash,author,versionNumber")
+	flag.BoolVar(&strictFieldsFlag, "strictFields", false, "Reject unknown names in -fields instead of ignoring them")
+	flag.BoolVar(&migratePushKeysFlag, "migratePushKeys", false, "Move push transactions to version ordered keys (admin only)")
+	flag.BoolVar(&registerRepoFlag, "registerRepo", false, "Register -repo so it is accepted when registration is required (admin only)")
+	flag.BoolVar(&listRegisteredFlag, "listRegisteredRepos", false, "List the registered repositories")
+	flag.BoolVar(&peerDiffFlag, "peerDiff", false, "Compare the commits and repository versions of the gateway peer with those of -otherEndpoint")
+	flag.StringVar(&otherEndpoint, "otherEndpoint", "", "The host:port of the peer to compare with for -peerDiff")
+	flag.StringVar(&otherTLSCertPath, "otherTLS", "", "The TLS CA certificate of the peer given by -otherEndpoint")
+	flag.StringVar(&asOf, "asOf", "", "Get the head commit of -repo as of the given RFC3339 time")
+	flag.BoolVar(&byTrailerFlag, "byTrailer", false, "Get the commits with the trailer given by -key and -value, e.g. -key Fixes -value \"#123\"")
+	flag.StringVar(&trailerKey, "key", "", "The trailer key for -byTrailer")
+	flag.StringVar(&trailerValue, "value", "", "The trailer value for -byTrailer")
+	flag.DurationVar(&drainTimeout, "drainTimeout", 30*time.Second, "How long an interrupted run waits for in-flight transactions to commit")
+	flag.BoolVar(&gitStatsFlag, "gitStats", false, "With -create, record the size of -hash from git show --shortstat in the current directory")
+	flag.BoolVar(&churnFlag, "churn", false, "Sum the insertions and deletions of -repo between -start and -end")
+	flag.BoolVar(&authorsFlag, "authors", false, "List the authors and co-authors of -repo with their commit counts and first and last contributions, most commits first")
+	flag.StringVar(&churnStart, "start", "", "The RFC3339 start time of the -churn window")
+	flag.StringVar(&churnEnd, "end", "", "The RFC3339 end time of the -churn window")
+	flag.BoolVar(&verifyTxFlag, "verifyTx", false, "Verify the endorsement signatures of the committed transaction -txid (needs qscc read access)")
+	flag.BoolVar(&txStatusFlag, "txStatus", false, "Report whether the submitted transaction -txid committed as valid, invalid or is still unknown to the peer")
+	flag.BoolVar(&getBlockFlag, "getBlock", false, "Print the block containing the transaction -txid, or block -number (needs qscc read access)")
+	flag.Var(newIntFlag(&blockNumber, -1, 0, math.MaxInt64), "number", "The block number for -getBlock")
+	flag.StringVar(&txID, "txid", "", "The transaction ID for -verifyTx, -txStatus and -getBlock")
+	flag.BoolVar(&migrateScopedFlag, "migrateScopedCommits", false, "Key commits by repository so the same hash can exist in several repositories (admin only, cannot be undone)")
+	flag.BoolVar(&deleteFlag, "delete", false, "Delete a Git commit that no other commit or push refers to")
+	flag.BoolVar(&forceFlag, "force", false, "With -delete, delete the commit even if it is still referenced (admin only)")
+	flag.BoolVar(&cascadeFlag, "cascade", false, "With -delete, also delete the push transactions of the commit instead of refusing")
+	flag.BoolVar(&tombstoneFlag, "tombstone", false, "With -delete, leave a tombstone so that reads of the commit report its deletion")
+	flag.StringVar(&deleteReason, "reason", "", "The reason recorded on the tombstone by -delete -tombstone")
+	flag.BoolVar(&tombstonesFlag, "tombstones", false, "List the tombstones of the commits deleted from -repo")
+	flag.BoolVar(&referencesFlag, "references", false, "List the commits and pushes that refer to -hash")
+	flag.BoolVar(&historyFlag, "history", false, "Show every change of the commit record -hash, oldest first, including its deletion")
+	flag.StringVar(&targetPeer, "targetPeer", "", "Send read queries to the gateway of this host:port instead of "+peerEndpoint+"; submits still follow the endorsement policy")
+	flag.StringVar(&targetTLSCertPath, "targetTLS", tlsCertPath, "The TLS CA certificate of the peer given by -targetPeer")
+	flag.BoolVar(&grantAccessFlag, "grantAccess", false, "Allow the organization -msp to write to -repo (admin only)")
+	flag.BoolVar(&revokeAccessFlag, "revokeAccess", false, "Remove the write access of -msp to -repo (admin only)")
+	flag.BoolVar(&getAccessFlag, "getAccess", false, "List the organizations allowed to write to -repo; empty means open to all")
+	flag.StringVar(&accessMSPID, "msp", "", "The MSP ID for -grantAccess and -revokeAccess")
+	flag.StringVar(&invokeFunction, "invoke", "", "Submit the named contract function with the remaining command line arguments, e.g. with -transient for private data functions")
+	flag.Var(&endorsingOrgs, "endorsingOrgs", "Comma separated MSP IDs of the only organizations to endorse submits, e.g. Org1MSP,Org2MSP; they must satisfy the endorsement policy. By default the gateway chooses")
+	flag.Var(transientData, "transient", "Pass key=value as transient data with submitted transactions (repeatable, prefix the value with base64: for binary data)")
+	flag.BoolVar(&autoRecordFlag, "autoRecord", false, "Keep recording new commits of the git repository at -path as -repo until interrupted")
+	flag.BoolVar(&syncAllFlag, "syncAll", false, "Keep recording new commits of every git repository under -root, named after its origin remote or directory, until interrupted")
+	flag.StringVar(&syncRoot, "root", "", "The directory -syncAll searches for git repositories")
+	flag.StringVar(&autoRecordPath, "path", ".", "The local git repository for -autoRecord and -verifyHash")
+	flag.BoolVar(&verifyHashFlag, "verifyHash", false, "Check that -hash is a commit in the git repository at -path and that git computes the same hash for it")
+	flag.BoolVar(&attestFlag, "attest", false, "With -verifyHash, record on the ledger that the hash was verified")
+	flag.BoolVar(&reviewFlag, "review", false, "Record that your organization reviewed and approved the commit -hash, valid for -ttl")
+	flag.DurationVar(&reviewTTL, "ttl", 0, "How long a -review approval counts towards a push, e.g. 72h; zero never expires")
+	flag.DurationVar(&autoRecordInterval, "interval", 10*time.Second, "How often -autoRecord and -syncAll check for new commits, and -waitFor for the commit")
+	flag.BoolVar(&waitForFlag, "waitFor", false, "Wait until the commit -hash is recorded, checking every -interval for up to -timeout; exits non-zero on timeout")
+	flag.DurationVar(&waitTimeout, "timeout", 2*time.Minute, "How long -waitFor waits for the commit")
+	flag.StringVar(&autoRecordStateFile, "stateFile", "", "Where -autoRecord keeps the last recorded hash (default: inside the .git directory)")
+	flag.BoolVar(&autoPushFlag, "autoPush", false, "With -autoRecord, also record a push to -url whenever HEAD advances; with -syncAll, to the origin remote")
+	flag.Var(newIntFlag(&queueDepth, 64, 0, maxCountFlag), "queueDepth", "The most transactions -syncAll queues for submission across the repositories it records in parallel; 0 means no limit")
+	flag.BoolVar(&signFlag, "sign", false, "With -create, sign the commit content with the client key so the ledger can prove who submitted it")
+	flag.BoolVar(&verifySignatureFlag, "verifySignature", false, "Check that the commit -hash still matches the signature of its submitter")
+	flag.BoolVar(&scoreFlag, "score", false, "Compute the importance score of the commit -hash")
+	flag.BoolVar(&topCommitsFlag, "topCommits", false, "List the -limit highest scoring commits of -repo")
+	flag.Var(newIntFlag(&topLimit, 10, 1, maxCountFlag), "limit", "How many commits -topCommits and -feed return")
+	flag.BoolVar(&timelineFlag, "timeline", false, "Show every version of -repo with the push that produced it")
+	flag.BoolVar(&validateDAGFlag, "validateDag", false, "Check the commit graph of -repo for dangling parents, cycles and a missing root")
+	flag.BoolVar(&versionGapsFlag, "versionGaps", false, "List the versions of -repo that no push records, e.g. lost deployments")
+	flag.BoolVar(&topoFlag, "topo", false, "List the commits of -repo with parents before their children, e.g. for release notes")
+	flag.BoolVar(&pruneFlag, "prune", false, "Delete the commits of -repo made before -before, keeping the latest -keep and any still referenced (admin only)")
+	flag.BoolVar(&pruneHistoryFlag, "pruneHistory", false, "List the pruning runs recorded for -repo")
+	flag.StringVar(&pruneBefore, "before", "", "The RFC3339 cutoff time for -prune")
+	flag.Var(newIntFlag(&pruneKeep, 10, 0, maxCountFlag), "keep", "How many of the latest commits -prune always keeps")
+	flag.BoolVar(&flagSecurityFlag, "flagSecurity", false, "Flag the commit -hash with the security level -level and optional -advisory (security team only)")
+	flag.BoolVar(&incrementVersionFlag, "incrementVersion", false, "Increment the version of -repo, retrying if another client increments it concurrently")
+	flag.BoolVar(&summaryFlag, "summary", false, "Show totals of repositories, commits, pushes and authors across the ledger")
+	flag.BoolVar(&reconcileVersionFlag, "reconcileVersion", false, "Check the version counter of -repo against the versions of its commits")
+	flag.BoolVar(&repairFlag, "repair", false, "With -reconcileVersion, raise a version counter that is behind its commits (admin only)")
+	flag.BoolVar(&exportCSVFlag, "exportCsv", false, "Write the Git commits of -repo, or of every repository, as CSV to -out")
+	flag.BoolVar(&provenanceFlag, "provenance", false, "Write a provenance document for the push of -repo at -version to -out: the commit, its author and submitter, reviews, build and deployment")
+	flag.Var(newIntFlag(&versionNumber, 0, 1, math.MaxInt32), "version", "The repository version for -provenance")
+	flag.BoolVar(&changelogFlag, "changelog", false, "Write a changelog of the commits of -repo from -fromVersion to -toVersion to -out, grouped into features, fixes and other changes")
+	flag.Var(newIntFlag(&fromVersion, 1, 1, math.MaxInt32), "fromVersion", "The first repository version in -changelog")
+	flag.Var(newIntFlag(&toVersion, 0, 0, math.MaxInt32), "toVersion", "The last repository version in -changelog; 0 means the latest")
+	flag.BoolVar(&feedFlag, "feed", false, "Write the -limit most recent commits of -repo as an Atom feed to -out, linking each to the remote of the latest push")
+	flag.StringVar(&changelogFormat, "format", "markdown", "The -changelog format, markdown or text")
+	flag.BoolVar(&syncFlag, "sync", false, "Copy every commit and push event of the ledger into the SQLite database -db, then follow new ones until interrupted")
+	flag.BoolVar(&listenFlag, "listen", false, "Print the commit and push events of new transactions as they are committed, until interrupted")
+	flag.StringVar(&syncDB, "db", "gittransfer.db", "The SQLite database -sync writes; it resumes from the last event it holds")
+	flag.StringVar(&exportOut, "out", "", "The file written by -exportCsv, -provenance, -changelog or -feed; standard output if empty")
+	flag.BoolVar(&pinFlag, "pin", false, "Pin the commit -hash so that pruning never removes it (admin only)")
+	flag.BoolVar(&unpinFlag, "unpin", false, "Let pruning remove the pinned commit -hash again (admin only)")
+	flag.BoolVar(&pinnedFlag, "pinned", false, "List the pinned commits of -repo")
+	flag.BoolVar(&incompleteFlag, "incomplete", false, "List the commits of -repo that lack recommended fields, such as the author, with the fields each lacks")
+	flag.BoolVar(&duplicatesFlag, "duplicates", false, "List the commit hashes recorded in more than one repository")
+	flag.BoolVar(&forkFlag, "fork", false, "Fork the repository -repo into the new repository -into")
+	flag.BoolVar(&forksFlag, "forks", false, "List the forks of the repository -repo")
+	flag.BoolVar(&compareReposFlag, "compareRepos", false, "List the commits only in -repo, only in -other and in both, e.g. to check that a fork is in sync")
+	flag.BoolVar(&importTagsFlag, "importTags", false, "Tag commits of -repo from a -file mapping tag names to commit hashes, skipping tags that already exist")
+	flag.BoolVar(&createTrainFlag, "createTrain", false, "Create the release train -train with -description, to group commits of several repositories released together")
+	flag.BoolVar(&addToTrainFlag, "addToTrain", false, "Add the commit -hash to the release train -train")
+	flag.StringVar(&trainName, "train", "", "The release train for -createTrain and -addToTrain; on its own, list the commits of the train")
+	flag.StringVar(&trainDescription, "description", "", "The description of the release train for -createTrain")
+	flag.BoolVar(&snapshotFlag, "snapshot", false, "Record the commits, version, remote heads and tags of -repo as the snapshot -name")
+	flag.BoolVar(&compareSnapshotsFlag, "compareSnapshots", false, "List what changed in -repo from the snapshot -name to the snapshot -with")
+	flag.StringVar(&snapshotName, "name", "", "The snapshot for -snapshot, or the earlier one for -compareSnapshots")
+	flag.StringVar(&otherSnapshot, "with", "", "The later snapshot for -compareSnapshots")
+	flag.StringVar(&otherRepository, "other", "", "The repository -repo is compared with by -compareRepos")
+	flag.BoolVar(&mergeReposFlag, "mergeRepos", false, "Move the commits and pushes of the repository -repo into the repository -into")
+	flag.StringVar(&forkInto, "into", "", "The name of the new repository for -fork, or the target repository for -mergeRepos")
+	flag.BoolVar(&byRepoFlag, "byRepo", false, "List the commits of -repo in commit time order, reading only that repository")
+	flag.StringVar(&richQuery, "query", "", `List the commits matching a CouchDB Mango query or just its selector, e.g. '{"author": "Alice", "insertions": {"$gt": 100}}'; needs CouchDB as the state database`)
+	flag.BoolVar(&byIssueFlag, "byIssue", false, "List the commits linked to the issue reference -issue")
+	flag.BoolVar(&byTypeFlag, "byType", false, "List the commits of -repo whose message starts with the Conventional Commits -type, e.g. feat: or feat(api):")
+	flag.StringVar(&commitType, "type", "", "The Conventional Commits type for -byType, such as feat, fix or chore")
+	flag.StringVar(&issueRefs, "issue", "", "The issue reference for -byIssue, or comma separated references to link with -create, e.g. JIRA-123,GH-45")
+	flag.BoolVar(&byRemoteFlag, "byRemote", false, "List the commits that were pushed to the remote -url")
+	flag.BoolVar(&bySecurityFlag, "bySecurity", false, "List the commits flagged with the security level -level")
+	flag.StringVar(&securityLevel, "level", "", "The security level for -flagSecurity and -bySecurity: none, low, medium, high or critical")
+	flag.StringVar(&advisoryURL, "advisory", "", "The advisory URL for -flagSecurity")
+	flag.StringVar(&metricsAddr, "metrics", "", "Serve Prometheus metrics of the transactions this client sends at this address, e.g. :9090")
+	flag.BoolVar(&identityFromEnv, "identityFromEnv", false, "Read the client certificate and key as PEM text from FABRIC_CERT_PEM and FABRIC_KEY_PEM instead of files")
+	flag.DurationVar(&gitTimeout, "gitTimeout", 30*time.Second, "Kill git commands that run longer than this (0 disables the limit)")
+	flag.BoolVar(&envelopeOutput, "envelope", false, "Wrap read results in an object with the operation, result count, channel, chaincode and time")
+	flag.BoolVar(&prettyFlag, "pretty", true, "Print results as indented JSON")
+	flag.BoolVar(&compactFlag, "compact", false, "Print results as compact JSON (overrides -pretty)")
+	flag.StringVar(&completionShell, "completion", "", "Print a completion script for bash, zsh or fish and exit")
+	flag.BoolVar(&diagnoseFlag, "diagnose", false, "Check the client identity, TLS certificates and peer connection step by step, then exit")
+	flag.Usage = printUsage
+	// parse flags
+	flag.Parse()
+	err := checkOperation()
+	if err != nil {
+		exitUsage(err)
+	}
+	if completionShell != "" {
+		err := printCompletion(os.Stdout, completionShell)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+	compactOutput = compactFlag || !prettyFlag
+	submitQueue.maxDepth = queueDepth
+	if metricsAddr != "" {
+		serveMetrics(metricsAddr)
+	}
+	drainOnSignal(drainTimeout)
+
+	chaincodeName := "git" // Adjust according to your deployment
+	if ccname := os.Getenv("CHAINCODE_NAME"); ccname != "" {
+		chaincodeName = ccname
+	}
+	channelName := "mychannel" // Adjust according to your deployment
+	if cname := os.Getenv("CHANNEL_NAME"); cname != "" {
+		channelName = cname
+	}
+	if diagnoseFlag {
+		if !runDiagnostics(channelName, chaincodeName) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Setup gRPC connection and client identity. They are created once and shared by every operation,
+	// including the long-running -autoRecord and -syncAll modes. The gateway, network and contract hold no
+	// per-call state after Connect and may be used from several goroutines, as -syncAll does for its submits
+	// while the -metrics endpoint is scraped. outputOperation is written by every evaluate, so a mode that
+	// evaluates concurrently would need to pass it per request instead.
+	clientConnection := newGrpcConnection()
+	defer clientConnection.Close()
+
+	id := newIdentity()
+	sign := newSign()
+
+	gw, err := client.Connect(
+		id,
+		client.WithSign(sign),
+		client.WithClientConnection(clientConnection),
+		client.WithEvaluateTimeout(evaluateTimeout),
+		client.WithEndorseTimeout(endorseTimeout),
+		client.WithSubmitTimeout(submitTimeout),
+		client.WithCommitStatusTimeout(commitStatusTimeout),
+	)
+	if err != nil {
+		fmt.Printf("Failed to connect to gateway: %v\n", err)
+		return
+	}
+	defer gw.Close()
+
+	network := gw.GetNetwork(channelName)
+	contract := network.GetContract(chaincodeName)
+	outputChannel, outputChaincode = channelName, chaincodeName
+
+	if targetPeer != "" {
+		targetGateway, targetConnection, err := connectPeerGateway(targetPeer, targetTLSCertPath, id, sign)
+		if err != nil {
+			fmt.Printf("Failed to connect to %s: %v\n", targetPeer, err)
+			return
+		}
+		defer targetConnection.Close()
+		defer targetGateway.Close()
+		evaluateTarget = targetGateway.GetNetwork(channelName).GetContract(chaincodeName)
+		fmt.Printf("*** Read queries go to %s\n", targetPeer)
+	}
+
+	// The root context of every gateway call. Nothing cancels it yet; each helper bounds its calls with the
+	// gateway timeouts and reports a cancellation like any other failure.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Execute smart contract functions based on flags
+	if createFlag && (payloadFile != "" || stdinFlag) {
+		createGitCommitFromFile(ctx, contract, sign, payloadFile, stdinFlag, strictFlag, signFlag)
+	} else if createFlag && gitStatsFlag {
+		createGitCommitWithStats(ctx, contract, commitHash, repository, commitMessage, author)
+	} else if createFlag && signFlag {
+		createSignedGitCommit(ctx, contract, sign, commitHash, repository, commitMessage, author)
+	} else if createFlag && issueRefs != "" {
+		createGitCommitWithIssues(ctx, contract, commitHash, repository, commitMessage, author, issueRefs)
+	} else if createFlag {
+		createGitCommit(ctx, contract, commitHash, repository, commitMessage, author)
+	} else if readFlag && repository != "" {
+		readGitCommitInRepository(ctx, contract, repository, commitHash)
+	} else if readFlag {
+		readGitCommit(ctx, contract, commitHash)
+	} else if pushFlag {
+		handleGitPush(ctx, contract, repository, remoteURL, commitHash, commitMessage, approvedBy, environment)
+	} else if currentFlag {
+		getCurrentDeployment(ctx, contract, repository, environment)
+	} else if getPushTransactionsFlag && repository != "" && environment != "" {
+		getPushesByEnvironment(ctx, contract, repository, environment)
+	} else if getPushTransactionsFlag && repository != "" {
+		getPushTransactionsByRepository(ctx, contract, repository)
+	} else if getPushTransactionsFlag {
+		getAllPushTransactions(ctx, contract)
+	} else if readManyFlag {
+		readGitCommits(ctx, contract, hashes)
+	} else if existsFlag && strings.Contains(commitHash, ",") {
+		checkGitCommitsExist(ctx, contract, strings.Split(commitHash, ","))
+	} else if existsFlag {
+		checkGitCommitExists(ctx, contract, commitHash)
+	} else if getAllFlag && fields != "" {
+		getGitCommitsProjection(ctx, contract, repository, fields, strictFieldsFlag)
+	} else if getAllFlag && repository != "" {
+		queryCommitsByRepository(ctx, contract, repository)
+	} else if getAllFlag {
+		getAllGitCommits(ctx, contract)
+	} else if unpushedFlag {
+		getUnpushedCommits(ctx, contract, repository)
+	} else if getConfigFlag {
+		getContractConfig(ctx, contract)
+	} else if setConfig != "" {
+		setContractConfig(ctx, contract, setConfig)
+	} else if archiveFlag {
+		archiveRepository(ctx, contract, repository)
+	} else if unarchiveFlag {
+		unarchiveRepository(ctx, contract, repository)
+	} else if listReposFlag {
+		listRepositories(ctx, contract)
+	} else if buildStatusFlag {
+		updateBuildStatus(ctx, contract, commitHash, buildStatus)
+	} else if patchJSON != "" {
+		patchGitCommit(ctx, contract, commitHash, patchJSON)
+	} else if updateFlag {
+		updateGitCommit(ctx, contract, commitHash, commitMessage, author)
+	} else if mergeFlag {
+		createMergeCommit(ctx, contract, commitHash, repository, parents, commitMessage, author)
+	} else if getMergesFlag {
+		getMergeCommits(ctx, contract, repository)
+	} else if migratePushKeysFlag {
+		migratePushTransactionKeys(ctx, contract)
+	} else if registerRepoFlag {
+		registerRepository(ctx, contract, repository)
+	} else if listRegisteredFlag {
+		listRegisteredRepositories(ctx, contract)
+	} else if peerDiffFlag {
+		diffPeers(ctx, contract, id, sign, channelName, chaincodeName, otherEndpoint, otherTLSCertPath)
+	} else if asOf != "" {
+		getCommitAtTime(ctx, contract, repository, asOf)
+	} else if byTrailerFlag {
+		queryCommitsByTrailer(ctx, contract, trailerKey, trailerValue)
+	} else if churnFlag {
+		getRepositoryChurn(ctx, contract, repository, churnStart, churnEnd)
+	} else if authorsFlag {
+		getRepositoryAuthors(ctx, contract, repository)
+	} else if verifyTxFlag {
+		verifyTransaction(ctx, network, channelName, txID)
+	} else if getBlockFlag {
+		getBlock(ctx, network, channelName, txID, blockNumber)
+	} else if txStatusFlag {
+		getTransactionStatus(ctx, gw, id, channelName, txID)
+	} else if migrateScopedFlag {
+		migrateToRepositoryScopedCommits(ctx, contract)
+	} else if deleteFlag && forceFlag && !tombstoneFlag {
+		forceDeleteGitCommit(ctx, contract, commitHash)
+	} else if deleteFlag {
+		deleteGitCommit(ctx, contract, commitHash, cascadeFlag, tombstoneFlag, deleteReason)
+	} else if tombstonesFlag {
+		getTombstones(ctx, contract, repository)
+	} else if referencesFlag {
+		getCommitReferences(ctx, contract, commitHash)
+	} else if historyFlag {
+		getCommitHistory(ctx, contract, commitHash)
+	} else if grantAccessFlag {
+		grantRepositoryAccess(ctx, contract, repository, accessMSPID)
+	} else if revokeAccessFlag {
+		revokeRepositoryAccess(ctx, contract, repository, accessMSPID)
+	} else if getAccessFlag {
+		getRepositoryAccess(ctx, contract, repository)
+	} else if invokeFunction != "" {
+		invokeTransaction(ctx, contract, invokeFunction, flag.Args())
+	} else if verifyHashFlag {
+		verifyHash(ctx, contract, autoRecordPath, commitHash, attestFlag)
+	} else if reviewFlag {
+		attestReview(ctx, contract, commitHash, reviewTTL)
+	} else if waitForFlag {
+		waitFor(ctx, contract, commitHash, waitTimeout, autoRecordInterval)
+	} else if autoRecordFlag {
+		autoRecord(ctx, contract, autoRecordPath, repository, remoteURL, autoRecordInterval, autoPushFlag, autoRecordStateFile)
+	} else if syncAllFlag {
+		syncAll(ctx, contract, syncRoot, autoRecordInterval, autoPushFlag)
+	} else if verifySignatureFlag {
+		verifySubmitterSignature(ctx, contract, commitHash)
+	} else if scoreFlag {
+		computeCommitScore(ctx, contract, commitHash)
+	} else if topCommitsFlag {
+		getTopCommits(ctx, contract, repository, topLimit)
+	} else if timelineFlag {
+		getRepositoryTimeline(ctx, contract, repository)
+	} else if validateDAGFlag {
+		validateRepositoryDAG(ctx, contract, repository)
+	} else if versionGapsFlag {
+		getVersionGaps(ctx, contract, repository)
+	} else if topoFlag {
+		getCommitsTopological(ctx, contract, repository)
+	} else if pruneFlag {
+		pruneCommitsBefore(ctx, contract, repository, pruneBefore, pruneKeep)
+	} else if pruneHistoryFlag {
+		getPruneHistory(ctx, contract, repository)
+	} else if flagSecurityFlag {
+		flagSecurityIssue(ctx, contract, commitHash, securityLevel, advisoryURL)
+	} else if incrementVersionFlag {
+		incrementVersionNumber(ctx, contract, repository)
+	} else if summaryFlag {
+		getLedgerSummary(ctx, contract)
+	} else if reconcileVersionFlag {
+		reconcileRepositoryVersion(ctx, contract, repository, repairFlag)
+	} else if syncFlag {
+		syncCache(ctx, network, chaincodeName, syncDB)
+	} else if listenFlag {
+		listenForEvents(ctx, network, chaincodeName)
+	} else if changelogFlag {
+		writeChangelog(ctx, contract, repository, fromVersion, toVersion, changelogFormat, exportOut)
+	} else if feedFlag {
+		writeFeed(ctx, contract, repository, topLimit, exportOut)
+	} else if provenanceFlag {
+		exportProvenance(ctx, network, contract, channelName, chaincodeName, repository, versionNumber, exportOut)
+	} else if exportCSVFlag {
+		exportCommitsCSV(ctx, contract, repository, exportOut)
+	} else if pinFlag {
+		pinCommit(ctx, contract, "PinCommit", commitHash)
+	} else if unpinFlag {
+		pinCommit(ctx, contract, "UnpinCommit", commitHash)
+	} else if pinnedFlag {
+		getPinnedCommits(ctx, contract, repository)
+	} else if incompleteFlag {
+		getIncompleteCommits(ctx, contract, repository)
+	} else if duplicatesFlag {
+		findDuplicateCommitHashes(ctx, contract)
+	} else if forkFlag {
+		forkRepository(ctx, contract, repository, forkInto)
+	} else if compareReposFlag {
+		compareRepositories(ctx, contract, repository, otherRepository)
+	} else if importTagsFlag {
+		importTags(ctx, contract, repository, payloadFile)
+	} else if importRepoFlag {
+		importRepository(ctx, contract, repository, payloadFile, stdinFlag, strictFlag)
+	} else if snapshotFlag {
+		createRepositorySnapshot(ctx, contract, repository, snapshotName)
+	} else if compareSnapshotsFlag {
+		compareSnapshots(ctx, contract, repository, snapshotName, otherSnapshot)
+	} else if createTrainFlag {
+		createReleaseTrain(ctx, contract, trainName, trainDescription)
+	} else if addToTrainFlag {
+		addCommitToTrain(ctx, contract, trainName, commitHash)
+	} else if forksFlag {
+		getForks(ctx, contract, repository)
+	} else if mergeReposFlag {
+		mergeRepositories(ctx, contract, repository, forkInto)
+	} else if richQuery != "" {
+		queryCommits(ctx, contract, richQuery)
+	} else if byRepoFlag {
+		getCommitsByRepository(ctx, contract, repository)
+	} else if byTypeFlag {
+		getCommitsByType(ctx, contract, repository, commitType)
+	} else if byIssueFlag {
+		getCommitsByIssue(ctx, contract, issueRefs)
+	} else if byRemoteFlag {
+		getCommitsByRemote(ctx, contract, remoteURL)
+	} else if bySecurityFlag {
+		getCommitsBySecurityLevel(ctx, contract, securityLevel)
+	} else if trainName != "" {
+		getTrainCommits(ctx, contract, trainName)
+	} else {
+		exitUsage(errNoOperation)
+	}
+}
+
+// operationFlags are the flags that select what the client does; every other flag changes how the selected
+// operation runs. -train is left out, as it is both an option of -createTrain and -addToTrain and, on its own,
+// the operation that lists a train.
+var operationFlags = []string{
+	"completion", "diagnose", "create", "read", "push", "current", "getPushTransactions", "readMany", "exists",
+	"getAll", "unpushed", "getConfig", "setConfig", "archive", "unarchive", "listRepos", "buildStatus", "patch",
+	"update", "merge", "getMerges", "migratePushKeys", "registerRepo", "listRegisteredRepos", "peerDiff", "asOf",
+	"byTrailer", "churn", "authors", "verifyTx", "getBlock", "txStatus", "migrateScopedCommits", "delete",
+	"tombstones", "references", "history", "grantAccess", "revokeAccess", "getAccess", "invoke", "verifyHash",
+	"review", "waitFor", "autoRecord", "syncAll", "verifySignature", "score", "topCommits", "timeline",
+	"validateDag", "versionGaps", "topo", "prune", "pruneHistory", "flagSecurity", "incrementVersion", "summary",
+	"reconcileVersion", "sync", "listen", "changelog", "feed", "provenance", "exportCsv", "pin", "unpin",
+	"pinned", "incomplete", "duplicates", "fork", "compareRepos", "importTags", "importRepo", "snapshot",
+	"compareSnapshots", "createTrain", "addToTrain", "forks", "mergeRepos", "byType", "byRepo", "query",
+	"byIssue", "byRemote", "bySecurity",
+}
+
+// errNoOperation is reported when the command line selects no operation
+var errNoOperation = errors.New("no operation specified")
+
+// checkOperation returns an error unless the command line selects exactly one operation. A flag selects its
+// operation when its value differs from the default, so -create=false selects nothing.
+func checkOperation() error {
+	var selected []string
+	for _, name := range operationFlags {
+		f := flag.Lookup(name)
+		if f.Value.String() != f.DefValue {
+			selected = append(selected, "-"+name)
+		}
+	}
+	if len(selected) > 1 {
+		return fmt.Errorf("the operations %s cannot be combined, choose one", strings.Join(selected, " "))
+	}
+	if len(selected) == 0 {
+		train := flag.Lookup("train")
+		if train.Value.String() == train.DefValue {
+			return errNoOperation
+		}
+	}
+	return nil
+}
+
+// exitUsage reports a command line error followed by the usage on standard error, and exits with status 2
+// like the flag package does for flags it cannot parse.
+func exitUsage(err error) {
+	fmt.Fprintf(flag.CommandLine.Output(), "%v\n\n", err)
+	flag.Usage()
+	os.Exit(2)
+}
+
+// flagChoices lists the accepted values of flags that take one of a fixed set, for shell completion
+var flagChoices = map[string][]string{
+	"completion": {"bash", "zsh", "fish"},
+	"level":      {"none", "low", "medium", "high", "critical"},
+	"format":     {"markdown", "text"},
+}
+
+// flagReferencePattern matches a mention of another flag, such as -repo, in a flag description
+var flagReferencePattern = regexp.MustCompile(`(?:^|[\s(])-([A-Za-z][A-Za-z0-9]*)`)
+
+// isBoolFlag reports whether a flag is a switch that takes no value.
+func isBoolFlag(f *flag.Flag) bool {
+	boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && boolFlag.IsBoolFlag()
+}
+
+// referencedFlags returns the defined flags that the description of f mentions, in order of mention.
+func referencedFlags(f *flag.Flag) []string {
+	var names []string
+	seen := map[string]bool{f.Name: true}
+	for _, match := range flagReferencePattern.FindAllStringSubmatch(f.Usage, -1) {
+		if !seen[match[1]] && flag.Lookup(match[1]) != nil {
+			seen[match[1]] = true
+			names = append(names, "-"+match[1])
+		}
+	}
+	return names
+}
+
+// printUsage replaces the default -help output. It lists the switches, which select operations or change how
+// they run, apart from the options that take values, and names the flags each description refers to. It is
+// built from the registered flags, so new operations appear without further changes.
+func printUsage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [arguments]\n", filepath.Base(os.Args[0]))
+	for _, section := range []struct {
+		title   string
+		boolean bool
+	}{{"Operations and switches", true}, {"Options", false}} {
+		fmt.Fprintf(out, "\n%s:\n", section.title)
+		flag.VisitAll(func(f *flag.Flag) {
+			if isBoolFlag(f) != section.boolean {
+				return
+			}
+			name := "-" + f.Name
+			if !section.boolean {
+				name += " value"
+			}
+			fmt.Fprintf(out, "  %-24s %s", name, f.Usage)
+			if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" && f.DefValue != "0s" {
+				fmt.Fprintf(out, " (default %s)", f.DefValue)
+			}
+			fmt.Fprintln(out)
+			if references := referencedFlags(f); len(references) > 0 {
+				fmt.Fprintf(out, "  %-24s uses %s\n", "", strings.Join(references, " "))
+			}
+		})
+	}
+}
+
+// printCompletion writes a completion script for shell covering every registered flag. Flags with a fixed set
+// of values complete those values; other value flags complete file names.
+func printCompletion(w io.Writer, shell string) error {
+	program := filepath.Base(os.Args[0])
+	var flags []*flag.Flag
+	flag.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, f)
+	})
+
+	switch shell {
+	case "bash":
+		function := "_" + regexp.MustCompile(`[^A-Za-z0-9_]`).ReplaceAllString(program, "_")
+		var names []string
+		fmt.Fprintf(w, "%s() {\n", function)
+		fmt.Fprintln(w, `    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+		fmt.Fprintln(w, `    case "$prev" in`)
+		for _, f := range flags {
+			names = append(names, "-"+f.Name)
+			if choices, ok := flagChoices[f.Name]; ok {
+				fmt.Fprintf(w, "        -%s) COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")); return ;;\n", f.Name, strings.Join(choices, " "))
+			} else if !isBoolFlag(f) {
+				fmt.Fprintf(w, "        -%s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", f.Name)
+			}
+		}
+		fmt.Fprintln(w, "    esac")
+		fmt.Fprintf(w, "    COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(names, " "))
+		fmt.Fprintln(w, "}")
+		fmt.Fprintf(w, "complete -F %s %s\n", function, program)
+	case "zsh":
+		escape := strings.NewReplacer(`\`, `\\`, `'`, `'\''`, `[`, `\[`, `]`, `\]`, `:`, `\:`)
+		fmt.Fprintf(w, "#compdef %s\n\n_arguments \\\n", program)
+		for _, f := range flags {
+			spec := fmt.Sprintf("-%s[%s]", f.Name, escape.Replace(f.Usage))
+			if choices, ok := flagChoices[f.Name]; ok {
+				spec += fmt.Sprintf(":%s:(%s)", f.Name, strings.Join(choices, " "))
+			} else if !isBoolFlag(f) {
+				spec += fmt.Sprintf(":%s:_files", f.Name)
+			}
+			fmt.Fprintf(w, "  '%s' \\\n", spec)
+		}
+		fmt.Fprintln(w, "  '*:argument:_files'")
+	case "fish":
+		escape := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+		for _, f := range flags {
+			fmt.Fprintf(w, "complete -c %s -o %s -d '%s'", program, f.Name, escape.Replace(f.Usage))
+			if choices, ok := flagChoices[f.Name]; ok {
+				fmt.Fprintf(w, " -x -a '%s'", strings.Join(choices, " "))
+			} else if !isBoolFlag(f) {
+				fmt.Fprint(w, " -r")
+			}
+			fmt.Fprintln(w)
+		}
+	default:
+		return fmt.Errorf("unsupported shell %q for -completion, use bash, zsh or fish", shell)
+	}
+	return nil
+}
+
+func newGrpcConnection() *grpc.ClientConn {
+	connection, err := dialPeer(peerEndpoint, tlsCertPath, gatewayPeer)
+	if err != nil {
+		panic(err)
+	}
+
+	return connection
+}
+
+// connectPeerGateway connects to the gateway of a peer other than the default one, for read-only use.
+func connectPeerGateway(endpoint, tlsCertPath string, id *identity.X509Identity, sign identity.Sign) (*client.Gateway, *grpc.ClientConn, error) {
+	// The peer certificates of the test network include the host name they are reached by
+	serverName, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid endpoint %s: %w", endpoint, err)
+	}
+	connection, err := dialPeer(endpoint, tlsCertPath, serverName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gw, err := client.Connect(
+		id,
+		client.WithSign(sign),
+		client.WithClientConnection(connection),
+		client.WithEvaluateTimeout(evaluateTimeout),
+	)
+	if err != nil {
+		connection.Close()
+		return nil, nil, fmt.Errorf("failed to connect to gateway: %w", err)
+	}
+
+	return gw, connection, nil
+}
+
+// dialPeer creates a gRPC connection to a peer, verifying its TLS certificate against the CA in tlsCertPath.
+func dialPeer(endpoint, tlsCertPath, serverName string) (*grpc.ClientConn, error) {
+	certificate, err := loadCertificate(tlsCertPath)
+	if err != nil {
+		return nil, err
+	}
+
+	certPool := x509.NewCertPool()
+	certPool.AddCert(certificate)
+	transportCredentials := credentials.NewClientTLSFromCert(certPool, serverName)
+
+	connection, err := grpc.Dial(endpoint, grpc.WithTransportCredentials(transportCredentials))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC connection: %w", err)
+	}
+
+	return connection, nil
+}
+
+// Environment variables holding the client certificate and private key as PEM text for -identityFromEnv
+const (
+	certPEMEnv = "FABRIC_CERT_PEM"
+	keyPEMEnv  = "FABRIC_KEY_PEM"
+)
+
+// identityFromEnv makes newIdentity and newSign read the client credentials from certPEMEnv and keyPEMEnv
+// instead of the crypto directory, so that they never have to be written to disk
+var identityFromEnv bool
+
+// loadIdentityFromEnv parses the client certificate and private key held in the environment and checks that
+// the key belongs to the certificate.
+func loadIdentityFromEnv() (*x509.Certificate, crypto.PrivateKey, error) {
+	certificatePEM := os.Getenv(certPEMEnv)
+	if certificatePEM == "" {
+		return nil, nil, fmt.Errorf("%s is not set", certPEMEnv)
+	}
+	keyPEM := os.Getenv(keyPEMEnv)
+	if keyPEM == "" {
+		return nil, nil, fmt.Errorf("%s is not set", keyPEMEnv)
+	}
+
+	certificate, err := identity.CertificateFromPEM([]byte(certificatePEM))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse certificate from %s: %w", certPEMEnv, err)
+	}
+	privateKey, err := identity.PrivateKeyFromPEM([]byte(keyPEM))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse private key from %s: %w", keyPEMEnv, err)
+	}
+
+	err = checkKeyMatchesCertificate(privateKey, certificate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("the private key in %s does not match the certificate in %s: %w", keyPEMEnv, certPEMEnv, err)
+	}
+
+	return certificate, privateKey, nil
+}
+
+// checkKeyMatchesCertificate returns an error unless privateKey is the key of the certificate's public key.
+func checkKeyMatchesCertificate(privateKey crypto.PrivateKey, certificate *x509.Certificate) error {
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return fmt.Errorf("unsupported private key type %T", privateKey)
+	}
+	publicKey, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !publicKey.Equal(certificate.PublicKey) {
+		return errors.New("the public keys differ")
+	}
+	return nil
+}
+
+// newIdentity creates a client identity for this Gateway connection using an X.509 certificate.
+func newIdentity() *identity.X509Identity {
+	var certificate *x509.Certificate
+	var err error
+	if identityFromEnv {
+		certificate, _, err = loadIdentityFromEnv()
+	} else {
+		certificate, err = loadCertificate(certPath)
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	id, err := identity.NewX509Identity(mspID, certificate)
+	if err != nil {
+		panic(err)
+	}
+
+	return id
+}
+
+func loadCertificate(filename string) (*x509.Certificate, error) {
+	certificatePEM, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file: %w", err)
+	}
+	return identity.CertificateFromPEM(certificatePEM)
+}
+
+// newSign creates a function that generates a digital signature from a message digest using a private key.
+func newSign() identity.Sign {
+	var privateKey crypto.PrivateKey
+	if identityFromEnv {
+		var err error
+		_, privateKey, err = loadIdentityFromEnv()
+		if err != nil {
+			panic(err)
+		}
+	} else {
+		files, err := os.ReadDir(keyPath)
+		if err != nil {
+			panic(fmt.Errorf("failed to read private key directory: %w", err))
+		}
+		privateKeyPEM, err := os.ReadFile(path.Join(keyPath, files[0].Name()))
+
+		if err != nil {
+			panic(fmt.Errorf("failed to read private key file: %w", err))
+		}
+
+		privateKey, err = identity.PrivateKeyFromPEM(privateKeyPEM)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	sign, err := identity.NewPrivateKeySign(privateKey)
+	if err != nil {
+		panic(err)
+	}
+
+	return sign
+}
+
+// certificateExpiryWarning is how close to expiry a certificate makes -diagnose print a warning
+const certificateExpiryWarning = 30 * 24 * time.Hour
+
+// diagnosis prints the outcome of each -diagnose step and remembers whether any of them failed.
+type diagnosis struct {
+	failed bool
+}
+
+func (d *diagnosis) ok(step, format string, args ...interface{}) {
+	fmt.Printf("[ OK ] %s: %s\n", step, fmt.Sprintf(format, args...))
+}
+
+func (d *diagnosis) warn(step, format string, args ...interface{}) {
+	fmt.Printf("[WARN] %s: %s\n", step, fmt.Sprintf(format, args...))
+}
+
+func (d *diagnosis) fail(step string, err error) {
+	d.failed = true
+	fmt.Printf("[FAIL] %s: %v\n", step, err)
+}
+
+// checkDates reports whether a certificate is valid now and warns when it expires within
+// certificateExpiryWarning. An expired certificate is a common cause of handshakes and calls failing
+// without a clear message.
+func (d *diagnosis) checkDates(step string, certificate *x509.Certificate) {
+	now := time.Now()
+	notAfter := certificate.NotAfter.UTC().Format(time.RFC3339)
+	switch {
+	case now.Before(certificate.NotBefore):
+		d.fail(step, fmt.Errorf("%s is not valid before %s", certificate.Subject, certificate.NotBefore.UTC().Format(time.RFC3339)))
+	case now.After(certificate.NotAfter):
+		d.fail(step, fmt.Errorf("%s expired at %s (NotAfter)", certificate.Subject, notAfter))
+	default:
+		d.ok(step, "%s, valid until %s", certificate.Subject, notAfter)
+		if remaining := certificate.NotAfter.Sub(now); remaining < certificateExpiryWarning {
+			d.warn(step, "expires in %d days, at %s", int(remaining.Hours()/24), notAfter)
+		}
+	}
+}
+
+// runDiagnostics walks the connection setup step by step: the client certificate and key, the TLS CA
+// certificate, the TLS handshake with the peer, the gRPC connection and finally an evaluate call. Each step
+// prints its outcome; a step the next ones depend on ends the run when it fails. It returns false if any
+// step failed.
+func runDiagnostics(channelName, chaincodeName string) bool {
+	d := &diagnosis{}
+
+	// Client identity
+	var certificate *x509.Certificate
+	var privateKey crypto.PrivateKey
+	if identityFromEnv {
+		var err error
+		certificate, privateKey, err = loadIdentityFromEnv()
+		if err != nil {
+			d.fail("client identity", err)
+			return false
+		}
+		d.ok("client identity", "read certificate and key from %s and %s", certPEMEnv, keyPEMEnv)
+	} else {
+		certificatePEM, err := os.ReadFile(certPath)
+		if err != nil {
+			d.fail("client certificate file", err)
+			return false
+		}
+		d.ok("client certificate file", "read %s", certPath)
+		certificate, err = identity.CertificateFromPEM(certificatePEM)
+		if err != nil {
+			d.fail("client certificate PEM", err)
+			return false
+		}
+
+		files, err := os.ReadDir(keyPath)
+		if err == nil && len(files) == 0 {
+			err = fmt.Errorf("no key file in %s", keyPath)
+		}
+		if err != nil {
+			d.fail("private key file", err)
+			return false
+		}
+		keyFile := path.Join(keyPath, files[0].Name())
+		privateKeyPEM, err := os.ReadFile(keyFile)
+		if err != nil {
+			d.fail("private key file", err)
+			return false
+		}
+		d.ok("private key file", "read %s", keyFile)
+		privateKey, err = identity.PrivateKeyFromPEM(privateKeyPEM)
+		if err != nil {
+			d.fail("private key PEM", err)
+			return false
+		}
+		err = checkKeyMatchesCertificate(privateKey, certificate)
+		if err != nil {
+			d.fail("private key", fmt.Errorf("does not belong to the client certificate: %w", err))
+			return false
+		}
+		d.ok("private key", "belongs to the client certificate")
+	}
+	d.checkDates("client certificate", certificate)
+
+	// TLS CA certificate and handshake
+	tlsCertificate, err := loadCertificate(tlsCertPath)
+	if err != nil {
+		d.fail("TLS CA certificate", fmt.Errorf("%s: %w", tlsCertPath, err))
+		return false
+	}
+	d.checkDates("TLS CA certificate", tlsCertificate)
+
+	certPool := x509.NewCertPool()
+	certPool.AddCert(tlsCertificate)
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	tlsConnection, err := tls.DialWithDialer(dialer, "tcp", peerEndpoint, &tls.Config{RootCAs: certPool, ServerName: gatewayPeer, NextProtos: []string{"h2"}})
+	if err != nil {
+		var unknownAuthority x509.UnknownAuthorityError
+		var hostname x509.HostnameError
+		var invalid x509.CertificateInvalidError
+		switch {
+		case errors.As(err, &unknownAuthority):
+			err = fmt.Errorf("%w; the peer certificate is not signed by the CA in %s", err, tlsCertPath)
+		case errors.As(err, &hostname):
+			err = fmt.Errorf("%w; the peer certificate does not name %s", err, gatewayPeer)
+		case errors.As(err, &invalid) && invalid.Reason == x509.Expired:
+			err = fmt.Errorf("%w; the peer certificate expired at %s (NotAfter)", err, invalid.Cert.NotAfter.UTC().Format(time.RFC3339))
+		}
+		d.fail("TLS handshake", fmt.Errorf("with %s: %w", peerEndpoint, err))
+		return false
+	}
+	peerCertificates := tlsConnection.ConnectionState().PeerCertificates
+	tlsConnection.Close()
+	d.ok("TLS handshake", "with %s as %s", peerEndpoint, gatewayPeer)
+	if len(peerCertificates) > 0 {
+		d.checkDates("peer TLS certificate", peerCertificates[0])
+	}
+
+	// gRPC connection
+	connection, err := dialPeer(peerEndpoint, tlsCertPath, gatewayPeer)
+	if err != nil {
+		d.fail("gRPC connection", err)
+		return false
+	}
+	defer connection.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	connection.Connect()
+	for state := connection.GetState(); state != connectivity.Ready; state = connection.GetState() {
+		if !connection.WaitForStateChange(ctx, state) {
+			d.fail("gRPC connection", fmt.Errorf("not ready after 5s, last state %s", state))
+			return false
+		}
+	}
+	d.ok("gRPC connection", "ready")
+
+	// Evaluate call through the gateway
+	id, err := identity.NewX509Identity(mspID, certificate)
+	if err != nil {
+		d.fail("gateway", err)
+		return false
+	}
+	sign, err := identity.NewPrivateKeySign(privateKey)
+	if err != nil {
+		d.fail("gateway", err)
+		return false
+	}
+	gw, err := client.Connect(id, client.WithSign(sign), client.WithClientConnection(connection), client.WithEvaluateTimeout(5*time.Second))
+	if err != nil {
+		d.fail("gateway", err)
+		return false
+	}
+	defer gw.Close()
+	result, err := gw.GetNetwork(channelName).GetContract(chaincodeName).EvaluateTransaction("GetSchemaVersion")
+	if err != nil {
+		d.fail("evaluate", fmt.Errorf("GetSchemaVersion of %s on %s: %s", chaincodeName, channelName, strings.Join(errorMessages(err), "; ")))
+		return false
+	}
+	d.ok("evaluate", "GetSchemaVersion of %s on %s returned %s", chaincodeName, channelName, result)
+
+	return !d.failed
+}
+
+// evaluateOnlyTransactions are the functions the chaincode lists in GetEvaluateTransactions. Submitting one of
+// them orders a transaction with no writes, so submitTransaction evaluates them instead. Keep it in step with
+// the chaincode.
+var evaluateOnlyTransactions = map[string]bool{
+	"ReadGitCommit": true, "GetUnpushedCommits": true, "GetContractConfig": true, "ListRepositories": true,
+	"GetAllGitCommitsWithPagination": true, "GetAllPushTransactionsWithPagination": true,
+	"GetMergeCommits": true, "GetGitCommitsProjection": true, "GetPushTransactionsByRepository": true,
+	"ListRegisteredRepositories": true, "GetCommitAtTime": true, "QueryCommitsByTrailer": true,
+	"GetRepositoryChurn": true, "GitCommitsExist": true, "ReadGitCommitInRepository": true,
+	"GetCommitReferences": true, "GetRepositoryAccess": true, "GetSchemaVersion": true,
+	"GetCommitsBySecurityLevel": true, "VerifySubmitterSignature": true, "GetPruneHistory": true,
+	"GetRepositoryTimeline": true, "ComputeCommitScore": true, "GetTopCommits": true,
+	"ValidateRepositoryDAG": true, "GetCommitsByRemote": true, "GetCommitsByIssue": true,
+	"GetLedgerSummary": true, "GetForks": true, "GetRepositoryVersion": true, "GitCommitExists": true,
+	"GetAllGitCommits": true, "GetAllPushTransactions": true, "GetTombstones": true,
+	"QueryCommitsByRepository": true, "FindDuplicateCommitHashes": true, "GetPinnedCommits": true,
+	"ReadGitCommits": true, "GetPushesByEnvironment": true,
+	"GetCurrentDeployment": true, "GetIncompleteCommits": true,
+	"GetCommitsByType": true, "GetTags": true, "GetCommitsTopological": true,
+	"GetVersionGaps": true, "ReadReleaseTrain": true, "GetTrainCommits": true,
+	"GetSnapshot": true, "CompareSnapshots": true, "GetRepositoryAuthors": true,
+	"GetCommitHistory": true, "QueryCommits": true, "QueryCommitsByAuthor": true,
+	"GetCommitsByRepository": true,
+}
+
+// Timeouts of the gateway calls. The calls that take a context do not apply the defaults given to client.Connect,
+// so evaluateTransaction and submitTransaction bound the context they are given with the same values.
+const (
+	evaluateTimeout     = 5 * time.Second
+	endorseTimeout      = 15 * time.Second
+	submitTimeout       = 5 * time.Second
+	commitStatusTimeout = 1 * time.Minute
+)
+
+// evaluateWithTimeout evaluates a transaction within evaluateTimeout.
+func evaluateWithTimeout(ctx context.Context, contract *client.Contract, name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, evaluateTimeout)
+	defer cancel()
+	return contract.EvaluateWithContext(ctx, name, client.WithArguments(args...))
+}
+
+// evaluateTransaction evaluates a read-only transaction, on the -targetPeer when one is set.
+// Cancelling ctx stops the evaluation before the evaluate timeout.
+func evaluateTransaction(ctx context.Context, contract *client.Contract, name string, args ...string) (result []byte, err error) {
+	start := time.Now()
+	defer func() { metrics.observe("evaluate", name, err, time.Since(start)) }()
+	outputOperation = name
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if evaluateTarget != nil {
+		contract = evaluateTarget
+	}
+	return evaluateWithTimeout(ctx, contract, name, args...)
+}
+
+// submitTransaction submits a transaction and waits for it to commit.
+// The transaction is tracked while in flight so that an interrupted run can wait for it. Cancelling ctx stops waiting
+// for the endorsement, submission or commit, although the transaction may still commit once submitted.
+func submitTransaction(ctx context.Context, contract *client.Contract, name string, args ...string) (result []byte, err error) {
+	if evaluateOnlyTransactions[name] {
+		fmt.Printf("*** %s is read-only, evaluating it instead of submitting\n", name)
+		return evaluateTransaction(ctx, contract, name, args...)
+	}
+	start := time.Now()
+	defer func() { metrics.observe("submit", name, err, time.Since(start)) }()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	options := []client.ProposalOption{client.WithArguments(args...)}
+	if len(transientData) > 0 {
+		options = append(options, client.WithTransient(transientData))
+	}
+	if len(endorsingOrgs) > 0 {
+		options = append(options, client.WithEndorsingOrganizations(endorsingOrgs...))
+	}
+	proposal, err := contract.NewProposal(name, options...)
+	if err != nil {
+		return nil, err
+	}
+	transactionID := proposal.TransactionID()
+	err = inFlightSubmits.start(transactionID, name)
+	if err != nil {
+		return nil, err
+	}
+	defer inFlightSubmits.finish(transactionID)
+
+	endorseCtx, cancel := context.WithTimeout(ctx, endorseTimeout)
+	defer cancel()
+	transaction, err := proposal.EndorseWithContext(endorseCtx)
+	if err != nil {
+		return nil, err
+	}
+	submitCtx, cancel := context.WithTimeout(ctx, submitTimeout)
+	defer cancel()
+	commit, err := transaction.SubmitWithContext(submitCtx)
+	if err != nil {
+		return nil, err
+	}
+	statusCtx, cancel := context.WithTimeout(ctx, commitStatusTimeout)
+	defer cancel()
+	status, err := commit.StatusWithContext(statusCtx)
+	if err != nil {
+		return nil, err
+	}
+	if !status.Successful {
+		return nil, fmt.Errorf("transaction %s failed to commit with status code %d (%s)", status.TransactionID, int32(status.Code), status.Code)
+	}
+
+	return transaction.Result(), nil
+}
+
+// fieldError is one entry of a structured validation error returned by the chaincode
+type fieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Value   string `json:"value"`
+}
+
+// errorMessages returns the message of an error followed by the chaincode messages in the details the gateway
+// attaches to endorsement failures, where the chaincode message is not part of the error itself.
+func errorMessages(err error) []string {
+	messages := []string{err.Error()}
+	for _, detail := range status.Convert(err).Details() {
+		if errorDetail, ok := detail.(*gateway.ErrorDetail); ok {
+			messages = append(messages, errorDetail.GetMessage())
+		}
+	}
+	return messages
+}
+
+// validationErrors extracts the failing fields from an error the chaincode returned with the
+// StructuredValidationErrors setting on. It returns nil for any other error.
+func validationErrors(err error) []*fieldError {
+	for _, message := range errorMessages(err) {
+		start := strings.Index(message, `{"validationErrors":`)
+		if start < 0 {
+			continue
+		}
+		var parsed struct {
+			ValidationErrors []*fieldError `json:"validationErrors"`
+		}
+		// Decode stops after the object, so text following it in the message does no harm
+		if json.NewDecoder(strings.NewReader(message[start:])).Decode(&parsed) == nil {
+			return parsed.ValidationErrors
+		}
+	}
+	return nil
+}
+
+// versionConflictPattern matches the message of a VersionConflictError of the chaincode
+var versionConflictPattern = regexp.MustCompile(`version conflict on repository .*: expected version \d+, current version is \d+`)
+
+// isVersionConflict reports whether a submit lost a race for the version number of a repository, either in
+// the chaincode's compare-and-set or at commit time through an MVCC read conflict.
+func isVersionConflict(err error) bool {
+	for _, message := range errorMessages(err) {
+		if versionConflictPattern.MatchString(message) || strings.Contains(message, peer.TxValidationCode_MVCC_READ_CONFLICT.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// printValidationErrors lists the failing fields of a structured validation error, one per line.
+func printValidationErrors(err error) {
+	for _, fieldError := range validationErrors(err) {
+		fmt.Printf("    %s (%s): %s\n", fieldError.Field, fieldError.Rule, fieldError.Message)
+	}
+}
+
+// Implementation of smart contract interaction functions: createGitCommit, readGitCommit, checkGitCommitExists, getAllGitCommits
+// These functions will interact with the smart contract based on the flag inputs and perform the respective blockchain transactions
+// Omitted for brevity, but would include calling contract.SubmitTransaction() or contract.EvaluateTransaction() with the appropriate function names and arguments from your smart contract
+// CreateGitCommit issues a new GitCommit to the world state with given details.
+func createGitCommit(ctx context.Context, contract *client.Contract, commitHash, repository, commitMessage, author string) {
+	fmt.Println("--> Submit Transaction: CreateGitCommit")
+	_, err := submitTransaction(ctx, contract, "CreateGitCommit", commitHash, repository, commitMessage, author)
+	if err != nil {
+		fmt.Printf("Failed to submit CreateGitCommit transaction: %v\n", err)
+		printValidationErrors(err)
+		return
+	}
+	fmt.Println("CreateGitCommit transaction successfully submitted")
+}
+
+// CreateGitCommitWithIssues creates a commit linked to issue references in addition to those in its message.
+func createGitCommitWithIssues(ctx context.Context, contract *client.Contract, commitHash, repository, commitMessage, author, issueRefs string) {
+	fmt.Println("--> Submit Transaction: CreateGitCommitWithIssues")
+	_, err := submitTransaction(ctx, contract, "CreateGitCommitWithIssues", commitHash, repository, commitMessage, author, issueRefs)
+	if err != nil {
+		fmt.Printf("Failed to submit CreateGitCommitWithIssues transaction: %v\n", err)
+		printValidationErrors(err)
+		return
+	}
+	fmt.Println("CreateGitCommitWithIssues transaction successfully submitted")
+}
+
+// shortStatPattern matches one count of a git show --shortstat summary, e.g. "120 insertions(+)"
+var shortStatPattern = regexp.MustCompile(`(\d+) (files? changed|insertions?\(\+\)|deletions?\(-\))`)
+
+// gitShortStat returns the insertions, deletions and changed files of a commit in the local repository.
+func gitShortStat(commitHash string) (insertions, deletions, filesChanged int, err error) {
+	out, err := gitOutput("", "show", "--shortstat", "--format=", commitHash)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to get commit stats: %w", err)
+	}
+	for _, match := range shortStatPattern.FindAllStringSubmatch(out, -1) {
+		count, err := strconv.Atoi(match[1])
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("failed to parse commit stats: %w", err)
+		}
+		switch {
+		case strings.HasPrefix(match[2], "file"):
+			filesChanged = count
+		case strings.HasPrefix(match[2], "insertion"):
+			insertions = count
+		default:
+			deletions = count
+		}
+	}
+	return insertions, deletions, filesChanged, nil
+}
+
+// CreateGitCommitWithStats issues a new GitCommit along with its size taken from the local git repository.
+func createGitCommitWithStats(ctx context.Context, contract *client.Contract, commitHash, repository, commitMessage, author string) {
+	fmt.Println("--> Submit Transaction: CreateGitCommitWithStats")
+	insertions, deletions, filesChanged, err := gitShortStat(commitHash)
+	if err != nil {
+		fmt.Printf("Failed to read stats of commit %s: %v\n", commitHash, err)
+		return
+	}
+	_, err = submitTransaction(ctx, contract, "CreateGitCommitWithStats", commitHash, repository, commitMessage, author,
+		strconv.Itoa(insertions), strconv.Itoa(deletions), strconv.Itoa(filesChanged))
+	if err != nil {
+		fmt.Printf("Failed to submit CreateGitCommitWithStats transaction: %v\n", err)
+		printValidationErrors(err)
+		return
+	}
+	fmt.Println("CreateGitCommitWithStats transaction successfully submitted")
+}
+
+// HandleGitPush handles the git push operation, increments the version number, and provides a build message.
+// A push message or approver is recorded through HandleAnnotatedGitPush, and a deployment environment through
+// HandleDeploymentPush.
+func handleGitPush(ctx context.Context, contract *client.Contract, repository, remoteURL, commitHash, pushMessage, approvedBy, environment string) {
+	fmt.Println("--> Submit Transaction: CreateGitPush")
+	// Push the latest commit of the working tree unless -hash names one
+	if commitHash == "" {
+		latest, err := getLatestCommitHash()
+		if err != nil {
+			fmt.Printf("Failed to get latest commit hash: %v\n", err)
+			return
+		}
+		commitHash = latest
+	}
+
+	// Append the commit hash to the remote URL
+	remoteURLWithHash := fmt.Sprintf("%s", remoteURL)
+
+	name, args := "HandleGitPush", []string{repository, remoteURLWithHash, commitHash}
+	if environment != "" {
+		name, args = "HandleDeploymentPush", append(args, environment, pushMessage, approvedBy)
+	} else if pushMessage != "" || approvedBy != "" {
+		name, args = "HandleAnnotatedGitPush", append(args, pushMessage, approvedBy)
+	}
+	fmt.Printf("--> Submit Transaction: %s\n", name)
+	result, err := submitTransaction(ctx, contract, name, args...)
+	if err != nil {
+		fmt.Printf("Failed to submit %s transaction: %v\n", name, err)
+		return
+	}
+	fmt.Printf("%s transaction successfully submitted, result: %s\n", name, string(result))
+}
+
+var (
+	errNotGitRepository = errors.New("the current directory is not inside a git repository; run the client from your repository's working tree or pass the commit with -hash")
+	errNoCommits        = errors.New("the git repository has no commits yet; make a first commit or pass the commit with -hash")
+)
+
+// getLatestCommitHash returns the hash of HEAD in the working directory's repository. It returns
+// errNotGitRepository outside a repository and errNoCommits in a repository without commits.
+func getLatestCommitHash() (string, error) {
+	out, err := gitOutput("", "rev-parse", "--verify", "-q", "HEAD")
+	if err != nil {
+		if strings.Contains(err.Error(), "not a git repository") {
+			return "", errNotGitRepository
+		}
+		// With -q, git exits with status 1 and prints nothing when HEAD does not resolve to a commit
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", errNoCommits
+		}
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// autoRecordState is kept between runs of -autoRecord so that a restart only records new commits
+type autoRecordState struct {
+	LastRecorded string `json:"lastRecorded"`
+	LastPushed   string `json:"lastPushed"`
+}
+
+// gitOutput runs a git command in a repository directory, or the working directory if dir is empty, and
+// returns its output. The command is killed once it runs longer than -gitTimeout, and the error of a
+// failed command carries what git wrote to stderr.
+func gitOutput(dir string, args ...string) (string, error) {
+	ctx := context.Background()
+	if gitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, gitTimeout)
+		defer cancel()
+	}
+
+	gitArgs := args
+	if dir != "" {
+		gitArgs = append([]string{"-C", dir}, args...)
+	}
+	cmd := exec.CommandContext(ctx, "git", gitArgs...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	// A killed git may leave children holding the output pipes open, so do not wait on them forever
+	cmd.WaitDelay = time.Second
+
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("git %s timed out after %s", strings.Join(args, " "), gitTimeout)
+	}
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("git %s failed: %w: %s", strings.Join(args, " "), err, message)
+		}
+		return "", fmt.Errorf("git %s failed: %w", strings.Join(args, " "), err)
+	}
+	return string(out), nil
+}
+
+func loadAutoRecordState(statePath string) (*autoRecordState, error) {
+	state := &autoRecordState{}
+	stateJSON, err := os.ReadFile(statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	err = json.Unmarshal(stateJSON, state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", statePath, err)
+	}
+	return state, nil
+}
+
+// saveAutoRecordState writes the state through a temporary file so that an interruption cannot leave it half written.
+func saveAutoRecordState(statePath string, state *autoRecordState) error {
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmpPath := statePath + ".tmp"
+	err = os.WriteFile(tmpPath, stateJSON, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return os.Rename(tmpPath, statePath)
+}
+
+// autoRecordOnce records the commits made since the last recorded one and, with autoPush, a push of HEAD when
+// it has moved since the last recorded push.
+func autoRecordOnce(ctx context.Context, contract *client.Contract, dir, repository, remoteURL string, autoPush bool, state *autoRecordState, statePath string) error {
+	head, err := gitOutput(dir, "rev-parse", "--verify", "-q", "HEAD")
+	if err != nil {
+		return fmt.Errorf("the repository has no commits yet")
+	}
+	head = strings.TrimSpace(head)
+	_, err = gitOutput(dir, "symbolic-ref", "-q", "HEAD")
+	detached := err != nil
+	if detached {
+		fmt.Println("*** HEAD is detached, recording the commits reachable from it")
+	}
+	status, err := gitOutput(dir, "status", "--porcelain")
+	if err == nil && strings.TrimSpace(status) != "" {
+		fmt.Println("*** The working tree has uncommitted changes, only committed work is recorded")
+	}
+
+	revisions := "HEAD"
+	if state.LastRecorded != "" {
+		_, err = gitOutput(dir, "cat-file", "-e", state.LastRecorded+"^{commit}")
+		if err != nil {
+			fmt.Printf("*** Last recorded commit %s is no longer in the repository, checking all of HEAD\n", state.LastRecorded)
+		} else {
+			revisions = state.LastRecorded + "..HEAD"
+		}
+	}
+	log, err := gitOutput(dir, "log", "--reverse", "--format=%H%x00%an%x00%aI%x00%cI%x00%B%x1e", revisions)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range strings.Split(log, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(entry, "\n"), "\x00", 5)
+		if len(fields) != 5 {
+			continue
+		}
+		commitHash, author, authorDate, commitDate := fields[0], fields[1], fields[2], fields[3]
+		commitMessage := strings.TrimSpace(fields[4])
+
+		fmt.Printf("--> Submit Transaction: CreateGitCommitWithDates %s\n", commitHash)
+		_, err = submitRepositoryTransaction(ctx, contract, repository, "CreateGitCommitWithDates", commitHash, repository, commitMessage, author, authorDate, commitDate)
+		if err != nil && !strings.Contains(err.Error(), "already exists") {
+			return fmt.Errorf("failed to record commit %s: %w", commitHash, err)
+		}
+		state.LastRecorded = commitHash
+		err = saveAutoRecordState(statePath, state)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !autoPush || head == state.LastPushed {
+		return nil
+	}
+	if detached {
+		fmt.Println("*** Not recording a push while HEAD is detached")
+		return nil
+	}
+	fmt.Printf("--> Submit Transaction: HandleGitPush %s\n", head)
+	_, err = submitRepositoryTransaction(ctx, contract, repository, "HandleGitPush", repository, remoteURL, head)
+	if err != nil {
+		return fmt.Errorf("failed to record push of %s: %w", head, err)
+	}
+	state.LastPushed = head
+	return saveAutoRecordState(statePath, state)
+}
+
+// fullCommitHashPattern matches a full SHA-1 or SHA-256 object ID, as recorded on the ledger
+var fullCommitHashPattern = regexp.MustCompile(`^(?:[0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// gitObjectHash returns the object ID git computes for an object: the SHA-1, or in SHA-256 repositories the
+// SHA-256, of its type, size and content.
+func gitObjectHash(objectType string, content []byte, sha256Repository bool) string {
+	header := fmt.Sprintf("%s %d\x00", objectType, len(content))
+	if sha256Repository {
+		digest := sha256.Sum256(append([]byte(header), content...))
+		return hex.EncodeToString(digest[:])
+	}
+	digest := sha1.Sum(append([]byte(header), content...))
+	return hex.EncodeToString(digest[:])
+}
+
+// verifyCommitHash checks that commitHash names a commit object in the git repository at dir, that git
+// resolves it to itself, and that hashing the object's content gives the same hash, so that the hash is not
+// made up. A hash that is not in the repository is reported as such.
+func verifyCommitHash(dir, commitHash string) error {
+	if !fullCommitHashPattern.MatchString(commitHash) {
+		return fmt.Errorf("%q is not a full commit hash of 40 or 64 lowercase hex digits", commitHash)
+	}
+	objectType, err := gitOutput(dir, "cat-file", "-t", commitHash)
+	if err != nil {
+		if strings.Contains(err.Error(), "could not get object info") || strings.Contains(err.Error(), "Not a valid object name") {
+			return fmt.Errorf("the object %s does not exist in the git repository at %s; fetch it or check the hash", commitHash, dir)
+		}
+		return err
+	}
+	if objectType = strings.TrimSpace(objectType); objectType != "commit" {
+		return fmt.Errorf("the object %s is a %s, not a commit", commitHash, objectType)
+	}
+
+	resolved, err := gitOutput(dir, "rev-parse", "--verify", "-q", commitHash+"^{commit}")
+	if err != nil {
+		return err
+	}
+	if resolved = strings.TrimSpace(resolved); resolved != commitHash {
+		return fmt.Errorf("git resolves %s to %s", commitHash, resolved)
+	}
+	content, err := gitOutput(dir, "cat-file", "commit", commitHash)
+	if err != nil {
+		return err
+	}
+	computed := gitObjectHash("commit", []byte(content), len(commitHash) == 64)
+	if computed != commitHash {
+		return fmt.Errorf("the content of commit %s hashes to %s; the git repository at %s may be corrupt", commitHash, computed, dir)
+	}
+	return nil
+}
+
+// verifyHash checks a commit hash against the local git repository and, with attest, records on the ledger
+// that the submitting organization verified it.
+func verifyHash(ctx context.Context, contract *client.Contract, dir, commitHash string, attest bool) {
+	fmt.Printf("--> Verify commit hash %s in %s\n", commitHash, dir)
+	err := verifyCommitHash(dir, commitHash)
+	if err != nil {
+		fmt.Printf("Commit hash verification failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Commit hash %s matches the git object\n", commitHash)
+	if !attest {
+		return
+	}
+
+	fmt.Println("--> Submit Transaction: AttestCommitHash")
+	_, err = submitTransaction(ctx, contract, "AttestCommitHash", commitHash)
+	if err != nil {
+		fmt.Printf("Failed to submit AttestCommitHash transaction: %v\n", err)
+		return
+	}
+	fmt.Println("AttestCommitHash transaction successfully submitted")
+}
+
+// attestReview records the review approval of the client's organization for a commit. The contract counts
+// the TTL in whole seconds from the transaction time.
+func attestReview(ctx context.Context, contract *client.Contract, commitHash string, ttl time.Duration) {
+	if ttl < 0 || ttl%time.Second != 0 {
+		fmt.Printf("The -ttl %v must be a whole, non-negative number of seconds\n", ttl)
+		return
+	}
+
+	fmt.Println("--> Submit Transaction: AttestReview")
+	_, err := submitTransaction(ctx, contract, "AttestReview", commitHash, strconv.FormatInt(int64(ttl/time.Second), 10))
+	if err != nil {
+		fmt.Printf("Failed to submit AttestReview transaction: %v\n", err)
+		return
+	}
+	fmt.Println("AttestReview transaction successfully submitted")
+}
+
+// autoRecord keeps the ledger in step with a local git repository, checking for new commits every interval
+// until the process is interrupted.
+func autoRecord(ctx context.Context, contract *client.Contract, dir, repository, remoteURL string, interval time.Duration, autoPush bool, statePath string) {
+	if repository == "" {
+		fmt.Println("A repository name is required for -autoRecord, use -repo")
+		return
+	}
+	if autoPush && remoteURL == "" {
+		fmt.Println("A remote URL is required for -autoPush, use -url")
+		return
+	}
+	if statePath == "" {
+		gitDir, err := gitOutput(dir, "rev-parse", "--absolute-git-dir")
+		if err != nil {
+			fmt.Printf("%s is not a git repository: %v\n", dir, err)
+			return
+		}
+		statePath = filepath.Join(strings.TrimSpace(gitDir), "ledger-last-recorded.json")
+	}
+	state, err := loadAutoRecordState(statePath)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Printf("Recording commits of %s as %s every %v, state in %s\n", dir, repository, interval, statePath)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err = autoRecordOnce(ctx, contract, dir, repository, remoteURL, autoPush, state, statePath)
+		if err != nil {
+			fmt.Printf("Failed to record commits: %v\n", err)
+		}
+		<-ticker.C
+	}
+}
+
+// discoverRepositories returns the git repositories under root, in walk order. A directory is a repository
+// when it has a .git entry, a directory or, for worktrees and submodules, a file; repositories nested in
+// another one are found too. Directories that cannot be read are skipped rather than ending the walk.
+func discoverRepositories(root string) ([]string, error) {
+	var repositories []string
+	err := filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			fmt.Printf("*** Skipping %s: %v\n", path, err)
+			return filepath.SkipDir
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if _, err := os.Lstat(filepath.Join(path, ".git")); err == nil {
+			repositories = append(repositories, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return repositories, nil
+}
+
+// repositoryNameFromRemote returns the last path element of a remote URL without its .git suffix, such as
+// fabric-samples for git@github.com:hyperledger/fabric-samples.git, or "" if there is none.
+func repositoryNameFromRemote(remoteURL string) string {
+	remoteURL = strings.TrimSuffix(strings.TrimRight(remoteURL, "/"), ".git")
+	if i := strings.LastIndexAny(remoteURL, "/:"); i >= 0 {
+		remoteURL = remoteURL[i+1:]
+	}
+	return remoteURL
+}
+
+// syncTarget is a repository found by -syncAll with the ledger name and remote it is recorded under
+type syncTarget struct {
+	Dir        string
+	Repository string
+	RemoteURL  string
+}
+
+// syncTargets names the repositories under root after their origin remote, or their directory when they
+// have none. A second repository with a name already taken is reported and left out, as recording both
+// under one name would mix their commits.
+func syncTargets(root string) ([]*syncTarget, error) {
+	dirs, err := discoverRepositories(root)
+	if err != nil {
+		return nil, err
+	}
+	targets := []*syncTarget{}
+	names := make(map[string]string)
+	for _, dir := range dirs {
+		target := &syncTarget{Dir: dir}
+		remoteURL, err := gitOutput(dir, "remote", "get-url", "origin")
+		if err == nil {
+			target.RemoteURL = strings.TrimSpace(remoteURL)
+			target.Repository = repositoryNameFromRemote(target.RemoteURL)
+		}
+		if target.Repository == "" {
+			target.Repository = filepath.Base(dir)
+		}
+		if other, ok := names[target.Repository]; ok {
+			fmt.Printf("*** Skipping %s: repository name %s is already used by %s\n", dir, target.Repository, other)
+			continue
+		}
+		names[target.Repository] = dir
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// syncStatus is the outcome of recording one repository in a -syncAll pass
+type syncStatus struct {
+	Target       *syncTarget
+	LastRecorded string
+	Err          error
+}
+
+// syncRepository records the new commits of a repository, and with autoPush a push of HEAD to its origin,
+// keeping its state inside the .git directory like -autoRecord.
+func syncRepository(ctx context.Context, contract *client.Contract, target *syncTarget, autoPush bool) *syncStatus {
+	status := &syncStatus{Target: target}
+	gitDir, err := gitOutput(target.Dir, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	statePath := filepath.Join(strings.TrimSpace(gitDir), "ledger-last-recorded.json")
+	state, err := loadAutoRecordState(statePath)
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	status.Err = autoRecordOnce(ctx, contract, target.Dir, target.Repository, target.RemoteURL, autoPush && target.RemoteURL != "", state, statePath)
+	status.LastRecorded = state.LastRecorded
+	return status
+}
+
+// syncAll keeps the ledger in step with every git repository under root, looking for new repositories and
+// commits every interval until ctx is cancelled. The repositories of a pass are recorded in parallel. A
+// repository that fails is reported and retried on the next pass; it does not stop the others.
+func syncAll(ctx context.Context, contract *client.Contract, root string, interval time.Duration, autoPush bool) {
+	if root == "" {
+		fmt.Println("A directory is required for -syncAll, use -root")
+		return
+	}
+	if interval <= 0 {
+		fmt.Println("-interval must be positive")
+		return
+	}
+
+	fmt.Printf("Recording commits of the repositories under %s every %v\n", root, interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		targets, err := syncTargets(root)
+		if err != nil {
+			fmt.Printf("Failed to find repositories: %v\n", err)
+		}
+		statuses := syncPass(targets, func(target *syncTarget) *syncStatus {
+			return syncRepository(ctx, contract, target, autoPush)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		printSyncStatuses(os.Stdout, statuses)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncPass syncs every target at once and returns their statuses in target order. The transactions of the
+// repositories meet in submitQueue, which keeps each repository's in order while different repositories
+// proceed in parallel; a repository whose transaction finds the queue full fails for this pass.
+func syncPass(targets []*syncTarget, record func(*syncTarget) *syncStatus) []*syncStatus {
+	statuses := make([]*syncStatus, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target *syncTarget) {
+			defer wg.Done()
+			statuses[i] = record(target)
+		}(i, target)
+	}
+	wg.Wait()
+	return statuses
+}
+
+// printSyncStatuses writes one line per repository of a -syncAll pass.
+func printSyncStatuses(w io.Writer, statuses []*syncStatus) {
+	failed := 0
+	for _, status := range statuses {
+		if status.Err != nil {
+			failed++
+			fmt.Fprintf(w, "  FAILED  %s (%s): %v\n", status.Target.Repository, status.Target.Dir, status.Err)
+			continue
+		}
+		fmt.Fprintf(w, "  ok      %s (%s) at %s\n", status.Target.Repository, status.Target.Dir, status.LastRecorded)
+	}
+	fmt.Fprintf(w, "Synced %d of %d repositories\n", len(statuses)-failed, len(statuses))
+}
+
+// waitForCommit calls exists every interval until it reports the commit, the timeout elapses or ctx is
+// cancelled. A failed check is retried, as the peer may be briefly unreachable while a pipeline starts up.
+func waitForCommit(ctx context.Context, exists func(commitHash string) (bool, error), commitHash string, timeout, interval time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		found, err := exists(commitHash)
+		if err != nil {
+			fmt.Printf("Failed to check for commit %s, retrying: %v\n", commitHash, err)
+		} else if found {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("the commit %s did not appear within %v", commitHash, timeout)
+			}
+			return fmt.Errorf("stopped waiting for commit %s: %v", commitHash, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitFor blocks until a commit is recorded on the ledger, so that a pipeline step can depend on a commit
+// submitted by another process. It exits with status 1 when the commit does not appear in time or the wait is
+// interrupted.
+func waitFor(ctx context.Context, contract *client.Contract, commitHash string, timeout, interval time.Duration) {
+	if commitHash == "" {
+		fmt.Println("A commit hash is required for -waitFor, use -hash")
+		return
+	}
+	if timeout <= 0 || interval <= 0 {
+		fmt.Println("-timeout and -interval must be positive")
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	exists := func(commitHash string) (bool, error) {
+		result, err := evaluateTransaction(ctx, contract, "GitCommitExists", commitHash)
+		if err != nil {
+			return false, err
+		}
+		return string(result) == "true", nil
+	}
+
+	fmt.Printf("--> Waiting up to %v for commit %s, checking every %v\n", timeout, commitHash, interval)
+	err := waitForCommit(ctx, exists, commitHash, timeout, interval)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Printf("Commit %s is recorded\n", commitHash)
+}
+
+// gET ALL the push transcation
+func getAllPushTransactions(ctx context.Context, contract *client.Contract) {
+	fmt.Println("--> Evaluate Transaction: GetAllPushTransactions")
+	result, err := evaluateTransaction(ctx, contract, "GetAllPushTransactions")
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetAllPushTransactions transaction: %v\n", err)
+		return
+	}
+	var pushTransactions []*PushTransaction
+	err = json.Unmarshal(result, &pushTransactions)
+	if err != nil {
+		fmt.Printf("Failed to unmarshal result: %v\n", err)
+		return
+	}
+	// Print the result in the selected output format
+	prettyResult, err := marshalOutput(pushTransactions)
+	if err != nil {
+		fmt.Printf("Failed to format result: %v\n", err)
+		return
+	}
+	fmt.Printf("GetAllPushTransactions transaction successfully evaluated, result: %s\n", string(prettyResult))
+}
+
+// ReadGitCommit returns the GitCommit stored in the world state with given commit hash.
+func readGitCommit(ctx context.Context, contract *client.Contract, commitHash string) {
+	fmt.Println("--> Evaluate Transaction: ReadGitCommit")
+	result, err := evaluateTransaction(ctx, contract, "ReadGitCommit", commitHash)
+	if err != nil {
+		fmt.Printf("Failed to evaluate ReadGitCommit transaction: %v\n", err)
+		return
+	}
+	var gitCommit GitCommit
+	err = json.Unmarshal(result, &gitCommit)
+	if err != nil {
+		fmt.Printf("Failed to unmarshal result: %v\n", err)
+		return
+	}
+	gitCommitResult, err := marshalOutput(gitCommit)
+	if err != nil {
+		fmt.Printf("Failed to format result: %v\n", err)
+		return
+	}
+	fmt.Printf("ReadGitCommit transaction successfully evaluated, result: %s\n", string(gitCommitResult))
+}
+
+// ReadGitCommits reads several GitCommits by hash in one call and lists the hashes that were not found.
+func readGitCommits(ctx context.Context, contract *client.Contract, commitHashes string) {
+	fmt.Println("--> Evaluate Transaction: ReadGitCommits")
+	result, err := evaluateTransaction(ctx, contract, "ReadGitCommits", commitHashes)
+	if err != nil {
+		fmt.Printf("Failed to evaluate ReadGitCommits transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("ReadGitCommits transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// GitCommitExists checks if a GitCommit with the given commit hash exists in the world state.
+func checkGitCommitExists(ctx context.Context, contract *client.Contract, commitHash string) {
+	fmt.Println("--> Evaluate Transaction: GitCommitExists")
+	result, err := evaluateTransaction(ctx, contract, "GitCommitExists", commitHash)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GitCommitExists transaction: %v\n", err)
+		return
+	}
+	exists := string(result) == "true"
+	fmt.Printf("GitCommitExists transaction successfully evaluated, exists: %v\n", exists)
+}
+
+// existenceBatchSize is the most hashes the contract accepts in one GitCommitsExist call
+const existenceBatchSize = 500
+
+// gitCommitsExist checks any number of commit hashes, splitting them into GitCommitsExist calls of at most
+// existenceBatchSize hashes and merging the results.
+func gitCommitsExist(ctx context.Context, contract *client.Contract, commitHashes []string) (map[string]bool, error) {
+	exists := make(map[string]bool, len(commitHashes))
+	for start := 0; start < len(commitHashes); start += existenceBatchSize {
+		end := start + existenceBatchSize
+		if end > len(commitHashes) {
+			end = len(commitHashes)
+		}
+		result, err := evaluateTransaction(ctx, contract, "GitCommitsExist", strings.Join(commitHashes[start:end], ","))
+		if err != nil {
+			return nil, err
+		}
+		var batch map[string]bool
+		err = json.Unmarshal(result, &batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal result: %w", err)
+		}
+		for commitHash, found := range batch {
+			exists[commitHash] = found
+		}
+	}
+	return exists, nil
+}
+
+// GitCommitsExist checks which of several commit hashes exist in the world state.
+func checkGitCommitsExist(ctx context.Context, contract *client.Contract, commitHashes []string) {
+	fmt.Println("--> Evaluate Transaction: GitCommitsExist")
+	exists, err := gitCommitsExist(ctx, contract, commitHashes)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GitCommitsExist transaction: %v\n", err)
+		return
+	}
+	existsResult, err := marshalOutput(exists)
+	if err != nil {
+		fmt.Printf("Failed to format result: %v\n", err)
+		return
+	}
+	fmt.Printf("GitCommitsExist transaction successfully evaluated, result: %s\n", string(existsResult))
+}
+
+// GetAllGitCommits returns all GitCommits found in the world state.
+func getAllGitCommits(ctx context.Context, contract *client.Contract) {
+	fmt.Println("--> Evaluate Transaction: GetAllGitCommits")
+	result, err := evaluateTransaction(ctx, contract, "GetAllGitCommits")
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetAllGitCommits transaction: %v\n", err)
+		return
+	}
+	var gitCommits []*GitCommit
+	err = json.Unmarshal(result, &gitCommits)
+	if err != nil {
+		fmt.Printf("Failed to unmarshal result: %v\n", err)
+		return
+	}
+	// Print the result in the selected output format
+	prettyGitResult, err := marshalOutput(gitCommits)
+	if err != nil {
+		fmt.Printf("Failed to format result: %v\n", err)
+		return
+	}
+	fmt.Printf("GetAllGitCommits transaction successfully evaluated, result: %s\n", string(prettyGitResult))
+}
+
+// QueryCommitsByRepository returns the commits of one repository, without listing the whole ledger.
+func queryCommitsByRepository(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Evaluate Transaction: QueryCommitsByRepository")
+	result, err := evaluateTransaction(ctx, contract, "QueryCommitsByRepository", repository)
+	if err != nil {
+		fmt.Printf("Failed to evaluate QueryCommitsByRepository transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("QueryCommitsByRepository transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// GetCommitsByRepository returns the commits of one repository in commit time order.
+func getCommitsByRepository(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Evaluate Transaction: GetCommitsByRepository")
+	result, err := evaluateTransaction(ctx, contract, "GetCommitsByRepository", repository)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetCommitsByRepository transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetCommitsByRepository transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// GetUnpushedCommits returns the commits of a repository that have never been part of a push.
+func getUnpushedCommits(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Evaluate Transaction: GetUnpushedCommits")
+	result, err := evaluateTransaction(ctx, contract, "GetUnpushedCommits", repository)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetUnpushedCommits transaction: %v\n", err)
+		return
+	}
+	var gitCommits []*GitCommit
+	err = json.Unmarshal(result, &gitCommits)
+	if err != nil {
+		fmt.Printf("Failed to unmarshal result: %v\n", err)
+		return
+	}
+	prettyGitResult, err := marshalOutput(gitCommits)
+	if err != nil {
+		fmt.Printf("Failed to format result: %v\n", err)
+		return
+	}
+	fmt.Printf("GetUnpushedCommits transaction successfully evaluated, result: %s\n", string(prettyGitResult))
+}
+
+// GetContractConfig returns the settings that tune how the contract validates and stores records.
+func getContractConfig(ctx context.Context, contract *client.Contract) {
+	fmt.Println("--> Evaluate Transaction: GetContractConfig")
+	result, err := evaluateTransaction(ctx, contract, "GetContractConfig")
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetContractConfig transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetContractConfig transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// SetContractConfig replaces the contract settings.
+func setContractConfig(ctx context.Context, contract *client.Contract, configJSON string) {
+	fmt.Println("--> Submit Transaction: SetContractConfig")
+	_, err := submitTransaction(ctx, contract, "SetContractConfig", configJSON)
+	if err != nil {
+		fmt.Printf("Failed to submit SetContractConfig transaction: %v\n", err)
+		return
+	}
+	fmt.Println("SetContractConfig transaction successfully submitted")
+}
+
+// ArchiveRepository marks a repository as archived so that it rejects new commits and pushes.
+func archiveRepository(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Submit Transaction: ArchiveRepository")
+	_, err := submitTransaction(ctx, contract, "ArchiveRepository", repository)
+	if err != nil {
+		fmt.Printf("Failed to submit ArchiveRepository transaction: %v\n", err)
+		return
+	}
+	fmt.Println("ArchiveRepository transaction successfully submitted")
+}
+
+// UnarchiveRepository makes an archived repository writable again.
+func unarchiveRepository(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Submit Transaction: UnarchiveRepository")
+	_, err := submitTransaction(ctx, contract, "UnarchiveRepository", repository)
+	if err != nil {
+		fmt.Printf("Failed to submit UnarchiveRepository transaction: %v\n", err)
+		return
+	}
+	fmt.Println("UnarchiveRepository transaction successfully submitted")
+}
+
+// ListRepositories returns the version record and archived status of every repository.
+func listRepositories(ctx context.Context, contract *client.Contract) {
+	fmt.Println("--> Evaluate Transaction: ListRepositories")
+	result, err := evaluateTransaction(ctx, contract, "ListRepositories")
+	if err != nil {
+		fmt.Printf("Failed to evaluate ListRepositories transaction: %v\n", err)
+		return
+	}
+	var repositories []*RepositoryVersion
+	err = json.Unmarshal(result, &repositories)
+	if err != nil {
+		fmt.Printf("Failed to unmarshal result: %v\n", err)
+		return
+	}
+	repositoriesResult, err := marshalOutput(repositories)
+	if err != nil {
+		fmt.Printf("Failed to format result: %v\n", err)
+		return
+	}
+	fmt.Printf("ListRepositories transaction successfully evaluated, result: %s\n", string(repositoriesResult))
+}
+
+// UpdateBuildStatus records the CI pipeline status of a commit.
+func updateBuildStatus(ctx context.Context, contract *client.Contract, commitHash, status string) {
+	fmt.Println("--> Submit Transaction: UpdateBuildStatus")
+	_, err := submitTransaction(ctx, contract, "UpdateBuildStatus", commitHash, status)
+	if err != nil {
+		fmt.Printf("Failed to submit UpdateBuildStatus transaction: %v\n", err)
+		return
+	}
+	fmt.Println("UpdateBuildStatus transaction successfully submitted")
+}
+
+// PatchGitCommit applies a JSON merge patch to a commit: only the fields in the patch change and null removes one.
+func patchGitCommit(ctx context.Context, contract *client.Contract, commitHash, patchJSON string) {
+	if !json.Valid([]byte(patchJSON)) {
+		fmt.Println("The -patch must be a JSON object")
+		return
+	}
+
+	fmt.Println("--> Submit Transaction: PatchGitCommit")
+	_, err := submitTransaction(ctx, contract, "PatchGitCommit", commitHash, patchJSON)
+	if err != nil {
+		fmt.Printf("Failed to submit PatchGitCommit transaction: %v\n", err)
+		return
+	}
+	fmt.Println("PatchGitCommit transaction successfully submitted")
+}
+
+func updateGitCommit(ctx context.Context, contract *client.Contract, commitHash, commitMessage, author string) {
+	fmt.Println("--> Submit Transaction: UpdateGitCommit")
+	_, err := submitTransaction(ctx, contract, "UpdateGitCommit", commitHash, commitMessage, author)
+	if err != nil {
+		fmt.Printf("Failed to submit UpdateGitCommit transaction: %v\n", err)
+		return
+	}
+	fmt.Println("UpdateGitCommit transaction successfully submitted")
+}
+
+// queryCommits runs a rich query for commits. A bare selector is wrapped into a query, so that
+// '{"author": "Alice"}' and '{"selector": {"author": "Alice"}, "limit": 10}' both work.
+func queryCommits(ctx context.Context, contract *client.Contract, query string) {
+	var object map[string]json.RawMessage
+	err := json.Unmarshal([]byte(query), &object)
+	if err != nil {
+		fmt.Printf("The -query must be a JSON object: %v\n", err)
+		return
+	}
+	if _, ok := object["selector"]; !ok {
+		query = `{"selector": ` + query + `}`
+	}
+
+	fmt.Println("--> Evaluate Transaction: QueryCommits")
+	result, err := evaluateTransaction(ctx, contract, "QueryCommits", query)
+	if err != nil {
+		fmt.Printf("Failed to evaluate QueryCommits transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("QueryCommits transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// commitPayload is a commit described in a JSON file for -create -file. Field names follow GitCommit.
+type commitPayload struct {
+	CommitHash      string   `json:"CommitHash"`
+	Repository      string   `json:"Repository"`
+	CommitMessage   string   `json:"CommitMessage"`
+	Author          string   `json:"Author"`
+	ParentHashes    []string `json:"ParentHashes"`
+	AuthorTimestamp string   `json:"AuthorTimestamp"`
+	CommitTimestamp string   `json:"CommitTimestamp"`
+	IssueRefs       []string `json:"IssueRefs"`
+	Insertions      int      `json:"Insertions"`
+	Deletions       int      `json:"Deletions"`
+	FilesChanged    int      `json:"FilesChanged"`
+}
+
+// openInput opens the file at path or, with stdin set, returns standard input, together with a name for the
+// input in messages. Standard input must not be a terminal, since -stdin is meant to read piped input and would
+// otherwise wait for typing without a prompt.
+func openInput(path string, stdin bool) (io.ReadCloser, string, error) {
+	if !stdin {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, "", err
+		}
+		return file, "file " + path, nil
+	}
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to inspect standard input: %w", err)
+	}
+	if info.Mode()&os.ModeCharDevice != 0 {
+		return nil, "", errors.New("-stdin reads piped input, but standard input is a terminal")
+	}
+	return io.NopCloser(os.Stdin), "standard input", nil
+}
+
+// readCommitPayload reads a single JSON object from r, named source in errors. With strict set, fields
+// commitPayload does not know are rejected, which catches misspelled names that would otherwise be dropped
+// silently.
+func readCommitPayload(r io.Reader, source string, strict bool) (*commitPayload, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", source, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	var payload commitPayload
+	err = decoder.Decode(&payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit %s: %w", source, err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("invalid commit %s: it must hold a single JSON object", source)
+	}
+	return &payload, nil
+}
+
+// transaction checks a payload and returns the contract function that records it with its arguments. The
+// optional fields are recorded by different functions, so a payload may only use one group of them.
+func (p *commitPayload) transaction() (string, []string, error) {
+	var problems []string
+	for _, required := range []struct{ name, value string }{{"CommitHash", p.CommitHash}, {"Repository", p.Repository}, {"Author", p.Author}} {
+		if required.value == "" {
+			problems = append(problems, required.name+" is required")
+		}
+	}
+	for _, date := range []struct{ name, value string }{{"AuthorTimestamp", p.AuthorTimestamp}, {"CommitTimestamp", p.CommitTimestamp}} {
+		if date.value == "" {
+			continue
+		}
+		_, err := time.Parse(time.RFC3339, date.value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s %q must be RFC3339", date.name, date.value))
+		}
+	}
+	if p.Insertions < 0 || p.Deletions < 0 || p.FilesChanged < 0 {
+		problems = append(problems, "Insertions, Deletions and FilesChanged must not be negative")
+	}
+	if len(p.ParentHashes) != 0 && (len(p.ParentHashes) != 2 || p.ParentHashes[0] == p.ParentHashes[1]) {
+		problems = append(problems, "ParentHashes must be empty or name the two distinct parents of a merge")
+	}
+	for _, ref := range p.IssueRefs {
+		if ref == "" || strings.ContainsAny(ref, ", \t\r\n") {
+			problems = append(problems, fmt.Sprintf("issue reference %q must be non-empty without commas or white space", ref))
+		}
+	}
+	if len(problems) > 0 {
+		return "", nil, errors.New(strings.Join(problems, "; "))
+	}
+
+	base := []string{p.CommitHash, p.Repository, p.CommitMessage, p.Author}
+	var groups []string
+	name, args := "CreateGitCommit", base
+	if len(p.ParentHashes) > 0 {
+		groups = append(groups, "ParentHashes")
+		name, args = "CreateMergeCommit", []string{p.CommitHash, p.Repository, p.ParentHashes[0], p.ParentHashes[1], p.CommitMessage, p.Author}
+	}
+	if p.AuthorTimestamp != "" || p.CommitTimestamp != "" {
+		groups = append(groups, "timestamps")
+		name, args = "CreateGitCommitWithDates", append(base, p.AuthorTimestamp, p.CommitTimestamp)
+	}
+	if p.Insertions != 0 || p.Deletions != 0 || p.FilesChanged != 0 {
+		groups = append(groups, "stats")
+		name, args = "CreateGitCommitWithStats", append(base, strconv.Itoa(p.Insertions), strconv.Itoa(p.Deletions), strconv.Itoa(p.FilesChanged))
+	}
+	if len(p.IssueRefs) > 0 {
+		groups = append(groups, "IssueRefs")
+		name, args = "CreateGitCommitWithIssues", append(base, strings.Join(p.IssueRefs, ","))
+	}
+	if len(groups) > 1 {
+		return "", nil, fmt.Errorf("the payload sets %s, which no single contract function records together", strings.Join(groups, " and "))
+	}
+	return name, args, nil
+}
+
+// CreateGitCommitFromFile creates the commit described by a JSON file, checking it before anything is submitted.
+// With -sign the payload may only hold the basic fields, which are all the signature covers.
+func createGitCommitFromFile(ctx context.Context, contract *client.Contract, sign identity.Sign, path string, stdin, strict, signed bool) {
+	input, source, err := openInput(path, stdin)
+	if err != nil {
+		fmt.Printf("Failed to read commit: %v\n", err)
+		return
+	}
+	defer input.Close()
+	payload, err := readCommitPayload(input, source, strict)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	name, args, err := payload.transaction()
+	if err != nil {
+		fmt.Printf("Invalid commit %s: %v\n", source, err)
+		return
+	}
+	if signed {
+		if name != "CreateGitCommit" {
+			fmt.Printf("Invalid commit %s: a signed commit can only have CommitHash, Repository, CommitMessage and Author\n", source)
+			return
+		}
+		createSignedGitCommit(ctx, contract, sign, payload.CommitHash, payload.Repository, payload.CommitMessage, payload.Author)
+		return
+	}
+
+	fmt.Printf("--> Submit Transaction: %s\n", name)
+	_, err = submitTransaction(ctx, contract, name, args...)
+	if err != nil {
+		fmt.Printf("Failed to submit %s transaction: %v\n", name, err)
+		printValidationErrors(err)
+		return
+	}
+	fmt.Printf("%s transaction successfully submitted\n", name)
+}
+
+// gitLogImportFields is the number of tab separated fields of a line of -importRepo input: the commit hash,
+// author name, author date, commit date and subject
+const gitLogImportFields = 5
+
+// parseImportInput reads the commits of -importRepo from r: a JSON array of commit payloads when the input
+// starts with [, otherwise one commit per line in the tab separated git log format of the -importRepo help.
+// Commits without a repository are put in repository; commits of another repository are rejected. Every
+// commit is checked, and all problems are reported together, before anything is submitted.
+func parseImportInput(r io.Reader, repository string, strict bool) ([]*commitPayload, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 {
+		return nil, errors.New("the input holds no commits")
+	}
+
+	var payloads []*commitPayload
+	var labels []string
+	var problems []string
+	if trimmed[0] == '[' {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		if strict {
+			decoder.DisallowUnknownFields()
+		}
+		err = decoder.Decode(&payloads)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON array of commits: %w", err)
+		}
+		if decoder.More() {
+			return nil, errors.New("invalid JSON array of commits: unexpected data after the array")
+		}
+		for i := range payloads {
+			labels = append(labels, fmt.Sprintf("commit %d", i+1))
+		}
+	} else {
+		for i, line := range strings.Split(string(trimmed), "\n") {
+			line = strings.TrimRight(line, "\r")
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			label := fmt.Sprintf("line %d", i+1)
+			fields := strings.SplitN(line, "\t", gitLogImportFields)
+			if len(fields) != gitLogImportFields {
+				problems = append(problems, fmt.Sprintf("%s: expected %d tab separated fields, got %d", label, gitLogImportFields, len(fields)))
+				continue
+			}
+			payloads = append(payloads, &commitPayload{
+				CommitHash:      strings.TrimSpace(fields[0]),
+				Author:          strings.TrimSpace(fields[1]),
+				AuthorTimestamp: strings.TrimSpace(fields[2]),
+				CommitTimestamp: strings.TrimSpace(fields[3]),
+				CommitMessage:   strings.TrimSpace(fields[4]),
+			})
+			labels = append(labels, label)
+		}
+	}
+	if len(payloads) == 0 && len(problems) == 0 {
+		return nil, errors.New("the input holds no commits")
+	}
+
+	for i, payload := range payloads {
+		if payload == nil {
+			problems = append(problems, labels[i]+": must be a JSON object")
+			continue
+		}
+		if payload.Repository == "" {
+			payload.Repository = repository
+		} else if payload.Repository != repository {
+			problems = append(problems, fmt.Sprintf("%s: belongs to repository %s, not %s", labels[i], payload.Repository, repository))
+			continue
+		}
+		_, _, err = payload.transaction()
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", labels[i], err))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "\n"))
+	}
+	return payloads, nil
+}
+
+// importRepository records a batch of commits in a repository, read from a file or standard input, in input
+// order so that parents come before their merges. Commits the ledger already has are skipped, like -autoRecord
+// does.
+func importRepository(ctx context.Context, contract *client.Contract, repository, path string, stdin, strict bool) {
+	if repository == "" || (path == "" && !stdin) {
+		fmt.Println("-importRepo needs -repo and either -file or -stdin")
+		os.Exit(2)
+	}
+	input, source, err := openInput(path, stdin)
+	if err != nil {
+		fmt.Printf("Failed to read commits: %v\n", err)
+		os.Exit(1)
+	}
+	defer input.Close()
+	payloads, err := parseImportInput(input, repository, strict)
+	if err != nil {
+		fmt.Printf("Invalid commits in %s:\n%v\n", source, err)
+		os.Exit(1)
+	}
+
+	created, existing := 0, 0
+	for _, payload := range payloads {
+		name, args, _ := payload.transaction()
+		fmt.Printf("--> Submit Transaction: %s %s\n", name, payload.CommitHash)
+		_, err = submitRepositoryTransaction(ctx, contract, repository, name, args...)
+		if err != nil && strings.Contains(err.Error(), "already exists") {
+			existing++
+			continue
+		}
+		if err != nil {
+			fmt.Printf("Failed to submit %s transaction: %v\n", name, err)
+			fmt.Printf("Imported %d of %d commits into %s before the failure\n", created, len(payloads), repository)
+			os.Exit(1)
+		}
+		created++
+	}
+	fmt.Printf("Imported %d of %d commits into %s, %d were already recorded\n", created, len(payloads), repository, existing)
+}
+
+// CreateMergeCommit issues a merge commit joining two parent commits of the same repository.
+func createMergeCommit(ctx context.Context, contract *client.Contract, mergeHash, repository, parents, commitMessage, author string) {
+	parentHashes := strings.Split(parents, ",")
+	if len(parentHashes) != 2 {
+		fmt.Printf("A merge commit needs exactly two parents, got %q\n", parents)
+		return
+	}
+
+	fmt.Println("--> Submit Transaction: CreateMergeCommit")
+	_, err := submitTransaction(ctx, contract, "CreateMergeCommit", mergeHash, repository, strings.TrimSpace(parentHashes[0]), strings.TrimSpace(parentHashes[1]), commitMessage, author)
+	if err != nil {
+		fmt.Printf("Failed to submit CreateMergeCommit transaction: %v\n", err)
+		return
+	}
+	fmt.Println("CreateMergeCommit transaction successfully submitted")
+}
+
+// GetMergeCommits returns the merge commits of a repository.
+func getMergeCommits(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Evaluate Transaction: GetMergeCommits")
+	result, err := evaluateTransaction(ctx, contract, "GetMergeCommits", repository)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetMergeCommits transaction: %v\n", err)
+		return
+	}
+	var gitCommits []*GitCommit
+	err = json.Unmarshal(result, &gitCommits)
+	if err != nil {
+		fmt.Printf("Failed to unmarshal result: %v\n", err)
+		return
+	}
+	prettyGitResult, err := marshalOutput(gitCommits)
+	if err != nil {
+		fmt.Printf("Failed to format result: %v\n", err)
+		return
+	}
+	fmt.Printf("GetMergeCommits transaction successfully evaluated, result: %s\n", string(prettyGitResult))
+}
+
+// GetGitCommitsProjection returns only the selected fields of the commits, optionally limited to one repository.
+func getGitCommitsProjection(ctx context.Context, contract *client.Contract, repository, fields string, strict bool) {
+	fmt.Println("--> Evaluate Transaction: GetGitCommitsProjection")
+	result, err := evaluateTransaction(ctx, contract, "GetGitCommitsProjection", repository, fields, strconv.FormatBool(strict))
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetGitCommitsProjection transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetGitCommitsProjection transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// GetPushesByEnvironment returns the pushes of a repository deployed to an environment; the last one is what
+// the environment runs now.
+func getPushesByEnvironment(ctx context.Context, contract *client.Contract, repository, environment string) {
+	fmt.Println("--> Evaluate Transaction: GetPushesByEnvironment")
+	result, err := evaluateTransaction(ctx, contract, "GetPushesByEnvironment", repository, environment)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetPushesByEnvironment transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetPushesByEnvironment transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// GetCurrentDeployment returns the latest push of a repository deployed to an environment.
+func getCurrentDeployment(ctx context.Context, contract *client.Contract, repository, environment string) {
+	fmt.Println("--> Evaluate Transaction: GetCurrentDeployment")
+	result, err := evaluateTransaction(ctx, contract, "GetCurrentDeployment", repository, environment)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetCurrentDeployment transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetCurrentDeployment transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// GetPushTransactionsByRepository returns the push transactions of a repository in version order.
+func getPushTransactionsByRepository(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Evaluate Transaction: GetPushTransactionsByRepository")
+	result, err := evaluateTransaction(ctx, contract, "GetPushTransactionsByRepository", repository)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetPushTransactionsByRepository transaction: %v\n", err)
+		return
+	}
+	var pushTransactions []*PushTransaction
+	err = json.Unmarshal(result, &pushTransactions)
+	if err != nil {
+		fmt.Printf("Failed to unmarshal result: %v\n", err)
+		return
+	}
+	prettyResult, err := marshalOutput(pushTransactions)
+	if err != nil {
+		fmt.Printf("Failed to format result: %v\n", err)
+		return
+	}
+	fmt.Printf("GetPushTransactionsByRepository transaction successfully evaluated, result: %s\n", string(prettyResult))
+}
+
+// MigratePushTransactionKeys moves push transactions stored under legacy timestamp keys to version ordered keys.
+func migratePushTransactionKeys(ctx context.Context, contract *client.Contract) {
+	fmt.Println("--> Submit Transaction: MigratePushTransactionKeys")
+	result, err := submitTransaction(ctx, contract, "MigratePushTransactionKeys")
+	if err != nil {
+		fmt.Printf("Failed to submit MigratePushTransactionKeys transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("MigratePushTransactionKeys transaction successfully submitted, migrated: %s\n", string(result))
+}
+
+// MigrateToRepositoryScopedCommits moves commits to repository scoped keys and enables that mode.
+func migrateToRepositoryScopedCommits(ctx context.Context, contract *client.Contract) {
+	fmt.Println("--> Submit Transaction: MigrateToRepositoryScopedCommits")
+	result, err := submitTransaction(ctx, contract, "MigrateToRepositoryScopedCommits")
+	if err != nil {
+		fmt.Printf("Failed to submit MigrateToRepositoryScopedCommits transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("MigrateToRepositoryScopedCommits transaction successfully submitted, migrated: %s\n", string(result))
+}
+
+// ReadGitCommitInRepository returns the commit with the given hash in one repository.
+func readGitCommitInRepository(ctx context.Context, contract *client.Contract, repository, commitHash string) {
+	fmt.Println("--> Evaluate Transaction: ReadGitCommitInRepository")
+	result, err := evaluateTransaction(ctx, contract, "ReadGitCommitInRepository", repository, commitHash)
+	if err != nil {
+		fmt.Printf("Failed to evaluate ReadGitCommitInRepository transaction: %v\n", err)
+		return
+	}
+	var gitCommit GitCommit
+	err = json.Unmarshal(result, &gitCommit)
+	if err != nil {
+		fmt.Printf("Failed to unmarshal result: %v\n", err)
+		return
+	}
+	gitCommitResult, err := marshalOutput(gitCommit)
+	if err != nil {
+		fmt.Printf("Failed to format result: %v\n", err)
+		return
+	}
+	fmt.Printf("ReadGitCommitInRepository transaction successfully evaluated, result: %s\n", string(gitCommitResult))
+}
+
+// DeleteGitCommit removes a commit that is not referenced by any other record. With cascade, the push
+// transactions of the commit are removed with it. With tombstone, a tombstone recording the deletion and its
+// reason is left under the hash.
+func deleteGitCommit(ctx context.Context, contract *client.Contract, commitHash string, cascade, tombstone bool, reason string) {
+	mode := "purge"
+	if tombstone {
+		mode = "tombstone"
+	}
+	fmt.Println("--> Submit Transaction: DeleteGitCommit")
+	_, err := submitTransaction(ctx, contract, "DeleteGitCommit", commitHash, strconv.FormatBool(cascade), mode, reason)
+	if err != nil {
+		fmt.Printf("Failed to submit DeleteGitCommit transaction: %v\n", err)
+		return
+	}
+	fmt.Println("DeleteGitCommit transaction successfully submitted")
+}
+
+// ForceDeleteGitCommit removes a commit even if it is still referenced.
+func forceDeleteGitCommit(ctx context.Context, contract *client.Contract, commitHash string) {
+	fmt.Println("--> Submit Transaction: ForceDeleteGitCommit")
+	_, err := submitTransaction(ctx, contract, "ForceDeleteGitCommit", commitHash)
+	if err != nil {
+		fmt.Printf("Failed to submit ForceDeleteGitCommit transaction: %v\n", err)
+		return
+	}
+	fmt.Println("ForceDeleteGitCommit transaction successfully submitted")
+}
+
+// GetTombstones returns the tombstones of the commits deleted from a repository.
+func getTombstones(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Evaluate Transaction: GetTombstones")
+	result, err := evaluateTransaction(ctx, contract, "GetTombstones", repository)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetTombstones transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetTombstones transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// GetCommitReferences returns the records that refer to a commit.
+func getCommitReferences(ctx context.Context, contract *client.Contract, commitHash string) {
+	fmt.Println("--> Evaluate Transaction: GetCommitReferences")
+	result, err := evaluateTransaction(ctx, contract, "GetCommitReferences", commitHash)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetCommitReferences transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetCommitReferences transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// GetCommitHistory lists the changes of a commit record from the ledger history.
+func getCommitHistory(ctx context.Context, contract *client.Contract, commitHash string) {
+	fmt.Println("--> Evaluate Transaction: GetCommitHistory")
+	result, err := evaluateTransaction(ctx, contract, "GetCommitHistory", commitHash)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetCommitHistory transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetCommitHistory transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// GrantRepositoryAccess allows an organization to write to a repository.
+func grantRepositoryAccess(ctx context.Context, contract *client.Contract, repository, mspID string) {
+	fmt.Println("--> Submit Transaction: GrantRepositoryAccess")
+	_, err := submitTransaction(ctx, contract, "GrantRepositoryAccess", repository, mspID)
+	if err != nil {
+		fmt.Printf("Failed to submit GrantRepositoryAccess transaction: %v\n", err)
+		return
+	}
+	fmt.Println("GrantRepositoryAccess transaction successfully submitted")
+}
+
+// RevokeRepositoryAccess removes the write access of an organization to a repository.
+func revokeRepositoryAccess(ctx context.Context, contract *client.Contract, repository, mspID string) {
+	fmt.Println("--> Submit Transaction: RevokeRepositoryAccess")
+	_, err := submitTransaction(ctx, contract, "RevokeRepositoryAccess", repository, mspID)
+	if err != nil {
+		fmt.Printf("Failed to submit RevokeRepositoryAccess transaction: %v\n", err)
+		return
+	}
+	fmt.Println("RevokeRepositoryAccess transaction successfully submitted")
+}
+
+// GetRepositoryAccess returns the organizations allowed to write to a repository.
+func getRepositoryAccess(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Evaluate Transaction: GetRepositoryAccess")
+	result, err := evaluateTransaction(ctx, contract, "GetRepositoryAccess", repository)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetRepositoryAccess transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetRepositoryAccess transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// CreateSignedGitCommit issues a new GitCommit together with a signature over its content made with the client
+// key. The content is the JSON array of hash, repository, message and author, and the key signs its SHA-256
+// digest, matching what the chaincode verifies against the client certificate.
+func createSignedGitCommit(ctx context.Context, contract *client.Contract, sign identity.Sign, commitHash, repository, commitMessage, author string) {
+	fmt.Println("--> Submit Transaction: CreateSignedGitCommit")
+	content, err := json.Marshal([]string{commitHash, repository, commitMessage, author})
+	if err != nil {
+		fmt.Printf("Failed to encode commit content: %v\n", err)
+		return
+	}
+	digest := sha256.Sum256(content)
+	signature, err := sign(digest[:])
+	if err != nil {
+		fmt.Printf("Failed to sign commit content: %v\n", err)
+		return
+	}
+
+	_, err = submitTransaction(ctx, contract, "CreateSignedGitCommit", commitHash, repository, commitMessage, author, base64.StdEncoding.EncodeToString(signature))
+	if err != nil {
+		fmt.Printf("Failed to submit CreateSignedGitCommit transaction: %v\n", err)
+		printValidationErrors(err)
+		return
+	}
+	fmt.Println("CreateSignedGitCommit transaction successfully submitted")
+}
+
+// VerifySubmitterSignature checks a signed commit against the signature its submitter made.
+func verifySubmitterSignature(ctx context.Context, contract *client.Contract, commitHash string) {
+	fmt.Println("--> Evaluate Transaction: VerifySubmitterSignature")
+	result, err := evaluateTransaction(ctx, contract, "VerifySubmitterSignature", commitHash)
+	if err != nil {
+		fmt.Printf("Failed to evaluate VerifySubmitterSignature transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("VerifySubmitterSignature transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// ComputeCommitScore returns the importance score of a commit.
+func computeCommitScore(ctx context.Context, contract *client.Contract, commitHash string) {
+	fmt.Println("--> Evaluate Transaction: ComputeCommitScore")
+	result, err := evaluateTransaction(ctx, contract, "ComputeCommitScore", commitHash)
+	if err != nil {
+		fmt.Printf("Failed to evaluate ComputeCommitScore transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("ComputeCommitScore transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// GetTopCommits returns the highest scoring commits of a repository.
+func getTopCommits(ctx context.Context, contract *client.Contract, repository string, limit int) {
+	fmt.Println("--> Evaluate Transaction: GetTopCommits")
+	result, err := evaluateTransaction(ctx, contract, "GetTopCommits", repository, strconv.Itoa(limit))
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetTopCommits transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetTopCommits transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// GetRepositoryTimeline prints the versions of a repository oldest first, one line each, with the push that
+// produced every version. With -compact or -envelope the timeline is printed as JSON instead.
+func getRepositoryTimeline(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Evaluate Transaction: GetRepositoryTimeline")
+	result, err := evaluateTransaction(ctx, contract, "GetRepositoryTimeline", repository)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetRepositoryTimeline transaction: %v\n", err)
+		return
+	}
+	if compactOutput || envelopeOutput {
+		fmt.Printf("GetRepositoryTimeline transaction successfully evaluated, result: %s\n", formatJSON(result))
+		return
+	}
+
+	var timeline []*VersionMilestone
+	err = json.Unmarshal(result, &timeline)
+	if err != nil {
+		fmt.Printf("Failed to unmarshal result: %v\n", err)
+		return
+	}
+	fmt.Printf("GetRepositoryTimeline transaction successfully evaluated, %s has %d versions:\n", repository, len(timeline))
+	for _, milestone := range timeline {
+		switch {
+		case milestone.Initial:
+			fmt.Printf("  v%-5d %-20s initial version\n", milestone.Version, "")
+		case milestone.Gap:
+			fmt.Printf("  v%-5d %-20s no recorded push\n", milestone.Version, "")
+		default:
+			fmt.Printf("  v%-5d %-20s %s -> %s\n", milestone.Version, milestone.Timestamp, milestone.CommitHash, milestone.Push.RemoteURL)
+		}
+	}
+}
+
+// InvokeTransaction submits any contract function, for functions without a dedicated flag such as those that
+// take their input from -transient data.
+func invokeTransaction(ctx context.Context, contract *client.Contract, name string, args []string) {
+	fmt.Printf("--> Submit Transaction: %s\n", name)
+	result, err := submitTransaction(ctx, contract, name, args...)
+	if err != nil {
+		fmt.Printf("Failed to submit %s transaction: %v\n", name, err)
+		return
+	}
+	if !json.Valid(result) {
+		// Not every function returns JSON, or anything at all
+		fmt.Printf("%s transaction successfully submitted, result: %s\n", name, result)
+		return
+	}
+	fmt.Printf("%s transaction successfully submitted, result: %s\n", name, formatJSON(result))
+}
+
+// ValidateRepositoryDAG checks the commit graph of a repository and prints the report of any dangling parents
+// or cycles.
+func validateRepositoryDAG(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Evaluate Transaction: ValidateRepositoryDAG")
+	result, err := evaluateTransaction(ctx, contract, "ValidateRepositoryDAG", repository)
+	if err != nil {
+		fmt.Printf("Failed to evaluate ValidateRepositoryDAG transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("ValidateRepositoryDAG transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// GetVersionGaps returns the versions of a repository that no push records.
+func getVersionGaps(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Evaluate Transaction: GetVersionGaps")
+	result, err := evaluateTransaction(ctx, contract, "GetVersionGaps", repository)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetVersionGaps transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetVersionGaps transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// GetCommitsTopological returns the commits of a repository with every parent before its children. A cycle in
+// the commit graph fails the query; -validateDag reports where it is.
+func getCommitsTopological(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Evaluate Transaction: GetCommitsTopological")
+	result, err := evaluateTransaction(ctx, contract, "GetCommitsTopological", repository)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetCommitsTopological transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetCommitsTopological transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// PruneCommitsBefore deletes the old commits of a repository and prints which were pruned and which were kept.
+func pruneCommitsBefore(ctx context.Context, contract *client.Contract, repository, before string, keep int) {
+	fmt.Println("--> Submit Transaction: PruneCommitsBefore")
+	result, err := submitTransaction(ctx, contract, "PruneCommitsBefore", repository, before, strconv.Itoa(keep))
+	if err != nil {
+		fmt.Printf("Failed to submit PruneCommitsBefore transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("PruneCommitsBefore transaction successfully submitted, result: %s\n", formatJSON(result))
+}
+
+// GetPruneHistory returns the pruning runs recorded for a repository.
+func getPruneHistory(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Evaluate Transaction: GetPruneHistory")
+	result, err := evaluateTransaction(ctx, contract, "GetPruneHistory", repository)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetPruneHistory transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetPruneHistory transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// casAttempts bounds how often -incrementVersion retries after losing a race to another client
+const casAttempts = 5
+
+// IncrementVersionNumber advances the version of a repository with optimistic concurrency: it reads the
+// current version, asks the chaincode to increment it only if it is unchanged and, when another client got
+// there first, reads it again and retries.
+func incrementVersionNumber(ctx context.Context, contract *client.Contract, repository string) {
+	for attempt := 1; attempt <= casAttempts; attempt++ {
+		fmt.Println("--> Evaluate Transaction: GetRepositoryVersion")
+		result, err := evaluateTransaction(ctx, contract, "GetRepositoryVersion", repository)
+		if err != nil {
+			fmt.Printf("Failed to evaluate GetRepositoryVersion transaction: %v\n", err)
+			return
+		}
+		var repoVersion RepositoryVersion
+		err = json.Unmarshal(result, &repoVersion)
+		if err != nil {
+			fmt.Printf("Failed to unmarshal result: %v\n", err)
+			return
+		}
+
+		fmt.Println("--> Submit Transaction: IncrementVersionNumberCAS")
+		result, err = submitTransaction(ctx, contract, "IncrementVersionNumberCAS", repository, strconv.Itoa(repoVersion.VersionNumber))
+		if err == nil {
+			fmt.Printf("IncrementVersionNumberCAS transaction successfully submitted, %s is now at version %s\n", repository, result)
+			return
+		}
+		if !isVersionConflict(err) {
+			fmt.Printf("Failed to submit IncrementVersionNumberCAS transaction: %v\n", err)
+			return
+		}
+		fmt.Printf("*** Version %d of %s was advanced by another transaction (attempt %d of %d)\n", repoVersion.VersionNumber, repository, attempt, casAttempts)
+	}
+	fmt.Printf("Failed to increment the version of %s: it kept changing after %d attempts\n", repository, casAttempts)
+}
+
+// FlagSecurityIssue sets the security level of a commit, optionally linking the advisory that describes it.
+func flagSecurityIssue(ctx context.Context, contract *client.Contract, commitHash, level, advisoryURL string) {
+	fmt.Println("--> Submit Transaction: FlagSecurityIssue")
+	_, err := submitTransaction(ctx, contract, "FlagSecurityIssue", commitHash, level, advisoryURL)
+	if err != nil {
+		fmt.Printf("Failed to submit FlagSecurityIssue transaction: %v\n", err)
+		return
+	}
+	fmt.Println("FlagSecurityIssue transaction successfully submitted")
+}
+
+// GetLedgerSummary returns the totals of the whole ledger.
+func getLedgerSummary(ctx context.Context, contract *client.Contract) {
+	fmt.Println("--> Evaluate Transaction: GetLedgerSummary")
+	result, err := evaluateTransaction(ctx, contract, "GetLedgerSummary")
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetLedgerSummary transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetLedgerSummary transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// ReconcileRepositoryVersion reports whether the version counter of a repository is behind its commits. The check
+// is only evaluated; with repair it is submitted, so that the counter is raised.
+func reconcileRepositoryVersion(ctx context.Context, contract *client.Contract, repository string, repair bool) {
+	if !repair {
+		fmt.Println("--> Evaluate Transaction: ReconcileRepositoryVersion")
+		result, err := evaluateTransaction(ctx, contract, "ReconcileRepositoryVersion", repository, "false")
+		if err != nil {
+			fmt.Printf("Failed to evaluate ReconcileRepositoryVersion transaction: %v\n", err)
+			return
+		}
+		fmt.Printf("ReconcileRepositoryVersion transaction successfully evaluated, result: %s\n", formatJSON(result))
+		return
+	}
+
+	fmt.Println("--> Submit Transaction: ReconcileRepositoryVersion")
+	result, err := submitTransaction(ctx, contract, "ReconcileRepositoryVersion", repository, "true")
+	if err != nil {
+		fmt.Printf("Failed to submit ReconcileRepositoryVersion transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("ReconcileRepositoryVersion transaction successfully submitted, result: %s\n", formatJSON(result))
+}
+
+// csvPageSize is the number of commits -exportCsv fetches per page, bounding how many it holds at once
+const csvPageSize = 500
+
+// exportCommitsCSV writes commits as CSV to the file out, or to standard output if out is empty. The commits of
+// a single repository are fetched with QueryCommitsByRepository; a full export pages through
+// GetAllGitCommitsWithPagination and writes each page before fetching the next.
+func exportCommitsCSV(ctx context.Context, contract *client.Contract, repository, out string) {
+	var output io.Writer = os.Stdout
+	if out != "" {
+		file, err := os.Create(out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", out, err)
+			return
+		}
+		defer file.Close()
+		output = file
+	}
+
+	writer := newCommitCSVWriter(output)
+	err := writer.writeHeader()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write CSV: %v\n", err)
+		return
+	}
+
+	if repository != "" {
+		result, err := evaluateTransaction(ctx, contract, "QueryCommitsByRepository", repository)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to evaluate QueryCommitsByRepository transaction: %v\n", err)
+			return
+		}
+		var gitCommits []*GitCommit
+		err = json.Unmarshal(result, &gitCommits)
+		if err == nil {
+			err = writer.write(gitCommits)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to export commits: %v\n", err)
+			return
+		}
+	} else {
+		bookmark := ""
+		for {
+			result, err := evaluateTransaction(ctx, contract, "GetAllGitCommitsWithPagination", strconv.Itoa(csvPageSize), bookmark)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to evaluate GetAllGitCommitsWithPagination transaction: %v\n", err)
+				return
+			}
+			var page struct {
+				Records  []*GitCommit `json:"records"`
+				Bookmark string       `json:"bookmark"`
+			}
+			err = json.Unmarshal(result, &page)
+			if err == nil {
+				err = writer.write(page.Records)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to export commits: %v\n", err)
+				return
+			}
+			if page.Bookmark == "" || len(page.Records) == 0 {
+				break
+			}
+			bookmark = page.Bookmark
+		}
+	}
+
+	if out != "" {
+		fmt.Printf("Exported %d commits to %s\n", writer.count, out)
+	}
+}
+
+// commitCSVWriter writes GitCommits as CSV rows, one column per GitCommit field named by its JSON key.
+// encoding/csv quotes fields containing commas, quotes or line breaks as RFC 4180 describes. List and map
+// fields are written as JSON.
+type commitCSVWriter struct {
+	csv   *csv.Writer
+	count int
+}
+
+func newCommitCSVWriter(w io.Writer) *commitCSVWriter {
+	return &commitCSVWriter{csv: csv.NewWriter(w)}
+}
+
+// csvColumn returns the column name of a GitCommit field: its JSON key.
+func csvColumn(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func (w *commitCSVWriter) writeHeader() error {
+	commitType := reflect.TypeOf(GitCommit{})
+	header := make([]string, commitType.NumField())
+	for i := range header {
+		header[i] = csvColumn(commitType.Field(i))
+	}
+	err := w.csv.Write(header)
+	if err != nil {
+		return err
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// write writes one row per commit and flushes them, so that memory stays bounded by the page being written.
+func (w *commitCSVWriter) write(gitCommits []*GitCommit) error {
+	for _, gitCommit := range gitCommits {
+		value := reflect.ValueOf(gitCommit).Elem()
+		record := make([]string, value.NumField())
+		for i := range record {
+			field := value.Field(i)
+			switch field.Kind() {
+			case reflect.Slice, reflect.Map:
+				if field.Len() == 0 {
+					continue
+				}
+				cell, err := json.Marshal(field.Interface())
+				if err != nil {
+					return err
+				}
+				record[i] = string(cell)
+			default:
+				record[i] = fmt.Sprint(field.Interface())
+			}
+		}
+		err := w.csv.Write(record)
+		if err != nil {
+			return err
+		}
+		w.count++
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// PinCommit and UnpinCommit control whether retention pruning may remove a commit; name selects which one runs.
+func pinCommit(ctx context.Context, contract *client.Contract, name, commitHash string) {
+	fmt.Printf("--> Submit Transaction: %s\n", name)
+	_, err := submitTransaction(ctx, contract, name, commitHash)
+	if err != nil {
+		fmt.Printf("Failed to submit %s transaction: %v\n", name, err)
+		return
+	}
+	fmt.Printf("%s transaction successfully submitted\n", name)
+}
+
+// GetPinnedCommits returns the pinned commits of a repository.
+func getPinnedCommits(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Evaluate Transaction: GetPinnedCommits")
+	result, err := evaluateTransaction(ctx, contract, "GetPinnedCommits", repository)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetPinnedCommits transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetPinnedCommits transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// GetIncompleteCommits returns the commits of a repository that lack any of the fields recommended by the
+// contract settings, with the fields each one lacks.
+func getIncompleteCommits(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Evaluate Transaction: GetIncompleteCommits")
+	result, err := evaluateTransaction(ctx, contract, "GetIncompleteCommits", repository)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetIncompleteCommits transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetIncompleteCommits transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// FindDuplicateCommitHashes returns the commit hashes recorded in more than one repository, with their
+// repositories.
+func findDuplicateCommitHashes(ctx context.Context, contract *client.Contract) {
+	fmt.Println("--> Evaluate Transaction: FindDuplicateCommitHashes")
+	result, err := evaluateTransaction(ctx, contract, "FindDuplicateCommitHashes")
+	if err != nil {
+		fmt.Printf("Failed to evaluate FindDuplicateCommitHashes transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("FindDuplicateCommitHashes transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// ForkRepository creates a repository that starts from the version of an existing one.
+func forkRepository(ctx context.Context, contract *client.Contract, repository, fork string) {
+	fmt.Println("--> Submit Transaction: ForkRepository")
+	_, err := submitTransaction(ctx, contract, "ForkRepository", repository, fork)
+	if err != nil {
+		fmt.Printf("Failed to submit ForkRepository transaction: %v\n", err)
+		return
+	}
+	fmt.Println("ForkRepository transaction successfully submitted")
+}
+
+// GetForks returns the version records of the repositories forked from a repository.
+func getForks(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Evaluate Transaction: GetForks")
+	result, err := evaluateTransaction(ctx, contract, "GetForks", repository)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetForks transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetForks transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// repositoryComparison splits the commits of two repositories by whether their hash occurs in the other. Each
+// list keeps the commit time order of the repository it was taken from, the first one for common.
+type repositoryComparison struct {
+	onlyInA []*GitCommit
+	onlyInB []*GitCommit
+	common  []*GitCommit
+}
+
+// compareRepositoryCommits compares two commit sets by hash.
+func compareRepositoryCommits(a, b []*GitCommit) repositoryComparison {
+	inA := make(map[string]bool, len(a))
+	for _, gitCommit := range a {
+		inA[gitCommit.CommitHash] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, gitCommit := range b {
+		inB[gitCommit.CommitHash] = true
+	}
+
+	var comparison repositoryComparison
+	for _, gitCommit := range a {
+		if inB[gitCommit.CommitHash] {
+			comparison.common = append(comparison.common, gitCommit)
+		} else {
+			comparison.onlyInA = append(comparison.onlyInA, gitCommit)
+		}
+	}
+	for _, gitCommit := range b {
+		if !inA[gitCommit.CommitHash] {
+			comparison.onlyInB = append(comparison.onlyInB, gitCommit)
+		}
+	}
+	return comparison
+}
+
+// printCommitSection prints a titled list of commits with the first line of their message.
+func printCommitSection(title string, gitCommits []*GitCommit) {
+	fmt.Printf("%s (%d):\n", title, len(gitCommits))
+	if len(gitCommits) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, gitCommit := range gitCommits {
+		summary, _, _ := strings.Cut(gitCommit.CommitMessage, "\n")
+		fmt.Printf("  %s %s\n", gitCommit.CommitHash, summary)
+	}
+}
+
+// compareRepositories reports the commits unique to each of two repositories and those they share. Hashes are
+// only recorded in several repositories once commits are repository scoped, or when comparing mirrors.
+func compareRepositories(ctx context.Context, contract *client.Contract, a, b string) {
+	if a == "" || b == "" {
+		fmt.Println("Both -repo and -other are required for -compareRepos")
+		return
+	}
+
+	commits := make([][]*GitCommit, 2)
+	for i, repository := range []string{a, b} {
+		fmt.Printf("--> Evaluate Transaction: QueryCommitsByRepository %s\n", repository)
+		result, err := evaluateTransaction(ctx, contract, "QueryCommitsByRepository", repository)
+		if err != nil {
+			fmt.Printf("Failed to evaluate QueryCommitsByRepository transaction: %v\n", err)
+			return
+		}
+		err = json.Unmarshal(result, &commits[i])
+		if err != nil {
+			fmt.Printf("Failed to parse the commits of %s: %v\n", repository, err)
+			return
+		}
+		if len(commits[i]) == 0 {
+			fmt.Printf("*** Repository %s has no commits\n", repository)
+		}
+	}
+
+	comparison := compareRepositoryCommits(commits[0], commits[1])
+	printCommitSection("Only in "+a, comparison.onlyInA)
+	printCommitSection("Only in "+b, comparison.onlyInB)
+	printCommitSection("Common", comparison.common)
+	if len(comparison.onlyInA) == 0 && len(comparison.onlyInB) == 0 {
+		fmt.Printf("Repositories %s and %s are in sync\n", a, b)
+	} else {
+		fmt.Printf("Repositories %s and %s differ\n", a, b)
+	}
+}
+
+// tagImport sorts the entries of a tag mapping by what importing them does, each list ordered by tag name.
+type tagImport struct {
+	create   []tagEntry
+	existing []tagEntry
+	dangling []tagEntry
+}
+
+// tagEntry is a tag name and the commit hash it names.
+type tagEntry struct {
+	name       string
+	commitHash string
+}
+
+// parseTagMapping reads a JSON object mapping tag names to commit hashes, such as {"v1.0": "a1b2c3"}, and
+// returns its entries ordered by tag name. The first malformed entry fails the whole mapping.
+func parseTagMapping(data []byte) ([]tagEntry, error) {
+	var mapping map[string]json.RawMessage
+	err := json.Unmarshal(data, &mapping)
+	if err != nil {
+		return nil, fmt.Errorf("the tag mapping must be a JSON object of tag names to commit hashes: %w", err)
+	}
+	if len(mapping) == 0 {
+		return nil, fmt.Errorf("the tag mapping is empty")
+	}
+
+	entries := make([]tagEntry, 0, len(mapping))
+	for name, value := range mapping {
+		var commitHash string
+		err = json.Unmarshal(value, &commitHash)
+		if err != nil {
+			return nil, fmt.Errorf("the commit hash of tag %q must be a string, got %s", name, value)
+		}
+		entry := tagEntry{name: strings.TrimSpace(name), commitHash: strings.TrimSpace(commitHash)}
+		if entry.name == "" {
+			return nil, fmt.Errorf("the tag mapping contains an empty tag name")
+		}
+		if strings.IndexFunc(entry.name, unicode.IsSpace) >= 0 {
+			return nil, fmt.Errorf("the tag name %q must not contain whitespace", entry.name)
+		}
+		if entry.commitHash == "" {
+			return nil, fmt.Errorf("the commit hash of tag %q must not be empty", entry.name)
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+	return entries, nil
+}
+
+// planTagImport decides for each entry of a tag mapping whether to create it, skip it because the repository
+// already has a tag of that name, or report it because its commit is not in the repository.
+func planTagImport(entries []tagEntry, existingTags map[string]string, commits map[string]bool) tagImport {
+	var plan tagImport
+	for _, entry := range entries {
+		if commitHash, ok := existingTags[entry.name]; ok {
+			plan.existing = append(plan.existing, tagEntry{name: entry.name, commitHash: commitHash})
+		} else if !commits[entry.commitHash] {
+			plan.dangling = append(plan.dangling, entry)
+		} else {
+			plan.create = append(plan.create, entry)
+		}
+	}
+	return plan
+}
+
+// importTags creates the tags of a mapping file in a repository with CreateTag, for migrating the tags of an
+// existing git repository. Tags the repository already has are skipped, and tags of commits it does not have
+// are reported instead of submitted.
+func importTags(ctx context.Context, contract *client.Contract, repository, path string) {
+	if repository == "" || path == "" {
+		fmt.Println("Both -repo and -file are required for -importTags")
+		os.Exit(2)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("Failed to read tag mapping: %v\n", err)
+		os.Exit(1)
+	}
+	entries, err := parseTagMapping(data)
+	if err != nil {
+		fmt.Printf("Invalid tag mapping %s: %v\n", path, err)
+		os.Exit(1)
+	}
+
+	fmt.Println("--> Evaluate Transaction: GetTags")
+	result, err := evaluateTransaction(ctx, contract, "GetTags", repository)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetTags transaction: %v\n", err)
+		return
+	}
+	var tags []struct {
+		Name       string `json:"Name"`
+		CommitHash string `json:"CommitHash"`
+	}
+	err = json.Unmarshal(result, &tags)
+	if err != nil {
+		fmt.Printf("Failed to parse the tags of %s: %v\n", repository, err)
+		return
+	}
+	existingTags := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		existingTags[tag.Name] = tag.CommitHash
+	}
+
+	fmt.Println("--> Evaluate Transaction: QueryCommitsByRepository")
+	result, err = evaluateTransaction(ctx, contract, "QueryCommitsByRepository", repository)
+	if err != nil {
+		fmt.Printf("Failed to evaluate QueryCommitsByRepository transaction: %v\n", err)
+		return
+	}
+	var gitCommits []*GitCommit
+	err = json.Unmarshal(result, &gitCommits)
+	if err != nil {
+		fmt.Printf("Failed to parse the commits of %s: %v\n", repository, err)
+		return
+	}
+	commits := make(map[string]bool, len(gitCommits))
+	for _, gitCommit := range gitCommits {
+		commits[gitCommit.CommitHash] = true
+	}
+
+	plan := planTagImport(entries, existingTags, commits)
+	failed := 0
+	for _, entry := range plan.create {
+		fmt.Printf("--> Submit Transaction: CreateTag %s %s\n", entry.name, entry.commitHash)
+		_, err = submitRepositoryTransaction(ctx, contract, repository, "CreateTag", repository, entry.name, entry.commitHash)
+		if err != nil {
+			fmt.Printf("Failed to submit CreateTag transaction: %v\n", err)
+			failed++
+		}
+	}
+
+	fmt.Printf("Created %d of %d tags in %s\n", len(plan.create)-failed, len(entries), repository)
+	if len(plan.existing) > 0 {
+		fmt.Printf("Skipped %d tags that already exist:\n", len(plan.existing))
+		for _, entry := range plan.existing {
+			fmt.Printf("  %s -> %s\n", entry.name, entry.commitHash)
+		}
+	}
+	if len(plan.dangling) > 0 {
+		fmt.Printf("Skipped %d tags of commits missing from %s:\n", len(plan.dangling), repository)
+		for _, entry := range plan.dangling {
+			fmt.Printf("  %s -> %s\n", entry.name, entry.commitHash)
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("%d CreateTag transactions failed\n", failed)
+		os.Exit(1)
+	}
+}
+
+// CreateRepositorySnapshot records the state of a repository under a name and returns the snapshot.
+func createRepositorySnapshot(ctx context.Context, contract *client.Contract, repository, name string) {
+	fmt.Println("--> Submit Transaction: CreateRepositorySnapshot")
+	result, err := submitRepositoryTransaction(ctx, contract, repository, "CreateRepositorySnapshot", repository, name)
+	if err != nil {
+		fmt.Printf("Failed to submit CreateRepositorySnapshot transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("CreateRepositorySnapshot transaction successfully submitted, result: %s\n", formatJSON(result))
+}
+
+// CompareSnapshots lists the commits, heads and tags that changed between two snapshots of a repository.
+func compareSnapshots(ctx context.Context, contract *client.Contract, repository, from, to string) {
+	fmt.Println("--> Evaluate Transaction: CompareSnapshots")
+	result, err := evaluateTransaction(ctx, contract, "CompareSnapshots", repository, from, to)
+	if err != nil {
+		fmt.Printf("Failed to evaluate CompareSnapshots transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("CompareSnapshots transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// CreateReleaseTrain creates an empty release train.
+func createReleaseTrain(ctx context.Context, contract *client.Contract, name, description string) {
+	fmt.Println("--> Submit Transaction: CreateReleaseTrain")
+	_, err := submitTransaction(ctx, contract, "CreateReleaseTrain", name, description)
+	if err != nil {
+		fmt.Printf("Failed to submit CreateReleaseTrain transaction: %v\n", err)
+		return
+	}
+	fmt.Println("CreateReleaseTrain transaction successfully submitted")
+}
+
+// AddCommitToTrain adds a commit to a release train.
+func addCommitToTrain(ctx context.Context, contract *client.Contract, name, commitHash string) {
+	fmt.Println("--> Submit Transaction: AddCommitToTrain")
+	_, err := submitTransaction(ctx, contract, "AddCommitToTrain", name, commitHash)
+	if err != nil {
+		fmt.Printf("Failed to submit AddCommitToTrain transaction: %v\n", err)
+		return
+	}
+	fmt.Println("AddCommitToTrain transaction successfully submitted")
+}
+
+// GetTrainCommits returns the commits of a release train, grouped by repository.
+func getTrainCommits(ctx context.Context, contract *client.Contract, name string) {
+	fmt.Println("--> Evaluate Transaction: GetTrainCommits")
+	result, err := evaluateTransaction(ctx, contract, "GetTrainCommits", name)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetTrainCommits transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetTrainCommits transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// MergeRepositories moves the commits and pushes of a repository recorded under another spelling into the
+// canonical one.
+func mergeRepositories(ctx context.Context, contract *client.Contract, from, into string) {
+	fmt.Println("--> Submit Transaction: MergeRepositories")
+	_, err := submitTransaction(ctx, contract, "MergeRepositories", from, into)
+	if err != nil {
+		fmt.Printf("Failed to submit MergeRepositories transaction: %v\n", err)
+		return
+	}
+	fmt.Println("MergeRepositories transaction successfully submitted")
+}
+
+// GetCommitsByIssue returns the commits linked to an issue reference.
+func getCommitsByIssue(ctx context.Context, contract *client.Contract, issueRef string) {
+	fmt.Println("--> Evaluate Transaction: GetCommitsByIssue")
+	result, err := evaluateTransaction(ctx, contract, "GetCommitsByIssue", issueRef)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetCommitsByIssue transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetCommitsByIssue transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// GetCommitsByType returns the commits of a repository with a Conventional Commits type, such as feat or fix.
+func getCommitsByType(ctx context.Context, contract *client.Contract, repository, commitType string) {
+	fmt.Println("--> Evaluate Transaction: GetCommitsByType")
+	result, err := evaluateTransaction(ctx, contract, "GetCommitsByType", repository, commitType)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetCommitsByType transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetCommitsByType transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// conventionalHeaderPattern matches the header of a Conventional Commits message and captures its type, scope
+// and description, like the pattern the contract indexes commit types with
+var conventionalHeaderPattern = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^()\r\n]*)\))?!?:\s+(.*)`)
+
+// changelogSections are the sections of a changelog in order, by the commit types they collect; the last one
+// takes every other commit
+var changelogSections = []struct {
+	title string
+	types []string
+}{
+	{"Features", []string{"feat"}},
+	{"Fixes", []string{"fix"}},
+	{"Other changes", nil},
+}
+
+// changelogTime returns the time a commit is ordered by in a changelog: its git commit date, else its author
+// date, else when it was recorded. Unparsable dates count as the zero time.
+func changelogTime(gitCommit *GitCommit) time.Time {
+	for _, timestamp := range []string{gitCommit.CommitTimestamp, gitCommit.AuthorTimestamp, gitCommit.RecordedTimestamp, gitCommit.Timestamp} {
+		parsed, err := time.Parse(time.RFC3339, timestamp)
+		if err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
+
+// commitWebURL returns the web page of a commit on a remote hosted like GitHub, or "" when the remote is not
+// an http(s) URL.
+func commitWebURL(remoteURL, commitHash string) string {
+	if !strings.HasPrefix(remoteURL, "https://") && !strings.HasPrefix(remoteURL, "http://") {
+		return ""
+	}
+	base := strings.TrimSuffix(strings.TrimRight(remoteURL, "/"), ".git")
+	return base + "/commit/" + commitHash
+}
+
+// renderChangelog writes the changelog of a repository version range as Markdown or plain text. Commits are
+// ordered by version, then time, within their section. remotes maps commit hashes to the remote they were
+// pushed to; commits never pushed themselves link to defaultRemote, the latest remote of the repository.
+func renderChangelog(w io.Writer, repository string, from, to int, gitCommits []*GitCommit, remotes map[string]string, defaultRemote string, markdown bool) {
+	commits := append([]*GitCommit{}, gitCommits...)
+	sort.SliceStable(commits, func(i, j int) bool {
+		if commits[i].VersionNumber != commits[j].VersionNumber {
+			return commits[i].VersionNumber < commits[j].VersionNumber
+		}
+		return changelogTime(commits[i]).Before(changelogTime(commits[j]))
+	})
+
+	versions := fmt.Sprintf("versions %d to %d", from, to)
+	if to == 0 {
+		versions = fmt.Sprintf("versions %d to latest", from)
+	}
+	if markdown {
+		fmt.Fprintf(w, "# Changelog of %s, %s\n", repository, versions)
+	} else {
+		fmt.Fprintf(w, "Changelog of %s, %s\n", repository, versions)
+	}
+	if len(commits) == 0 {
+		fmt.Fprintf(w, "\nNo commits in %s.\n", versions)
+		return
+	}
+
+	entries := make([][]string, len(changelogSections))
+	for _, gitCommit := range commits {
+		header, _, _ := strings.Cut(gitCommit.CommitMessage, "\n")
+		section := len(changelogSections) - 1
+		description := header
+		if match := conventionalHeaderPattern.FindStringSubmatch(header); match != nil {
+			commitType := strings.ToLower(match[1])
+			for i, candidate := range changelogSections {
+				for _, sectionType := range candidate.types {
+					if sectionType == commitType {
+						section = i
+					}
+				}
+			}
+			description = match[3]
+			if match[2] != "" {
+				if markdown {
+					description = fmt.Sprintf("**%s:** %s", match[2], description)
+				} else {
+					description = fmt.Sprintf("%s: %s", match[2], description)
+				}
+			}
+		}
+
+		shortHash := gitCommit.CommitHash
+		if len(shortHash) > 7 {
+			shortHash = shortHash[:7]
+		}
+		remote, ok := remotes[gitCommit.CommitHash]
+		if !ok {
+			remote = defaultRemote
+		}
+		link := commitWebURL(remote, gitCommit.CommitHash)
+		var entry string
+		switch {
+		case markdown && link != "":
+			entry = fmt.Sprintf("- %s ([%s](%s), %s, version %d)", description, shortHash, link, gitCommit.Author, gitCommit.VersionNumber)
+		case markdown:
+			entry = fmt.Sprintf("- %s (`%s`, %s, version %d)", description, shortHash, gitCommit.Author, gitCommit.VersionNumber)
+		case link != "":
+			entry = fmt.Sprintf("  * %s (%s, %s, version %d) %s", description, shortHash, gitCommit.Author, gitCommit.VersionNumber, link)
+		default:
+			entry = fmt.Sprintf("  * %s (%s, %s, version %d)", description, shortHash, gitCommit.Author, gitCommit.VersionNumber)
+		}
+		entries[section] = append(entries[section], entry)
+	}
+
+	for i, section := range changelogSections {
+		if len(entries[i]) == 0 {
+			continue
+		}
+		if markdown {
+			fmt.Fprintf(w, "\n## %s\n\n", section.title)
+		} else {
+			fmt.Fprintf(w, "\n%s:\n", section.title)
+		}
+		for _, entry := range entries[i] {
+			fmt.Fprintln(w, entry)
+		}
+	}
+}
+
+// writeChangelog writes the changelog of the commits of a repository between two versions, inclusive, to out
+// or standard output. The commits come from QueryCommitsByRepository and their remotes from the push records.
+func writeChangelog(ctx context.Context, contract *client.Contract, repository string, from, to int, format, out string) {
+	if repository == "" {
+		fmt.Fprintln(os.Stderr, "A repository is required for -changelog, use -repo")
+		os.Exit(2)
+	}
+	if format != "markdown" && format != "text" {
+		fmt.Fprintf(os.Stderr, "Unknown -format %q, must be markdown or text\n", format)
+		os.Exit(2)
+	}
+	if to != 0 && to < from {
+		fmt.Fprintf(os.Stderr, "-toVersion %d is before -fromVersion %d\n", to, from)
+		os.Exit(2)
+	}
+
+	result, err := evaluateTransaction(ctx, contract, "QueryCommitsByRepository", repository)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to evaluate QueryCommitsByRepository transaction: %v\n", err)
+		os.Exit(1)
+	}
+	var gitCommits []*GitCommit
+	err = json.Unmarshal(result, &gitCommits)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse the commits of %s: %v\n", repository, err)
+		os.Exit(1)
+	}
+	var inRange []*GitCommit
+	for _, gitCommit := range gitCommits {
+		if gitCommit.VersionNumber >= from && (to == 0 || gitCommit.VersionNumber <= to) {
+			inRange = append(inRange, gitCommit)
+		}
+	}
+
+	result, err = evaluateTransaction(ctx, contract, "GetPushTransactionsByRepository", repository)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to evaluate GetPushTransactionsByRepository transaction: %v\n", err)
+		os.Exit(1)
+	}
+	var pushTransactions []*PushTransaction
+	err = json.Unmarshal(result, &pushTransactions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse the pushes of %s: %v\n", repository, err)
+		os.Exit(1)
+	}
+	// Pushes are in version order, so the last remote of each commit and of the repository wins
+	remotes := make(map[string]string)
+	defaultRemote := ""
+	for _, pushTx := range pushTransactions {
+		remotes[pushTx.CommitHash] = pushTx.RemoteURL
+		defaultRemote = pushTx.RemoteURL
+	}
+
+	var output io.Writer = os.Stdout
+	if out != "" {
+		file, err := os.Create(out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", out, err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		output = file
+	}
+	renderChangelog(output, repository, from, to, inRange, remotes, defaultRemote, format == "markdown")
+}
+
+// atomFeed is an Atom feed (RFC 4287) of the commits of a repository
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    *atomLink   `xml:"link,omitempty"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Updated string     `xml:"updated"`
+	Author  atomPerson `xml:"author"`
+	Link    *atomLink  `xml:"link,omitempty"`
+	Content atomText   `xml:"content"`
+}
+
+// renderFeed writes the limit most recent commits of a repository as an Atom feed, newest first. Entries link
+// to the commit on remoteURL when it is a web URL. IDs are URNs of the repository and commit hash, so they stay
+// the same when the remote moves. The feed is as recent as its newest entry, or now when it has none.
+func renderFeed(w io.Writer, repository string, gitCommits []*GitCommit, remoteURL string, limit int, now time.Time) error {
+	commits := append([]*GitCommit{}, gitCommits...)
+	sort.SliceStable(commits, func(i, j int) bool {
+		return changelogTime(commits[i]).After(changelogTime(commits[j]))
+	})
+	if len(commits) > limit {
+		commits = commits[:limit]
+	}
+
+	feed := atomFeed{
+		ID:      "urn:gittransfer:repository:" + url.PathEscape(repository),
+		Title:   "Commits of " + repository,
+		Updated: now.UTC().Format(time.RFC3339),
+	}
+	if strings.HasPrefix(remoteURL, "https://") || strings.HasPrefix(remoteURL, "http://") {
+		feed.Link = &atomLink{Href: strings.TrimSuffix(strings.TrimRight(remoteURL, "/"), ".git")}
+	}
+	for i, gitCommit := range commits {
+		updated := changelogTime(gitCommit).UTC().Format(time.RFC3339)
+		if i == 0 {
+			feed.Updated = updated
+		}
+		title, _, _ := strings.Cut(strings.TrimSpace(gitCommit.CommitMessage), "\n")
+		entry := atomEntry{
+			ID:      "urn:gittransfer:commit:" + url.PathEscape(repository) + ":" + gitCommit.CommitHash,
+			Title:   strings.TrimSpace(title),
+			Updated: updated,
+			Author:  atomPerson{Name: gitCommit.Author},
+			Content: atomText{Type: "text", Text: gitCommit.CommitMessage},
+		}
+		if webURL := commitWebURL(remoteURL, gitCommit.CommitHash); webURL != "" {
+			entry.Link = &atomLink{Href: webURL}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	output, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, output)
+	return err
+}
+
+// writeFeed writes the most recent commits of a repository as an Atom feed to out, or to standard output.
+func writeFeed(ctx context.Context, contract *client.Contract, repository string, limit int, out string) {
+	if repository == "" {
+		fmt.Fprintln(os.Stderr, "A repository is required for -feed, use -repo")
+		os.Exit(2)
+	}
+
+	result, err := evaluateTransaction(ctx, contract, "QueryCommitsByRepository", repository)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to evaluate QueryCommitsByRepository transaction: %v\n", err)
+		os.Exit(1)
+	}
+	var gitCommits []*GitCommit
+	err = json.Unmarshal(result, &gitCommits)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse the commits of %s: %v\n", repository, err)
+		os.Exit(1)
+	}
+
+	result, err = evaluateTransaction(ctx, contract, "GetPushTransactionsByRepository", repository)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to evaluate GetPushTransactionsByRepository transaction: %v\n", err)
+		os.Exit(1)
+	}
+	var pushTransactions []*PushTransaction
+	err = json.Unmarshal(result, &pushTransactions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse the pushes of %s: %v\n", repository, err)
+		os.Exit(1)
+	}
+	// Pushes are in version order, so the last one is the latest
+	remoteURL := ""
+	if len(pushTransactions) > 0 {
+		remoteURL = pushTransactions[len(pushTransactions)-1].RemoteURL
+	}
+
+	var output io.Writer = os.Stdout
+	if out != "" {
+		file, err := os.Create(out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", out, err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		output = file
+	}
+	err = renderFeed(output, repository, gitCommits, remoteURL, limit, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write the feed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// GetCommitsByRemote returns the commits that were pushed to a remote.
+func getCommitsByRemote(ctx context.Context, contract *client.Contract, remoteURL string) {
+	fmt.Println("--> Evaluate Transaction: GetCommitsByRemote")
+	result, err := evaluateTransaction(ctx, contract, "GetCommitsByRemote", remoteURL)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetCommitsByRemote transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetCommitsByRemote transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// GetCommitsBySecurityLevel returns the commits flagged with a security level.
+func getCommitsBySecurityLevel(ctx context.Context, contract *client.Contract, level string) {
+	fmt.Println("--> Evaluate Transaction: GetCommitsBySecurityLevel")
+	result, err := evaluateTransaction(ctx, contract, "GetCommitsBySecurityLevel", level)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetCommitsBySecurityLevel transaction: %v\n", err)
+		return
+	}
+	var gitCommits []*GitCommit
+	err = json.Unmarshal(result, &gitCommits)
+	if err != nil {
+		fmt.Printf("Failed to unmarshal result: %v\n", err)
+		return
+	}
+	gitCommitsResult, err := marshalOutput(gitCommits)
+	if err != nil {
+		fmt.Printf("Failed to format result: %v\n", err)
+		return
+	}
+	fmt.Printf("GetCommitsBySecurityLevel transaction successfully evaluated, result: %s\n", string(gitCommitsResult))
+}
+
+// RegisterRepository adds a repository to the allowlist used when the contract requires registered repositories.
+func registerRepository(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Submit Transaction: RegisterRepository")
+	_, err := submitTransaction(ctx, contract, "RegisterRepository", repository)
+	if err != nil {
+		fmt.Printf("Failed to submit RegisterRepository transaction: %v\n", err)
+		return
+	}
+	fmt.Println("RegisterRepository transaction successfully submitted")
+}
+
+// ListRegisteredRepositories returns the names of the registered repositories.
+func listRegisteredRepositories(ctx context.Context, contract *client.Contract) {
+	fmt.Println("--> Evaluate Transaction: ListRegisteredRepositories")
+	result, err := evaluateTransaction(ctx, contract, "ListRegisteredRepositories")
+	if err != nil {
+		fmt.Printf("Failed to evaluate ListRegisteredRepositories transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("ListRegisteredRepositories transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// GetCommitAtTime returns the latest commit of a repository made at or before the given time.
+func getCommitAtTime(ctx context.Context, contract *client.Contract, repository, at string) {
+	fmt.Println("--> Evaluate Transaction: GetCommitAtTime")
+	result, err := evaluateTransaction(ctx, contract, "GetCommitAtTime", repository, at)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetCommitAtTime transaction: %v\n", err)
+		return
+	}
+	var gitCommit GitCommit
+	err = json.Unmarshal(result, &gitCommit)
+	if err != nil {
+		fmt.Printf("Failed to unmarshal result: %v\n", err)
+		return
+	}
+	gitCommitResult, err := marshalOutput(gitCommit)
+	if err != nil {
+		fmt.Printf("Failed to format result: %v\n", err)
+		return
+	}
+	fmt.Printf("GetCommitAtTime transaction successfully evaluated, result: %s\n", string(gitCommitResult))
+}
+
+// QueryCommitsByTrailer returns the commits whose message ends with the given trailer.
+func queryCommitsByTrailer(ctx context.Context, contract *client.Contract, key, value string) {
+	fmt.Println("--> Evaluate Transaction: QueryCommitsByTrailer")
+	result, err := evaluateTransaction(ctx, contract, "QueryCommitsByTrailer", key, value)
+	if err != nil {
+		fmt.Printf("Failed to evaluate QueryCommitsByTrailer transaction: %v\n", err)
+		return
+	}
+	var gitCommits []*GitCommit
+	err = json.Unmarshal(result, &gitCommits)
+	if err != nil {
+		fmt.Printf("Failed to unmarshal result: %v\n", err)
+		return
+	}
+	gitCommitsResult, err := marshalOutput(gitCommits)
+	if err != nil {
+		fmt.Printf("Failed to format result: %v\n", err)
+		return
+	}
+	fmt.Printf("QueryCommitsByTrailer transaction successfully evaluated, result: %s\n", string(gitCommitsResult))
+}
+
+// GetRepositoryChurn sums the insertions, deletions and changed files of a repository over a time window.
+func getRepositoryChurn(ctx context.Context, contract *client.Contract, repository, start, end string) {
+	fmt.Println("--> Evaluate Transaction: GetRepositoryChurn")
+	result, err := evaluateTransaction(ctx, contract, "GetRepositoryChurn", repository, start, end)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetRepositoryChurn transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetRepositoryChurn transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// GetRepositoryAuthors returns the contributors of a repository, most commits first.
+func getRepositoryAuthors(ctx context.Context, contract *client.Contract, repository string) {
+	fmt.Println("--> Evaluate Transaction: GetRepositoryAuthors")
+	result, err := evaluateTransaction(ctx, contract, "GetRepositoryAuthors", repository)
+	if err != nil {
+		fmt.Printf("Failed to evaluate GetRepositoryAuthors transaction: %v\n", err)
+		return
+	}
+	fmt.Printf("GetRepositoryAuthors transaction successfully evaluated, result: %s\n", formatJSON(result))
+}
+
+// endorsementCheck is the outcome of verifying one endorsement of a transaction
+type endorsementCheck struct {
+	MSPID    string `json:"mspID"`
+	Subject  string `json:"subject"`
+	Verified bool   `json:"verified"`
+	Error    string `json:"error,omitempty"`
+}
+
+// verifyEndorsement checks that an endorser signed the proposal response payload with the key of its certificate.
+// It does not check the certificate chain against the CAs of the endorsing organization.
+func verifyEndorsement(proposalResponsePayload []byte, endorsement *peer.Endorsement) *endorsementCheck {
+	check := &endorsementCheck{}
+
+	endorser := &msp.SerializedIdentity{}
+	err := proto.Unmarshal(endorsement.GetEndorser(), endorser)
+	if err != nil {
+		check.Error = fmt.Sprintf("failed to unmarshal endorser identity: %v", err)
+		return check
+	}
+	check.MSPID = endorser.GetMspid()
+
+	certificate, err := identity.CertificateFromPEM(endorser.GetIdBytes())
+	if err != nil {
+		check.Error = fmt.Sprintf("failed to parse endorser certificate: %v", err)
+		return check
+	}
+	check.Subject = certificate.Subject.String()
+
+	// Endorsers sign the proposal response payload followed by their serialized identity
+	message := append(append([]byte{}, proposalResponsePayload...), endorsement.GetEndorser()...)
+	switch publicKey := certificate.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(message)
+		check.Verified = ecdsa.VerifyASN1(publicKey, digest[:], endorsement.GetSignature())
+	case ed25519.PublicKey:
+		check.Verified = ed25519.Verify(publicKey, message, endorsement.GetSignature())
+	default:
+		check.Error = fmt.Sprintf("unsupported endorser key type %T", publicKey)
+		return check
+	}
+	if !check.Verified {
+		check.Error = "signature does not match the endorser certificate"
+	}
+	return check
+}
+
+// verifyTransaction fetches a committed transaction from the ledger through the query system chaincode (qscc)
+// and verifies the signature of every endorsement against the endorser's certificate. The client identity
+// must satisfy the channel ACL for qscc/GetTransactionByID, which defaults to the channel Readers policy.
+func verifyTransaction(ctx context.Context, network *client.Network, channelName, transactionID string) {
+	fmt.Println("--> Evaluate Transaction: qscc GetTransactionByID")
+	if transactionID == "" {
+		fmt.Println("A transaction ID is required for -verifyTx, use -txid")
+		return
+	}
+
+	qscc := network.GetContract("qscc")
+	outputOperation = "GetTransactionByID"
+	result, err := evaluateWithTimeout(ctx, qscc, "GetTransactionByID", channelName, transactionID)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such transaction ID") {
+			fmt.Printf("Transaction %s was not found on channel %s\n", transactionID, channelName)
+			return
+		}
+		fmt.Printf("Failed to evaluate GetTransactionByID transaction: %v\n", err)
+		return
+	}
+
+	processedTransaction := &peer.ProcessedTransaction{}
+	err = proto.Unmarshal(result, processedTransaction)
+	if err != nil {
+		fmt.Printf("Failed to unmarshal processed transaction: %v\n", err)
+		return
+	}
+	payload := &common.Payload{}
+	err = proto.Unmarshal(processedTransaction.GetTransactionEnvelope().GetPayload(), payload)
+	if err != nil {
+		fmt.Printf("Failed to unmarshal transaction payload: %v\n", err)
+		return
+	}
+	transaction := &peer.Transaction{}
+	err = proto.Unmarshal(payload.GetData(), transaction)
+	if err != nil {
+		fmt.Printf("Failed to unmarshal transaction: %v\n", err)
+		return
+	}
+
+	var checks []*endorsementCheck
+	for _, action := range transaction.GetActions() {
+		actionPayload := &peer.ChaincodeActionPayload{}
+		err = proto.Unmarshal(action.GetPayload(), actionPayload)
+		if err != nil {
+			fmt.Printf("Failed to unmarshal chaincode action payload: %v\n", err)
+			return
+		}
+		endorsedAction := actionPayload.GetAction()
+		for _, endorsement := range endorsedAction.GetEndorsements() {
+			checks = append(checks, verifyEndorsement(endorsedAction.GetProposalResponsePayload(), endorsement))
+		}
+	}
+
+	allVerified := len(checks) > 0
+	for _, check := range checks {
+		allVerified = allVerified && check.Verified
+	}
+	report, err := marshalOutput(struct {
+		TransactionID  string              `json:"transactionID"`
+		ValidationCode string              `json:"validationCode"`
+		Endorsements   []*endorsementCheck `json:"endorsements"`
+		AllVerified    bool                `json:"allVerified"`
+	}{
+		TransactionID:  transactionID,
+		ValidationCode: peer.TxValidationCode(processedTransaction.GetValidationCode()).String(),
+		Endorsements:   checks,
+		AllVerified:    allVerified,
+	})
+	if err != nil {
+		fmt.Printf("Failed to format result: %v\n", err)
+		return
+	}
+	fmt.Printf("GetTransactionByID transaction successfully evaluated, result: %s\n", string(report))
+}
+
+// blockTransaction is a transaction of a block as printed by -getBlock.
+type blockTransaction struct {
+	TransactionID  string `json:"transactionID"`
+	Type           string `json:"type"`
+	ValidationCode string `json:"validationCode"`
+}
+
+// blockTransactions returns the ID, type and validation code of each transaction of a block, in block order.
+func blockTransactions(block *common.Block) ([]*blockTransaction, error) {
+	// The transactions filter holds one validation code per transaction, in block order
+	var validationCodes []byte
+	metadata := block.GetMetadata().GetMetadata()
+	if len(metadata) > int(common.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+		validationCodes = metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER]
+	}
+	transactions := []*blockTransaction{}
+	for i, envelopeBytes := range block.GetData().GetData() {
+		envelope := &common.Envelope{}
+		err := proto.Unmarshal(envelopeBytes, envelope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal envelope: %w", err)
+		}
+		payload := &common.Payload{}
+		err = proto.Unmarshal(envelope.GetPayload(), payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal transaction payload: %w", err)
+		}
+		channelHeader := &common.ChannelHeader{}
+		err = proto.Unmarshal(payload.GetHeader().GetChannelHeader(), channelHeader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal channel header: %w", err)
+		}
+		transaction := &blockTransaction{
+			TransactionID:  channelHeader.GetTxId(),
+			Type:           common.HeaderType(channelHeader.GetType()).String(),
+			ValidationCode: "UNKNOWN",
+		}
+		if i < len(validationCodes) {
+			transaction.ValidationCode = peer.TxValidationCode(validationCodes[i]).String()
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, nil
+}
+
+// getBlock fetches a block through the query system chaincode (qscc), either the one containing a transaction
+// or the one with a given number, and prints its header and the validation code of each transaction. It ties a
+// commit or push to the block it was ordered in and shows whether the peer marked it invalid. The client
+// identity must satisfy the channel ACLs for qscc/GetBlockByTxID and qscc/GetBlockByNumber, which default to
+// the channel Readers policy.
+func getBlock(ctx context.Context, network *client.Network, channelName, transactionID string, number int64) {
+	var function string
+	var args []string
+	switch {
+	case transactionID != "":
+		function = "GetBlockByTxID"
+		args = []string{channelName, transactionID}
+	case number >= 0:
+		function = "GetBlockByNumber"
+		args = []string{channelName, strconv.FormatInt(number, 10)}
+	default:
+		fmt.Println("A transaction ID or block number is required for -getBlock, use -txid or -number")
+		return
+	}
+	fmt.Println("--> Evaluate Transaction: qscc " + function)
+
+	qscc := network.GetContract("qscc")
+	outputOperation = function
+	result, err := evaluateWithTimeout(ctx, qscc, function, args...)
+	if err != nil {
+		message := err.Error()
+		switch {
+		case strings.Contains(message, "no such transaction ID"):
+			fmt.Printf("Transaction %s was not found on channel %s\n", transactionID, channelName)
+		case strings.Contains(message, "out of range") || strings.Contains(message, "not found"):
+			fmt.Printf("Block %d was not found on channel %s\n", number, channelName)
+		case strings.Contains(message, "access denied") || status.Code(err) == codes.PermissionDenied:
+			fmt.Printf("The client identity may not read blocks on channel %s; %s needs the channel Readers policy: %v\n", channelName, function, err)
+		default:
+			fmt.Printf("Failed to evaluate %s transaction: %v\n", function, err)
+		}
+		return
+	}
+
+	block := &common.Block{}
+	err = proto.Unmarshal(result, block)
+	if err != nil {
+		fmt.Printf("Failed to unmarshal block: %v\n", err)
+		return
+	}
+
+	transactions, err := blockTransactions(block)
+	if err != nil {
+		fmt.Printf("Failed to read block transactions: %v\n", err)
+		return
+	}
+
+	report, err := marshalOutput(struct {
+		Number       uint64              `json:"number"`
+		DataHash     string              `json:"dataHash"`
+		PreviousHash string              `json:"previousHash"`
+		Transactions []*blockTransaction `json:"transactions"`
+	}{
+		Number:       block.GetHeader().GetNumber(),
+		DataHash:     hex.EncodeToString(block.GetHeader().GetDataHash()),
+		PreviousHash: hex.EncodeToString(block.GetHeader().GetPreviousHash()),
+		Transactions: transactions,
+	})
+	if err != nil {
+		fmt.Printf("Failed to format result: %v\n", err)
+		return
+	}
+	fmt.Printf("%s transaction successfully evaluated, result: %s\n", function, string(report))
 }
 
-// PushTransaction struct to match the smart contract definition
-type PushTransaction struct {
+// provenanceFormat identifies the layout of the documents written by -provenance
+const provenanceFormat = "gittransfer-provenance/v1"
+
+// provenance links a pushed repository version to what the ledger records about it, in the spirit of SLSA
+// provenance: the commit and who wrote and submitted it, which organizations verified and reviewed it, how its
+// build went and where it was deployed.
+type provenance struct {
+	Format           string             `json:"format"`
+	Subject          provenanceSubject  `json:"subject"`
+	Source           provenanceSource   `json:"source"`
+	HashAttestations []*HashAttestation `json:"hashAttestations"`
+	Reviews          []*ReviewApproval  `json:"reviewApprovals"`
+	BuildStatus      string             `json:"buildStatus"`
+	Push             *PushTransaction   `json:"push"`
+	// Deployments lists every push of the commit to an environment, the requested one included
+	Deployments []*PushTransaction `json:"deployments"`
+	Ledger      provenanceLedger   `json:"ledger"`
+}
+
+// provenanceSubject is the repository version a provenance document describes
+type provenanceSubject struct {
 	Repository string `json:"repository"`
-	RemoteURL  string `json:"remoteURL"`
-	Timestamp  string `json:"timestamp"`
 	Version    int    `json:"version"`
-	CommitHash string `json:"commitHash"` // Add this field
+	CommitHash string `json:"commitHash"`
+	RemoteURL  string `json:"remoteURL"`
 }
 
-func main() {
+// provenanceSource is who wrote and who submitted the commit. SignatureValid is set for commits created with
+// a submitter signature, by VerifySubmitterSignature.
+type provenanceSource struct {
+	Author          string `json:"author"`
+	AuthorTimestamp string `json:"authorTimestamp,omitempty"`
+	CommitTimestamp string `json:"commitTimestamp,omitempty"`
+	SubmittedBy     string `json:"submittedBy,omitempty"`
+	SignatureValid  *bool  `json:"signatureValid,omitempty"`
+}
 
-	// Define and parse command-line flags
-	var (
-		createFlag              bool
-		pushFlag                bool
-		readFlag                bool
-		existsFlag              bool
-		getAllFlag              bool
-		commitHash              string
-		repository              string
-		commitMessage           string
-		author                  string
-		remoteURL               string
-		getPushTransactionsFlag bool
-		//versionNumber int
-	)
+// provenanceLedger is where the records were read. The block holding the push transaction is signed by the
+// orderer, so it anchors the document in the ledger; it is missing for pushes recorded before pushes kept their
+// transaction ID, or when the client may not read blocks.
+type provenanceLedger struct {
+	Channel        string `json:"channel"`
+	Chaincode      string `json:"chaincode"`
+	PushTxID       string `json:"pushTxID,omitempty"`
+	BlockNumber    uint64 `json:"blockNumber,omitempty"`
+	BlockDataHash  string `json:"blockDataHash,omitempty"`
+	ValidationCode string `json:"validationCode,omitempty"`
+	GeneratedAt    string `json:"generatedAt"`
+}
 
-	flag.BoolVar(&createFlag, "create", false, "Create a new Git commit")
-	flag.BoolVar(&pushFlag, "push", false, "Handle git push")
-	flag.BoolVar(&readFlag, "read", false, "Read a Git commit by its hash")
-	flag.BoolVar(&existsFlag, "exists", false, "Check if a Git commit exists")
-	flag.BoolVar(&getAllFlag, "getAll", false, "Get all Git commits")
-	flag.StringVar(&commitHash, "hash", "", "The hash of the Git commit")
-	flag.StringVar(&repository, "repo", "", "The repository of the Git commit")
-	flag.StringVar(&commitMessage, "message", "", "The commit message")
-	flag.StringVar(&author, "author", "", "The author of the Git commit")
-	flag.StringVar(&remoteURL, "url", "", "The remote repository URL")
-	flag.BoolVar(&getPushTransactionsFlag, "getPushTransactions", false, "Get all push transactions")
-	//flag.IntVar(&versionNumber, "version", 0, "The version number of the Git commit")
-	// parse flags
-	flag.Parse()
+// pushOfVersion returns the push that created a repository version, or nil if there is none.
+func pushOfVersion(pushTransactions []*PushTransaction, version int) *PushTransaction {
+	for _, pushTx := range pushTransactions {
+		if pushTx.Version == version {
+			return pushTx
+		}
+	}
+	return nil
+}
 
-	// Setup gRPC connection and client identity
-	clientConnection := newGrpcConnection()
-	defer clientConnection.Close()
+// buildProvenance assembles the provenance document of a push from the pushed commit and every push of the
+// repository, which supply the deployments of the commit.
+func buildProvenance(push *PushTransaction, pushTransactions []*PushTransaction, gitCommit *GitCommit) *provenance {
+	document := &provenance{
+		Format: provenanceFormat,
+		Subject: provenanceSubject{
+			Repository: push.Repository,
+			Version:    push.Version,
+			CommitHash: push.CommitHash,
+			RemoteURL:  push.RemoteURL,
+		},
+		Source: provenanceSource{
+			Author:          gitCommit.Author,
+			AuthorTimestamp: gitCommit.AuthorTimestamp,
+			CommitTimestamp: gitCommit.CommitTimestamp,
+			SubmittedBy:     gitCommit.SubmittedBy,
+		},
+		HashAttestations: gitCommit.HashAttestations,
+		Reviews:          gitCommit.ReviewApprovals,
+		BuildStatus:      gitCommit.BuildStatus,
+		Push:             push,
+		Deployments:      []*PushTransaction{},
+	}
+	if document.HashAttestations == nil {
+		document.HashAttestations = []*HashAttestation{}
+	}
+	if document.Reviews == nil {
+		document.Reviews = []*ReviewApproval{}
+	}
+	for _, pushTx := range pushTransactions {
+		if pushTx.CommitHash == push.CommitHash && pushTx.Environment != "" {
+			document.Deployments = append(document.Deployments, pushTx)
+		}
+	}
+	return document
+}
 
-	id := newIdentity()
-	sign := newSign()
+// exportProvenance writes the provenance document of a repository version to out, or standard output. Each
+// part is read from the contract: the push from GetPushTransactionsByRepository, the commit with its reviews,
+// hash attestations and build status from ReadGitCommitInRepository and, for a signed commit, the signature
+// check from VerifySubmitterSignature. The block of the push transaction is looked up through qscc.
+func exportProvenance(ctx context.Context, network *client.Network, contract *client.Contract, channelName, chaincodeName, repository string, version int, out string) {
+	if repository == "" || version == 0 {
+		fmt.Fprintln(os.Stderr, "Both -repo and -version are required for -provenance")
+		os.Exit(2)
+	}
 
-	gw, err := client.Connect(
-		id,
-		client.WithSign(sign),
-		client.WithClientConnection(clientConnection),
-		client.WithEvaluateTimeout(5*time.Second),
-		client.WithEndorseTimeout(15*time.Second),
-		client.WithSubmitTimeout(5*time.Second),
-		client.WithCommitStatusTimeout(1*time.Minute),
-	)
+	result, err := evaluateTransaction(ctx, contract, "GetPushTransactionsByRepository", repository)
 	if err != nil {
-		fmt.Printf("Failed to connect to gateway: %v\n", err)
-		return
+		fmt.Fprintf(os.Stderr, "Failed to evaluate GetPushTransactionsByRepository transaction: %v\n", err)
+		os.Exit(1)
 	}
-	defer gw.Close()
+	var pushTransactions []*PushTransaction
+	err = json.Unmarshal(result, &pushTransactions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse the pushes of %s: %v\n", repository, err)
+		os.Exit(1)
+	}
+	push := pushOfVersion(pushTransactions, version)
+	if push == nil {
+		fmt.Fprintf(os.Stderr, "The repository %s has no push of version %d\n", repository, version)
+		os.Exit(1)
+	}
+	commitHash := push.CommitHash
 
-	chaincodeName := "git" // Adjust according to your deployment
-	if ccname := os.Getenv("CHAINCODE_NAME"); ccname != "" {
-		chaincodeName = ccname
+	result, err = evaluateTransaction(ctx, contract, "ReadGitCommitInRepository", push.Repository, commitHash)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to evaluate ReadGitCommitInRepository transaction: %v\n", err)
+		os.Exit(1)
 	}
-	channelName := "mychannel" // Adjust according to your deployment
-	if cname := os.Getenv("CHANNEL_NAME"); cname != "" {
-		channelName = cname
+	var gitCommit GitCommit
+	err = json.Unmarshal(result, &gitCommit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse commit %s: %v\n", commitHash, err)
+		os.Exit(1)
 	}
+	document := buildProvenance(push, pushTransactions, &gitCommit)
 
-	network := gw.GetNetwork(channelName)
-	contract := network.GetContract(chaincodeName)
+	if gitCommit.SubmitterSignature != "" {
+		result, err = evaluateTransaction(ctx, contract, "VerifySubmitterSignature", commitHash)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to evaluate VerifySubmitterSignature transaction: %v\n", err)
+			os.Exit(1)
+		}
+		valid := string(result) == "true"
+		document.Source.SignatureValid = &valid
+	}
 
-	// Execute smart contract functions based on flags
-	if createFlag {
-		createGitCommit(contract, commitHash, repository, commitMessage, author)
-	} else if readFlag {
-		readGitCommit(contract, commitHash)
-	} else if pushFlag {
-		handleGitPush(contract, repository, remoteURL, commitHash)
-	} else if getPushTransactionsFlag {
-		getAllPushTransactions(contract)
-	} else if existsFlag {
-		checkGitCommitExists(contract, commitHash)
-	} else if getAllFlag {
-		getAllGitCommits(contract)
+	document.Ledger = provenanceLedger{
+		Channel:     channelName,
+		Chaincode:   chaincodeName,
+		PushTxID:    document.Push.TxID,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if document.Push.TxID == "" {
+		fmt.Fprintf(os.Stderr, "The push of version %d has no transaction ID, the document is not tied to a block\n", version)
 	} else {
-		fmt.Println("No operation specified or unrecognized flag.")
+		blockResult, err := evaluateWithTimeout(ctx, network.GetContract("qscc"), "GetBlockByTxID", channelName, document.Push.TxID)
+		block := &common.Block{}
+		if err == nil {
+			err = proto.Unmarshal(blockResult, block)
+		}
+		var transactions []*blockTransaction
+		if err == nil {
+			transactions, err = blockTransactions(block)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read the block of push transaction %s, the document is not tied to a block: %v\n", document.Push.TxID, err)
+		} else {
+			document.Ledger.BlockNumber = block.GetHeader().GetNumber()
+			document.Ledger.BlockDataHash = hex.EncodeToString(block.GetHeader().GetDataHash())
+			for _, transaction := range transactions {
+				if transaction.TransactionID == document.Push.TxID {
+					document.Ledger.ValidationCode = transaction.ValidationCode
+				}
+			}
+		}
 	}
-}
 
-func newGrpcConnection() *grpc.ClientConn {
-	certificate, err := loadCertificate(tlsCertPath)
+	documentJSON, err := json.MarshalIndent(document, "", "    ")
 	if err != nil {
-		panic(err)
+		fmt.Fprintf(os.Stderr, "Failed to encode provenance: %v\n", err)
+		os.Exit(1)
 	}
+	documentJSON = append(documentJSON, '\n')
+	if out == "" {
+		os.Stdout.Write(documentJSON)
+		return
+	}
+	err = os.WriteFile(out, documentJSON, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", out, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote the provenance of %s version %d to %s\n", repository, version, out)
+}
 
-	certPool := x509.NewCertPool()
-	certPool.AddCert(certificate)
-	transportCredentials := credentials.NewClientTLSFromCert(certPool, gatewayPeer)
+// Chaincode events of the contract, see CommitCreatedEvent and GitPushedEvent
+const (
+	commitCreatedEvent = "CommitCreated"
+	gitPushedEvent     = "GitPushed"
+)
 
-	connection, err := grpc.Dial(peerEndpoint, grpc.WithTransportCredentials(transportCredentials))
-	if err != nil {
-		panic(fmt.Errorf("failed to create gRPC connection: %w", err))
+// syncBatchSize is the most events -sync writes to the database in one transaction
+const syncBatchSize = 500
+
+// cacheSchema creates the tables of the -sync database. Each commit and push keeps the block and transaction
+// of the event that recorded it, and its full record as JSON for fields without a column. The checkpoint is
+// the last event written, in the form client.WithCheckpoint resumes from.
+const cacheSchema = `
+CREATE TABLE IF NOT EXISTS repos (
+	repository TEXT PRIMARY KEY,
+	version_number INTEGER NOT NULL,
+	block_number INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS commits (
+	repository TEXT NOT NULL,
+	commit_hash TEXT NOT NULL,
+	commit_message TEXT NOT NULL,
+	author TEXT NOT NULL,
+	version_number INTEGER NOT NULL,
+	timestamp TEXT NOT NULL,
+	author_timestamp TEXT,
+	commit_timestamp TEXT,
+	submitted_by TEXT,
+	record TEXT NOT NULL,
+	block_number INTEGER NOT NULL,
+	transaction_id TEXT NOT NULL,
+	PRIMARY KEY (repository, commit_hash)
+);
+CREATE TABLE IF NOT EXISTS pushes (
+	repository TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	commit_hash TEXT NOT NULL,
+	remote_url TEXT NOT NULL,
+	timestamp TEXT NOT NULL,
+	environment TEXT,
+	approved_by TEXT,
+	message TEXT,
+	record TEXT NOT NULL,
+	block_number INTEGER NOT NULL,
+	transaction_id TEXT NOT NULL,
+	PRIMARY KEY (repository, version)
+);
+CREATE TABLE IF NOT EXISTS checkpoint (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	block_number INTEGER NOT NULL,
+	transaction_id TEXT NOT NULL
+);
+`
+
+// The upserts of the -sync database. Replaying an event changes nothing, and an event older than the row it
+// would update is ignored, so events may arrive more than once and in any order.
+const (
+	upsertCommitSQL = `INSERT INTO commits (repository, commit_hash, commit_message, author, version_number, timestamp, author_timestamp, commit_timestamp, submitted_by, record, block_number, transaction_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (repository, commit_hash) DO UPDATE SET commit_message = excluded.commit_message, author = excluded.author, version_number = excluded.version_number, timestamp = excluded.timestamp, author_timestamp = excluded.author_timestamp, commit_timestamp = excluded.commit_timestamp, submitted_by = excluded.submitted_by, record = excluded.record, block_number = excluded.block_number, transaction_id = excluded.transaction_id
+WHERE excluded.block_number >= commits.block_number`
+	upsertPushSQL = `INSERT INTO pushes (repository, version, commit_hash, remote_url, timestamp, environment, approved_by, message, record, block_number, transaction_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (repository, version) DO UPDATE SET commit_hash = excluded.commit_hash, remote_url = excluded.remote_url, timestamp = excluded.timestamp, environment = excluded.environment, approved_by = excluded.approved_by, message = excluded.message, record = excluded.record, block_number = excluded.block_number, transaction_id = excluded.transaction_id
+WHERE excluded.block_number >= pushes.block_number`
+	// Versions only grow, so the highest one seen is the current one whatever order the events came in
+	upsertRepoSQL = `INSERT INTO repos (repository, version_number, block_number) VALUES (?, ?, ?)
+ON CONFLICT (repository) DO UPDATE SET version_number = max(repos.version_number, excluded.version_number), block_number = max(repos.block_number, excluded.block_number)`
+	upsertCheckpointSQL = `INSERT INTO checkpoint (id, block_number, transaction_id) VALUES (1, ?, ?)
+ON CONFLICT (id) DO UPDATE SET block_number = excluded.block_number, transaction_id = excluded.transaction_id
+WHERE excluded.block_number >= checkpoint.block_number`
+)
+
+// cacheStatement is a parameterized statement of the -sync database with its arguments.
+type cacheStatement struct {
+	query string
+	args  []interface{}
+}
+
+// sqlNullable returns s as a statement argument, or nil for NULL when it is empty.
+func sqlNullable(s string) interface{} {
+	if s == "" {
+		return nil
 	}
+	return s
+}
 
-	return connection
+// cacheStatements returns the statements that apply one chaincode event to the -sync database. Events of
+// other kinds return no statements.
+func cacheStatements(event *client.ChaincodeEvent) ([]cacheStatement, error) {
+	var repository string
+	var version int
+	var statements []cacheStatement
+	switch event.EventName {
+	case commitCreatedEvent:
+		var gitCommit GitCommit
+		err := json.Unmarshal(event.Payload, &gitCommit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s event in transaction %s: %w", event.EventName, event.TransactionID, err)
+		}
+		repository, version = gitCommit.Repository, gitCommit.VersionNumber
+		statements = append(statements, cacheStatement{upsertCommitSQL, []interface{}{
+			gitCommit.Repository, gitCommit.CommitHash, gitCommit.CommitMessage, gitCommit.Author, gitCommit.VersionNumber, gitCommit.Timestamp,
+			sqlNullable(gitCommit.AuthorTimestamp), sqlNullable(gitCommit.CommitTimestamp), sqlNullable(gitCommit.SubmittedBy), string(event.Payload), event.BlockNumber, event.TransactionID,
+		}})
+	case gitPushedEvent:
+		var pushTx PushTransaction
+		err := json.Unmarshal(event.Payload, &pushTx)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s event in transaction %s: %w", event.EventName, event.TransactionID, err)
+		}
+		repository, version = pushTx.Repository, pushTx.Version
+		statements = append(statements, cacheStatement{upsertPushSQL, []interface{}{
+			pushTx.Repository, pushTx.Version, pushTx.CommitHash, pushTx.RemoteURL, pushTx.Timestamp,
+			sqlNullable(pushTx.Environment), sqlNullable(pushTx.ApprovedBy), sqlNullable(pushTx.Message), string(event.Payload), event.BlockNumber, event.TransactionID,
+		}})
+	default:
+		return nil, nil
+	}
+
+	statements = append(statements, cacheStatement{upsertRepoSQL, []interface{}{repository, version, event.BlockNumber}})
+	return statements, nil
 }
 
-// newIdentity creates a client identity for this Gateway connection using an X.509 certificate.
-func newIdentity() *identity.X509Identity {
-	certificate, err := loadCertificate(certPath)
+// syncCheckpoint is the position of the last event in the -sync database
+type syncCheckpoint struct {
+	blockNumber   uint64
+	transactionID string
+}
+
+func (c *syncCheckpoint) BlockNumber() uint64   { return c.blockNumber }
+func (c *syncCheckpoint) TransactionID() string { return c.transactionID }
+
+// sqliteCache is the SQLite database of -sync, opened with the pure Go driver of modernc.org/sqlite so that
+// the client needs neither cgo nor the sqlite3 shell.
+type sqliteCache struct {
+	path string
+	db   *sql.DB
+}
+
+// openSQLiteCache opens the database at path, creating it and its tables if needed.
+func openSQLiteCache(path string) (*sqliteCache, error) {
+	db, err := sql.Open("sqlite", path)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
 	}
-
-	id, err := identity.NewX509Identity(mspID, certificate)
+	// SQLite allows one writer at a time, and -sync is the only one
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec(cacheSchema)
 	if err != nil {
-		panic(err)
+		db.Close()
+		return nil, fmt.Errorf("failed to create the tables of %s: %w", path, err)
 	}
+	return &sqliteCache{path: path, db: db}, nil
+}
 
-	return id
+func (c *sqliteCache) close() error {
+	return c.db.Close()
 }
 
-func loadCertificate(filename string) (*x509.Certificate, error) {
-	certificatePEM, err := os.ReadFile(filename)
+// checkpoint returns the position of the last event written, or nil for a new database.
+func (c *sqliteCache) checkpoint() (*syncCheckpoint, error) {
+	var checkpoint syncCheckpoint
+	err := c.db.QueryRow("SELECT block_number, transaction_id FROM checkpoint WHERE id = 1").Scan(&checkpoint.blockNumber, &checkpoint.transactionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to read certificate file: %w", err)
+		return nil, fmt.Errorf("failed to read the checkpoint of %s: %w", c.path, err)
 	}
-	return identity.CertificateFromPEM(certificatePEM)
+	return &checkpoint, nil
 }
 
-// newSign creates a function that generates a digital signature from a message digest using a private key.
-func newSign() identity.Sign {
-	files, err := os.ReadDir(keyPath)
+// write applies a batch of events and moves the checkpoint to the last of them, in one transaction. The
+// checkpoint never moves back to an earlier block.
+func (c *sqliteCache) write(events []*client.ChaincodeEvent) error {
+	tx, err := c.db.Begin()
 	if err != nil {
-		panic(fmt.Errorf("failed to read private key directory: %w", err))
+		return err
 	}
-	privateKeyPEM, err := os.ReadFile(path.Join(keyPath, files[0].Name()))
+	defer tx.Rollback()
 
+	for _, event := range events {
+		statements, err := cacheStatements(event)
+		if err != nil {
+			return err
+		}
+		for _, statement := range statements {
+			_, err = tx.Exec(statement.query, statement.args...)
+			if err != nil {
+				return fmt.Errorf("failed to apply the %s event of transaction %s: %w", event.EventName, event.TransactionID, err)
+			}
+		}
+	}
+	last := events[len(events)-1]
+	_, err = tx.Exec(upsertCheckpointSQL, last.BlockNumber, last.TransactionID)
 	if err != nil {
-		panic(fmt.Errorf("failed to read private key file: %w", err))
+		return fmt.Errorf("failed to move the checkpoint: %w", err)
 	}
+	return tx.Commit()
+}
 
-	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
+// syncCache copies the commit and push events of the chaincode into a SQLite database for offline SQL
+// queries, starting from the first block for a new database and after its checkpoint otherwise, and keeps
+// following new events until interrupted. Records deleted or changed on the ledger after they were created are
+// not updated, as the contract only emits events for new commits and pushes.
+func syncCache(ctx context.Context, network *client.Network, chaincodeName, dbPath string) {
+	cache, err := openSQLiteCache(dbPath)
 	if err != nil {
-		panic(err)
+		fmt.Printf("Failed to open the cache: %v\n", err)
+		os.Exit(1)
+	}
+	defer cache.close()
+	checkpoint, err := cache.checkpoint()
+	if err != nil {
+		fmt.Printf("Failed to read the cache checkpoint: %v\n", err)
+		os.Exit(1)
+	}
+	// Without a start, the peer would only send events from the next block on
+	start := client.WithStartBlock(0)
+	if checkpoint != nil {
+		start = client.WithCheckpoint(checkpoint)
+		fmt.Printf("--> Resuming sync into %s after transaction %s in block %d\n", dbPath, checkpoint.transactionID, checkpoint.blockNumber)
+	} else {
+		fmt.Printf("--> Syncing into %s from the first block\n", dbPath)
 	}
 
-	sign, err := identity.NewPrivateKeySign(privateKey)
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	events, err := network.ChaincodeEvents(ctx, chaincodeName, start)
 	if err != nil {
-		panic(err)
+		fmt.Printf("Failed to read chaincode events: %v\n", err)
+		os.Exit(1)
 	}
 
-	return sign
+	synced := 0
+	for event := range events {
+		// Write whatever else has already arrived along with it
+		batch := []*client.ChaincodeEvent{event}
+	drain:
+		for len(batch) < syncBatchSize {
+			select {
+			case next, ok := <-events:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+
+		err = cache.write(batch)
+		if err != nil {
+			fmt.Printf("Failed to write events to %s: %v\n", dbPath, err)
+			os.Exit(1)
+		}
+		synced += len(batch)
+		last := batch[len(batch)-1]
+		fmt.Printf("Synced %d events, up to transaction %s in block %d\n", synced, last.TransactionID, last.BlockNumber)
+	}
+	if ctx.Err() == nil {
+		fmt.Println("The chaincode event stream closed; run -sync again to resume")
+		os.Exit(1)
+	}
 }
 
-// Implementation of smart contract interaction functions: createGitCommit, readGitCommit, checkGitCommitExists, getAllGitCommits
-// These functions will interact with the smart contract based on the flag inputs and perform the respective blockchain transactions
-// Omitted for brevity, but would include calling contract.SubmitTransaction() or contract.EvaluateTransaction() with the appropriate function names and arguments from your smart contract
-// CreateGitCommit issues a new GitCommit to the world state with given details.
-func createGitCommit(contract *client.Contract, commitHash, repository, commitMessage, author string) {
-	fmt.Println("--> Submit Transaction: CreateGitCommit")
-	_, err := contract.SubmitTransaction("CreateGitCommit", commitHash, repository, commitMessage, author)
+// listenForEvents prints the chaincode events of transactions committed from now on, such as the commits and
+// pushes they record, until interrupted. Unlike -sync it keeps no checkpoint, so events committed while it
+// is not running are not shown.
+func listenForEvents(ctx context.Context, network *client.Network, chaincodeName string) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	events, err := network.ChaincodeEvents(ctx, chaincodeName)
 	if err != nil {
-		fmt.Printf("Failed to submit CreateGitCommit transaction: %v\n", err)
-		return
+		fmt.Printf("Failed to read chaincode events: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("--> Listening for chaincode events, press Ctrl-C to stop")
+	for event := range events {
+		payload := string(event.Payload)
+		if json.Valid(event.Payload) {
+			payload = formatJSON(event.Payload)
+		}
+		fmt.Printf("<-- %s event in transaction %s, block %d: %s\n", event.EventName, event.TransactionID, event.BlockNumber, payload)
+	}
+	if ctx.Err() == nil {
+		fmt.Println("The chaincode event stream closed")
+		os.Exit(1)
 	}
-	fmt.Println("CreateGitCommit transaction successfully submitted")
 }
 
-// HandleGitPush handles the git push operation, increments the version number, and provides a build message.
-func handleGitPush(contract *client.Contract, repository, remoteURL, commitHash string) {
-	fmt.Println("--> Submit Transaction: CreateGitPush")
-	// Get the latest commit hash
-	commitHash, err := getLatestCommitHash()
+// txStatusWait bounds how long -txStatus waits for the peer. The commit status service blocks until the
+// transaction commits, so a transaction the peer has not seen by then is reported as unknown.
+const txStatusWait = 10 * time.Second
+
+// getTransactionStatus asks the gateway for the commit status of a previously submitted transaction. It lets a
+// user whose submit ended in a commit status timeout find out later whether the transaction made it.
+func getTransactionStatus(ctx context.Context, gw *client.Gateway, id identity.Identity, channelName, transactionID string) {
+	fmt.Println("--> Commit Status: " + transactionID)
+	if transactionID == "" {
+		fmt.Println("A transaction ID is required for -txStatus, use -txid")
+		return
+	}
+
+	request, err := proto.Marshal(&gateway.CommitStatusRequest{
+		ChannelId:     channelName,
+		TransactionId: transactionID,
+		Identity:      serializeIdentity(id),
+	})
+	if err != nil {
+		fmt.Printf("Failed to create commit status request: %v\n", err)
+		return
+	}
+	commit, err := gw.NewCommit(request)
 	if err != nil {
-		fmt.Printf("Failed to get latest commit hash: %v\n", err)
+		fmt.Printf("Failed to create commit status request: %v\n", err)
 		return
 	}
 
-	// Append the commit hash to the remote URL
-	remoteURLWithHash := fmt.Sprintf("%s", remoteURL)
+	ctx, cancel := context.WithTimeout(ctx, txStatusWait)
+	defer cancel()
+	commitStatus, err := commit.StatusWithContext(ctx)
+
+	result := struct {
+		TransactionID  string `json:"transactionID"`
+		Status         string `json:"status"`
+		ValidationCode string `json:"validationCode,omitempty"`
+		BlockNumber    uint64 `json:"blockNumber,omitempty"`
+	}{TransactionID: transactionID}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded:
+		result.Status = "UNKNOWN"
+	case err != nil:
+		fmt.Printf("Failed to get commit status: %v\n", err)
+		return
+	case commitStatus.Successful:
+		result.Status = "VALID"
+		result.ValidationCode = commitStatus.Code.String()
+		result.BlockNumber = commitStatus.BlockNumber
+	default:
+		result.Status = "INVALID"
+		result.ValidationCode = commitStatus.Code.String()
+		result.BlockNumber = commitStatus.BlockNumber
+	}
 
-	fmt.Println("--> Submit Transaction: HandleGitPush")
-	result, err := contract.SubmitTransaction("HandleGitPush", repository, remoteURLWithHash, commitHash)
+	report, err := marshalOutput(result)
 	if err != nil {
-		fmt.Printf("Failed to submit HandleGitPush transaction: %v\n", err)
+		fmt.Printf("Failed to format result: %v\n", err)
 		return
 	}
-	fmt.Printf("HandleGitPush transaction successfully submitted, result: %s\n", string(result))
+	fmt.Printf("Commit status successfully retrieved, result: %s\n", string(report))
+	if result.Status == "UNKNOWN" {
+		fmt.Printf("*** The peer has not seen %s commit within %s; it may still be pending, or the ID may be wrong\n", transactionID, txStatusWait)
+	}
 }
 
-// Helper function to get the latest commit hash
-func getLatestCommitHash() (string, error) {
-	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+// serializeIdentity encodes a client identity the way it appears in signed requests and transaction headers.
+func serializeIdentity(id identity.Identity) []byte {
+	serialized, err := proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   id.MspID(),
+		IdBytes: id.Credentials(),
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to get latest commit hash: %w", err)
+		panic(err)
 	}
-	return strings.TrimSpace(string(out)), nil
+	return serialized
 }
 
-// gET ALL the push transcation
-func getAllPushTransactions(contract *client.Contract) {
-	fmt.Println("--> Evaluate Transaction: GetAllPushTransactions")
-	result, err := contract.EvaluateTransaction("GetAllPushTransactions")
-	if err != nil {
-		fmt.Printf("Failed to evaluate GetAllPushTransactions transaction: %v\n", err)
-		return
+// peerState is what one peer reports about the ledger: its commit hashes and the version of each repository.
+type peerState struct {
+	endpoint string
+	commits  map[string]bool
+	versions map[string]int
+}
+
+// fetchPeerState pages through all commits and lists the repositories of a peer. It calls the contract
+// directly rather than through evaluateTransaction so that -targetPeer cannot redirect it to the other peer.
+func fetchPeerState(ctx context.Context, contract *client.Contract, endpoint string) (*peerState, error) {
+	state := &peerState{endpoint: endpoint, commits: map[string]bool{}, versions: map[string]int{}}
+
+	bookmark := ""
+	for {
+		result, err := evaluateWithTimeout(ctx, contract, "GetAllGitCommitsWithPagination", "1000", bookmark)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate GetAllGitCommitsWithPagination transaction: %w", err)
+		}
+		var page struct {
+			Records  []*GitCommit `json:"records"`
+			Bookmark string       `json:"bookmark"`
+		}
+		err = json.Unmarshal(result, &page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal result: %w", err)
+		}
+		for _, gitCommit := range page.Records {
+			state.commits[gitCommit.CommitHash] = true
+		}
+		if page.Bookmark == "" {
+			break
+		}
+		bookmark = page.Bookmark
 	}
-	var pushTransactions []*PushTransaction
-	err = json.Unmarshal(result, &pushTransactions)
+
+	result, err := evaluateWithTimeout(ctx, contract, "ListRepositories")
 	if err != nil {
-		fmt.Printf("Failed to unmarshal result: %v\n", err)
-		return
+		return nil, fmt.Errorf("failed to evaluate ListRepositories transaction: %w", err)
 	}
-	// Print the result in a readable format
-	prettyResult, err := json.MarshalIndent(pushTransactions, "", "    ")
+	var repositories []*RepositoryVersion
+	err = json.Unmarshal(result, &repositories)
 	if err != nil {
-		fmt.Printf("Failed to format result: %v\n", err)
-		return
+		return nil, fmt.Errorf("failed to unmarshal result: %w", err)
 	}
-	fmt.Printf("GetAllPushTransactions transaction successfully evaluated, result: %s\n", formatJSON(prettyResult))
+	for _, repoVersion := range repositories {
+		state.versions[repoVersion.Repository] = repoVersion.VersionNumber
+	}
+
+	return state, nil
 }
 
-// ReadGitCommit returns the GitCommit stored in the world state with given commit hash.
-func readGitCommit(contract *client.Contract, commitHash string) {
-	fmt.Println("--> Evaluate Transaction: ReadGitCommit")
-	result, err := contract.EvaluateTransaction("ReadGitCommit", commitHash)
-	if err != nil {
-		fmt.Printf("Failed to evaluate ReadGitCommit transaction: %v\n", err)
-		return
+// missingCommits returns the sorted hashes that peer a has and peer b does not.
+func missingCommits(a, b *peerState) []string {
+	var hashes []string
+	for hash := range a.commits {
+		if !b.commits[hash] {
+			hashes = append(hashes, hash)
+		}
 	}
-	fmt.Printf("ReadGitCommit transaction successfully evaluated, result: %s\n", string(result))
+	sort.Strings(hashes)
+	return hashes
 }
 
-// GitCommitExists checks if a GitCommit with the given commit hash exists in the world state.
-func checkGitCommitExists(contract *client.Contract, commitHash string) {
-	fmt.Println("--> Evaluate Transaction: GitCommitExists")
-	result, err := contract.EvaluateTransaction("GitCommitExists", commitHash)
-	if err != nil {
-		fmt.Printf("Failed to evaluate GitCommitExists transaction: %v\n", err)
+// diffPeers compares the ledger state seen through the gateway peer with that of a second peer and reports
+// commits only one of them has and the repository versions of each. Committed state is the same on every
+// peer eventually, so differences point to a peer that is behind in block processing.
+func diffPeers(ctx context.Context, contract *client.Contract, id *identity.X509Identity, sign identity.Sign, channelName, chaincodeName, otherEndpoint, otherTLSCertPath string) {
+	fmt.Printf("--> Comparing ledger state of %s and %s\n", peerEndpoint, otherEndpoint)
+	if otherEndpoint == "" || otherTLSCertPath == "" {
+		fmt.Println("Both -otherEndpoint and -otherTLS are required for -peerDiff")
 		return
 	}
-	exists := string(result) == "true"
-	fmt.Printf("GitCommitExists transaction successfully evaluated, exists: %v\n", exists)
-}
 
-// GetAllGitCommits returns all GitCommits found in the world state.
-func getAllGitCommits(contract *client.Contract) {
-	fmt.Println("--> Evaluate Transaction: GetAllGitCommits")
-	result, err := contract.EvaluateTransaction("GetAllGitCommits")
+	otherGateway, otherConnection, err := connectPeerGateway(otherEndpoint, otherTLSCertPath, id, sign)
 	if err != nil {
-		fmt.Printf("Failed to evaluate GetAllGitCommits transaction: %v\n", err)
+		fmt.Printf("Failed to connect to %s: %v\n", otherEndpoint, err)
 		return
 	}
-	var gitCommits []*GitCommit
-	err = json.Unmarshal(result, &gitCommits)
+	defer otherConnection.Close()
+	defer otherGateway.Close()
+	otherContract := otherGateway.GetNetwork(channelName).GetContract(chaincodeName)
+
+	local, err := fetchPeerState(ctx, contract, peerEndpoint)
 	if err != nil {
-		fmt.Printf("Failed to unmarshal result: %v\n", err)
+		fmt.Printf("Failed to read ledger state of %s: %v\n", peerEndpoint, err)
 		return
 	}
-	// Print the result in a readable format
-	prettyGitResult, err := json.MarshalIndent(gitCommits, "", "    ")
+	other, err := fetchPeerState(ctx, otherContract, otherEndpoint)
 	if err != nil {
-		fmt.Printf("Failed to format result: %v\n", err)
+		fmt.Printf("Failed to read ledger state of %s: %v\n", otherEndpoint, err)
 		return
 	}
-	fmt.Printf("GetAllGitCommits transaction successfully evaluated, result: %s\n", string(prettyGitResult))
+
+	inSync := true
+	for _, peers := range [][2]*peerState{{local, other}, {other, local}} {
+		for _, hash := range missingCommits(peers[0], peers[1]) {
+			fmt.Printf("Commit %s is on %s but not on %s\n", hash, peers[0].endpoint, peers[1].endpoint)
+			inSync = false
+		}
+	}
+
+	repositorySet := map[string]bool{}
+	for repository := range local.versions {
+		repositorySet[repository] = true
+	}
+	for repository := range other.versions {
+		repositorySet[repository] = true
+	}
+	var repositories []string
+	for repository := range repositorySet {
+		repositories = append(repositories, repository)
+	}
+	sort.Strings(repositories)
+
+	for _, repository := range repositories {
+		localVersion, otherVersion := local.versions[repository], other.versions[repository]
+		fmt.Printf("Repository %s: version %d on %s, version %d on %s", repository, localVersion, local.endpoint, otherVersion, other.endpoint)
+		if localVersion < otherVersion {
+			fmt.Printf(" (%s is lagging)", local.endpoint)
+			inSync = false
+		} else if otherVersion < localVersion {
+			fmt.Printf(" (%s is lagging)", other.endpoint)
+			inSync = false
+		}
+		fmt.Println()
+	}
+
+	if inSync {
+		fmt.Println("Peers are in sync")
+	} else {
+		fmt.Println("Peers differ")
+	}
 }
 
-func exampleErrorHandling(contract *client.Contract) {
+func exampleErrorHandling(ctx context.Context, contract *client.Contract) {
 	fmt.Println("\n--> Submit Transaction: IncorrectFunction, intentionally failing to demonstrate error handling")
 
 	// Intentionally using an incorrect function name or wrong number of arguments to trigger an error
-	_, err := contract.SubmitTransaction("IncorrectFunctionName", "someArgument")
+	ctx, cancel := context.WithTimeout(ctx, endorseTimeout)
+	defer cancel()
+	_, err := contract.SubmitWithContext(ctx, "IncorrectFunctionName", client.WithArguments("someArgument"))
 	if err == nil {
 		panic("Expected an error but did not receive one.")
 	}
@@ -336,6 +5495,7 @@ func exampleErrorHandling(contract *client.Contract) {
 	case *client.CommitStatusError:
 		if errors.Is(err, context.DeadlineExceeded) {
 			fmt.Printf("Timeout waiting for transaction %s commit status: %s\n", err.TransactionID, err)
+			fmt.Printf("Check again later with -txStatus -txid %s\n", err.TransactionID)
 		} else {
 			fmt.Printf("Error obtaining commit status for transaction %s with gRPC status %v: %s\n", err.TransactionID, status.Code(err), err)
 		}
@@ -358,10 +5518,67 @@ func exampleErrorHandling(contract *client.Contract) {
 	}
 }
 
+// resultEnvelope wraps a printed result with what produced it, for -envelope
+type resultEnvelope struct {
+	Operation string          `json:"operation"`
+	Count     int             `json:"count"`
+	Channel   string          `json:"channel"`
+	Chaincode string          `json:"chaincode"`
+	Timestamp string          `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// wrapEnvelope places a JSON result into a resultEnvelope. Arrays count their elements, null counts as no
+// result and anything else as one.
+func wrapEnvelope(data []byte) ([]byte, error) {
+	var decoded interface{}
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return nil, err
+	}
+	count := 1
+	switch decoded := decoded.(type) {
+	case []interface{}:
+		count = len(decoded)
+	case nil:
+		count = 0
+	}
+
+	return json.Marshal(resultEnvelope{
+		Operation: outputOperation,
+		Count:     count,
+		Channel:   outputChannel,
+		Chaincode: outputChaincode,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data:      data,
+	})
+}
+
 func formatJSON(data []byte) string {
 	var prettyJSON bytes.Buffer
-	if err := json.Indent(&prettyJSON, data, "", "    "); err != nil {
+	var err error
+	if envelopeOutput {
+		data, err = wrapEnvelope(data)
+		if err != nil {
+			panic(fmt.Errorf("failed to wrap result: %v", err))
+		}
+	}
+	if compactOutput {
+		err = json.Compact(&prettyJSON, data)
+	} else {
+		err = json.Indent(&prettyJSON, data, "", "    ")
+	}
+	if err != nil {
 		panic(fmt.Errorf("failed to format JSON: %v", err))
 	}
 	return prettyJSON.String()
 }
+
+// marshalOutput encodes a result for printing, honoring the -pretty, -compact and -envelope flags.
+func marshalOutput(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(formatJSON(data)), nil
+}
@@ -0,0 +1,662 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// checkError fails the test unless err has the message want, or is nil when want is empty.
+func checkError(t *testing.T, err error, want string) {
+	t.Helper()
+	if want == "" {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	if err == nil || err.Error() != want {
+		t.Fatalf("got error %v, want %q", err, want)
+	}
+}
+
+// checkEqual fails the test unless got and want are deeply equal.
+func checkEqual(t *testing.T, got, want interface{}) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestRepositoryQueueOrdersEachRepository(t *testing.T) {
+	queue := newRepositoryQueue(0)
+	var mu sync.Mutex
+	ran := map[string][]int{}
+	running := map[string]*int32{"repo1": new(int32), "repo2": new(int32)}
+
+	var dones []<-chan error
+	for i := 0; i < 20; i++ {
+		for _, repository := range []string{"repo1", "repo2"} {
+			i, repository := i, repository
+			done, err := queue.enqueue(repository, func() error {
+				// No other transaction of the repository may run at the same time
+				if atomic.AddInt32(running[repository], 1) != 1 {
+					return fmt.Errorf("concurrent submit for %s", repository)
+				}
+				defer atomic.AddInt32(running[repository], -1)
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				ran[repository] = append(ran[repository], i)
+				mu.Unlock()
+				return nil
+			})
+			checkError(t, err, "")
+			dones = append(dones, done)
+		}
+	}
+	for _, done := range dones {
+		checkError(t, <-done, "")
+	}
+
+	for _, repository := range []string{"repo1", "repo2"} {
+		if len(ran[repository]) != 20 {
+			t.Fatalf("%d transactions of %s ran, want 20", len(ran[repository]), repository)
+		}
+		for i, order := range ran[repository] {
+			if order != i {
+				t.Fatalf("transaction %d of %s ran as number %d", order, repository, i)
+			}
+		}
+	}
+	checkEqual(t, queue.depths(), map[string]int{})
+}
+
+func TestRepositoryQueueConcurrentCallers(t *testing.T) {
+	queue := newRepositoryQueue(0)
+	var count int64
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- queue.do(fmt.Sprintf("repo%d", i%3), func() error {
+				atomic.AddInt64(&count, 1)
+				return nil
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		checkError(t, err, "")
+	}
+	checkEqual(t, atomic.LoadInt64(&count), int64(50))
+	checkEqual(t, queue.depths(), map[string]int{})
+}
+
+func TestRepositoryQueueFull(t *testing.T) {
+	queue := newRepositoryQueue(2)
+	release := make(chan struct{})
+	block := func() error {
+		<-release
+		return nil
+	}
+
+	first, err := queue.enqueue("repo1", block)
+	checkError(t, err, "")
+	second, err := queue.enqueue("repo2", block)
+	checkError(t, err, "")
+	checkEqual(t, queue.depths(), map[string]int{"repo1": 1, "repo2": 1})
+
+	_, err = queue.enqueue("repo1", block)
+	checkError(t, err, "the submit queue is full (2 transactions queued or running)")
+
+	close(release)
+	checkError(t, <-first, "")
+	checkError(t, <-second, "")
+
+	// A failed transaction reports its error and frees its place
+	err = queue.do("repo1", func() error {
+		return errors.New("endorsement failed")
+	})
+	checkError(t, err, "endorsement failed")
+}
+
+func TestSyncPassRecordsRepositoriesInParallel(t *testing.T) {
+	targets := []*syncTarget{{Repository: "repo1"}, {Repository: "repo2"}, {Repository: "repo3"}}
+	queue := newRepositoryQueue(0)
+	var started sync.WaitGroup
+	started.Add(len(targets))
+	allStarted := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(allStarted)
+	}()
+
+	statuses := syncPass(targets, func(target *syncTarget) *syncStatus {
+		err := queue.do(target.Repository, func() error {
+			// Each repository waits until all of them are being submitted at once
+			started.Done()
+			select {
+			case <-allStarted:
+				return nil
+			case <-time.After(5 * time.Second):
+				return fmt.Errorf("%s was not submitted in parallel with the others", target.Repository)
+			}
+		})
+		return &syncStatus{Target: target, LastRecorded: "hash-" + target.Repository, Err: err}
+	})
+
+	checkEqual(t, len(statuses), len(targets))
+	for i, status := range statuses {
+		checkEqual(t, status.Target, targets[i])
+		checkError(t, status.Err, "")
+	}
+	checkEqual(t, queue.depths(), map[string]int{})
+}
+
+func TestSyncPassFullQueue(t *testing.T) {
+	targets := []*syncTarget{{Repository: "repo1"}, {Repository: "repo2"}, {Repository: "repo3"}}
+	queue := newRepositoryQueue(2)
+	release := make(chan struct{})
+	var once sync.Once
+
+	statuses := syncPass(targets, func(target *syncTarget) *syncStatus {
+		err := queue.do(target.Repository, func() error {
+			<-release
+			return nil
+		})
+		if err != nil {
+			// The repository left out lets the other two finish
+			once.Do(func() { close(release) })
+		}
+		return &syncStatus{Target: target, Err: err}
+	})
+
+	failed := 0
+	for _, status := range statuses {
+		if status.Err != nil {
+			failed++
+			checkError(t, status.Err, "the submit queue is full (2 transactions queued or running)")
+		}
+	}
+	checkEqual(t, failed, 1)
+}
+
+func TestIntFlagSet(t *testing.T) {
+	var value int
+	f := newIntFlag(&value, -1, 0, 100)
+	checkEqual(t, value, -1)
+	checkEqual(t, f.String(), "-1")
+
+	checkError(t, f.Set("42"), "")
+	checkEqual(t, value, 42)
+	checkError(t, f.Set("0"), "")
+	checkEqual(t, value, 0)
+
+	for input, message := range map[string]string{
+		"":                      "must not be empty",
+		"ten":                   "must be a whole number",
+		"1.5":                   "must be a whole number",
+		"-1":                    "must be at least 0",
+		"101":                   "must be at most 100",
+		"99999999999999999999":  "must be at most 100",
+		"-99999999999999999999": "must be at least 0",
+	} {
+		checkError(t, f.Set(input), message)
+	}
+	// A rejected value leaves the flag unchanged
+	checkEqual(t, value, 0)
+
+	var zero *intFlag[int64]
+	checkEqual(t, zero.String(), "0")
+}
+
+func TestCheckOperation(t *testing.T) {
+	commandLine := flag.CommandLine
+	defer func() {
+		flag.CommandLine = commandLine
+	}()
+	parse := func(args ...string) error {
+		flag.CommandLine = flag.NewFlagSet("gitTransfer", flag.ContinueOnError)
+		for _, name := range operationFlags {
+			flag.Bool(name, false, "")
+		}
+		flag.String("train", "", "")
+		checkError(t, flag.CommandLine.Parse(args), "")
+		return checkOperation()
+	}
+
+	checkError(t, parse("-create"), "")
+	checkError(t, parse("-train", "release-1"), "")
+	checkEqual(t, parse(), errNoOperation)
+	checkEqual(t, parse("-create=false"), errNoOperation)
+	checkError(t, parse("-create", "-delete"), "the operations -create -delete cannot be combined, choose one")
+}
+
+func TestMetricsEndpointConcurrentRequests(t *testing.T) {
+	m := &clientMetrics{counts: map[metricKey]int{}, latencies: map[metricKey]*latencyHistogram{}}
+	server := httptest.NewServer(m)
+	defer server.Close()
+
+	// Transactions are recorded and queued while the endpoint is scraped in parallel
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				err := submitQueue.do(fmt.Sprintf("repo%d", i%3), func() error {
+					m.observe("submit", "CreateGitCommit", nil, time.Millisecond)
+					return nil
+				})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				m.observe("evaluate", "ReadGitCommit", errors.New("not found"), time.Duration(j)*time.Millisecond)
+			}
+		}(i)
+	}
+	errs := make(chan error, 40)
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := http.Get(server.URL + "/metrics")
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer response.Body.Close()
+			body, err := io.ReadAll(response.Body)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if !strings.Contains(string(body), "# TYPE gittransfer_submit_queue_depth gauge") {
+				errs <- fmt.Errorf("incomplete metrics response: %q", body)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		checkError(t, err, "")
+	}
+
+	checkEqual(t, m.counts[metricKey{"submit", "CreateGitCommit", "success"}], 400)
+	checkEqual(t, m.counts[metricKey{"evaluate", "ReadGitCommit", "error"}], 400)
+	checkEqual(t, submitQueue.depths(), map[string]int{})
+}
+
+func TestRenderFeed(t *testing.T) {
+	gitCommits := []*GitCommit{
+		{CommitHash: "hash1", Repository: "repo1", CommitMessage: "Initial commit", Author: "Alice", Timestamp: "2026-01-01T00:00:00Z"},
+		{CommitHash: "hash3", Repository: "repo1", CommitMessage: "Fixed bug\n\nThe parser & the lexer", Author: "Carol", Timestamp: "2026-01-03T00:00:00Z"},
+		{CommitHash: "hash2", Repository: "repo1", CommitMessage: "Added feature", Author: "Bob", Timestamp: "2026-01-02T00:00:00Z"},
+	}
+
+	var out bytes.Buffer
+	now := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	checkError(t, renderFeed(&out, "repo1", gitCommits, "https://example.com/repo1.git", 2, now), "")
+	if !strings.HasPrefix(out.String(), xml.Header) {
+		t.Fatalf("the feed does not start with the XML header: %s", out.String())
+	}
+
+	var feed atomFeed
+	checkError(t, xml.Unmarshal(out.Bytes(), &feed), "")
+	checkEqual(t, feed.ID, "urn:gittransfer:repository:repo1")
+	checkEqual(t, feed.Updated, "2026-01-03T00:00:00Z")
+	checkEqual(t, feed.Link, &atomLink{Href: "https://example.com/repo1"})
+	if len(feed.Entries) != 2 {
+		t.Fatalf("the feed has %d entries, want 2", len(feed.Entries))
+	}
+	checkEqual(t, feed.Entries[0].ID, "urn:gittransfer:commit:repo1:hash3")
+	checkEqual(t, feed.Entries[0].Title, "Fixed bug")
+	checkEqual(t, feed.Entries[0].Content.Text, "Fixed bug\n\nThe parser & the lexer")
+	checkEqual(t, feed.Entries[0].Link, &atomLink{Href: "https://example.com/repo1/commit/hash3"})
+	checkEqual(t, feed.Entries[1].ID, "urn:gittransfer:commit:repo1:hash2")
+
+	// An empty feed is as recent as now and an ssh remote has no web links
+	out.Reset()
+	checkError(t, renderFeed(&out, "repo1", nil, "git@example.com:repo1.git", 10, now), "")
+	feed = atomFeed{}
+	checkError(t, xml.Unmarshal(out.Bytes(), &feed), "")
+	checkEqual(t, feed.Updated, "2026-02-01T00:00:00Z")
+	checkEqual(t, feed.Link, (*atomLink)(nil))
+	checkEqual(t, len(feed.Entries), 0)
+}
+
+func TestParseImportInput(t *testing.T) {
+	payloads, err := parseImportInput(strings.NewReader(`[
+		{"CommitHash": "hash1", "CommitMessage": "Initial commit", "Author": "Alice"},
+		{"CommitHash": "hash2", "Repository": "repo1", "CommitMessage": "Added feature", "Author": "Bob", "CommitTimestamp": "2026-01-02T00:00:00Z"}
+	]`), "repo1", true)
+	checkError(t, err, "")
+	checkEqual(t, payloads, []*commitPayload{
+		{CommitHash: "hash1", Repository: "repo1", CommitMessage: "Initial commit", Author: "Alice"},
+		{CommitHash: "hash2", Repository: "repo1", CommitMessage: "Added feature", Author: "Bob", CommitTimestamp: "2026-01-02T00:00:00Z"},
+	})
+
+	gitLog := "hash1\tAlice\t2026-01-01T00:00:00Z\t2026-01-01T00:00:00Z\tInitial commit\r\n\n" +
+		"hash2\tBob\t2026-01-02T00:00:00Z\t2026-01-02T00:00:00Z\tAdded\ttabbed feature\n"
+	payloads, err = parseImportInput(strings.NewReader(gitLog), "repo1", false)
+	checkError(t, err, "")
+	checkEqual(t, len(payloads), 2)
+	checkEqual(t, payloads[1], &commitPayload{CommitHash: "hash2", Repository: "repo1", CommitMessage: "Added\ttabbed feature", Author: "Bob", AuthorTimestamp: "2026-01-02T00:00:00Z", CommitTimestamp: "2026-01-02T00:00:00Z"})
+
+	_, err = parseImportInput(strings.NewReader("  \n"), "repo1", false)
+	checkError(t, err, "the input holds no commits")
+	_, err = parseImportInput(strings.NewReader(`[{"CommitHash": "hash1", "Author": "Alice", "Branch": "main"}]`), "repo1", true)
+	checkError(t, err, `invalid JSON array of commits: json: unknown field "Branch"`)
+	_, err = parseImportInput(strings.NewReader(`[{"CommitHash": "hash1", "Author": "Alice"}] []`), "repo1", false)
+	checkError(t, err, "invalid JSON array of commits: unexpected data after the array")
+	_, err = parseImportInput(strings.NewReader("hash1\tAlice\tInitial commit\n"), "repo1", false)
+	checkError(t, err, "line 1: expected 5 tab separated fields, got 3")
+
+	// All problems are reported together
+	_, err = parseImportInput(strings.NewReader(`[
+		{"CommitHash": "hash1", "Repository": "repo2", "Author": "Alice"},
+		null,
+		{"CommitHash": "hash3", "CommitTimestamp": "yesterday"}
+	]`), "repo1", false)
+	checkError(t, err, "commit 1: belongs to repository repo2, not repo1\ncommit 2: must be a JSON object\n"+
+		`commit 3: Author is required; CommitTimestamp "yesterday" must be RFC3339`)
+}
+
+func TestCommitCSVWriter(t *testing.T) {
+	var out bytes.Buffer
+	writer := newCommitCSVWriter(&out)
+	checkError(t, writer.writeHeader(), "")
+	checkError(t, writer.write([]*GitCommit{
+		{CommitHash: "hash1", Repository: "repo1", CommitMessage: "Fix \"quoted\", parser\nsecond line", Author: "Alice", VersionNumber: 2, IssueRefs: []string{"#1", "#2"}},
+		{CommitHash: "hash2", Repository: "repo1", CommitMessage: "Added feature", Author: "Bob"},
+	}), "")
+	checkEqual(t, writer.count, 2)
+
+	records, err := csv.NewReader(&out).ReadAll()
+	checkError(t, err, "")
+	checkEqual(t, len(records), 3)
+	header := records[0]
+	checkEqual(t, header[:5], []string{"commitHash", "repository", "commitMessage", "author", "versionNumber"})
+	column := func(record []string, name string) string {
+		for i, column := range header {
+			if column == name {
+				return record[i]
+			}
+		}
+		t.Fatalf("no column %s", name)
+		return ""
+	}
+	checkEqual(t, column(records[1], "commitMessage"), "Fix \"quoted\", parser\nsecond line")
+	checkEqual(t, column(records[1], "versionNumber"), "2")
+	checkEqual(t, column(records[1], "issueRefs"), `["#1","#2"]`)
+	checkEqual(t, column(records[2], "issueRefs"), "")
+	checkEqual(t, column(records[2], "pinned"), "false")
+}
+
+func TestParseTagMapping(t *testing.T) {
+	entries, err := parseTagMapping([]byte(`{"v1.1": " hash2 ", "v1.0": "hash1"}`))
+	checkError(t, err, "")
+	checkEqual(t, entries, []tagEntry{{name: "v1.0", commitHash: "hash1"}, {name: "v1.1", commitHash: "hash2"}})
+
+	for data, message := range map[string]string{
+		`{}`:                 "the tag mapping is empty",
+		`{"v1.0": 1}`:        `the commit hash of tag "v1.0" must be a string, got 1`,
+		`{" ": "hash1"}`:     "the tag mapping contains an empty tag name",
+		`{"v 1.0": "hash1"}`: `the tag name "v 1.0" must not contain whitespace`,
+		`{"v1.0": "  "}`:     `the commit hash of tag "v1.0" must not be empty`,
+	} {
+		_, err = parseTagMapping([]byte(data))
+		checkError(t, err, message)
+	}
+	_, err = parseTagMapping([]byte(`["v1.0"]`))
+	if err == nil || !strings.HasPrefix(err.Error(), "the tag mapping must be a JSON object of tag names to commit hashes: ") {
+		t.Fatalf("unexpected error for a JSON array: %v", err)
+	}
+}
+
+func TestCompareRepositoryCommits(t *testing.T) {
+	commit := func(hash string) *GitCommit {
+		return &GitCommit{CommitHash: hash}
+	}
+	hashes := func(gitCommits []*GitCommit) []string {
+		hashes := []string{}
+		for _, gitCommit := range gitCommits {
+			hashes = append(hashes, gitCommit.CommitHash)
+		}
+		return hashes
+	}
+
+	comparison := compareRepositoryCommits(
+		[]*GitCommit{commit("hash1"), commit("hash3"), commit("hash2")},
+		[]*GitCommit{commit("hash4"), commit("hash2"), commit("hash1")},
+	)
+	checkEqual(t, hashes(comparison.onlyInA), []string{"hash3"})
+	checkEqual(t, hashes(comparison.onlyInB), []string{"hash4"})
+	// Common commits keep the order of the first repository
+	checkEqual(t, hashes(comparison.common), []string{"hash1", "hash2"})
+
+	comparison = compareRepositoryCommits(nil, []*GitCommit{commit("hash1")})
+	checkEqual(t, hashes(comparison.onlyInA), []string{})
+	checkEqual(t, hashes(comparison.onlyInB), []string{"hash1"})
+	checkEqual(t, hashes(comparison.common), []string{})
+}
+
+func TestWaitForCommit(t *testing.T) {
+	calls := 0
+	exists := func(commitHash string) (bool, error) {
+		calls++
+		switch calls {
+		case 1:
+			return false, nil
+		case 2:
+			return false, errors.New("connection refused")
+		}
+		return commitHash == "hash1", nil
+	}
+	checkError(t, waitForCommit(context.Background(), exists, "hash1", time.Second, time.Millisecond), "")
+	checkEqual(t, calls, 3)
+
+	err := waitForCommit(context.Background(), exists, "hash2", 20*time.Millisecond, time.Millisecond)
+	checkError(t, err, "the commit hash2 did not appear within 20ms")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = waitForCommit(ctx, exists, "hash2", time.Second, time.Millisecond)
+	checkError(t, err, "stopped waiting for commit hash2: context canceled")
+}
+
+func TestDiscoverRepositories(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"a/.git", "a/vendor/nested/.git", "b/src", "c/deep/d/.git/objects"} {
+		checkError(t, os.MkdirAll(filepath.Join(root, dir), 0o755), "")
+	}
+	// A worktree or submodule has a .git file instead of a directory
+	checkError(t, os.MkdirAll(filepath.Join(root, "e"), 0o755), "")
+	checkError(t, os.WriteFile(filepath.Join(root, "e", ".git"), []byte("gitdir: ../a/.git/worktrees/e\n"), 0o644), "")
+
+	repositories, err := discoverRepositories(root)
+	checkError(t, err, "")
+	var relative []string
+	for _, repository := range repositories {
+		path, err := filepath.Rel(root, repository)
+		checkError(t, err, "")
+		relative = append(relative, filepath.ToSlash(path))
+	}
+	checkEqual(t, relative, []string{"a", "a/vendor/nested", "c/deep/d", "e"})
+
+	_, err = discoverRepositories(filepath.Join(root, "missing"))
+	if err == nil {
+		t.Fatal("discovering repositories under a missing directory succeeded")
+	}
+}
+
+func TestCacheStatements(t *testing.T) {
+	statements, err := cacheStatements(&client.ChaincodeEvent{
+		BlockNumber:   7,
+		TransactionID: "tx1",
+		EventName:     commitCreatedEvent,
+		Payload:       []byte(`{"commitHash": "hash1", "repository": "repo1", "commitMessage": "Fix O'Brien's bug", "author": "Alice", "versionNumber": 3, "timestamp": "2026-01-01T00:00:00Z"}`),
+	})
+	checkError(t, err, "")
+	checkEqual(t, len(statements), 2)
+	checkEqual(t, statements[0].query, upsertCommitSQL)
+	checkEqual(t, statements[0].args[:9], []interface{}{"repo1", "hash1", "Fix O'Brien's bug", "Alice", 3, "2026-01-01T00:00:00Z", nil, nil, nil})
+	checkEqual(t, statements[1], cacheStatement{upsertRepoSQL, []interface{}{"repo1", 3, uint64(7)}})
+
+	// Other events are not cached
+	statements, err = cacheStatements(&client.ChaincodeEvent{EventName: "SomethingElse", Payload: []byte("not JSON")})
+	checkError(t, err, "")
+	checkEqual(t, len(statements), 0)
+
+	_, err = cacheStatements(&client.ChaincodeEvent{TransactionID: "tx3", EventName: commitCreatedEvent, Payload: []byte("{")})
+	checkError(t, err, "invalid CommitCreated event in transaction tx3: unexpected end of JSON input")
+}
+
+// cacheRows returns the rows of a query on the -sync database, each as its columns joined by |.
+func cacheRows(t *testing.T, cache *sqliteCache, query string) []string {
+	t.Helper()
+	rows, err := cache.db.Query(query)
+	checkError(t, err, "")
+	defer rows.Close()
+	columns, err := rows.Columns()
+	checkError(t, err, "")
+
+	result := []string{}
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		checkError(t, rows.Scan(pointers...), "")
+		fields := make([]string, len(values))
+		for i, value := range values {
+			fields[i] = value.String
+			if !value.Valid {
+				fields[i] = "NULL"
+			}
+		}
+		result = append(result, strings.Join(fields, "|"))
+	}
+	checkError(t, rows.Err(), "")
+	return result
+}
+
+func TestSQLiteCacheReplaysEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gittransfer.db")
+	cache, err := openSQLiteCache(path)
+	checkError(t, err, "")
+	defer cache.close()
+
+	checkpoint, err := cache.checkpoint()
+	checkError(t, err, "")
+	checkEqual(t, checkpoint, (*syncCheckpoint)(nil))
+
+	commit1 := &client.ChaincodeEvent{BlockNumber: 5, TransactionID: "tx1", EventName: commitCreatedEvent,
+		Payload: []byte(`{"commitHash": "hash1", "repository": "repo1", "commitMessage": "Robert'); DROP TABLE commits;--", "author": "Alice", "versionNumber": 1, "timestamp": "2026-01-01T00:00:00Z"}`)}
+	commit2 := &client.ChaincodeEvent{BlockNumber: 6, TransactionID: "tx2", EventName: commitCreatedEvent,
+		Payload: []byte(`{"commitHash": "hash2", "repository": "repo1", "commitMessage": "Added feature", "author": "Bob", "versionNumber": 1, "timestamp": "2026-01-02T00:00:00Z", "authorTimestamp": "2026-01-01T12:00:00Z"}`)}
+	push2 := &client.ChaincodeEvent{BlockNumber: 7, TransactionID: "tx3", EventName: gitPushedEvent,
+		Payload: []byte(`{"repository": "repo1", "version": 2, "commitHash": "hash2", "remoteURL": "https://example.com/repo1.git", "timestamp": "2026-01-03T00:00:00Z", "environment": "staging"}`)}
+	push3 := &client.ChaincodeEvent{BlockNumber: 9, TransactionID: "tx4", EventName: gitPushedEvent,
+		Payload: []byte(`{"repository": "repo1", "version": 3, "commitHash": "hash2", "remoteURL": "https://example.com/repo1.git", "timestamp": "2026-01-04T00:00:00Z"}`)}
+	other := &client.ChaincodeEvent{BlockNumber: 9, TransactionID: "tx5", EventName: "SomethingElse", Payload: []byte("not JSON")}
+
+	checkError(t, cache.write([]*client.ChaincodeEvent{commit1, commit2, push2}), "")
+	checkError(t, cache.write([]*client.ChaincodeEvent{push3, other}), "")
+	// Replayed and late events change nothing, and the checkpoint stays at the latest block
+	checkError(t, cache.write([]*client.ChaincodeEvent{commit2, push2, commit1}), "")
+
+	checkEqual(t, cacheRows(t, cache, "SELECT repository, commit_hash, commit_message, author, version_number, author_timestamp, block_number, transaction_id FROM commits ORDER BY commit_hash"), []string{
+		"repo1|hash1|Robert'); DROP TABLE commits;--|Alice|1|NULL|5|tx1",
+		"repo1|hash2|Added feature|Bob|1|2026-01-01T12:00:00Z|6|tx2",
+	})
+	checkEqual(t, cacheRows(t, cache, "SELECT repository, version, commit_hash, environment, approved_by, block_number, transaction_id FROM pushes ORDER BY version"), []string{
+		"repo1|2|hash2|staging|NULL|7|tx3",
+		"repo1|3|hash2|NULL|NULL|9|tx4",
+	})
+	checkEqual(t, cacheRows(t, cache, "SELECT repository, version_number, block_number FROM repos"), []string{"repo1|3|9"})
+
+	checkpoint, err = cache.checkpoint()
+	checkError(t, err, "")
+	checkEqual(t, checkpoint, &syncCheckpoint{blockNumber: 9, transactionID: "tx5"})
+
+	// A newer event for a known commit replaces its row
+	amended := &client.ChaincodeEvent{BlockNumber: 10, TransactionID: "tx6", EventName: commitCreatedEvent,
+		Payload: []byte(`{"commitHash": "hash1", "repository": "repo1", "commitMessage": "Initial commit", "author": "Alice", "versionNumber": 3, "timestamp": "2026-01-05T00:00:00Z"}`)}
+	checkError(t, cache.write([]*client.ChaincodeEvent{amended}), "")
+	checkEqual(t, cacheRows(t, cache, "SELECT commit_message, block_number FROM commits WHERE commit_hash = 'hash1'"), []string{"Initial commit|10"})
+
+	// A batch with an invalid event is not written at all
+	invalid := &client.ChaincodeEvent{BlockNumber: 11, TransactionID: "tx7", EventName: gitPushedEvent, Payload: []byte("{")}
+	checkError(t, cache.write([]*client.ChaincodeEvent{push3, invalid}), "invalid GitPushed event in transaction tx7: unexpected end of JSON input")
+	checkpoint, err = cache.checkpoint()
+	checkError(t, err, "")
+	checkEqual(t, checkpoint, &syncCheckpoint{blockNumber: 10, transactionID: "tx6"})
+
+	// Reopening resumes from the checkpoint
+	checkError(t, cache.close(), "")
+	cache, err = openSQLiteCache(path)
+	checkError(t, err, "")
+	checkpoint, err = cache.checkpoint()
+	checkError(t, err, "")
+	checkEqual(t, checkpoint, &syncCheckpoint{blockNumber: 10, transactionID: "tx6"})
+}
+
+// gitRepository creates a git repository with one commit and returns its directory and the commit hash.
+func gitRepository(t *testing.T) (string, string) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=Alice", "-c", "user.email=alice@example.com", "commit", "-q", "--allow-empty", "-m", "Initial commit"},
+	} {
+		_, err := gitOutput(dir, args...)
+		checkError(t, err, "")
+	}
+	commitHash, err := gitOutput(dir, "rev-parse", "HEAD")
+	checkError(t, err, "")
+	return dir, strings.TrimSpace(commitHash)
+}
+
+func TestVerifyCommitHash(t *testing.T) {
+	dir, commitHash := gitRepository(t)
+	checkError(t, verifyCommitHash(dir, commitHash), "")
+
+	checkError(t, verifyCommitHash(dir, commitHash[:12]), fmt.Sprintf("%q is not a full commit hash of 40 or 64 lowercase hex digits", commitHash[:12]))
+	checkError(t, verifyCommitHash(dir, strings.ToUpper(commitHash)), fmt.Sprintf("%q is not a full commit hash of 40 or 64 lowercase hex digits", strings.ToUpper(commitHash)))
+
+	missing := strings.Repeat("0", len(commitHash))
+	checkError(t, verifyCommitHash(dir, missing), fmt.Sprintf("the object %s does not exist in the git repository at %s; fetch it or check the hash", missing, dir))
+
+	tree, err := gitOutput(dir, "rev-parse", "HEAD^{tree}")
+	checkError(t, err, "")
+	tree = strings.TrimSpace(tree)
+	checkError(t, verifyCommitHash(dir, tree), fmt.Sprintf("the object %s is a tree, not a commit", tree))
+}
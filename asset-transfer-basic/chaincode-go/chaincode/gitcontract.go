@@ -6,8 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"sort"
-	"time"
+	"strings"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
@@ -19,29 +18,64 @@ type SmartContract struct {
 
 var crumb string
 
-// GitCommit describes basic details of what makes up a Git commit
+// GitCommit describes basic details of what makes up a Git commit. Its JSON names are camelCase, like those
+// of PushTransaction; records written before schema version 3 use PascalCase names, which still decode into the
+// same fields because encoding/json matches names case-insensitively.
 type GitCommit struct {
-	CommitHash    string `json:"CommitHash"`
-	Repository    string `json:"Repository"`
-	CommitMessage string `json:"CommitMessage"`
-	Author        string `json:"Author"`
-	VersionNumber int    `json:"VersionNumber"`
-	Timestamp     string `json:"Timestamp"`
-	//RemoteURL     string `json:"RemoteURL"`
+	CommitHash           string              `json:"commitHash"`
+	Repository           string              `json:"repository"`
+	CommitMessage        string              `json:"commitMessage"`
+	Author               string              `json:"author"`
+	VersionNumber        int                 `json:"versionNumber"`
+	Timestamp            string              `json:"timestamp"`
+	BuildStatus          string              `json:"buildStatus,omitempty"`
+	ParentHashes         []string            `json:"parentHashes,omitempty"`
+	IsMerge              bool                `json:"isMerge,omitempty"`
+	Trailers             map[string][]string `json:"trailers,omitempty"`
+	Insertions           int                 `json:"insertions,omitempty"`
+	Deletions            int                 `json:"deletions,omitempty"`
+	FilesChanged         int                 `json:"filesChanged,omitempty"`
+	AuthorTimestamp      string              `json:"authorTimestamp,omitempty"`
+	CommitTimestamp      string              `json:"commitTimestamp,omitempty"`
+	RecordedTimestamp    string              `json:"recordedTimestamp,omitempty"`
+	SchemaVersion        int                 `json:"schemaVersion,omitempty"`
+	SecurityLevel        string              `json:"securityLevel,omitempty"`
+	AdvisoryURL          string              `json:"advisoryURL,omitempty"`
+	SubmittedBy          string              `json:"submittedBy,omitempty"`
+	SubmitterCertificate string              `json:"submitterCertificate,omitempty"`
+	SubmitterSignature   string              `json:"submitterSignature,omitempty"`
+	IssueRefs            []string            `json:"issueRefs,omitempty"`
+	MessageBlob          string              `json:"messageBlob,omitempty"`
+	Pinned               bool                `json:"pinned,omitempty"`
+	HashAttestations     []*HashAttestation  `json:"hashAttestations,omitempty"`
+	ReviewApprovals      []*ReviewApproval   `json:"reviewApprovals,omitempty"`
+	//RemoteURL     string `json:"remoteURL"`
 }
 
+// PushTransaction records a push of a repository. Like GitCommit it uses camelCase JSON names; the PascalCase
+// CommitHash of older records still decodes.
 type PushTransaction struct {
 	Repository string `json:"repository"`
 	RemoteURL  string `json:"remoteURL"`
 	Timestamp  string `json:"timestamp"`
 	Version    int    `json:"version"`
-	CommitHash string `json:"CommitHash"`
+	CommitHash string `json:"commitHash"`
+	// Message and ApprovedBy annotate pushes recorded by HandleAnnotatedGitPush or HandleDeploymentPush
+	Message    string `json:"message,omitempty"`
+	ApprovedBy string `json:"approvedBy,omitempty"`
+	// Environment is where a push recorded by HandleDeploymentPush was deployed, such as dev, staging or prod
+	Environment string `json:"environment,omitempty"`
+	// TxID is the transaction that recorded the push, for finding it on the ledger; older pushes lack it
+	TxID string `json:"txID,omitempty"`
 }
 
 // RepositoryVersion tracks the current version number of a repository
 type RepositoryVersion struct {
 	Repository    string `json:"Repository"`
 	VersionNumber int    `json:"VersionNumber"`
+	Archived      bool   `json:"Archived"`
+	ForkedFrom    string `json:"ForkedFrom,omitempty"`
+	DisplayName   string `json:"DisplayName,omitempty"`
 }
 
 type BuildRequest struct {
@@ -59,6 +93,7 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 	}
 
 	for _, gitCommit := range gitCommits {
+		upgradeGitCommit(&gitCommit)
 		gitCommitJSON, err := json.Marshal(gitCommit)
 		if err != nil {
 			return err
@@ -93,51 +128,196 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 
 // CreateGitCommit issues a new GitCommit to the world state with given details.
 func (s *SmartContract) CreateGitCommit(ctx contractapi.TransactionContextInterface, commitHash string, repository string, commitMessage string, author string) error {
-	exists, err := s.GitCommitExists(ctx, commitHash)
+	gitCommit, err := s.newGitCommit(ctx, commitHash, repository, commitMessage, author)
 	if err != nil {
 		return err
 	}
-	if exists {
-		return fmt.Errorf("the commit %s already exists", commitHash)
+
+	return s.addGitCommit(ctx, gitCommit)
+}
+
+// newGitCommit validates the details of a commit that is about to be created and returns it, stamped with
+// the current version of its repository. The repository version record is created if it does not exist yet.
+func (s *SmartContract) newGitCommit(ctx contractapi.TransactionContextInterface, commitHash string, repository string, commitMessage string, author string) (*GitCommit, error) {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	displayName := repositoryDisplayName(config, repository)
+	repository = canonicalRepositoryName(config, repository)
+	commitHash = strings.TrimSpace(commitHash)
+
+	validation := newValidationError(config)
+	validation.add(requiredField("CommitHash", "commit hash", commitHash))
+	validation.add(requiredField("Repository", "repository name", repository))
+	validation.add(requiredField("Author", "author", strings.TrimSpace(author)))
+	err = validation.errorOrNil()
+	if err != nil {
+		return nil, err
+	}
+	err = checkKeyParts(config, repository, commitHash)
+	if err != nil {
+		return nil, err
+	}
+
+	// Repository scoped commits only need to be unique within their repository
+	commitKey, err := s.gitCommitKey(ctx, config, repository, commitHash)
+	if err != nil {
+		return nil, err
+	}
+	existingJSON, err := ctx.GetStub().GetState(commitKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existingJSON != nil {
+		return nil, fmt.Errorf("the commit %s already exists", commitHash)
+	}
+	commitMessage, author, err = sanitizeCommitText(config, commitMessage, author)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkRepositoryRegistered(ctx, config, repository)
+	if err != nil {
+		return nil, err
+	}
+	err = s.checkRepositoryWriteAccess(ctx, repository)
+	if err != nil {
+		return nil, err
 	}
 
 	repoVersion, err := s.GetRepositoryVersion(ctx, repository)
 	if err != nil {
 		if err.Error() == fmt.Sprintf("the repository %s does not have a version number", repository) {
-			repoVersion = &RepositoryVersion{Repository: repository, VersionNumber: 1}
+			repoVersion = &RepositoryVersion{Repository: repository, VersionNumber: 1, DisplayName: displayName}
 			err = s.SetRepositoryVersion(ctx, repoVersion)
 			if err != nil {
-				return err
+				return nil, err
 			}
 		} else {
+			return nil, err
+		}
+	}
+	if repoVersion.Archived {
+		return nil, fmt.Errorf("the repository %s is archived", repository)
+	}
+
+	// Timestamp keeps carrying the recorded time for clients that predate RecordedTimestamp
+	recorded, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GitCommit{
+		CommitHash:        commitHash,
+		Repository:        repository,
+		CommitMessage:     commitMessage,
+		Author:            author,
+		VersionNumber:     repoVersion.VersionNumber,
+		Timestamp:         recorded,
+		RecordedTimestamp: recorded,
+		Trailers:          parseTrailers(commitMessage),
+		IssueRefs:         parseIssueRefs(commitMessage),
+	}, nil
+}
+
+// addGitCommit stores a newly created GitCommit together with its index entries.
+func (s *SmartContract) addGitCommit(ctx contractapi.TransactionContextInterface, gitCommit *GitCommit) error {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return err
+	}
+	err = s.checkUniqueCommitMessage(ctx, config, gitCommit)
+	if err != nil {
+		return err
+	}
+	err = s.storeMessageBlob(ctx, config, gitCommit)
+	if err != nil {
+		return err
+	}
+	err = s.putGitCommit(ctx, gitCommit)
+	if err != nil {
+		return err
+	}
+	if config.RepositoryScopedCommits {
+		err = s.indexCommitRepository(ctx, gitCommit)
+		if err != nil {
 			return err
 		}
 	}
 
-	gitCommit := GitCommit{
-		CommitHash:    commitHash,
-		Repository:    repository,
-		CommitMessage: commitMessage,
-		Author:        author,
-		VersionNumber: repoVersion.VersionNumber,
-		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+	err = s.indexTrailers(ctx, gitCommit)
+	if err != nil {
+		return err
+	}
+	err = s.indexCommitType(ctx, config, gitCommit)
+	if err != nil {
+		return err
+	}
+	err = s.indexIssueRefs(ctx, gitCommit)
+	if err != nil {
+		return err
+	}
+	err = s.indexCommitMessage(ctx, gitCommit)
+	if err != nil {
+		return err
+	}
+	return setEvent(ctx, CommitCreatedEvent, gitCommit)
+}
+
+// putGitCommit writes a GitCommit to the world state under its commit hash, or under its repository and
+// commit hash when commits are repository scoped. The record is upgraded to the current schema version first.
+// The message of a commit with a message blob is not stored again.
+func (s *SmartContract) putGitCommit(ctx contractapi.TransactionContextInterface, gitCommit *GitCommit) error {
+	upgradeGitCommit(gitCommit)
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return err
+	}
+	commitKey, err := s.gitCommitKey(ctx, config, gitCommit.Repository, gitCommit.CommitHash)
+	if err != nil {
+		return err
+	}
+	stored := *gitCommit
+	if stored.MessageBlob != "" {
+		stored.CommitMessage = ""
 	}
-	gitCommitJSON, err := json.Marshal(gitCommit)
+	gitCommitJSON, err := json.Marshal(&stored)
 	if err != nil {
 		return err
 	}
 
-	return ctx.GetStub().PutState(commitHash, gitCommitJSON)
+	return ctx.GetStub().PutState(commitKey, gitCommitJSON)
 }
 
-// ReadGitCommit returns the GitCommit stored in the world state with given commit hash.
+// ReadGitCommit returns the GitCommit stored in the world state with given commit hash. When commits are
+// repository scoped and the hash exists in several repositories, ReadGitCommitInRepository must be used.
 func (s *SmartContract) ReadGitCommit(ctx contractapi.TransactionContextInterface, commitHash string) (*GitCommit, error) {
+	commitHash = strings.TrimSpace(commitHash)
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if config.RepositoryScopedCommits {
+		repositories, err := s.commitRepositories(ctx, commitHash)
+		if err != nil {
+			return nil, err
+		}
+		if len(repositories) == 0 {
+			return nil, s.missingCommitError(ctx, config, "", commitHash, fmt.Errorf("the commit %s does not exist", commitHash))
+		}
+		if len(repositories) > 1 {
+			return nil, ambiguousCommitError(commitHash, repositories)
+		}
+		return s.ReadGitCommitInRepository(ctx, repositories[0], commitHash)
+	}
+
 	gitCommitJSON, err := ctx.GetStub().GetState(commitHash)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read from world state: %v", err)
 	}
 	if gitCommitJSON == nil {
-		return nil, fmt.Errorf("the commit %s does not exist", commitHash)
+		return nil, s.missingCommitError(ctx, config, "", commitHash, fmt.Errorf("the commit %s does not exist", commitHash))
 	}
 
 	var gitCommit GitCommit
@@ -145,12 +325,30 @@ func (s *SmartContract) ReadGitCommit(ctx contractapi.TransactionContextInterfac
 	if err != nil {
 		return nil, err
 	}
+	err = s.resolveMessageBlob(ctx, &gitCommit)
+	if err != nil {
+		return nil, err
+	}
 
 	return &gitCommit, nil
 }
 
-// GitCommitExists returns true when a GitCommit with the given commit hash exists in the world state.
+// GitCommitExists returns true when a GitCommit with the given commit hash exists in the world state,
+// in any repository when commits are repository scoped.
 func (s *SmartContract) GitCommitExists(ctx contractapi.TransactionContextInterface, commitHash string) (bool, error) {
+	commitHash = strings.TrimSpace(commitHash)
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return false, err
+	}
+	if config.RepositoryScopedCommits {
+		repositories, err := s.commitRepositories(ctx, commitHash)
+		if err != nil {
+			return false, err
+		}
+		return len(repositories) > 0, nil
+	}
+
 	gitCommitJSON, err := ctx.GetStub().GetState(commitHash)
 	if err != nil {
 		return false, fmt.Errorf("failed to read from world state: %v", err)
@@ -159,33 +357,143 @@ func (s *SmartContract) GitCommitExists(ctx contractapi.TransactionContextInterf
 	return gitCommitJSON != nil, nil
 }
 
-// GetAllGitCommits returns all GitCommits found in the world state.
-func (s *SmartContract) GetAllGitCommits(ctx contractapi.TransactionContextInterface) ([]*GitCommit, error) {
-	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+// maxExistenceChecks caps the hashes GitCommitsExist looks up in one call
+const maxExistenceChecks = 500
+
+// GitCommitsExist reports for each hash in a comma-separated list whether a GitCommit with that hash exists,
+// so that callers can check many commits in one round trip.
+func (s *SmartContract) GitCommitsExist(ctx contractapi.TransactionContextInterface, hashesCSV string) (map[string]bool, error) {
+	commitHashes := splitCommitHashes(hashesCSV)
+	if len(commitHashes) > maxExistenceChecks {
+		return nil, fmt.Errorf("too many commit hashes (%d), at most %d can be checked per call", len(commitHashes), maxExistenceChecks)
+	}
+
+	config, err := s.GetContractConfig(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer resultsIterator.Close()
 
-	var gitCommits []*GitCommit
-	for resultsIterator.HasNext() {
-		queryResponse, err := resultsIterator.Next()
+	exists := make(map[string]bool, len(commitHashes))
+	for _, commitHash := range commitHashes {
+		if config.RepositoryScopedCommits {
+			repositories, err := s.commitRepositories(ctx, commitHash)
+			if err != nil {
+				return nil, err
+			}
+			exists[commitHash] = len(repositories) > 0
+			continue
+		}
+		if !isCommitKey(commitHash) {
+			exists[commitHash] = false
+			continue
+		}
+		gitCommitJSON, err := ctx.GetStub().GetState(commitHash)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to read from world state: %v", err)
+		}
+		exists[commitHash] = gitCommitJSON != nil
+	}
+
+	return exists, nil
+}
+
+// splitCommitHashes returns the non-empty hashes of a comma-separated list.
+func splitCommitHashes(hashesCSV string) []string {
+	var commitHashes []string
+	for _, commitHash := range strings.Split(hashesCSV, ",") {
+		commitHash = strings.TrimSpace(commitHash)
+		if commitHash != "" {
+			commitHashes = append(commitHashes, commitHash)
 		}
+	}
+	return commitHashes
+}
+
+// maxBatchReads caps the commits ReadGitCommits returns in one call
+const maxBatchReads = 100
+
+// GitCommitBatch is the result of ReadGitCommits. Commits holds the commits found, in the order they were
+// requested; NotFound holds the requested hashes that do not exist, also in request order.
+type GitCommitBatch struct {
+	Commits  []*GitCommit `json:"Commits"`
+	NotFound []string     `json:"NotFound"`
+}
+
+// ReadGitCommits returns the commits with the hashes in a comma-separated list, so that callers can read
+// several commits in one round trip. A hash listed twice is returned once. When commits are repository
+// scoped, a hash recorded in several repositories is an error, as with ReadGitCommit.
+func (s *SmartContract) ReadGitCommits(ctx contractapi.TransactionContextInterface, hashesCSV string) (*GitCommitBatch, error) {
+	commitHashes := splitCommitHashes(hashesCSV)
+	if len(commitHashes) > maxBatchReads {
+		return nil, fmt.Errorf("too many commit hashes (%d), at most %d can be read per call", len(commitHashes), maxBatchReads)
+	}
 
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &GitCommitBatch{Commits: []*GitCommit{}, NotFound: []string{}}
+	requested := make(map[string]bool, len(commitHashes))
+	for _, commitHash := range commitHashes {
+		if requested[commitHash] {
+			continue
+		}
+		requested[commitHash] = true
+
+		if config.RepositoryScopedCommits {
+			repositories, err := s.commitRepositories(ctx, commitHash)
+			if err != nil {
+				return nil, err
+			}
+			if len(repositories) == 0 {
+				batch.NotFound = append(batch.NotFound, commitHash)
+				continue
+			}
+			gitCommit, err := s.ReadGitCommit(ctx, commitHash)
+			if err != nil {
+				return nil, err
+			}
+			batch.Commits = append(batch.Commits, gitCommit)
+			continue
+		}
+
+		// Version, push and blob records share the key space but are not commits
+		if !isCommitKey(commitHash) {
+			batch.NotFound = append(batch.NotFound, commitHash)
+			continue
+		}
+		gitCommitJSON, err := ctx.GetStub().GetState(commitHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read from world state: %v", err)
+		}
+		if gitCommitJSON == nil {
+			batch.NotFound = append(batch.NotFound, commitHash)
+			continue
+		}
 		var gitCommit GitCommit
-		err = json.Unmarshal(queryResponse.Value, &gitCommit)
+		err = json.Unmarshal(gitCommitJSON, &gitCommit)
+		if err != nil {
+			return nil, err
+		}
+		err = s.resolveMessageBlob(ctx, &gitCommit)
 		if err != nil {
 			return nil, err
 		}
-		gitCommits = append(gitCommits, &gitCommit)
+		batch.Commits = append(batch.Commits, &gitCommit)
 	}
 
-	// Sort gitCommits by timestamp
-	sort.Slice(gitCommits, func(i, j int) bool {
-		return gitCommits[i].Timestamp < gitCommits[j].Timestamp
-	})
+	return batch, nil
+}
+
+// GetAllGitCommits returns all GitCommits found in the world state, sorted by commit date, falling back to
+// author date and then recorded time. Version, push, blob and snapshot records share the key range of
+// commits that are not repository scoped and are left out.
+func (s *SmartContract) GetAllGitCommits(ctx contractapi.TransactionContextInterface) ([]*GitCommit, error) {
+	gitCommits, err := s.listGitCommits(ctx, func(*GitCommit) bool { return true })
+	if err != nil {
+		return nil, err
+	}
 	// Format the output in a readable JSON format
 	prettyGIt, err := json.MarshalIndent(gitCommits, "", "    ")
 	if err != nil {
@@ -196,20 +504,70 @@ func (s *SmartContract) GetAllGitCommits(ctx contractapi.TransactionContextInter
 	return gitCommits, nil
 }
 
-// IncrementVersionNumber increments the version number of a repository.
-func (s *SmartContract) IncrementVersionNumber(ctx contractapi.TransactionContextInterface, repository string) error {
+// IncrementVersionNumber increments the version number of a repository and returns the new version. A
+// transaction does not read its own writes, so callers must use the returned version rather than read it back.
+func (s *SmartContract) IncrementVersionNumber(ctx contractapi.TransactionContextInterface, repository string) (int, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return 0, err
+	}
 	repoVersion, err := s.GetRepositoryVersion(ctx, repository)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	repoVersion.VersionNumber++
-	return s.SetRepositoryVersion(ctx, repoVersion)
+	err = s.SetRepositoryVersion(ctx, repoVersion)
+	if err != nil {
+		return 0, err
+	}
+	return repoVersion.VersionNumber, nil
+}
+
+// VersionConflictError is returned by IncrementVersionNumberCAS when the version of a repository is not the one
+// the caller expected, because another transaction advanced it first. The message starts with "version
+// conflict" so that clients can recognize it and retry with the current version.
+type VersionConflictError struct {
+	Repository      string
+	ExpectedVersion int
+	CurrentVersion  int
+}
 
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("version conflict on repository %s: expected version %d, current version is %d", e.Repository, e.ExpectedVersion, e.CurrentVersion)
+}
+
+// IncrementVersionNumberCAS increments the version number of a repository only if it still equals
+// expectedVersion, and returns the new version. Concurrent increments are still caught by MVCC when they
+// commit; this makes the expected version explicit, so that a client can read the version, decide and
+// retry on a VersionConflictError instead of overwriting a version it has not seen.
+func (s *SmartContract) IncrementVersionNumberCAS(ctx contractapi.TransactionContextInterface, repository string, expectedVersion int) (int, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return 0, err
+	}
+	repoVersion, err := s.GetRepositoryVersion(ctx, repository)
+	if err != nil {
+		return 0, err
+	}
+	if repoVersion.VersionNumber != expectedVersion {
+		return 0, &VersionConflictError{Repository: repository, ExpectedVersion: expectedVersion, CurrentVersion: repoVersion.VersionNumber}
+	}
+
+	repoVersion.VersionNumber++
+	err = s.SetRepositoryVersion(ctx, repoVersion)
+	if err != nil {
+		return 0, err
+	}
+	return repoVersion.VersionNumber, nil
 }
 
 // GetRepositoryVersion retrieves the current version number for a repository.
 func (s *SmartContract) GetRepositoryVersion(ctx contractapi.TransactionContextInterface, repository string) (*RepositoryVersion, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
 	repoVersionJSON, err := ctx.GetStub().GetState("VERSION_" + repository)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read from world state: %v", err)
@@ -229,24 +587,78 @@ func (s *SmartContract) GetRepositoryVersion(ctx contractapi.TransactionContextI
 
 // SetRepositoryVersion sets the current version number for a repository.
 func (s *SmartContract) SetRepositoryVersion(ctx contractapi.TransactionContextInterface, repoVersion *RepositoryVersion) error {
+	repository, err := s.canonicalRepository(ctx, repoVersion.Repository)
+	if err != nil {
+		return err
+	}
+	repoVersion.Repository = repository
+
 	repoVersionJSON, err := json.Marshal(repoVersion)
 	if err != nil {
 		return err
 	}
 
-	return ctx.GetStub().PutState("VERSION_"+repoVersion.Repository, repoVersionJSON)
+	return ctx.GetStub().PutState("VERSION_"+repository, repoVersionJSON)
 }
 
 // HandleGitPush handles the git push operation, increments the version number, and provides a build message.
 // HandleGitPush handles the git push operation, increments the version number, and provides a build message.
 func (s *SmartContract) HandleGitPush(ctx contractapi.TransactionContextInterface, repository, remoteURL, commitHash string) (string, error) {
-	err := s.IncrementVersionNumber(ctx, repository)
+	return s.handleGitPush(ctx, repository, remoteURL, commitHash, "", "", "")
+}
+
+// handleGitPush records a push, optionally annotated with a message, the approver and the deployment
+// environment, and returns the build message rendered from the PushMessageTemplate setting.
+func (s *SmartContract) handleGitPush(ctx contractapi.TransactionContextInterface, repository, remoteURL, commitHash, pushMessage, approvedBy, environment string) (string, error) {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+	repository = canonicalRepositoryName(config, repository)
+	commitHash = strings.TrimSpace(commitHash)
+	validation := newValidationError(config)
+	validation.add(requiredField("CommitHash", "commit hash", commitHash))
+	validation.add(requiredField("Repository", "repository name", repository))
+	err = validation.errorOrNil()
+	if err != nil {
+		return "", err
+	}
+	err = checkKeyParts(config, repository, commitHash)
+	if err != nil {
+		return "", err
+	}
+	pushMessage, approvedBy, environment, err = sanitizePushText(config, pushMessage, approvedBy, environment)
+	if err != nil {
+		return "", err
+	}
+	err = s.checkRepositoryRegistered(ctx, config, repository)
+	if err != nil {
+		return "", err
+	}
+	err = s.checkRepositoryWriteAccess(ctx, repository)
+	if err != nil {
+		return "", err
+	}
+	err = s.checkApprovedBy(ctx, repository, approvedBy)
+	if err != nil {
+		return "", err
+	}
+	err = s.checkRepositoryNotArchived(ctx, repository)
+	if err != nil {
+		return "", err
+	}
+
+	version, err := s.IncrementVersionNumber(ctx, repository)
 	if err != nil {
 		return "", err
 	}
 
 	// Fetch the commit using the provided commit hash ADDED NEW
-	commitJSON, err := ctx.GetStub().GetState(commitHash)
+	commitKey, err := s.gitCommitKey(ctx, config, repository, commitHash)
+	if err != nil {
+		return "", err
+	}
+	commitJSON, err := ctx.GetStub().GetState(commitKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to get commit: %s", err.Error())
 	}
@@ -263,6 +675,15 @@ func (s *SmartContract) HandleGitPush(ctx contractapi.TransactionContextInterfac
 	if lastCommit.Repository != repository {
 		return "", fmt.Errorf("commit repository mismatch: expected %s, got %s", repository, lastCommit.Repository)
 	}
+	err = checkReviewQuorum(ctx, config, &lastCommit)
+	if err != nil {
+		return "", err
+	}
+
+	// Queue the pushed commit for the build stage
+	if canTransitionBuildStatus(lastCommit.BuildStatus, BuildStatusPending) {
+		lastCommit.BuildStatus = BuildStatusPending
+	}
 
 	// Update the last commit with the remote URL
 	remoteURLWithHash := fmt.Sprintf("%s", remoteURL)
@@ -270,12 +691,7 @@ func (s *SmartContract) HandleGitPush(ctx contractapi.TransactionContextInterfac
 	//lastCommit.RemoteURL = remoteURLWithHash
 
 	// Save the updated commit
-	updatedCommitJSON, err := json.Marshal(lastCommit)
-	if err != nil {
-		return "", err
-	}
-
-	err = ctx.GetStub().PutState(commitHash, updatedCommitJSON)
+	err = s.putGitCommit(ctx, &lastCommit)
 	if err != nil {
 		return "", err
 	}
@@ -325,33 +741,45 @@ func (s *SmartContract) HandleGitPush(ctx contractapi.TransactionContextInterfac
 	//}
 
 	// Store the push transaction
-	repoVersion, err := s.GetRepositoryVersion(ctx, repository)
+	timestamp, err := txTimestamp(ctx)
 	if err != nil {
 		return "", err
 	}
 	pushTx := PushTransaction{
 		Repository: repository,
 		RemoteURL:  remoteURLWithHash,
-		Timestamp:  time.Now().UTC().Format(time.RFC3339),
-		Version:    repoVersion.VersionNumber,
+		Timestamp:  timestamp,
+		Version:    version,
 		//CommitHash: lastCommit.CommitHash, // Add commit hash to the push transaction
-		CommitHash: commitHash,
+		CommitHash:  commitHash,
+		Message:     pushMessage,
+		ApprovedBy:  approvedBy,
+		Environment: environment,
+		TxID:        ctx.GetStub().GetTxID(),
 	}
 	pushTxJSON, err := json.Marshal(pushTx)
 	if err != nil {
 		return "", err
 	}
-	pushTxKey := fmt.Sprintf("PUSH_%s_%s", repository, pushTx.Timestamp)
+	pushTxKey := pushTransactionKey(repository, pushTx.Version, pushTx.TxID)
 	err = ctx.GetStub().PutState(pushTxKey, pushTxJSON)
 	if err != nil {
 		return "", err
 	}
+	err = setEvent(ctx, GitPushedEvent, &pushTx)
+	if err != nil {
+		return "", err
+	}
 
-	message := fmt.Sprintf("All testing is done and audit processes approved, move to build stage. Git clone link: %s", remoteURLWithHash)
-	return message, nil
+	return renderPushMessage(config, &pushTx)
 }
 
 func (s *SmartContract) GetAllPushTransactions(ctx contractapi.TransactionContextInterface) ([]*PushTransaction, error) {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	resultsIterator, err := ctx.GetStub().GetStateByRange("PUSH_", "PUSH_~")
 	if err != nil {
 		return nil, err
@@ -371,6 +799,10 @@ func (s *SmartContract) GetAllPushTransactions(ctx contractapi.TransactionContex
 			return nil, err
 		}
 		pushTransactions = append(pushTransactions, &pushTx)
+		err = checkResultLimit(config, len(pushTransactions))
+		if err != nil {
+			return nil, err
+		}
 	}
 	// Format the output in a readable JSON format
 	prettyResult, err := json.MarshalIndent(pushTransactions, "", "    ")
@@ -385,7 +817,7 @@ func (s *SmartContract) GetAllPushTransactions(ctx contractapi.TransactionContex
 
 // GetEvaluateTransactions returns functions of ComplexContract not to be tagged as submit
 func (s *SmartContract) GetEvaluateTransactions() []string {
-	return []string{"ReadGitCommit"}
+	return []string{"ReadGitCommit", "GetUnpushedCommits", "GetContractConfig", "ListRepositories", "GetAllGitCommitsWithPagination", "GetAllPushTransactionsWithPagination", "GetMergeCommits", "GetGitCommitsProjection", "GetPushTransactionsByRepository", "ListRegisteredRepositories", "GetCommitAtTime", "QueryCommitsByTrailer", "GetRepositoryChurn", "GitCommitsExist", "ReadGitCommitInRepository", "GetCommitReferences", "GetRepositoryAccess", "GetSchemaVersion", "GetCommitsBySecurityLevel", "VerifySubmitterSignature", "GetPruneHistory", "GetRepositoryTimeline", "ComputeCommitScore", "GetTopCommits", "ValidateRepositoryDAG", "GetCommitsByRemote", "GetCommitsByIssue", "GetLedgerSummary", "GetForks", "GetRepositoryVersion", "GitCommitExists", "GetAllGitCommits", "GetAllPushTransactions", "GetTombstones", "QueryCommitsByRepository", "FindDuplicateCommitHashes", "GetPinnedCommits", "ReadGitCommits", "GetPushesByEnvironment", "GetCurrentDeployment", "GetIncompleteCommits", "GetCommitsByType", "GetTags", "GetCommitsTopological", "GetVersionGaps", "ReadReleaseTrain", "GetTrainCommits", "GetSnapshot", "CompareSnapshots", "GetRepositoryAuthors", "GetCommitHistory", "QueryCommits", "QueryCommitsByAuthor", "GetCommitsByRepository"}
 }
 
 func getCrumb(jenkinsURL, username, apiToken string) (string, error) {
@@ -424,34 +856,22 @@ func getCrumb(jenkinsURL, username, apiToken string) (string, error) {
 }
 
 func (s *SmartContract) TriggerBuild(ctx contractapi.TransactionContextInterface, repository string) (string, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return "", err
+	}
 	// Retrieve the latest push transaction details from the ledger
-
-	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	pushTransactions, err := s.getRepositoryPushTransactions(ctx, repository)
 	if err != nil {
-		return "", fmt.Errorf("failed to get state by range: %v", err)
+		return "", err
 	}
-	defer resultsIterator.Close()
 
 	var latestPushTransaction PushTransaction
 	var found bool
-
-	for resultsIterator.HasNext() {
-		queryResponse, err := resultsIterator.Next()
-		if err != nil {
-			return "", fmt.Errorf("failed to iterate query results: %v", err)
-		}
-
-		var pushTransaction PushTransaction
-		err = json.Unmarshal(queryResponse.Value, &pushTransaction)
-		if err != nil {
-			return "", fmt.Errorf("failed to unmarshal query response: %v", err)
-		}
-
-		if pushTransaction.Repository == repository {
-			if !found || pushTransaction.Timestamp > latestPushTransaction.Timestamp {
-				latestPushTransaction = pushTransaction
-				found = true
-			}
+	for _, pushTransaction := range pushTransactions {
+		if !found || pushTransaction.Timestamp > latestPushTransaction.Timestamp {
+			latestPushTransaction = *pushTransaction
+			found = true
 		}
 	}
 
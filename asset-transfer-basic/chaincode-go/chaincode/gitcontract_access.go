@@ -0,0 +1,140 @@
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// requireAdmin returns an error unless the submitting client belongs to the admin MSP of the contract config.
+func (s *SmartContract) requireAdmin(ctx contractapi.TransactionContextInterface) error {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get MSPID: %v", err)
+	}
+	if clientMSPID != config.AdminMSPID {
+		return fmt.Errorf("client from org %v is not authorized to perform this operation", clientMSPID)
+	}
+
+	return nil
+}
+
+const repositoryAccessObjectType = "repositoryAccess"
+
+// GrantRepositoryAccess allows clients of an organization to write commits and pushes to a repository. Once a
+// repository has at least one grant, clients of other organizations may no longer write to it.
+// Only the admin organization may grant access.
+func (s *SmartContract) GrantRepositoryAccess(ctx contractapi.TransactionContextInterface, repository string, mspID string) error {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return err
+	}
+	err = s.requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	if mspID == "" {
+		return fmt.Errorf("the MSP ID must not be empty")
+	}
+
+	accessKey, err := ctx.GetStub().CreateCompositeKey(repositoryAccessObjectType, []string{repository, mspID})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	grant, err := ctx.GetStub().GetState(accessKey)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if grant != nil {
+		return fmt.Errorf("org %s already has access to repository %s", mspID, repository)
+	}
+
+	// Composite key values cannot be empty, so store a single null byte
+	return ctx.GetStub().PutState(accessKey, []byte{0x00})
+}
+
+// RevokeRepositoryAccess removes the write access of an organization to a repository. Revoking the last grant
+// opens the repository to all organizations again. Only the admin organization may revoke access.
+func (s *SmartContract) RevokeRepositoryAccess(ctx contractapi.TransactionContextInterface, repository string, mspID string) error {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return err
+	}
+	err = s.requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	accessKey, err := ctx.GetStub().CreateCompositeKey(repositoryAccessObjectType, []string{repository, mspID})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	grant, err := ctx.GetStub().GetState(accessKey)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if grant == nil {
+		return fmt.Errorf("org %s has no access grant for repository %s", mspID, repository)
+	}
+
+	return ctx.GetStub().DelState(accessKey)
+}
+
+// GetRepositoryAccess returns the organizations granted write access to a repository. An empty list means
+// the repository is open to all organizations.
+func (s *SmartContract) GetRepositoryAccess(ctx contractapi.TransactionContextInterface, repository string) ([]string, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(repositoryAccessObjectType, []string{repository})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	mspIDs := []string{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		mspIDs = append(mspIDs, compositeKeyParts[1])
+	}
+
+	return mspIDs, nil
+}
+
+// checkRepositoryWriteAccess returns an error when a repository has access grants and none of them is for the
+// organization of the submitting client.
+func (s *SmartContract) checkRepositoryWriteAccess(ctx contractapi.TransactionContextInterface, repository string) error {
+	mspIDs, err := s.GetRepositoryAccess(ctx, repository)
+	if err != nil {
+		return err
+	}
+	if len(mspIDs) == 0 {
+		return nil
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get MSPID: %v", err)
+	}
+	for _, mspID := range mspIDs {
+		if mspID == clientMSPID {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("client from org %v is not allowed to write to repository %s", clientMSPID, repository)
+}
@@ -0,0 +1,72 @@
+package chaincode_test
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryOpenWithoutGrants(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	setClientMSPID(transactionContext, "Org2MSP")
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	_, err := gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.NoError(t, err)
+
+	mspIDs, err := gitContract.GetRepositoryAccess(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Empty(t, mspIDs)
+}
+
+func TestRepositoryAccessGrants(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+
+	setClientMSPID(transactionContext, "Org2MSP")
+	err := gitContract.GrantRepositoryAccess(transactionContext, "repo1", "Org2MSP")
+	require.EqualError(t, err, "client from org Org2MSP is not authorized to perform this operation")
+
+	setClientMSPID(transactionContext, adminMSPID)
+	require.NoError(t, gitContract.GrantRepositoryAccess(transactionContext, "repo1", "Org3MSP"))
+	require.NoError(t, gitContract.GrantRepositoryAccess(transactionContext, "repo1", "Org1MSP"))
+	err = gitContract.GrantRepositoryAccess(transactionContext, "repo1", "Org3MSP")
+	require.EqualError(t, err, "org Org3MSP already has access to repository repo1")
+
+	mspIDs, err := gitContract.GetRepositoryAccess(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Org1MSP", "Org3MSP"}, mspIDs)
+
+	// Denied writer
+	setClientMSPID(transactionContext, "Org2MSP")
+	err = gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob")
+	require.EqualError(t, err, "client from org Org2MSP is not allowed to write to repository repo1")
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.EqualError(t, err, "client from org Org2MSP is not allowed to write to repository repo1")
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo2", "Other repository", "Bob"))
+
+	// Allowed writer
+	setClientMSPID(transactionContext, "Org3MSP")
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash2")
+	require.NoError(t, err)
+
+	setClientMSPID(transactionContext, adminMSPID)
+	require.NoError(t, gitContract.RevokeRepositoryAccess(transactionContext, "repo1", "Org3MSP"))
+	err = gitContract.RevokeRepositoryAccess(transactionContext, "repo1", "Org3MSP")
+	require.EqualError(t, err, "org Org3MSP has no access grant for repository repo1")
+
+	setClientMSPID(transactionContext, "Org3MSP")
+	err = gitContract.CreateGitCommit(transactionContext, "hash4", "repo1", "Fixed bug", "Carol")
+	require.EqualError(t, err, "client from org Org3MSP is not allowed to write to repository repo1")
+
+	// Revoking the last grant opens the repository again
+	setClientMSPID(transactionContext, adminMSPID)
+	require.NoError(t, gitContract.RevokeRepositoryAccess(transactionContext, "repo1", "Org1MSP"))
+	setClientMSPID(transactionContext, "Org3MSP")
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash4", "repo1", "Fixed bug", "Carol"))
+}
@@ -0,0 +1,153 @@
+package chaincode
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// HashAttestation records that an organization checked a commit hash against a real git object: the object
+// exists in its copy of the repository and git computes the same hash for it.
+type HashAttestation struct {
+	MSPID      string `json:"MSPID"`
+	VerifiedAt string `json:"VerifiedAt"`
+}
+
+// AttestCommitHash records that the organization of the submitting client verified the hash of a commit
+// against its git repository, as the client does with -verifyHash. The contract cannot see the repository, so
+// the attestation is only as good as the organization making it; each organization may attest a commit once.
+func (s *SmartContract) AttestCommitHash(ctx contractapi.TransactionContextInterface, commitHash string) error {
+	gitCommit, err := s.ReadGitCommit(ctx, commitHash)
+	if err != nil {
+		return err
+	}
+	err = s.checkRepositoryWriteAccess(ctx, gitCommit.Repository)
+	if err != nil {
+		return err
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get MSPID: %v", err)
+	}
+	for _, attestation := range gitCommit.HashAttestations {
+		if attestation.MSPID == clientMSPID {
+			return fmt.Errorf("the hash of commit %s was already attested by %s", commitHash, clientMSPID)
+		}
+	}
+	verifiedAt, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	gitCommit.HashAttestations = append(gitCommit.HashAttestations, &HashAttestation{MSPID: clientMSPID, VerifiedAt: verifiedAt})
+	return s.putGitCommit(ctx, gitCommit)
+}
+
+// ReviewApproval records that an organization reviewed and approved a commit. An approval with an ExpiresAt
+// stops counting at that time, so that an approval of a commit that was since rebased or amended does not last
+// forever.
+type ReviewApproval struct {
+	MSPID      string `json:"MSPID"`
+	ApprovedAt string `json:"ApprovedAt"`
+	ExpiresAt  string `json:"ExpiresAt,omitempty"`
+}
+
+// AttestReview records that the organization of the submitting client approved a commit, for ttlSeconds from
+// the transaction time; zero means the approval does not expire. An organization approving a commit again
+// replaces its earlier approval, which renews it.
+func (s *SmartContract) AttestReview(ctx contractapi.TransactionContextInterface, commitHash string, ttlSeconds int) error {
+	if ttlSeconds < 0 {
+		return fmt.Errorf("the review TTL must not be negative")
+	}
+	approvedAt, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	expiresAt := ""
+	if ttlSeconds > 0 {
+		// txTimestamp is RFC3339, which it parses back from without loss
+		approvedTime, _ := time.Parse(time.RFC3339, approvedAt)
+		expiresAt = approvedTime.Add(time.Duration(ttlSeconds) * time.Second).Format(time.RFC3339)
+	}
+
+	return s.attestReview(ctx, commitHash, approvedAt, expiresAt)
+}
+
+// AttestReviewUntil records a review approval like AttestReview that expires at the given RFC3339 time.
+func (s *SmartContract) AttestReviewUntil(ctx contractapi.TransactionContextInterface, commitHash string, expiresAt string) error {
+	expiresTime, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		return fmt.Errorf("invalid expiry time %q, must be RFC3339: %v", expiresAt, err)
+	}
+	approvedAt, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	approvedTime, _ := time.Parse(time.RFC3339, approvedAt)
+	if !expiresTime.After(approvedTime) {
+		return fmt.Errorf("the expiry time %s is not after the approval time %s", expiresAt, approvedAt)
+	}
+
+	return s.attestReview(ctx, commitHash, approvedAt, expiresTime.UTC().Format(time.RFC3339))
+}
+
+// attestReview stores the review approval of the submitting client's organization on a commit.
+func (s *SmartContract) attestReview(ctx contractapi.TransactionContextInterface, commitHash, approvedAt, expiresAt string) error {
+	gitCommit, err := s.ReadGitCommit(ctx, commitHash)
+	if err != nil {
+		return err
+	}
+	err = s.checkRepositoryWriteAccess(ctx, gitCommit.Repository)
+	if err != nil {
+		return err
+	}
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get MSPID: %v", err)
+	}
+
+	approvals := []*ReviewApproval{}
+	for _, approval := range gitCommit.ReviewApprovals {
+		if approval.MSPID != clientMSPID {
+			approvals = append(approvals, approval)
+		}
+	}
+	gitCommit.ReviewApprovals = append(approvals, &ReviewApproval{MSPID: clientMSPID, ApprovedAt: approvedAt, ExpiresAt: expiresAt})
+	return s.putGitCommit(ctx, gitCommit)
+}
+
+// countReviewApprovals returns how many review approvals of a commit are still valid at the given time and how
+// many have expired. An approval expires at its ExpiresAt, not after it.
+func countReviewApprovals(gitCommit *GitCommit, at time.Time) (int, int) {
+	valid, expired := 0, 0
+	for _, approval := range gitCommit.ReviewApprovals {
+		expiresAt, err := time.Parse(time.RFC3339, approval.ExpiresAt)
+		if approval.ExpiresAt != "" && (err != nil || !at.Before(expiresAt)) {
+			expired++
+			continue
+		}
+		valid++
+	}
+	return valid, expired
+}
+
+// checkReviewQuorum returns an error when a commit has fewer unexpired review approvals than the
+// RequiredReviewApprovals setting asks for. Expiry is judged at the transaction time, so every endorser
+// reaches the same result.
+func checkReviewQuorum(ctx contractapi.TransactionContextInterface, config *ContractConfig, gitCommit *GitCommit) error {
+	if config.RequiredReviewApprovals == 0 {
+		return nil
+	}
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+
+	valid, expired := countReviewApprovals(gitCommit, timestamp.AsTime())
+	if valid < config.RequiredReviewApprovals {
+		return fmt.Errorf("the commit %s has %d valid review approvals (%d expired), %d required", gitCommit.CommitHash, valid, expired, config.RequiredReviewApprovals)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package chaincode_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttestCommitHash(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	setTxTime(chaincodeStub, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	validHash := "0123456789abcdef0123456789abcdef01234567"
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, validHash, "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.AttestCommitHash(transactionContext, validHash))
+
+	err := gitContract.AttestCommitHash(transactionContext, validHash)
+	require.EqualError(t, err, "the hash of commit "+validHash+" was already attested by Org1MSP")
+
+	setClientMSPID(transactionContext, "Org2MSP")
+	require.NoError(t, gitContract.AttestCommitHash(transactionContext, validHash))
+
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, validHash)
+	require.NoError(t, err)
+	require.Equal(t, []*chaincode.HashAttestation{
+		{MSPID: "Org1MSP", VerifiedAt: "2026-03-01T12:00:00Z"},
+		{MSPID: "Org2MSP", VerifiedAt: "2026-03-01T12:00:00Z"},
+	}, gitCommit.HashAttestations)
+
+	// A hash that was never recorded cannot be attested
+	err = gitContract.AttestCommitHash(transactionContext, "fedcba9876543210fedcba9876543210fedcba98")
+	require.EqualError(t, err, "the commit fedcba9876543210fedcba9876543210fedcba98 does not exist")
+}
+
+func TestAttestCommitHashRequiresWriteAccess(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.GrantRepositoryAccess(transactionContext, "repo1", adminMSPID))
+
+	setClientMSPID(transactionContext, "Org2MSP")
+	err := gitContract.AttestCommitHash(transactionContext, "hash1")
+	require.EqualError(t, err, "client from org Org2MSP is not allowed to write to repository repo1")
+}
+
+func TestReviewApprovalExpiry(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	setTxTime(chaincodeStub, start)
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"RequiredReviewApprovals": 2}`))
+
+	// Org1 approves for an hour, Org2 until the end of the day
+	require.NoError(t, gitContract.AttestReview(transactionContext, "hash1", 3600))
+	setClientMSPID(transactionContext, "Org2MSP")
+	require.NoError(t, gitContract.AttestReviewUntil(transactionContext, "hash1", "2026-03-02T00:00:00+02:00"))
+
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, []*chaincode.ReviewApproval{
+		{MSPID: "Org1MSP", ApprovedAt: "2026-03-01T12:00:00Z", ExpiresAt: "2026-03-01T13:00:00Z"},
+		{MSPID: "Org2MSP", ApprovedAt: "2026-03-01T12:00:00Z", ExpiresAt: "2026-03-01T22:00:00Z"},
+	}, gitCommit.ReviewApprovals)
+
+	// Both approvals are still valid
+	setTxTime(chaincodeStub, start.Add(59*time.Minute))
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.NoError(t, err)
+
+	// The approval of Org1 expires at its expiry time
+	setTxTime(chaincodeStub, start.Add(time.Hour))
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.EqualError(t, err, "the commit hash1 has 1 valid review approvals (1 expired), 2 required")
+
+	// Approving again renews the approval instead of adding a second one
+	setClientMSPID(transactionContext, adminMSPID)
+	require.NoError(t, gitContract.AttestReview(transactionContext, "hash1", 0))
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.NoError(t, err)
+	gitCommit, err = gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Len(t, gitCommit.ReviewApprovals, 2)
+
+	// An approval without expiry outlasts one with
+	setTxTime(chaincodeStub, start.Add(10*time.Hour))
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.EqualError(t, err, "the commit hash1 has 1 valid review approvals (1 expired), 2 required")
+}
+
+func TestAttestReviewRejectsInvalidExpiry(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	setTxTime(chaincodeStub, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+
+	err := gitContract.AttestReview(transactionContext, "hash1", -1)
+	require.EqualError(t, err, "the review TTL must not be negative")
+	err = gitContract.AttestReviewUntil(transactionContext, "hash1", "2026-03-01T12:00:00Z")
+	require.EqualError(t, err, "the expiry time 2026-03-01T12:00:00Z is not after the approval time 2026-03-01T12:00:00Z")
+	err = gitContract.AttestReviewUntil(transactionContext, "hash1", "tomorrow")
+	require.ErrorContains(t, err, `invalid expiry time "tomorrow", must be RFC3339`)
+
+	err = gitContract.SetContractConfig(transactionContext, `{"RequiredReviewApprovals": -1}`)
+	require.EqualError(t, err, "RequiredReviewApprovals must not be negative")
+}
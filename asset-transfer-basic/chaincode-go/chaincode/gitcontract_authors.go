@@ -0,0 +1,92 @@
+package chaincode
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// coAuthorTrailer is the trailer key git hosts use to credit co-authors, matched case-insensitively
+const coAuthorTrailer = "co-authored-by"
+
+// RepositoryAuthor summarizes the contributions of one person to a repository. Commits counts the commits
+// they authored or co-authored, CoAuthored those of them they only co-authored.
+type RepositoryAuthor struct {
+	Author            string `json:"Author"`
+	Commits           int    `json:"Commits"`
+	CoAuthored        int    `json:"CoAuthored"`
+	FirstContribution string `json:"FirstContribution"`
+	LastContribution  string `json:"LastContribution"`
+}
+
+// contributorName returns the name of a "Name <email>" author or trailer value, so that a co-author trailer
+// and a plain author field of the same person count as one contributor.
+func contributorName(value string) string {
+	if i := strings.Index(value, "<"); i > 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value)
+}
+
+// GetRepositoryAuthors returns everyone who authored or, through a Co-authored-by trailer, co-authored a
+// commit of a repository, by number of commits descending and then by name. Contribution times are commit
+// times as used for ordering commits.
+func (s *SmartContract) GetRepositoryAuthors(ctx contractapi.TransactionContextInterface, repository string) ([]*RepositoryAuthor, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	gitCommits, err := s.getRepositoryCommits(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	sortGitCommits(gitCommits)
+
+	authors := make(map[string]*RepositoryAuthor)
+	contribute := func(name string, gitCommit *GitCommit, coAuthored bool) {
+		author, ok := authors[name]
+		if !ok {
+			author = &RepositoryAuthor{Author: name, FirstContribution: commitTime(gitCommit).UTC().Format(time.RFC3339)}
+			authors[name] = author
+		}
+		author.Commits++
+		if coAuthored {
+			author.CoAuthored++
+		}
+		author.LastContribution = commitTime(gitCommit).UTC().Format(time.RFC3339)
+	}
+	for _, gitCommit := range gitCommits {
+		authorName := contributorName(gitCommit.Author)
+		if authorName != "" {
+			contribute(authorName, gitCommit, false)
+		}
+		credited := map[string]bool{authorName: true}
+		for key, values := range gitCommit.Trailers {
+			if strings.ToLower(key) != coAuthorTrailer {
+				continue
+			}
+			for _, value := range values {
+				name := contributorName(value)
+				if name == "" || credited[name] {
+					continue
+				}
+				credited[name] = true
+				contribute(name, gitCommit, true)
+			}
+		}
+	}
+
+	result := make([]*RepositoryAuthor, 0, len(authors))
+	for _, author := range authors {
+		result = append(result, author)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Commits != result[j].Commits {
+			return result[i].Commits > result[j].Commits
+		}
+		return result[i].Author < result[j].Author
+	})
+	return result, nil
+}
@@ -0,0 +1,35 @@
+package chaincode_test
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRepositoryAuthors(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "hash1", "repo1", "Initial commit", "Alice", "", "2026-01-01T10:00:00Z"))
+	require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "hash2", "repo1",
+		"Added feature\n\nCo-authored-by: Carol <carol@example.com>\nCo-authored-by: Alice <alice@example.com>", "Bob", "", "2026-01-02T10:00:00Z"))
+	require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "hash3", "repo1",
+		"Fixed bug\n\nco-authored-by: Bob <bob@example.com>", "Alice <alice@example.com>", "", "2026-01-03T10:00:00Z"))
+	// The author crediting themselves as co-author counts once
+	require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "hash4", "repo1",
+		"Tidied up\n\nCo-authored-by: Alice <alice@example.com>", "Alice", "", "2026-01-04T10:00:00Z"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash5", "repo2", "Other repository", "Dave"))
+
+	authors, err := gitContract.GetRepositoryAuthors(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []*chaincode.RepositoryAuthor{
+		{Author: "Alice", Commits: 4, CoAuthored: 1, FirstContribution: "2026-01-01T10:00:00Z", LastContribution: "2026-01-04T10:00:00Z"},
+		{Author: "Bob", Commits: 2, CoAuthored: 1, FirstContribution: "2026-01-02T10:00:00Z", LastContribution: "2026-01-03T10:00:00Z"},
+		{Author: "Carol", Commits: 1, CoAuthored: 1, FirstContribution: "2026-01-02T10:00:00Z", LastContribution: "2026-01-02T10:00:00Z"},
+	}, authors)
+
+	authors, err = gitContract.GetRepositoryAuthors(transactionContext, "repo3")
+	require.NoError(t, err)
+	require.Empty(t, authors)
+}
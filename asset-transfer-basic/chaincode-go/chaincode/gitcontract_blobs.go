@@ -0,0 +1,121 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const blobKeyPrefix = "BLOB_"
+
+// messageBlob holds a commit message shared by every commit whose MessageBlob is its SHA-256 hash
+type messageBlob struct {
+	Content string `json:"Content"`
+	// RefCount is the number of commits referencing the blob; the blob is deleted when it drops to zero
+	RefCount int `json:"RefCount"`
+}
+
+// getBlob returns the blob with the given hash, or nil if there is none.
+func (s *SmartContract) getBlob(ctx contractapi.TransactionContextInterface, blobHash string) (*messageBlob, error) {
+	blobJSON, err := ctx.GetStub().GetState(blobKeyPrefix + blobHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if blobJSON == nil {
+		return nil, nil
+	}
+
+	var blob messageBlob
+	err = json.Unmarshal(blobJSON, &blob)
+	if err != nil {
+		return nil, err
+	}
+	return &blob, nil
+}
+
+func (s *SmartContract) putBlob(ctx contractapi.TransactionContextInterface, blobHash string, blob *messageBlob) error {
+	blobJSON, err := json.Marshal(blob)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(blobKeyPrefix+blobHash, blobJSON)
+	if err != nil {
+		return fmt.Errorf("failed to put to world state: %v", err)
+	}
+	return nil
+}
+
+// retainBlob stores content as a blob unless an identical one exists, counts one more reference to it and
+// returns its hash.
+func (s *SmartContract) retainBlob(ctx contractapi.TransactionContextInterface, content string) (string, error) {
+	digest := sha256.Sum256([]byte(content))
+	blobHash := hex.EncodeToString(digest[:])
+	blob, err := s.getBlob(ctx, blobHash)
+	if err != nil {
+		return "", err
+	}
+	if blob == nil {
+		blob = &messageBlob{Content: content}
+	}
+	blob.RefCount++
+
+	return blobHash, s.putBlob(ctx, blobHash, blob)
+}
+
+// releaseBlob drops count references to a blob and deletes it once no commit references it. Reads do not see
+// the writes of their own transaction, so callers removing several commits must release each blob once with
+// the total count rather than once per commit.
+func (s *SmartContract) releaseBlob(ctx contractapi.TransactionContextInterface, blobHash string, count int) error {
+	blob, err := s.getBlob(ctx, blobHash)
+	if err != nil {
+		return err
+	}
+	if blob == nil {
+		return nil
+	}
+
+	blob.RefCount -= count
+	if blob.RefCount > 0 {
+		return s.putBlob(ctx, blobHash, blob)
+	}
+	err = ctx.GetStub().DelState(blobKeyPrefix + blobHash)
+	if err != nil {
+		return fmt.Errorf("failed to delete state: %v", err)
+	}
+	return nil
+}
+
+// storeMessageBlob moves the message of a new commit into a blob when the MessageBlobMinSize setting asks for it.
+// The commit keeps its CommitMessage in memory; putGitCommit leaves it out of the stored record.
+func (s *SmartContract) storeMessageBlob(ctx contractapi.TransactionContextInterface, config *ContractConfig, gitCommit *GitCommit) error {
+	if config.MessageBlobMinSize <= 0 || len(gitCommit.CommitMessage) < config.MessageBlobMinSize {
+		return nil
+	}
+
+	blobHash, err := s.retainBlob(ctx, gitCommit.CommitMessage)
+	if err != nil {
+		return err
+	}
+	gitCommit.MessageBlob = blobHash
+	return nil
+}
+
+// resolveMessageBlob fills in the CommitMessage of a commit stored with a message blob.
+func (s *SmartContract) resolveMessageBlob(ctx contractapi.TransactionContextInterface, gitCommit *GitCommit) error {
+	if gitCommit.MessageBlob == "" {
+		return nil
+	}
+
+	blob, err := s.getBlob(ctx, gitCommit.MessageBlob)
+	if err != nil {
+		return err
+	}
+	if blob == nil {
+		return fmt.Errorf("the message blob %s of commit %s does not exist", gitCommit.MessageBlob, gitCommit.CommitHash)
+	}
+	gitCommit.CommitMessage = blob.Content
+	return nil
+}
@@ -0,0 +1,107 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+// blobRefCounts returns the reference count of every message blob in the world state, keyed by blob hash.
+func blobRefCounts(t *testing.T, world *worldState) map[string]int {
+	refCounts := map[string]int{}
+	for key, value := range world.state {
+		if !strings.HasPrefix(key, "BLOB_") {
+			continue
+		}
+		var blob struct{ RefCount int }
+		require.NoError(t, json.Unmarshal(value, &blob))
+		refCounts[strings.TrimPrefix(key, "BLOB_")] = blob.RefCount
+	}
+	return refCounts
+}
+
+const templatedMessage = "Bump dependencies to their latest releases"
+
+func TestMessageBlobsAreDeduplicated(t *testing.T) {
+	transactionContext, _, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"MessageBlobMinSize": 20}`))
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", templatedMessage, "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", templatedMessage, "Bob"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo1", "Fix typo", "Carol"))
+
+	refCounts := blobRefCounts(t, world)
+	require.Len(t, refCounts, 1)
+	var blobHash string
+	for hash := range refCounts {
+		blobHash = hash
+	}
+	require.Equal(t, 2, refCounts[blobHash])
+
+	var stored chaincode.GitCommit
+	require.NoError(t, json.Unmarshal(world.state["hash1"], &stored))
+	require.Empty(t, stored.CommitMessage)
+	require.Equal(t, blobHash, stored.MessageBlob)
+	var inline chaincode.GitCommit
+	require.NoError(t, json.Unmarshal(world.state["hash3"], &inline))
+	require.Equal(t, "Fix typo", inline.CommitMessage)
+	require.Empty(t, inline.MessageBlob)
+
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, templatedMessage, gitCommit.CommitMessage)
+
+	gitCommits, err := gitContract.GetUnpushedCommits(transactionContext, "repo1")
+	require.NoError(t, err)
+	for _, gitCommit := range gitCommits {
+		require.NotEmpty(t, gitCommit.CommitMessage)
+	}
+
+	// Updating a commit must not inline its message again
+	require.NoError(t, gitContract.FlagSecurityIssue(transactionContext, "hash2", "low", ""))
+	var updated chaincode.GitCommit
+	require.NoError(t, json.Unmarshal(world.state["hash2"], &updated))
+	require.Empty(t, updated.CommitMessage)
+	require.Equal(t, blobHash, updated.MessageBlob)
+}
+
+func TestMessageBlobsAreReleased(t *testing.T) {
+	transactionContext, chaincodeStub, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"MessageBlobMinSize": 20}`))
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, commitHash := range []string{"hash1", "hash2", "hash3", "hash4"} {
+		setTxTime(chaincodeStub, start.Add(time.Duration(i+1)*time.Hour))
+		require.NoError(t, gitContract.CreateGitCommit(transactionContext, commitHash, "repo1", templatedMessage, "Alice"))
+	}
+	require.Equal(t, []int{4}, refCountValues(blobRefCounts(t, world)))
+
+	require.NoError(t, gitContract.DeleteGitCommit(transactionContext, "hash4", false, chaincode.DeletePurge, ""))
+	require.Equal(t, []int{3}, refCountValues(blobRefCounts(t, world)))
+
+	audit, err := gitContract.PruneCommitsBefore(transactionContext, "repo1", start.Add(24*time.Hour).Format(time.RFC3339), 1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1", "hash2"}, audit.Pruned)
+	require.Equal(t, []int{1}, refCountValues(blobRefCounts(t, world)))
+
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash3")
+	require.NoError(t, err)
+	require.Equal(t, templatedMessage, gitCommit.CommitMessage)
+
+	require.NoError(t, gitContract.DeleteGitCommit(transactionContext, "hash3", false, chaincode.DeletePurge, ""))
+	require.Empty(t, blobRefCounts(t, world))
+}
+
+func refCountValues(refCounts map[string]int) []int {
+	values := []int{}
+	for _, refCount := range refCounts {
+		values = append(values, refCount)
+	}
+	return values
+}
@@ -0,0 +1,53 @@
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Build statuses a GitCommit moves through once it has been pushed
+const (
+	BuildStatusPending  = "pending"
+	BuildStatusBuilding = "building"
+	BuildStatusPassed   = "passed"
+	BuildStatusFailed   = "failed"
+)
+
+// buildStatusTransitions lists the statuses each build status may move to. A commit that was never
+// pushed has an empty status, and a failed build can be queued again by a new push.
+var buildStatusTransitions = map[string][]string{
+	"":                  {BuildStatusPending},
+	BuildStatusPending:  {BuildStatusBuilding, BuildStatusFailed},
+	BuildStatusBuilding: {BuildStatusPassed, BuildStatusFailed},
+	BuildStatusFailed:   {BuildStatusPending},
+	BuildStatusPassed:   {},
+}
+
+// canTransitionBuildStatus reports whether a commit's build status may move from one status to another.
+func canTransitionBuildStatus(from, to string) bool {
+	for _, allowed := range buildStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateBuildStatus records the CI pipeline status of a commit.
+func (s *SmartContract) UpdateBuildStatus(ctx contractapi.TransactionContextInterface, commitHash string, status string) error {
+	if _, ok := buildStatusTransitions[status]; !ok || status == "" {
+		return fmt.Errorf("invalid build status %q, must be one of %s, %s, %s or %s", status, BuildStatusPending, BuildStatusBuilding, BuildStatusPassed, BuildStatusFailed)
+	}
+
+	gitCommit, err := s.ReadGitCommit(ctx, commitHash)
+	if err != nil {
+		return err
+	}
+	if !canTransitionBuildStatus(gitCommit.BuildStatus, status) {
+		return fmt.Errorf("the build status of commit %s cannot change from %q to %q", commitHash, gitCommit.BuildStatus, status)
+	}
+
+	gitCommit.BuildStatus = status
+	return s.putGitCommit(ctx, gitCommit)
+}
@@ -0,0 +1,56 @@
+package chaincode_test
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateBuildStatus(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+
+	err := gitContract.UpdateBuildStatus(transactionContext, "hash1", chaincode.BuildStatusBuilding)
+	require.EqualError(t, err, `the build status of commit hash1 cannot change from "" to "building"`)
+
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.NoError(t, err)
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, chaincode.BuildStatusPending, gitCommit.BuildStatus)
+
+	require.NoError(t, gitContract.UpdateBuildStatus(transactionContext, "hash1", chaincode.BuildStatusBuilding))
+	require.NoError(t, gitContract.UpdateBuildStatus(transactionContext, "hash1", chaincode.BuildStatusPassed))
+
+	err = gitContract.UpdateBuildStatus(transactionContext, "hash1", chaincode.BuildStatusPending)
+	require.EqualError(t, err, `the build status of commit hash1 cannot change from "passed" to "pending"`)
+
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.NoError(t, err)
+	gitCommit, err = gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, chaincode.BuildStatusPassed, gitCommit.BuildStatus)
+
+	err = gitContract.UpdateBuildStatus(transactionContext, "hash1", "done")
+	require.EqualError(t, err, `invalid build status "done", must be one of pending, building, passed or failed`)
+
+	err = gitContract.UpdateBuildStatus(transactionContext, "hash2", chaincode.BuildStatusPending)
+	require.EqualError(t, err, "the commit hash2 does not exist")
+}
+
+func TestFailedBuildCanBeRequeued(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.UpdateBuildStatus(transactionContext, "hash1", chaincode.BuildStatusPending))
+	require.NoError(t, gitContract.UpdateBuildStatus(transactionContext, "hash1", chaincode.BuildStatusFailed))
+
+	err := gitContract.UpdateBuildStatus(transactionContext, "hash1", chaincode.BuildStatusPassed)
+	require.EqualError(t, err, `the build status of commit hash1 cannot change from "failed" to "passed"`)
+
+	require.NoError(t, gitContract.UpdateBuildStatus(transactionContext, "hash1", chaincode.BuildStatusPending))
+}
@@ -0,0 +1,205 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/template"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const configObjectType = "config"
+
+// ContractConfig holds the settings that tune how the contract validates and stores records
+type ContractConfig struct {
+	// AdminMSPID is the organization allowed to change settings and perform administrative operations
+	AdminMSPID string `json:"AdminMSPID"`
+	// MaxQueryResults caps the records an unpaginated list query may load; zero disables the cap
+	MaxQueryResults int `json:"MaxQueryResults"`
+	// RequireRegisteredRepositories rejects commits and pushes for repositories not added by RegisterRepository
+	// instead of creating their version record on first use
+	RequireRegisteredRepositories bool `json:"RequireRegisteredRepositories"`
+	// RepositoryScopedCommits keys commits by repository and hash, so the same hash may exist in several
+	// repositories. It is switched on by MigrateToRepositoryScopedCommits and cannot be set directly.
+	RepositoryScopedCommits bool `json:"RepositoryScopedCommits"`
+	// MigratedSchemaVersion is the schema version MigrateAllCommits last brought every stored commit to, so
+	// that queries can skip looking for older records. It is set by MigrateAllCommits and cannot be set directly.
+	MigratedSchemaVersion int `json:"MigratedSchemaVersion"`
+	// StripControlCharacters removes control characters from text fields instead of rejecting them
+	StripControlCharacters bool `json:"StripControlCharacters"`
+	// StructuredValidationErrors returns validation failures as a JSON object listing each failing field,
+	// instead of a plain message
+	StructuredValidationErrors bool `json:"StructuredValidationErrors"`
+	// SecurityTeamMSPID is the organization allowed to flag security issues; empty means the admin organization
+	SecurityTeamMSPID string `json:"SecurityTeamMSPID"`
+	// ScoreWeights tunes the commit scores computed by ComputeCommitScore
+	ScoreWeights ScoreWeights `json:"ScoreWeights"`
+	// MessageBlobMinSize stores commit messages of at least this many bytes once, as a content-addressed blob
+	// shared by every commit with the same message; zero keeps all messages inline
+	MessageBlobMinSize int `json:"MessageBlobMinSize"`
+	// CanonicalRepositoryNames stores and looks up repositories under their canonical name: trimmed, lowercased
+	// and without a trailing ".git", so that "MyRepo" and "myrepo.git " are one repository. Names recorded
+	// before it was switched on keep their spelling; MergeRepositories folds them into the canonical one.
+	CanonicalRepositoryNames bool `json:"CanonicalRepositoryNames"`
+	// EnforceChronologicalOrder rejects a commit created with an author date that predates the latest commit
+	// of its repository by more than ChronologicalToleranceSeconds, to catch clock-skewed or back-dated imports
+	EnforceChronologicalOrder bool `json:"EnforceChronologicalOrder"`
+	// ChronologicalToleranceSeconds is how far an author date may lag behind the latest commit of the repository
+	// under EnforceChronologicalOrder
+	ChronologicalToleranceSeconds int `json:"ChronologicalToleranceSeconds"`
+	// AllowedEnvironments lists the deployment environments HandleDeploymentPush accepts; empty accepts any
+	AllowedEnvironments []string `json:"AllowedEnvironments"`
+	// RequiredReviewApprovals is how many unexpired review approvals from AttestReview a commit needs before it
+	// may be pushed; zero disables the check
+	RequiredReviewApprovals int `json:"RequiredReviewApprovals"`
+	// RecommendedCommitFields names the GitCommit fields, by their JSON names, that GetIncompleteCommits expects
+	// every commit to have; empty means defaultRecommendedCommitFields
+	RecommendedCommitFields []string `json:"RecommendedCommitFields"`
+	// ConventionalCommitTypes lists the Conventional Commits types, such as feat and fix, that are indexed for
+	// GetCommitsByType; empty means defaultConventionalCommitTypes
+	ConventionalCommitTypes []string `json:"ConventionalCommitTypes"`
+	// UniqueCommitMessages rejects a commit, or a patch of its message, when another commit of the same
+	// repository already has exactly the same message, such as a second "wip"
+	UniqueCommitMessages bool `json:"UniqueCommitMessages"`
+	// PushMessageTemplate is the text/template of the build message returned for a push, executed with the
+	// PushTransaction; empty means defaultPushMessageTemplate
+	PushMessageTemplate string `json:"PushMessageTemplate"`
+}
+
+// ScoreWeights are the integer points a commit scores per unit of each signal. Scores use integer
+// arithmetic only, so every endorser computes the same value.
+type ScoreWeights struct {
+	FileChanged int `json:"FileChanged"`
+	LineChanged int `json:"LineChanged"`
+	CoAuthor    int `json:"CoAuthor"`
+	Merge       int `json:"Merge"`
+}
+
+// defaultContractConfig returns the settings used until SetContractConfig is called.
+func defaultContractConfig() *ContractConfig {
+	return &ContractConfig{
+		AdminMSPID:      "Org1MSP",
+		MaxQueryResults: 10000,
+		ScoreWeights: ScoreWeights{
+			FileChanged: 10,
+			LineChanged: 1,
+			CoAuthor:    25,
+			Merge:       50,
+		},
+	}
+}
+
+// GetContractConfig returns the contract settings stored in the world state, or the defaults if none were set.
+func (s *SmartContract) GetContractConfig(ctx contractapi.TransactionContextInterface) (*ContractConfig, error) {
+	configKey, err := ctx.GetStub().CreateCompositeKey(configObjectType, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create composite key: %v", err)
+	}
+	configJSON, err := ctx.GetStub().GetState(configKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	config := defaultContractConfig()
+	if len(configJSON) == 0 {
+		return config, nil
+	}
+	err = json.Unmarshal(configJSON, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// SetContractConfig replaces the contract settings. Fields missing from configJSON take their default values,
+// except RepositoryScopedCommits and MigratedSchemaVersion, which keep their current values and may not be
+// changed.
+// Only the current admin organization may change the settings.
+func (s *SmartContract) SetContractConfig(ctx contractapi.TransactionContextInterface, configJSON string) error {
+	err := s.requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	current, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	config := defaultContractConfig()
+	config.RepositoryScopedCommits = current.RepositoryScopedCommits
+	config.MigratedSchemaVersion = current.MigratedSchemaVersion
+	err = json.Unmarshal([]byte(configJSON), config)
+	if err != nil {
+		return fmt.Errorf("failed to parse contract config: %v", err)
+	}
+	if config.RepositoryScopedCommits != current.RepositoryScopedCommits {
+		return fmt.Errorf("RepositoryScopedCommits can only be enabled by MigrateToRepositoryScopedCommits")
+	}
+	if config.MigratedSchemaVersion != current.MigratedSchemaVersion {
+		return fmt.Errorf("MigratedSchemaVersion can only be set by MigrateAllCommits")
+	}
+	if config.AdminMSPID == "" {
+		return fmt.Errorf("the contract config must name an AdminMSPID")
+	}
+	if config.MaxQueryResults < 0 {
+		return fmt.Errorf("MaxQueryResults must not be negative")
+	}
+	weights := config.ScoreWeights
+	if weights.FileChanged < 0 || weights.LineChanged < 0 || weights.CoAuthor < 0 || weights.Merge < 0 {
+		return fmt.Errorf("ScoreWeights must not be negative")
+	}
+	if config.MessageBlobMinSize < 0 {
+		return fmt.Errorf("MessageBlobMinSize must not be negative")
+	}
+	if config.ChronologicalToleranceSeconds < 0 {
+		return fmt.Errorf("ChronologicalToleranceSeconds must not be negative")
+	}
+	if config.RequiredReviewApprovals < 0 {
+		return fmt.Errorf("RequiredReviewApprovals must not be negative")
+	}
+	for _, environment := range config.AllowedEnvironments {
+		if environment == "" {
+			return fmt.Errorf("AllowedEnvironments must not contain an empty environment")
+		}
+	}
+	for _, commitType := range config.ConventionalCommitTypes {
+		if commitType == "" || parseCommitType(commitType+": ") != commitType {
+			return fmt.Errorf("ConventionalCommitTypes must be lower case letters only, got %q", commitType)
+		}
+	}
+	if len(config.RecommendedCommitFields) > 0 {
+		_, err = recommendedCommitFields(config)
+		if err != nil {
+			return fmt.Errorf("invalid RecommendedCommitFields: %v", err)
+		}
+	}
+	_, err = template.New("push").Parse(config.PushMessageTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse PushMessageTemplate: %v", err)
+	}
+
+	return s.putContractConfig(ctx, config)
+}
+
+// putContractConfig stores the contract settings without validating them.
+func (s *SmartContract) putContractConfig(ctx contractapi.TransactionContextInterface, config *ContractConfig) error {
+	configKey, err := ctx.GetStub().CreateCompositeKey(configObjectType, []string{})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	storedJSON, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(configKey, storedJSON)
+}
+
+// checkResultLimit returns an error once an unpaginated query has loaded more records than the configured cap.
+func checkResultLimit(config *ContractConfig, count int) error {
+	if config.MaxQueryResults > 0 && count > config.MaxQueryResults {
+		return fmt.Errorf("result set too large (more than %d records), use pagination", config.MaxQueryResults)
+	}
+	return nil
+}
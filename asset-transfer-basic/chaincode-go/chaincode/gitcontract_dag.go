@@ -0,0 +1,167 @@
+package chaincode
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// DanglingParent is a parent reference to a commit that the repository does not hold
+type DanglingParent struct {
+	CommitHash string `json:"CommitHash"`
+	ParentHash string `json:"ParentHash"`
+}
+
+// DAGReport is the result of checking the commit graph of a repository
+type DAGReport struct {
+	Repository  string   `json:"Repository"`
+	Valid       bool     `json:"Valid"`
+	CommitCount int      `json:"CommitCount"`
+	Roots       []string `json:"Roots"`
+	// DanglingParents lists the parent hashes that name no commit of the repository
+	DanglingParents []*DanglingParent `json:"DanglingParents"`
+	// Cycles lists each detected cycle as a path of hashes, every commit followed by its parent, ending with
+	// the commit whose parent is the first one
+	Cycles [][]string `json:"Cycles"`
+}
+
+// ValidateRepositoryDAG checks the commit graph of a repository before ancestry queries are trusted: every
+// parent must be a commit of the same repository, the parent links must not form a cycle and at least one
+// commit must have no parents. It changes nothing and reports every problem it finds rather than failing on
+// the first. A repository without commits has no root and so is not valid.
+func (s *SmartContract) ValidateRepositoryDAG(ctx contractapi.TransactionContextInterface, repository string) (*DAGReport, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	gitCommits, err := s.getRepositoryCommits(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DAGReport{
+		Repository:      repository,
+		CommitCount:     len(gitCommits),
+		Roots:           []string{},
+		DanglingParents: []*DanglingParent{},
+		Cycles:          [][]string{},
+	}
+	byHash := make(map[string]*GitCommit, len(gitCommits))
+	for _, gitCommit := range gitCommits {
+		byHash[gitCommit.CommitHash] = gitCommit
+	}
+	for _, gitCommit := range gitCommits {
+		if len(gitCommit.ParentHashes) == 0 {
+			report.Roots = append(report.Roots, gitCommit.CommitHash)
+		}
+		for _, parentHash := range gitCommit.ParentHashes {
+			if byHash[parentHash] == nil {
+				report.DanglingParents = append(report.DanglingParents, &DanglingParent{CommitHash: gitCommit.CommitHash, ParentHash: parentHash})
+			}
+		}
+	}
+
+	// Depth first search along the parent links. A commit still on the path when it is reached again closes a
+	// cycle; a finished commit is never entered again, so each cycle is reported once.
+	const (
+		unvisited = iota
+		onPath
+		finished
+	)
+	state := make(map[string]int, len(gitCommits))
+	var path []string
+	var visit func(commitHash string)
+	visit = func(commitHash string) {
+		state[commitHash] = onPath
+		path = append(path, commitHash)
+		for _, parentHash := range byHash[commitHash].ParentHashes {
+			if byHash[parentHash] == nil {
+				continue
+			}
+			switch state[parentHash] {
+			case unvisited:
+				visit(parentHash)
+			case onPath:
+				for i := len(path) - 1; i >= 0; i-- {
+					if path[i] == parentHash {
+						report.Cycles = append(report.Cycles, append([]string{}, path[i:]...))
+						break
+					}
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[commitHash] = finished
+	}
+	for _, gitCommit := range gitCommits {
+		if state[gitCommit.CommitHash] == unvisited {
+			visit(gitCommit.CommitHash)
+		}
+	}
+
+	report.Valid = len(report.Roots) > 0 && len(report.DanglingParents) == 0 && len(report.Cycles) == 0
+	return report, nil
+}
+
+// GetCommitsTopological returns the commits of a repository with every parent before its children, for
+// example to write release notes that respect ancestry. Among commits whose parents have all been listed,
+// the earliest by commit time comes first, then the lowest hash. Parents the repository does not hold are
+// ignored; a cycle fails the query, since no order exists.
+func (s *SmartContract) GetCommitsTopological(ctx contractapi.TransactionContextInterface, repository string) ([]*GitCommit, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	gitCommits, err := s.listRepositoryCommits(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	byHash := make(map[string]*GitCommit, len(gitCommits))
+	for _, gitCommit := range gitCommits {
+		byHash[gitCommit.CommitHash] = gitCommit
+	}
+	children := make(map[string][]*GitCommit, len(gitCommits))
+	pendingParents := make(map[string]int, len(gitCommits))
+	for _, gitCommit := range gitCommits {
+		for _, parentHash := range gitCommit.ParentHashes {
+			if byHash[parentHash] != nil {
+				children[parentHash] = append(children[parentHash], gitCommit)
+				pendingParents[gitCommit.CommitHash]++
+			}
+		}
+	}
+
+	// Kahn's algorithm: ready holds the commits whose parents are all listed, kept in tie-break order
+	var ready []*GitCommit
+	push := func(gitCommit *GitCommit) {
+		i := sort.Search(len(ready), func(i int) bool { return commitBefore(gitCommit, ready[i]) })
+		ready = append(ready, nil)
+		copy(ready[i+1:], ready[i:])
+		ready[i] = gitCommit
+	}
+	for _, gitCommit := range gitCommits {
+		if pendingParents[gitCommit.CommitHash] == 0 {
+			push(gitCommit)
+		}
+	}
+
+	ordered := make([]*GitCommit, 0, len(gitCommits))
+	for len(ready) > 0 {
+		gitCommit := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, gitCommit)
+		for _, child := range children[gitCommit.CommitHash] {
+			pendingParents[child.CommitHash]--
+			if pendingParents[child.CommitHash] == 0 {
+				push(child)
+			}
+		}
+	}
+	if len(ordered) < len(gitCommits) {
+		return nil, fmt.Errorf("the commit graph of repository %s has a cycle: %d commits are on or descend from it, see ValidateRepositoryDAG", repository, len(gitCommits)-len(ordered))
+	}
+
+	return ordered, nil
+}
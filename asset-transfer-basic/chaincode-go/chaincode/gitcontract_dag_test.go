@@ -0,0 +1,145 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+// putCommitWithParents writes a commit straight to the world state, bypassing the parent checks of the contract.
+func putCommitWithParents(t *testing.T, world *worldState, commitHash string, repository string, parentHashes ...string) {
+	commitJSON, err := json.Marshal(&chaincode.GitCommit{CommitHash: commitHash, Repository: repository, ParentHashes: parentHashes})
+	require.NoError(t, err)
+	world.state[commitHash] = commitJSON
+}
+
+func TestValidateRepositoryDAG(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+	require.NoError(t, gitContract.CreateMergeCommit(transactionContext, "merge1", "repo1", "hash1", "hash2", "Merge branch", "Alice"))
+
+	report, err := gitContract.ValidateRepositoryDAG(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.True(t, report.Valid)
+	require.Equal(t, 3, report.CommitCount)
+	require.Equal(t, []string{"hash1", "hash2"}, report.Roots)
+	require.Empty(t, report.DanglingParents)
+	require.Empty(t, report.Cycles)
+
+	report, err = gitContract.ValidateRepositoryDAG(transactionContext, "repo2")
+	require.NoError(t, err)
+	require.False(t, report.Valid)
+	require.Zero(t, report.CommitCount)
+	require.Empty(t, report.Roots)
+}
+
+func TestValidateRepositoryDAGDanglingParent(t *testing.T) {
+	transactionContext, _, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	putCommitWithParents(t, world, "hash1", "repo1")
+	putCommitWithParents(t, world, "hash2", "repo1", "hash1", "missing")
+	putCommitWithParents(t, world, "hash3", "repo2")
+	putCommitWithParents(t, world, "hash4", "repo1", "hash3")
+
+	report, err := gitContract.ValidateRepositoryDAG(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.False(t, report.Valid)
+	require.Equal(t, []string{"hash1"}, report.Roots)
+	require.ElementsMatch(t, []*chaincode.DanglingParent{
+		{CommitHash: "hash2", ParentHash: "missing"},
+		{CommitHash: "hash4", ParentHash: "hash3"},
+	}, report.DanglingParents)
+	require.Empty(t, report.Cycles)
+}
+
+func TestValidateRepositoryDAGCycle(t *testing.T) {
+	transactionContext, _, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	putCommitWithParents(t, world, "hash1", "repo1")
+	putCommitWithParents(t, world, "hash2", "repo1", "hash1", "hash4")
+	putCommitWithParents(t, world, "hash3", "repo1", "hash2")
+	putCommitWithParents(t, world, "hash4", "repo1", "hash3")
+
+	report, err := gitContract.ValidateRepositoryDAG(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.False(t, report.Valid)
+	require.Equal(t, []string{"hash1"}, report.Roots)
+	require.Empty(t, report.DanglingParents)
+	require.Len(t, report.Cycles, 1)
+	require.ElementsMatch(t, []string{"hash2", "hash3", "hash4"}, report.Cycles[0])
+
+	putCommitWithParents(t, world, "hash1", "repo1", "hash1")
+	report, err = gitContract.ValidateRepositoryDAG(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.False(t, report.Valid)
+	require.Empty(t, report.Roots)
+	require.Contains(t, report.Cycles, []string{"hash1"})
+}
+
+// putCommitAt writes a commit with a commit time straight to the world state, like putCommitWithParents.
+func putCommitAt(t *testing.T, world *worldState, commitHash string, commitTime time.Time, parentHashes ...string) {
+	commitJSON, err := json.Marshal(&chaincode.GitCommit{CommitHash: commitHash, Repository: "repo1", ParentHashes: parentHashes, CommitTimestamp: commitTime.Format(time.RFC3339)})
+	require.NoError(t, err)
+	world.state[commitHash] = commitJSON
+}
+
+func TestGetCommitsTopologicalLinear(t *testing.T) {
+	transactionContext, _, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Commit times run against the ancestry, which wins
+	putCommitAt(t, world, "hash1", start.Add(2*time.Hour))
+	putCommitAt(t, world, "hash2", start.Add(time.Hour), "hash1")
+	putCommitAt(t, world, "hash3", start, "hash2")
+
+	gitCommits, err := gitContract.GetCommitsTopological(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1", "hash2", "hash3"}, commitHashes(gitCommits))
+
+	gitCommits, err = gitContract.GetCommitsTopological(transactionContext, "repo2")
+	require.NoError(t, err)
+	require.Empty(t, gitCommits)
+}
+
+func TestGetCommitsTopologicalMerged(t *testing.T) {
+	transactionContext, _, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	putCommitAt(t, world, "root", start)
+	// Two branches from root, interleaved in time; ties go to the earlier commit, then the lower hash
+	putCommitAt(t, world, "b1", start.Add(2*time.Hour), "root")
+	putCommitAt(t, world, "a1", start.Add(time.Hour), "root")
+	putCommitAt(t, world, "a2", start.Add(3*time.Hour), "a1")
+	putCommitAt(t, world, "b2", start.Add(3*time.Hour), "b1")
+	putCommitAt(t, world, "merge", start.Add(30*time.Minute), "a2", "b2")
+	// A parent the repository does not hold does not hold its child back
+	putCommitAt(t, world, "orphan", start.Add(4*time.Hour), "missing")
+
+	gitCommits, err := gitContract.GetCommitsTopological(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"root", "a1", "b1", "a2", "b2", "merge", "orphan"}, commitHashes(gitCommits))
+}
+
+func TestGetCommitsTopologicalCycle(t *testing.T) {
+	transactionContext, _, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	putCommitWithParents(t, world, "hash1", "repo1")
+	putCommitWithParents(t, world, "hash2", "repo1", "hash1", "hash4")
+	putCommitWithParents(t, world, "hash3", "repo1", "hash2")
+	putCommitWithParents(t, world, "hash4", "repo1", "hash3")
+	putCommitWithParents(t, world, "hash5", "repo1", "hash4")
+
+	_, err := gitContract.GetCommitsTopological(transactionContext, "repo1")
+	require.EqualError(t, err, "the commit graph of repository repo1 has a cycle: 4 commits are on or descend from it, see ValidateRepositoryDAG")
+}
@@ -0,0 +1,131 @@
+package chaincode
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// txTimestamp returns the timestamp the client put in the transaction proposal, formatted as RFC3339. Unlike
+// the clock of the peer it is the same on every endorser, so records stamped with it endorse consistently.
+func txTimestamp(ctx contractapi.TransactionContextInterface) (string, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return "", fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	return timestamp.AsTime().UTC().Format(time.RFC3339), nil
+}
+
+// commitTime returns the time a commit is ordered by: its git commit date, else its git author date, else
+// the time it was recorded on the ledger.
+func commitTime(gitCommit *GitCommit) time.Time {
+	for _, timestamp := range []string{gitCommit.CommitTimestamp, gitCommit.AuthorTimestamp, gitCommit.RecordedTimestamp, gitCommit.Timestamp} {
+		if timestamp == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, timestamp)
+		if err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
+
+// commitBefore reports whether commit a is ordered before b: by commitTime, then by hash and repository, so
+// that commits imported with the same date, or recorded in the same block, always come back in one order.
+func commitBefore(a, b *GitCommit) bool {
+	timeA, timeB := commitTime(a), commitTime(b)
+	if !timeA.Equal(timeB) {
+		return timeA.Before(timeB)
+	}
+	if a.CommitHash != b.CommitHash {
+		return a.CommitHash < b.CommitHash
+	}
+	return a.Repository < b.Repository
+}
+
+// sortGitCommits orders commits by commitBefore.
+func sortGitCommits(gitCommits []*GitCommit) {
+	sort.Slice(gitCommits, func(i, j int) bool {
+		return commitBefore(gitCommits[i], gitCommits[j])
+	})
+}
+
+// checkCommitDates adds a field error to validation for each of the git author and commit dates that is set
+// but not RFC3339.
+func checkCommitDates(validation *ValidationError, authorTimestamp, commitTimestamp string) {
+	for _, date := range []struct{ field, name, value string }{{"AuthorTimestamp", "author", authorTimestamp}, {"CommitTimestamp", "commit", commitTimestamp}} {
+		if date.value == "" {
+			continue
+		}
+		_, err := time.Parse(time.RFC3339, date.value)
+		if err != nil {
+			validation.add(&FieldError{Field: date.field, Rule: "rfc3339", Message: fmt.Sprintf("invalid %s timestamp %q, must be RFC3339: %v", date.name, date.value, err), Value: date.value})
+		}
+	}
+}
+
+// CreateGitCommitWithDates issues a new GitCommit like CreateGitCommit and records the git author and commit
+// dates, as printed by git log --format=%aI and %cI. Either date may be left empty.
+func (s *SmartContract) CreateGitCommitWithDates(ctx contractapi.TransactionContextInterface, commitHash string, repository string, commitMessage string, author string, authorTimestamp string, commitTimestamp string) error {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return err
+	}
+	validation := newValidationError(config)
+	checkCommitDates(validation, authorTimestamp, commitTimestamp)
+	err = validation.errorOrNil()
+	if err != nil {
+		return err
+	}
+
+	gitCommit, err := s.newGitCommit(ctx, commitHash, repository, commitMessage, author)
+	if err != nil {
+		return err
+	}
+	gitCommit.AuthorTimestamp = authorTimestamp
+	gitCommit.CommitTimestamp = commitTimestamp
+	if config.EnforceChronologicalOrder && authorTimestamp != "" {
+		err = s.checkChronologicalOrder(ctx, config, gitCommit)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.addGitCommit(ctx, gitCommit)
+}
+
+// checkChronologicalOrder returns an error naming the latest commit of the repository when the author date of
+// a new commit predates it by more than the configured tolerance. Finding the latest commit reads every commit
+// of the repository, which is why the check is opt-in.
+func (s *SmartContract) checkChronologicalOrder(ctx contractapi.TransactionContextInterface, config *ContractConfig, gitCommit *GitCommit) error {
+	authored, err := time.Parse(time.RFC3339, gitCommit.AuthorTimestamp)
+	if err != nil {
+		return err
+	}
+
+	var latest *GitCommit
+	err = s.forEachRepositoryCommit(ctx, config, gitCommit.Repository, func(existing *GitCommit) error {
+		if latest == nil || commitTime(existing).After(commitTime(latest)) {
+			latest = existing
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if latest == nil {
+		return nil
+	}
+
+	tolerance := time.Duration(config.ChronologicalToleranceSeconds) * time.Second
+	latestTime := commitTime(latest)
+	if authored.Add(tolerance).Before(latestTime) {
+		return fmt.Errorf("the commit %s was authored at %s, %s before commit %s of repository %s at %s, more than the allowed %s",
+			gitCommit.CommitHash, gitCommit.AuthorTimestamp, latestTime.Sub(authored), latest.CommitHash, gitCommit.Repository, latestTime.Format(time.RFC3339), tolerance)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package chaincode_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateGitCommitWithDates(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	recorded := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	setTxTime(chaincodeStub, recorded)
+	require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "hash1", "repo1", "Initial commit", "Alice", "2026-01-01T09:00:00+02:00", "2026-01-01T10:00:00+02:00"))
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, "2026-01-01T09:00:00+02:00", gitCommit.AuthorTimestamp)
+	require.Equal(t, "2026-01-01T10:00:00+02:00", gitCommit.CommitTimestamp)
+	require.Equal(t, "2026-01-02T12:00:00Z", gitCommit.RecordedTimestamp)
+	require.Equal(t, gitCommit.RecordedTimestamp, gitCommit.Timestamp)
+
+	err = gitContract.CreateGitCommitWithDates(transactionContext, "hash2", "repo1", "Added feature", "Bob", "", "last week")
+	require.ErrorContains(t, err, `invalid commit timestamp "last week", must be RFC3339`)
+	exists, err := gitContract.GitCommitExists(transactionContext, "hash2")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestCommitSortFallbackOrder(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Only the recorded time is known, and it is the latest of all
+	setTxTime(chaincodeStub, start.Add(3*time.Hour))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "recorded", "repo1", "Initial commit", "Alice"))
+
+	// The author date wins over a later recorded time
+	setTxTime(chaincodeStub, start.Add(4*time.Hour))
+	require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "authored", "repo1", "Added feature", "Bob", start.Add(time.Hour).Format(time.RFC3339), ""))
+
+	// The commit date wins over a later author date
+	setTxTime(chaincodeStub, start.Add(5*time.Hour))
+	require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "committed", "repo1", "Fixed bug", "Carol", start.Add(6*time.Hour).Format(time.RFC3339), start.Add(2*time.Hour).Format(time.RFC3339)))
+
+	// Dates are compared as instants, so an offset that sorts last as a string still comes first
+	require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "zoned", "repo1", "Rebased", "Dave", "", "2026-01-01T00:30:00+01:00"))
+
+	gitCommits, err := gitContract.GetUnpushedCommits(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"zoned", "authored", "committed", "recorded"}, commitHashes(gitCommits))
+}
+
+func TestCommitSortTieBreak(t *testing.T) {
+	commitOrders := func(hashes []string) ([]string, []string) {
+		transactionContext, chaincodeStub, _ := prepWorldState()
+		gitContract := chaincode.SmartContract{}
+		// All commits share one recorded time, as if created in the same block
+		setTxTime(chaincodeStub, time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+		for _, hash := range hashes {
+			require.NoError(t, gitContract.CreateGitCommit(transactionContext, hash, "repo1", "Commit "+hash, "Alice"))
+		}
+		// Commits of several repositories imported with the same author date
+		require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "dated2", "repo2", "JIRA-9: port fix", "Bob", "2025-12-31T00:00:00Z", ""))
+		require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "dated1", "repo3", "JIRA-9: fix crash", "Bob", "2025-12-31T00:00:00Z", ""))
+
+		repositoryCommits, err := gitContract.QueryCommitsByRepository(transactionContext, "repo1")
+		require.NoError(t, err)
+		importedCommits, err := gitContract.GetCommitsByIssue(transactionContext, "JIRA-9")
+		require.NoError(t, err)
+		return commitHashes(repositoryCommits), commitHashes(importedCommits)
+	}
+
+	repositoryOrder, importedOrder := commitOrders([]string{"hash3", "hash1", "hash4", "hash2"})
+	require.Equal(t, []string{"hash1", "hash2", "hash3", "hash4"}, repositoryOrder)
+	require.Equal(t, []string{"dated1", "dated2"}, importedOrder)
+
+	// Creating the same commits in another order gives the same results
+	repositoryOrder, importedOrder = commitOrders([]string{"hash2", "hash4", "hash1", "hash3"})
+	require.Equal(t, []string{"hash1", "hash2", "hash3", "hash4"}, repositoryOrder)
+	require.Equal(t, []string{"dated1", "dated2"}, importedOrder)
+}
+
+func TestEnforceChronologicalOrder(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	setTxTime(chaincodeStub, start.Add(24*time.Hour))
+
+	// Out of order imports are accepted by default
+	require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "hash1", "repo1", "Initial commit", "Alice", start.Format(time.RFC3339), ""))
+	require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "old", "repo1", "Back-dated", "Mallory", start.Add(-48*time.Hour).Format(time.RFC3339), ""))
+
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"EnforceChronologicalOrder": true, "ChronologicalToleranceSeconds": 300}`))
+
+	// In order
+	require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "hash2", "repo1", "Added feature", "Bob", start.Add(time.Hour).Format(time.RFC3339), ""))
+
+	// Slightly out of order, within the tolerance
+	require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "hash3", "repo1", "Fixed bug", "Carol", start.Add(57*time.Minute).Format(time.RFC3339), ""))
+
+	// Badly out of order
+	err := gitContract.CreateGitCommitWithDates(transactionContext, "hash4", "repo1", "Back-dated", "Mallory", start.Format(time.RFC3339), "")
+	require.EqualError(t, err, "the commit hash4 was authored at 2026-01-01T12:00:00Z, 1h0m0s before commit hash2 of repository repo1 at 2026-01-01T13:00:00Z, more than the allowed 5m0s")
+	exists, err := gitContract.GitCommitExists(transactionContext, "hash4")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// Other repositories and commits without an author date are not affected
+	require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "hash5", "repo2", "Initial commit", "Dave", start.Format(time.RFC3339), ""))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash6", "repo1", "Recorded now", "Erin"))
+
+	err = gitContract.SetContractConfig(transactionContext, `{"ChronologicalToleranceSeconds": -1}`)
+	require.EqualError(t, err, "ChronologicalToleranceSeconds must not be negative")
+}
@@ -0,0 +1,352 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Kinds of records that can reference a commit
+const (
+	ReferenceParent = "parent"
+	ReferencePush   = "push"
+	ReferenceTag    = "tag"
+	ReferenceTrain  = "releaseTrain"
+)
+
+// CommitReference describes a record that refers to a commit
+type CommitReference struct {
+	Kind       string `json:"Kind"`
+	Repository string `json:"Repository"`
+	// Reference identifies the referring record: the hash of a child commit, the world state key of a push, a
+	// tag name or a release train name
+	Reference string `json:"Reference"`
+}
+
+func (r *CommitReference) String() string {
+	if r.Kind == ReferencePush {
+		return fmt.Sprintf("push %s", r.Reference)
+	}
+	if r.Kind == ReferenceTag {
+		return fmt.Sprintf("tag %s", r.Reference)
+	}
+	if r.Kind == ReferenceTrain {
+		return fmt.Sprintf("release train %s", r.Reference)
+	}
+	return fmt.Sprintf("%s of commit %s", r.Kind, r.Reference)
+}
+
+// GetCommitReferences returns the records of the commit's repository that refer to it: commits listing it
+// as a parent, pushes of it, tags of it and release trains it belongs to.
+func (s *SmartContract) GetCommitReferences(ctx contractapi.TransactionContextInterface, commitHash string) ([]*CommitReference, error) {
+	gitCommit, err := s.ReadGitCommit(ctx, commitHash)
+	if err != nil {
+		return nil, err
+	}
+	return s.getCommitReferences(ctx, gitCommit)
+}
+
+func (s *SmartContract) getCommitReferences(ctx contractapi.TransactionContextInterface, gitCommit *GitCommit) ([]*CommitReference, error) {
+	references := []*CommitReference{}
+
+	children, err := s.getGitCommits(ctx, func(child *GitCommit) bool {
+		if child.Repository != gitCommit.Repository {
+			return false
+		}
+		for _, parentHash := range child.ParentHashes {
+			if parentHash == gitCommit.CommitHash {
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].CommitHash < children[j].CommitHash
+	})
+	for _, child := range children {
+		references = append(references, &CommitReference{Kind: ReferenceParent, Repository: child.Repository, Reference: child.CommitHash})
+	}
+
+	pushKeys, err := s.commitPushKeys(ctx, gitCommit)
+	if err != nil {
+		return nil, err
+	}
+	for _, pushKey := range pushKeys {
+		references = append(references, &CommitReference{Kind: ReferencePush, Repository: gitCommit.Repository, Reference: pushKey})
+	}
+
+	tags, err := s.GetTags(ctx, gitCommit.Repository)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		if tag.CommitHash == gitCommit.CommitHash {
+			references = append(references, &CommitReference{Kind: ReferenceTag, Repository: tag.Repository, Reference: tag.Name})
+		}
+	}
+
+	trainNames, err := s.getCommitTrains(ctx, gitCommit)
+	if err != nil {
+		return nil, err
+	}
+	for _, trainName := range trainNames {
+		references = append(references, &CommitReference{Kind: ReferenceTrain, Repository: gitCommit.Repository, Reference: trainName})
+	}
+
+	return references, nil
+}
+
+// Deletion modes of DeleteGitCommit
+const (
+	DeletePurge     = "purge"
+	DeleteTombstone = "tombstone"
+)
+
+// DeleteGitCommit removes a commit that no other record refers to, which needs write access to its repository.
+// Referenced commits are listed in the error and can only be removed by the admin organization with
+// ForceDeleteGitCommit. With cascade, the push transactions of the commit are removed with it, for a commit that
+// was pushed by mistake; any other record referring to the commit still blocks the deletion. The repository
+// version is left as it is, so the removed pushes show up as version gaps.
+//
+// The purge mode leaves nothing behind. The tombstone mode keeps a tombstone under the hash, recording who
+// deleted the commit, when and for what reason, so that reads report the deletion and references to the hash
+// stay traceable. Since the tombstone keeps them traceable, the admin organization may tombstone a commit that
+// is still referenced.
+func (s *SmartContract) DeleteGitCommit(ctx contractapi.TransactionContextInterface, commitHash string, cascade bool, mode string, reason string) error {
+	if mode != DeletePurge && mode != DeleteTombstone {
+		return fmt.Errorf("invalid deletion mode %q, must be %s or %s", mode, DeletePurge, DeleteTombstone)
+	}
+	if mode == DeletePurge && reason != "" {
+		return fmt.Errorf("a deletion reason is only recorded by the %s mode", DeleteTombstone)
+	}
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return err
+	}
+	reason, fieldError := sanitizeText("Reason", "deletion reason", reason, true, config.StripControlCharacters)
+	if fieldError != nil {
+		validation := newValidationError(config)
+		validation.add(fieldError)
+		return validation
+	}
+
+	gitCommit, err := s.ReadGitCommit(ctx, commitHash)
+	if err != nil {
+		return err
+	}
+	err = s.checkRepositoryWriteAccess(ctx, gitCommit.Repository)
+	if err != nil {
+		return err
+	}
+	err = s.checkRepositoryNotArchived(ctx, gitCommit.Repository)
+	if err != nil {
+		return err
+	}
+
+	// Pushes of the commit do not block a cascading delete, as they go with it
+	var ignoredKinds []string
+	if cascade {
+		ignoredKinds = append(ignoredKinds, ReferencePush)
+	}
+	err = s.checkCommitUnreferenced(ctx, gitCommit, ignoredKinds...)
+	if err != nil && (mode != DeleteTombstone || s.requireAdmin(ctx) != nil) {
+		return err
+	}
+
+	if cascade {
+		pushKeys, err := s.commitPushKeys(ctx, gitCommit)
+		if err != nil {
+			return err
+		}
+		for _, pushKey := range pushKeys {
+			err = ctx.GetStub().DelState(pushKey)
+			if err != nil {
+				return fmt.Errorf("failed to delete state: %v", err)
+			}
+		}
+	}
+	err = s.removeGitCommit(ctx, gitCommit)
+	if err != nil {
+		return err
+	}
+	if mode == DeleteTombstone {
+		return s.putTombstone(ctx, config, gitCommit, reason)
+	}
+	return nil
+}
+
+// commitPushKeys returns the keys of the push transactions of a commit. The keys are taken from the range
+// rather than rebuilt from the records, as pushes recorded before they carried a transaction ID lack it.
+func (s *SmartContract) commitPushKeys(ctx contractapi.TransactionContextInterface, gitCommit *GitCommit) ([]string, error) {
+	prefix := fmt.Sprintf("%s%s_", pushKeyPrefix, gitCommit.Repository)
+	resultsIterator, err := ctx.GetStub().GetStateByRange(prefix, prefix+"~")
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var keys []string
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var pushTx PushTransaction
+		err = json.Unmarshal(queryResponse.Value, &pushTx)
+		if err != nil {
+			return nil, err
+		}
+		if pushTx.Repository == gitCommit.Repository && pushTx.CommitHash == gitCommit.CommitHash {
+			keys = append(keys, queryResponse.Key)
+		}
+	}
+	return keys, nil
+}
+
+// checkCommitUnreferenced returns an error listing the records that still refer to a commit, if any, leaving
+// out references of the ignored kinds.
+func (s *SmartContract) checkCommitUnreferenced(ctx contractapi.TransactionContextInterface, gitCommit *GitCommit, ignoredKinds ...string) error {
+	references, err := s.getCommitReferences(ctx, gitCommit)
+	if err != nil {
+		return err
+	}
+	var described []string
+	for _, reference := range references {
+		ignored := false
+		for _, kind := range ignoredKinds {
+			ignored = ignored || reference.Kind == kind
+		}
+		if !ignored {
+			described = append(described, reference.String())
+		}
+	}
+	if len(described) > 0 {
+		return fmt.Errorf("the commit %s is still referenced by %s", gitCommit.CommitHash, strings.Join(described, ", "))
+	}
+	return nil
+}
+
+// ForceDeleteGitCommit removes a commit even if other records still refer to it.
+// Only the admin organization may force a deletion.
+func (s *SmartContract) ForceDeleteGitCommit(ctx contractapi.TransactionContextInterface, commitHash string) error {
+	err := s.requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	gitCommit, err := s.ReadGitCommit(ctx, commitHash)
+	if err != nil {
+		return err
+	}
+
+	return s.removeGitCommit(ctx, gitCommit)
+}
+
+// removeGitCommit deletes a commit together with its index entries.
+func (s *SmartContract) removeGitCommit(ctx contractapi.TransactionContextInterface, gitCommit *GitCommit) error {
+	return s.removeGitCommits(ctx, []*GitCommit{gitCommit})
+}
+
+// removeGitCommits deletes commits together with their index entries and releases their message blobs, each
+// blob once for all of its commits.
+func (s *SmartContract) removeGitCommits(ctx contractapi.TransactionContextInterface, gitCommits []*GitCommit) error {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	blobReferences := make(map[string]int)
+	for _, gitCommit := range gitCommits {
+		commitKey, err := s.gitCommitKey(ctx, config, gitCommit.Repository, gitCommit.CommitHash)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, commitKey)
+		indexKeys, err := s.commitIndexKeys(ctx, config, gitCommit)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, indexKeys...)
+		trainKeys, err := s.trainMembershipKeys(ctx, gitCommit)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, trainKeys...)
+		if gitCommit.MessageBlob != "" {
+			blobReferences[gitCommit.MessageBlob]++
+		}
+	}
+
+	for _, key := range keys {
+		err = ctx.GetStub().DelState(key)
+		if err != nil {
+			return fmt.Errorf("failed to delete state: %v", err)
+		}
+	}
+
+	blobHashes := make([]string, 0, len(blobReferences))
+	for blobHash := range blobReferences {
+		blobHashes = append(blobHashes, blobHash)
+	}
+	sort.Strings(blobHashes)
+	for _, blobHash := range blobHashes {
+		err = s.releaseBlob(ctx, blobHash, blobReferences[blobHash])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// commitIndexKeys returns the keys of the index entries of a commit: its repository entry when commits are
+// repository scoped, and its trailer, issue, commit type, message and security level entries.
+func (s *SmartContract) commitIndexKeys(ctx contractapi.TransactionContextInterface, config *ContractConfig, gitCommit *GitCommit) ([]string, error) {
+	var keys []string
+	if config.RepositoryScopedCommits {
+		indexKey, err := ctx.GetStub().CreateCompositeKey(commitRepositoryIndexName, []string{gitCommit.CommitHash, gitCommit.Repository})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create composite key: %v", err)
+		}
+		keys = append(keys, indexKey)
+	}
+	for _, legacy := range []bool{false, true} {
+		trailerKeys, err := s.trailerIndexKeys(ctx, gitCommit, legacy)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, trailerKeys...)
+	}
+	issueKeys, err := s.issueIndexKeys(ctx, gitCommit)
+	if err != nil {
+		return nil, err
+	}
+	keys = append(keys, issueKeys...)
+	typeKey, err := s.commitTypeIndexKey(ctx, gitCommit)
+	if err != nil {
+		return nil, err
+	}
+	if typeKey != "" {
+		keys = append(keys, typeKey)
+	}
+	messageKey, err := s.commitMessageIndexKey(ctx, gitCommit)
+	if err != nil {
+		return nil, err
+	}
+	keys = append(keys, messageKey)
+	if gitCommit.SecurityLevel != "" {
+		securityKey, err := s.securityLevelIndexKey(ctx, gitCommit)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, securityKey)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,244 @@
+package chaincode_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteGitCommit(t *testing.T) {
+	transactionContext, _, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Fix crash\n\nFixes: #123", "Alice"))
+
+	references, err := gitContract.GetCommitReferences(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Empty(t, references)
+
+	require.NoError(t, gitContract.DeleteGitCommit(transactionContext, "hash1", false, chaincode.DeletePurge, ""))
+	exists, err := gitContract.GitCommitExists(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.False(t, exists)
+	require.Len(t, world.state, 1, "only the repository version record should remain")
+
+	gitCommits, err := gitContract.QueryCommitsByTrailer(transactionContext, "Fixes", "#123")
+	require.NoError(t, err)
+	require.Empty(t, gitCommits)
+
+	err = gitContract.DeleteGitCommit(transactionContext, "hash1", false, chaincode.DeletePurge, "")
+	require.EqualError(t, err, "the commit hash1 does not exist")
+}
+
+func TestDeleteGitCommitDeniedWriter(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.GrantRepositoryAccess(transactionContext, "repo1", adminMSPID))
+
+	setClientMSPID(transactionContext, "Org2MSP")
+	err := gitContract.DeleteGitCommit(transactionContext, "hash1", false, chaincode.DeletePurge, "")
+	require.EqualError(t, err, "client from org Org2MSP is not allowed to write to repository repo1")
+	exists, err := gitContract.GitCommitExists(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	setClientMSPID(transactionContext, adminMSPID)
+	require.NoError(t, gitContract.DeleteGitCommit(transactionContext, "hash1", false, chaincode.DeletePurge, ""))
+}
+
+func TestDeleteGitCommitBlockedByMergeParent(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+	require.NoError(t, gitContract.CreateMergeCommit(transactionContext, "hash3", "repo1", "hash1", "hash2", "Merge feature", "Carol"))
+
+	references, err := gitContract.GetCommitReferences(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, []*chaincode.CommitReference{{Kind: chaincode.ReferenceParent, Repository: "repo1", Reference: "hash3"}}, references)
+
+	err = gitContract.DeleteGitCommit(transactionContext, "hash1", false, chaincode.DeletePurge, "")
+	require.EqualError(t, err, "the commit hash1 is still referenced by parent of commit hash3")
+
+	// The merge commit itself is not referenced and can go, which frees its parents
+	require.NoError(t, gitContract.DeleteGitCommit(transactionContext, "hash3", false, chaincode.DeletePurge, ""))
+	require.NoError(t, gitContract.DeleteGitCommit(transactionContext, "hash1", false, chaincode.DeletePurge, ""))
+}
+
+func TestDeleteGitCommitBlockedByPush(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	for i := 0; i < 2; i++ {
+		chaincodeStub.GetTxIDReturns(fmt.Sprintf("tx%d", i))
+		_, err := gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+		require.NoError(t, err)
+	}
+
+	err := gitContract.DeleteGitCommit(transactionContext, "hash1", false, chaincode.DeletePurge, "")
+	require.EqualError(t, err, "the commit hash1 is still referenced by push PUSH_repo1_0000000002_tx0, push PUSH_repo1_0000000003_tx1")
+	references, err := gitContract.GetCommitReferences(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, []*chaincode.CommitReference{
+		{Kind: chaincode.ReferencePush, Repository: "repo1", Reference: "PUSH_repo1_0000000002_tx0"},
+		{Kind: chaincode.ReferencePush, Repository: "repo1", Reference: "PUSH_repo1_0000000003_tx1"},
+	}, references)
+
+	setClientMSPID(transactionContext, "Org2MSP")
+	err = gitContract.ForceDeleteGitCommit(transactionContext, "hash1")
+	require.EqualError(t, err, "client from org Org2MSP is not authorized to perform this operation")
+
+	setClientMSPID(transactionContext, adminMSPID)
+	require.NoError(t, gitContract.ForceDeleteGitCommit(transactionContext, "hash1"))
+	exists, err := gitContract.GitCommitExists(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestDeleteGitCommitCascade(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added secrets", "Bob"))
+	for i, commitHash := range []string{"hash1", "hash2", "hash2"} {
+		chaincodeStub.GetTxIDReturns(fmt.Sprintf("tx%d", i))
+		_, err := gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", commitHash)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, gitContract.DeleteGitCommit(transactionContext, "hash2", true, chaincode.DeletePurge, ""))
+	exists, err := gitContract.GitCommitExists(transactionContext, "hash2")
+	require.NoError(t, err)
+	require.False(t, exists)
+	pushTransactions, err := gitContract.GetPushTransactionsByRepository(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Len(t, pushTransactions, 1)
+	require.Equal(t, "hash1", pushTransactions[0].CommitHash)
+
+	// Other references still block the deletion
+	require.NoError(t, gitContract.CreateTag(transactionContext, "repo1", "v1.0", "hash1"))
+	err = gitContract.DeleteGitCommit(transactionContext, "hash1", true, chaincode.DeletePurge, "")
+	require.EqualError(t, err, "the commit hash1 is still referenced by tag v1.0")
+	pushTransactions, err = gitContract.GetPushTransactionsByRepository(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Len(t, pushTransactions, 1)
+
+	require.NoError(t, gitContract.GrantRepositoryAccess(transactionContext, "repo1", adminMSPID))
+	setClientMSPID(transactionContext, "Org2MSP")
+	err = gitContract.DeleteGitCommit(transactionContext, "hash1", true, chaincode.DeletePurge, "")
+	require.EqualError(t, err, "client from org Org2MSP is not allowed to write to repository repo1")
+}
+
+func TestDeleteGitCommitWithTombstone(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	setTxTime(chaincodeStub, time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Add credentials", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Fix typo", "Bob"))
+	require.NoError(t, gitContract.DeleteGitCommit(transactionContext, "hash1", false, chaincode.DeleteTombstone, "leaked a secret"))
+
+	expected := &chaincode.CommitTombstone{CommitHash: "hash1", Repository: "repo1", Reason: "leaked a secret", DeletedBy: adminMSPID, DeletedAt: "2026-01-01T12:00:00Z", IsDeleted: true}
+	_, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.EqualError(t, err, "the commit hash1 was deleted from repository repo1 by Org1MSP at 2026-01-01T12:00:00Z: leaked a secret")
+	var deleted *chaincode.CommitDeletedError
+	require.True(t, errors.As(err, &deleted))
+	require.Equal(t, expected, deleted.Tombstone)
+
+	_, err = gitContract.ReadGitCommitInRepository(transactionContext, "repo1", "hash1")
+	require.True(t, errors.As(err, &deleted))
+	_, err = gitContract.ReadGitCommitInRepository(transactionContext, "repo2", "hash1")
+	require.EqualError(t, err, "the commit hash1 does not exist in repository repo2")
+
+	exists, err := gitContract.GitCommitExists(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	tombstones, err := gitContract.GetTombstones(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []*chaincode.CommitTombstone{expected}, tombstones)
+	tombstones, err = gitContract.GetTombstones(transactionContext, "repo2")
+	require.NoError(t, err)
+	require.Empty(t, tombstones)
+
+	// A full purge leaves nothing behind
+	require.NoError(t, gitContract.DeleteGitCommit(transactionContext, "hash2", false, chaincode.DeletePurge, ""))
+	_, err = gitContract.ReadGitCommit(transactionContext, "hash2")
+	require.EqualError(t, err, "the commit hash2 does not exist")
+}
+
+func TestDeleteReferencedGitCommitWithTombstone(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	chaincodeStub.GetTxIDReturns("tx1")
+	_, err := gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.NoError(t, err)
+
+	setClientMSPID(transactionContext, "Org2MSP")
+	err = gitContract.DeleteGitCommit(transactionContext, "hash1", false, chaincode.DeleteTombstone, "")
+	require.EqualError(t, err, "the commit hash1 is still referenced by push PUSH_repo1_0000000002_tx1")
+
+	setClientMSPID(transactionContext, adminMSPID)
+	require.NoError(t, gitContract.DeleteGitCommit(transactionContext, "hash1", false, chaincode.DeleteTombstone, ""))
+	_, err = gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.ErrorContains(t, err, "the commit hash1 was deleted from repository repo1 by Org1MSP at ")
+}
+
+func TestDeleteScopedGitCommitWithTombstone(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	_, err := gitContract.MigrateToRepositoryScopedCommits(transactionContext)
+	require.NoError(t, err)
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.DeleteGitCommit(transactionContext, "hash1", false, chaincode.DeleteTombstone, "wrong repository"))
+	_, err = gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.ErrorContains(t, err, "the commit hash1 was deleted from repository repo1")
+
+	// The hash may be recorded again in another repository, while repo1 keeps reporting the deletion
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo2", "Initial commit", "Alice"))
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, "repo2", gitCommit.Repository)
+	_, err = gitContract.ReadGitCommitInRepository(transactionContext, "repo1", "hash1")
+	require.ErrorContains(t, err, "the commit hash1 was deleted from repository repo1")
+
+	tombstones, err := gitContract.GetTombstones(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Len(t, tombstones, 1)
+	require.Equal(t, "wrong repository", tombstones[0].Reason)
+}
+
+func TestDeleteGitCommitModes(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	err := gitContract.DeleteGitCommit(transactionContext, "hash1", false, "archive", "")
+	require.EqualError(t, err, `invalid deletion mode "archive", must be purge or tombstone`)
+	err = gitContract.DeleteGitCommit(transactionContext, "hash1", false, chaincode.DeletePurge, "leaked a secret")
+	require.EqualError(t, err, "a deletion reason is only recorded by the tombstone mode")
+
+	// Tombstoning needs write access like purging
+	require.NoError(t, gitContract.GrantRepositoryAccess(transactionContext, "repo1", adminMSPID))
+	setClientMSPID(transactionContext, "Org2MSP")
+	err = gitContract.DeleteGitCommit(transactionContext, "hash1", false, chaincode.DeleteTombstone, "leaked a secret")
+	require.EqualError(t, err, "client from org Org2MSP is not allowed to write to repository repo1")
+	exists, err := gitContract.GitCommitExists(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.True(t, exists)
+	tombstones, err := gitContract.GetTombstones(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Empty(t, tombstones)
+}
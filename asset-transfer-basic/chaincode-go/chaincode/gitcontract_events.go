@@ -0,0 +1,30 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Chaincode events, which let clients follow new records without polling. Fabric keeps one event per
+// transaction, and every transaction creates at most one commit or push.
+const (
+	// CommitCreatedEvent carries the GitCommit created by a transaction, with its full message
+	CommitCreatedEvent = "CommitCreated"
+	// GitPushedEvent carries the PushTransaction recorded by a transaction
+	GitPushedEvent = "GitPushed"
+)
+
+// setEvent sets the chaincode event of the transaction to a record encoded as JSON.
+func setEvent(ctx contractapi.TransactionContextInterface, name string, record interface{}) error {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().SetEvent(name, payload)
+	if err != nil {
+		return fmt.Errorf("failed to set event %s: %v", name, err)
+	}
+	return nil
+}
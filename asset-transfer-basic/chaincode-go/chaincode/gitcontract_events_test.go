@@ -0,0 +1,42 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitAndPushEvents(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"MessageBlobMinSize": 1}`))
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.Equal(t, 1, chaincodeStub.SetEventCallCount())
+	name, payload := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, chaincode.CommitCreatedEvent, name)
+	var gitCommit chaincode.GitCommit
+	require.NoError(t, json.Unmarshal(payload, &gitCommit))
+	require.Equal(t, "hash1", gitCommit.CommitHash)
+	require.Equal(t, "repo1", gitCommit.Repository)
+	// The message is in the event even when it is stored as a blob
+	require.Equal(t, "Initial commit", gitCommit.CommitMessage)
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	_, err := gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.NoError(t, err)
+	require.Equal(t, 2, chaincodeStub.SetEventCallCount())
+	name, payload = chaincodeStub.SetEventArgsForCall(1)
+	require.Equal(t, chaincode.GitPushedEvent, name)
+	var pushTx chaincode.PushTransaction
+	require.NoError(t, json.Unmarshal(payload, &pushTx))
+	require.Equal(t, "hash1", pushTx.CommitHash)
+	require.Equal(t, 2, pushTx.Version)
+	require.Equal(t, "tx1", pushTx.TxID)
+
+	// A rejected commit sets no event
+	require.Error(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.Equal(t, 2, chaincodeStub.SetEventCallCount())
+}
@@ -0,0 +1,100 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// CommitHistoryEntry is one change of a commit record: the transaction that made it, when, and the commit as
+// it was written. Deletions have no commit.
+type CommitHistoryEntry struct {
+	TxID      string     `json:"TxID"`
+	Timestamp string     `json:"Timestamp"`
+	IsDelete  bool       `json:"IsDelete"`
+	GitCommit *GitCommit `json:"GitCommit,omitempty"`
+}
+
+// GetCommitHistory returns every change of a commit record in chronological order, from the ledger history.
+// Once commits are repository scoped, the history of the record under the plain hash key from before the
+// migration is included; the history of a scoped commit that was deleted cannot be found, as the hash no
+// longer names a repository. Message blobs are not resolved, since a blob may have been released since.
+func (s *SmartContract) GetCommitHistory(ctx contractapi.TransactionContextInterface, commitHash string) ([]*CommitHistoryEntry, error) {
+	commitHash = strings.TrimSpace(commitHash)
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{commitHash}
+	if config.RepositoryScopedCommits {
+		repositories, err := s.commitRepositories(ctx, commitHash)
+		if err != nil {
+			return nil, err
+		}
+		if len(repositories) > 1 {
+			return nil, ambiguousCommitError(commitHash, repositories)
+		}
+		if len(repositories) == 1 {
+			commitKey, err := s.gitCommitKey(ctx, config, repositories[0], commitHash)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, commitKey)
+		}
+	}
+
+	history := []*CommitHistoryEntry{}
+	for _, key := range keys {
+		entries, err := s.getKeyHistory(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, entries...)
+	}
+	if len(history) == 0 {
+		return nil, fmt.Errorf("the commit %s does not exist", commitHash)
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		timeI, _ := time.Parse(time.RFC3339Nano, history[i].Timestamp)
+		timeJ, _ := time.Parse(time.RFC3339Nano, history[j].Timestamp)
+		return timeI.Before(timeJ)
+	})
+	return history, nil
+}
+
+// getKeyHistory returns the changes of a commit record stored under key.
+func (s *SmartContract) getKeyHistory(ctx contractapi.TransactionContextInterface, key string) ([]*CommitHistoryEntry, error) {
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var entries []*CommitHistoryEntry
+	for resultsIterator.HasNext() {
+		modification, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		entry := &CommitHistoryEntry{TxID: modification.TxId, IsDelete: modification.IsDelete}
+		if modification.Timestamp != nil {
+			entry.Timestamp = modification.Timestamp.AsTime().UTC().Format(time.RFC3339Nano)
+		}
+		if !modification.IsDelete {
+			var gitCommit GitCommit
+			err = json.Unmarshal(modification.Value, &gitCommit)
+			if err != nil {
+				return nil, err
+			}
+			entry.GitCommit = &gitCommit
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,66 @@
+package chaincode_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCommitHistory(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	setTxTime(chaincodeStub, start)
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Intial commit", "Alice"))
+	chaincodeStub.GetTxIDReturns("tx2")
+	setTxTime(chaincodeStub, start.Add(time.Hour))
+	require.NoError(t, gitContract.UpdateGitCommit(transactionContext, "hash1", "Initial commit", "Alice"))
+	chaincodeStub.GetTxIDReturns("tx3")
+	setTxTime(chaincodeStub, start.Add(2*time.Hour))
+	require.NoError(t, gitContract.DeleteGitCommit(transactionContext, "hash1", false, chaincode.DeletePurge, ""))
+
+	// The history outlives the commit and comes back oldest first
+	history, err := gitContract.GetCommitHistory(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	require.Equal(t, "tx1", history[0].TxID)
+	require.Equal(t, "2026-01-01T12:00:00Z", history[0].Timestamp)
+	require.Equal(t, "Intial commit", history[0].GitCommit.CommitMessage)
+	require.Equal(t, "tx2", history[1].TxID)
+	require.Equal(t, "Initial commit", history[1].GitCommit.CommitMessage)
+	require.Equal(t, &chaincode.CommitHistoryEntry{TxID: "tx3", Timestamp: "2026-01-01T14:00:00Z", IsDelete: true}, history[2])
+
+	_, err = gitContract.GetCommitHistory(transactionContext, "hash2")
+	require.EqualError(t, err, "the commit hash2 does not exist")
+}
+
+func TestGetCommitHistoryAcrossScopedMigration(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	setTxTime(chaincodeStub, start)
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	chaincodeStub.GetTxIDReturns("tx2")
+	setTxTime(chaincodeStub, start.Add(time.Hour))
+	_, err := gitContract.MigrateToRepositoryScopedCommits(transactionContext)
+	require.NoError(t, err)
+
+	history, err := gitContract.GetCommitHistory(transactionContext, "hash1")
+	require.NoError(t, err)
+	var changes []string
+	for _, entry := range history {
+		change := entry.TxID + " write"
+		if entry.IsDelete {
+			change = entry.TxID + " delete"
+		}
+		changes = append(changes, change)
+	}
+	// The migration deletes the plain key and writes the scoped one in the same transaction
+	require.Equal(t, []string{"tx1 write", "tx2 delete", "tx2 write"}, changes)
+}
@@ -0,0 +1,103 @@
+package chaincode
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// defaultRecommendedCommitFields are the fields GetIncompleteCommits checks when the RecommendedCommitFields
+// setting is empty.
+var defaultRecommendedCommitFields = []string{"author", "commitMessage", "authorTimestamp", "filesChanged"}
+
+// IncompleteCommit is a commit reported by GetIncompleteCommits together with the recommended fields it lacks
+type IncompleteCommit struct {
+	Commit        *GitCommit `json:"Commit"`
+	MissingFields []string   `json:"MissingFields"`
+}
+
+// recommendedCommitFields returns the JSON names of the fields the contract settings recommend, in sorted order.
+func recommendedCommitFields(config *ContractConfig) ([]string, error) {
+	fields := config.RecommendedCommitFields
+	if len(fields) == 0 {
+		fields = defaultRecommendedCommitFields
+	}
+	projection, err := parseProjection(strings.Join(fields, ","), true)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(projection))
+	for name := range projection {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// missingCommitFields returns the given fields that are empty in a commit. Zero counts and empty lists count as
+// missing, since the contract cannot tell them apart from fields that were never imported.
+func missingCommitFields(gitCommit *GitCommit, fields []string) []string {
+	wanted := make(map[string]bool)
+	for _, field := range fields {
+		wanted[field] = true
+	}
+
+	missing := []string{}
+	commitValue := reflect.ValueOf(gitCommit).Elem()
+	commitType := commitValue.Type()
+	for i := 0; i < commitType.NumField(); i++ {
+		name := strings.Split(commitType.Field(i).Tag.Get("json"), ",")[0]
+		if !wanted[name] {
+			continue
+		}
+		value := commitValue.Field(i)
+		empty := value.IsZero()
+		if value.Kind() == reflect.Slice || value.Kind() == reflect.Map {
+			empty = value.Len() == 0
+		}
+		if empty {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// GetIncompleteCommits returns the commits of a repository, sorted by commit time, that lack any of the fields
+// named by the RecommendedCommitFields setting, so that poorly imported records can be found and backfilled.
+// Each commit lists the fields it lacks.
+func (s *SmartContract) GetIncompleteCommits(ctx contractapi.TransactionContextInterface, repository string) ([]*IncompleteCommit, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	if repository == "" {
+		return nil, fmt.Errorf("the repository name must not be empty")
+	}
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := recommendedCommitFields(config)
+	if err != nil {
+		return nil, err
+	}
+
+	gitCommits, err := s.listRepositoryCommits(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	incomplete := []*IncompleteCommit{}
+	for _, gitCommit := range gitCommits {
+		missing := missingCommitFields(gitCommit, fields)
+		if len(missing) > 0 {
+			incomplete = append(incomplete, &IncompleteCommit{Commit: gitCommit, MissingFields: missing})
+		}
+	}
+
+	return incomplete, nil
+}
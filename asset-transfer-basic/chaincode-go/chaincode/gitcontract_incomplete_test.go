@@ -0,0 +1,62 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+// incompleteFields maps the hashes of incomplete commits to the fields they lack.
+func incompleteFields(incomplete []*chaincode.IncompleteCommit) map[string][]string {
+	fields := make(map[string][]string)
+	for _, commit := range incomplete {
+		fields[commit.Commit.CommitHash] = commit.MissingFields
+	}
+	return fields
+}
+
+func TestGetIncompleteCommits(t *testing.T) {
+	transactionContext, chaincodeStub, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	setTxTime(chaincodeStub, start)
+
+	require.NoError(t, gitContract.CreateGitCommitWithStats(transactionContext, "stats", "repo1", "Added feature", "Alice", 10, 2, 3))
+	require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "dated", "repo1", "Fixed bug", "Bob", start.Format(time.RFC3339), ""))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "other", "repo2", "Initial commit", "Carol"))
+
+	// An import that left out the author and message
+	importedJSON, err := json.Marshal(map[string]interface{}{
+		"commitHash":    "imported",
+		"repository":    "repo1",
+		"versionNumber": 1,
+		"timestamp":     start.Format(time.RFC3339),
+	})
+	require.NoError(t, err)
+	world.state["imported"] = importedJSON
+
+	incomplete, err := gitContract.GetIncompleteCommits(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		"stats":    {"authorTimestamp"},
+		"dated":    {"filesChanged"},
+		"imported": {"author", "authorTimestamp", "commitMessage", "filesChanged"},
+	}, incompleteFields(incomplete))
+
+	// The recommended fields are configurable, and names match case-insensitively
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"RecommendedCommitFields": ["Author", "commitMessage"]}`))
+	incomplete, err = gitContract.GetIncompleteCommits(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		"imported": {"author", "commitMessage"},
+	}, incompleteFields(incomplete))
+
+	err = gitContract.SetContractConfig(transactionContext, `{"RecommendedCommitFields": ["author", "files"]}`)
+	require.EqualError(t, err, `invalid RecommendedCommitFields: unknown commit field "files"`)
+
+	_, err = gitContract.GetIncompleteCommits(transactionContext, "")
+	require.EqualError(t, err, "the repository name must not be empty")
+}
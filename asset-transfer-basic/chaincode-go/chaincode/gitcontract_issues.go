@@ -0,0 +1,150 @@
+package chaincode
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const issueIndexName = "issue~ref~hash~repository"
+
+// issueRefPattern matches tracker keys in the style of JIRA-123 or GH-45 in a commit message
+var issueRefPattern = regexp.MustCompile(`\b[A-Z][A-Z0-9]*-[0-9]+\b`)
+
+// parseIssueRefs returns the issue references found in a commit message, in message order and without duplicates.
+func parseIssueRefs(commitMessage string) []string {
+	return appendIssueRefs(nil, issueRefPattern.FindAllString(commitMessage, -1))
+}
+
+// appendIssueRefs appends the references not yet in issueRefs.
+func appendIssueRefs(issueRefs []string, refs []string) []string {
+	for _, ref := range refs {
+		duplicate := false
+		for _, existing := range issueRefs {
+			if existing == ref {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			issueRefs = append(issueRefs, ref)
+		}
+	}
+	return issueRefs
+}
+
+// validIssueRef checks an explicitly given issue reference. Trackers differ too much for a stricter format,
+// so any reference without white space is accepted.
+func validIssueRef(ref string) *FieldError {
+	if ref == "" {
+		return &FieldError{Field: "IssueRefs", Rule: "issueRef", Message: "an issue reference must not be empty", Value: ref}
+	}
+	if strings.IndexFunc(ref, unicode.IsSpace) >= 0 {
+		return &FieldError{Field: "IssueRefs", Rule: "issueRef", Message: fmt.Sprintf("the issue reference %q must not contain white space", ref), Value: ref}
+	}
+	return nil
+}
+
+// issueIndexKeys returns the issue index keys of a commit.
+func (s *SmartContract) issueIndexKeys(ctx contractapi.TransactionContextInterface, gitCommit *GitCommit) ([]string, error) {
+	var indexKeys []string
+	for _, ref := range gitCommit.IssueRefs {
+		indexKey, err := ctx.GetStub().CreateCompositeKey(issueIndexName, []string{ref, gitCommit.CommitHash, gitCommit.Repository})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create composite key: %v", err)
+		}
+		indexKeys = append(indexKeys, indexKey)
+	}
+	return indexKeys, nil
+}
+
+// indexIssueRefs adds an issue index entry for every issue reference of a commit.
+func (s *SmartContract) indexIssueRefs(ctx contractapi.TransactionContextInterface, gitCommit *GitCommit) error {
+	indexKeys, err := s.issueIndexKeys(ctx, gitCommit)
+	if err != nil {
+		return err
+	}
+
+	for _, indexKey := range indexKeys {
+		// Composite key values cannot be empty, so store a single null byte
+		err = ctx.GetStub().PutState(indexKey, []byte{0x00})
+		if err != nil {
+			return fmt.Errorf("failed to put to world state: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// CreateGitCommitWithIssues issues a new GitCommit like CreateGitCommit and links it to the comma separated
+// issueRefs in addition to the references found in its message.
+func (s *SmartContract) CreateGitCommitWithIssues(ctx contractapi.TransactionContextInterface, commitHash string, repository string, commitMessage string, author string, issueRefs string) error {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return err
+	}
+	validation := newValidationError(config)
+	var refs []string
+	for _, ref := range strings.Split(issueRefs, ",") {
+		ref = strings.TrimSpace(ref)
+		fieldError := validIssueRef(ref)
+		validation.add(fieldError)
+		if fieldError == nil {
+			refs = append(refs, ref)
+		}
+	}
+	err = validation.errorOrNil()
+	if err != nil {
+		return err
+	}
+
+	gitCommit, err := s.newGitCommit(ctx, commitHash, repository, commitMessage, author)
+	if err != nil {
+		return err
+	}
+	gitCommit.IssueRefs = appendIssueRefs(gitCommit.IssueRefs, refs)
+
+	return s.addGitCommit(ctx, gitCommit)
+}
+
+// GetCommitsByIssue returns the commits linked to an issue reference, sorted by commit time.
+func (s *SmartContract) GetCommitsByIssue(ctx contractapi.TransactionContextInterface, issueRef string) ([]*GitCommit, error) {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(issueIndexName, []string{issueRef})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	gitCommits := []*GitCommit{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		gitCommit, err := s.ReadGitCommitInRepository(ctx, compositeKeyParts[2], compositeKeyParts[1])
+		if err != nil {
+			return nil, err
+		}
+		gitCommits = append(gitCommits, gitCommit)
+		err = checkResultLimit(config, len(gitCommits))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sortGitCommits(gitCommits)
+	return gitCommits, nil
+}
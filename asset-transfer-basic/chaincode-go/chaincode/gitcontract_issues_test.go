@@ -0,0 +1,69 @@
+package chaincode_test
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateGitCommitParsesIssueRefs(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "JIRA-123: fix crash\n\nAlso closes GH-45 and JIRA-123.", "Alice"))
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"JIRA-123", "GH-45"}, gitCommit.IssueRefs)
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Tidy up", "Bob"))
+	gitCommit, err = gitContract.ReadGitCommit(transactionContext, "hash2")
+	require.NoError(t, err)
+	require.Nil(t, gitCommit.IssueRefs)
+}
+
+func TestGetCommitsByIssue(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "JIRA-123: fix crash", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommitWithIssues(transactionContext, "hash2", "repo1", "Refactor parser for GH-45", "Bob", "JIRA-123, GH-45,#7"))
+	require.NoError(t, gitContract.CreateGitCommitWithIssues(transactionContext, "hash3", "repo2", "Port the fix", "Carol", "JIRA-123"))
+
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash2")
+	require.NoError(t, err)
+	require.Equal(t, []string{"GH-45", "JIRA-123", "#7"}, gitCommit.IssueRefs)
+
+	gitCommits, err := gitContract.GetCommitsByIssue(transactionContext, "JIRA-123")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1", "hash2", "hash3"}, commitHashes(gitCommits))
+
+	gitCommits, err = gitContract.GetCommitsByIssue(transactionContext, "GH-45")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash2"}, commitHashes(gitCommits))
+
+	gitCommits, err = gitContract.GetCommitsByIssue(transactionContext, "#7")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash2"}, commitHashes(gitCommits))
+
+	gitCommits, err = gitContract.GetCommitsByIssue(transactionContext, "GH-46")
+	require.NoError(t, err)
+	require.Empty(t, gitCommits)
+
+	require.NoError(t, gitContract.DeleteGitCommit(transactionContext, "hash2", false, chaincode.DeletePurge, ""))
+	gitCommits, err = gitContract.GetCommitsByIssue(transactionContext, "GH-45")
+	require.NoError(t, err)
+	require.Empty(t, gitCommits)
+}
+
+func TestCreateGitCommitWithInvalidIssueRefs(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	err := gitContract.CreateGitCommitWithIssues(transactionContext, "hash1", "repo1", "Fix crash", "Alice", "JIRA-123,,JIRA 124")
+	require.EqualError(t, err, `an issue reference must not be empty; the issue reference "JIRA 124" must not contain white space`)
+
+	exists, err := gitContract.GitCommitExists(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
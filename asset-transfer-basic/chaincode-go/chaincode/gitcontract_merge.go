@@ -0,0 +1,66 @@
+package chaincode
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// CreateMergeCommit issues a merge commit joining two parent commits of the same repository.
+func (s *SmartContract) CreateMergeCommit(ctx contractapi.TransactionContextInterface, mergeHash string, repository string, parentA string, parentB string, commitMessage string, author string) error {
+	parentA, parentB = strings.TrimSpace(parentA), strings.TrimSpace(parentB)
+	if parentA == parentB {
+		return fmt.Errorf("a merge commit needs two distinct parents, got %s twice", parentA)
+	}
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return err
+	}
+	repository = canonicalRepositoryName(config, repository)
+	for _, parentHash := range []string{parentA, parentB} {
+		// Repository scoped hashes may exist in other repositories too, so look in the merge's repository
+		var parent *GitCommit
+		if config.RepositoryScopedCommits {
+			parent, err = s.ReadGitCommitInRepository(ctx, repository, parentHash)
+		} else {
+			parent, err = s.ReadGitCommit(ctx, parentHash)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid merge parent: %v", err)
+		}
+		if parent.Repository != repository {
+			return fmt.Errorf("merge parent %s belongs to repository %s, not %s", parentHash, parent.Repository, repository)
+		}
+	}
+
+	gitCommit, err := s.newGitCommit(ctx, mergeHash, repository, commitMessage, author)
+	if err != nil {
+		return err
+	}
+	gitCommit.ParentHashes = []string{parentA, parentB}
+	gitCommit.IsMerge = true
+
+	return s.addGitCommit(ctx, gitCommit)
+}
+
+// GetMergeCommits returns the merge commits of a repository sorted by timestamp.
+func (s *SmartContract) GetMergeCommits(ctx contractapi.TransactionContextInterface, repository string) ([]*GitCommit, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	gitCommits, err := s.listRepositoryCommits(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	mergeCommits := []*GitCommit{}
+	for _, gitCommit := range gitCommits {
+		if gitCommit.IsMerge {
+			mergeCommits = append(mergeCommits, gitCommit)
+		}
+	}
+
+	return mergeCommits, nil
+}
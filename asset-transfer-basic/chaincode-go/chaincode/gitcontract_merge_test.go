@@ -0,0 +1,47 @@
+package chaincode_test
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateMergeCommit(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo2", "Unrelated", "Carol"))
+
+	err := gitContract.CreateMergeCommit(transactionContext, "merge1", "repo1", "hash1", "hash3", "Merge branch", "Alice")
+	require.EqualError(t, err, "merge parent hash3 belongs to repository repo2, not repo1")
+
+	err = gitContract.CreateMergeCommit(transactionContext, "merge1", "repo1", "hash1", "hash4", "Merge branch", "Alice")
+	require.EqualError(t, err, "invalid merge parent: the commit hash4 does not exist")
+
+	err = gitContract.CreateMergeCommit(transactionContext, "merge1", "repo1", "hash1", "hash1", "Merge branch", "Alice")
+	require.EqualError(t, err, "a merge commit needs two distinct parents, got hash1 twice")
+
+	exists, err := gitContract.GitCommitExists(transactionContext, "merge1")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, gitContract.CreateMergeCommit(transactionContext, "merge1", "repo1", "hash1", "hash2", "Merge branch", "Alice"))
+	mergeCommit, err := gitContract.ReadGitCommit(transactionContext, "merge1")
+	require.NoError(t, err)
+	require.True(t, mergeCommit.IsMerge)
+	require.Equal(t, []string{"hash1", "hash2"}, mergeCommit.ParentHashes)
+
+	err = gitContract.CreateMergeCommit(transactionContext, "merge1", "repo1", "hash1", "hash2", "Merge branch", "Alice")
+	require.EqualError(t, err, "the commit merge1 already exists")
+
+	mergeCommits, err := gitContract.GetMergeCommits(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"merge1"}, commitHashes(mergeCommits))
+
+	mergeCommits, err = gitContract.GetMergeCommits(transactionContext, "repo2")
+	require.NoError(t, err)
+	require.Empty(t, mergeCommits)
+}
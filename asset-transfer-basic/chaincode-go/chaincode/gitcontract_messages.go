@@ -0,0 +1,69 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// commitMessageIndexName names the index of commits by the SHA-256 of their exact message, which the
+// UniqueCommitMessages setting checks new messages against
+const commitMessageIndexName = "msg~repository~digest~hash"
+
+// commitMessageIndexKey returns the commit message index key of a commit.
+func (s *SmartContract) commitMessageIndexKey(ctx contractapi.TransactionContextInterface, gitCommit *GitCommit) (string, error) {
+	digest := sha256.Sum256([]byte(gitCommit.CommitMessage))
+	indexKey, err := ctx.GetStub().CreateCompositeKey(commitMessageIndexName, []string{gitCommit.Repository, hex.EncodeToString(digest[:]), gitCommit.CommitHash})
+	if err != nil {
+		return "", fmt.Errorf("failed to create composite key: %v", err)
+	}
+	return indexKey, nil
+}
+
+// indexCommitMessage adds the commit message index entry of a commit. Entries are kept whether or not
+// UniqueCommitMessages is set, so that switching it on also covers the commits recorded before.
+func (s *SmartContract) indexCommitMessage(ctx contractapi.TransactionContextInterface, gitCommit *GitCommit) error {
+	indexKey, err := s.commitMessageIndexKey(ctx, gitCommit)
+	if err != nil {
+		return err
+	}
+
+	// Composite key values cannot be empty, so store a single null byte
+	err = ctx.GetStub().PutState(indexKey, []byte{0x00})
+	if err != nil {
+		return fmt.Errorf("failed to put to world state: %v", err)
+	}
+	return nil
+}
+
+// checkUniqueCommitMessage returns an error naming another commit of the repository with exactly the same
+// message when the UniqueCommitMessages setting is on. Commits created before the message index was added
+// are not found.
+func (s *SmartContract) checkUniqueCommitMessage(ctx contractapi.TransactionContextInterface, config *ContractConfig, gitCommit *GitCommit) error {
+	if !config.UniqueCommitMessages {
+		return nil
+	}
+	digest := sha256.Sum256([]byte(gitCommit.CommitMessage))
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(commitMessageIndexName, []string{gitCommit.Repository, hex.EncodeToString(digest[:])})
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return err
+		}
+		if existingHash := compositeKeyParts[2]; existingHash != gitCommit.CommitHash {
+			return fmt.Errorf("the commit %s in repository %s already has the message %q", existingHash, gitCommit.Repository, gitCommit.CommitMessage)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package chaincode_test
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUniqueCommitMessages(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	// The check is off by default
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "wip", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "wip", "Alice"))
+
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"UniqueCommitMessages": true}`))
+	err := gitContract.CreateGitCommit(transactionContext, "hash3", "repo1", "wip", "Bob")
+	require.EqualError(t, err, `the commit hash1 in repository repo1 already has the message "wip"`)
+	_, err = gitContract.ReadGitCommit(transactionContext, "hash3")
+	require.EqualError(t, err, "the commit hash3 does not exist")
+
+	// Only the exact message is a duplicate
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo1", "wip 2", "Bob"))
+	// The same message may be used in another repository
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash4", "repo2", "wip", "Carol"))
+}
+
+func TestUniqueCommitMessagesAfterPatchAndDelete(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"UniqueCommitMessages": true}`))
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Add parser", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Add lexer", "Alice"))
+
+	err := gitContract.PatchGitCommit(transactionContext, "hash2", `{"commitMessage": "Add parser"}`)
+	require.EqualError(t, err, `the commit hash1 in repository repo1 already has the message "Add parser"`)
+
+	// Patching a message frees the old one
+	require.NoError(t, gitContract.PatchGitCommit(transactionContext, "hash1", `{"commitMessage": "Add recursive descent parser"}`))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo1", "Add parser", "Bob"))
+
+	// The patched message is taken in its place
+	err = gitContract.CreateGitCommit(transactionContext, "hash5", "repo1", "Add recursive descent parser", "Bob")
+	require.EqualError(t, err, `the commit hash1 in repository repo1 already has the message "Add recursive descent parser"`)
+
+	// And so does deleting the commit
+	require.NoError(t, gitContract.DeleteGitCommit(transactionContext, "hash2", false, chaincode.DeletePurge, ""))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash4", "repo1", "Add lexer", "Bob"))
+}
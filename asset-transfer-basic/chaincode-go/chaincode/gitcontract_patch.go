@@ -0,0 +1,241 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// immutableCommitFields identify a commit and its place in the repository history, so no patch may touch them
+var immutableCommitFields = map[string]bool{"commitHash": true, "repository": true, "versionNumber": true}
+
+// patchableCommitFields are the fields PatchGitCommit may change. The other fields are derived from these, such
+// as the trailers of the message, or are owned by contract functions that check who may change them, such as
+// the build status and the security level.
+var patchableCommitFields = map[string]bool{
+	"commitMessage": true, "author": true,
+	"insertions": true, "deletions": true, "filesChanged": true,
+	"authorTimestamp": true, "commitTimestamp": true,
+}
+
+// applyMergePatch applies an RFC 7386 JSON merge patch to a decoded JSON object: members of the patch replace
+// those of the target, objects are merged recursively and null removes a member.
+func applyMergePatch(target map[string]interface{}, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = make(map[string]interface{})
+	}
+	for name, value := range patch {
+		if value == nil {
+			delete(target, name)
+			continue
+		}
+		patchObject, ok := value.(map[string]interface{})
+		if !ok {
+			target[name] = value
+			continue
+		}
+		targetObject, _ := target[name].(map[string]interface{})
+		target[name] = applyMergePatch(targetObject, patchObject)
+	}
+	return target
+}
+
+// parseCommitPatch decodes a merge patch of a GitCommit and checks that it only touches patchable fields. Field
+// names match case-insensitively, like those of a projection, and are returned as JSON names.
+func parseCommitPatch(gitCommit *GitCommit, patchJSON string) (map[string]interface{}, error) {
+	var patch map[string]interface{}
+	err := json.Unmarshal([]byte(patchJSON), &patch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse commit patch, must be a JSON object: %v", err)
+	}
+
+	known := make(map[string]string)
+	required := make(map[string]bool)
+	commitType := reflect.TypeOf(GitCommit{})
+	for i := 0; i < commitType.NumField(); i++ {
+		tag := strings.Split(commitType.Field(i).Tag.Get("json"), ",")
+		known[strings.ToLower(tag[0])] = tag[0]
+		required[tag[0]] = len(tag) == 1
+	}
+
+	normalized := make(map[string]interface{})
+	for field, value := range patch {
+		name, ok := known[strings.ToLower(field)]
+		if !ok {
+			return nil, fmt.Errorf("unknown commit field %q", field)
+		}
+		if immutableCommitFields[name] {
+			return nil, fmt.Errorf("the commit field %s cannot be changed", name)
+		}
+		if !patchableCommitFields[name] {
+			return nil, fmt.Errorf("the commit field %s cannot be patched, it is set by its own contract functions", name)
+		}
+		if value == nil && required[name] {
+			return nil, fmt.Errorf("the commit field %s is required and cannot be removed", name)
+		}
+		if gitCommit.SubmitterSignature != "" && (name == "commitMessage" || name == "author") {
+			return nil, fmt.Errorf("the commit %s is signed by its submitter, so its %s cannot be changed", gitCommit.CommitHash, name)
+		}
+		normalized[name] = value
+	}
+
+	return normalized, nil
+}
+
+// validatePatchedCommit applies the checks of the create functions to the fields a patch may change.
+func validatePatchedCommit(config *ContractConfig, gitCommit *GitCommit) error {
+	commitMessage, author, err := sanitizeCommitText(config, gitCommit.CommitMessage, gitCommit.Author)
+	if err != nil {
+		return err
+	}
+	gitCommit.CommitMessage = commitMessage
+	gitCommit.Author = author
+
+	if gitCommit.Insertions < 0 || gitCommit.Deletions < 0 || gitCommit.FilesChanged < 0 {
+		return fmt.Errorf("commit stats must not be negative, got %d insertions, %d deletions and %d files changed", gitCommit.Insertions, gitCommit.Deletions, gitCommit.FilesChanged)
+	}
+	validation := newValidationError(config)
+	checkCommitDates(validation, gitCommit.AuthorTimestamp, gitCommit.CommitTimestamp)
+	return validation.errorOrNil()
+}
+
+// PatchGitCommit changes some fields of a commit by applying an RFC 7386 JSON merge patch, such as
+// {"commitMessage": "Fix typo", "filesChanged": null}: only the fields in the patch change and null removes an
+// optional field. The hash, repository and version of a commit cannot be changed, and neither can fields owned
+// by other contract functions. Changing the message updates its trailers, issue references and their indexes.
+func (s *SmartContract) PatchGitCommit(ctx contractapi.TransactionContextInterface, commitHash string, patchJSON string) error {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return err
+	}
+	gitCommit, err := s.ReadGitCommit(ctx, commitHash)
+	if err != nil {
+		return err
+	}
+	err = s.checkRepositoryWriteAccess(ctx, gitCommit.Repository)
+	if err != nil {
+		return err
+	}
+	err = s.checkRepositoryNotArchived(ctx, gitCommit.Repository)
+	if err != nil {
+		return err
+	}
+	patch, err := parseCommitPatch(gitCommit, patchJSON)
+	if err != nil {
+		return err
+	}
+
+	gitCommitJSON, err := json.Marshal(gitCommit)
+	if err != nil {
+		return err
+	}
+	var document map[string]interface{}
+	err = json.Unmarshal(gitCommitJSON, &document)
+	if err != nil {
+		return err
+	}
+	patchedJSON, err := json.Marshal(applyMergePatch(document, patch))
+	if err != nil {
+		return err
+	}
+	var patched GitCommit
+	err = json.Unmarshal(patchedJSON, &patched)
+	if err != nil {
+		return fmt.Errorf("failed to apply commit patch: %v", err)
+	}
+	err = validatePatchedCommit(config, &patched)
+	if err != nil {
+		return err
+	}
+
+	if patched.CommitMessage != gitCommit.CommitMessage {
+		err = s.replaceCommitMessage(ctx, config, gitCommit, &patched)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.putGitCommit(ctx, &patched)
+}
+
+// UpdateGitCommit corrects the message and author of a commit, like PatchGitCommit with a patch of just those
+// two fields. The hash, repository, version and timestamps of the commit stay as they are.
+func (s *SmartContract) UpdateGitCommit(ctx contractapi.TransactionContextInterface, commitHash string, commitMessage string, author string) error {
+	patchJSON, err := json.Marshal(map[string]string{"commitMessage": commitMessage, "author": author})
+	if err != nil {
+		return err
+	}
+	return s.PatchGitCommit(ctx, commitHash, string(patchJSON))
+}
+
+// replaceCommitMessage moves the message derived state of a commit, its trailers, issue references, commit
+// type, message index entry and message blob, from the old record to the patched one.
+func (s *SmartContract) replaceCommitMessage(ctx contractapi.TransactionContextInterface, config *ContractConfig, old *GitCommit, patched *GitCommit) error {
+	err := s.checkUniqueCommitMessage(ctx, config, patched)
+	if err != nil {
+		return err
+	}
+
+	var staleKeys []string
+	for _, legacy := range []bool{false, true} {
+		trailerKeys, err := s.trailerIndexKeys(ctx, old, legacy)
+		if err != nil {
+			return err
+		}
+		staleKeys = append(staleKeys, trailerKeys...)
+	}
+	issueKeys, err := s.issueIndexKeys(ctx, old)
+	if err != nil {
+		return err
+	}
+	staleKeys = append(staleKeys, issueKeys...)
+	typeKey, err := s.commitTypeIndexKey(ctx, old)
+	if err != nil {
+		return err
+	}
+	if typeKey != "" {
+		staleKeys = append(staleKeys, typeKey)
+	}
+	messageKey, err := s.commitMessageIndexKey(ctx, old)
+	if err != nil {
+		return err
+	}
+	staleKeys = append(staleKeys, messageKey)
+	for _, key := range staleKeys {
+		err = ctx.GetStub().DelState(key)
+		if err != nil {
+			return fmt.Errorf("failed to delete state: %v", err)
+		}
+	}
+
+	if old.MessageBlob != "" {
+		err = s.releaseBlob(ctx, old.MessageBlob, 1)
+		if err != nil {
+			return err
+		}
+		patched.MessageBlob = ""
+	}
+	err = s.storeMessageBlob(ctx, config, patched)
+	if err != nil {
+		return err
+	}
+
+	patched.Trailers = parseTrailers(patched.CommitMessage)
+	patched.IssueRefs = parseIssueRefs(patched.CommitMessage)
+	err = s.indexTrailers(ctx, patched)
+	if err != nil {
+		return err
+	}
+	err = s.indexCommitType(ctx, config, patched)
+	if err != nil {
+		return err
+	}
+	err = s.indexCommitMessage(ctx, patched)
+	if err != nil {
+		return err
+	}
+	return s.indexIssueRefs(ctx, patched)
+}
@@ -0,0 +1,94 @@
+package chaincode_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchGitCommit(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	require.NoError(t, gitContract.CreateGitCommitWithStats(transactionContext, "hash1", "repo1", "Fix crash\n\nFixes: #123", "Alice", 10, 2, 3))
+
+	// Adding a field leaves the others as they are
+	require.NoError(t, gitContract.PatchGitCommit(transactionContext, "hash1", `{"authorTimestamp": "2026-01-01T09:00:00Z"}`))
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, "2026-01-01T09:00:00Z", gitCommit.AuthorTimestamp)
+	require.Equal(t, "Alice", gitCommit.Author)
+	require.Equal(t, 3, gitCommit.FilesChanged)
+
+	// Changing the message moves its trailers to the new value, and names match case-insensitively
+	require.NoError(t, gitContract.PatchGitCommit(transactionContext, "hash1", `{"CommitMessage": "Fix crash\n\nFixes: #124", "author": "Alice Smith"}`))
+	gitCommit, err = gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, "Fix crash\n\nFixes: #124", gitCommit.CommitMessage)
+	require.Equal(t, "Alice Smith", gitCommit.Author)
+	require.Equal(t, map[string][]string{"Fixes": {"#124"}}, gitCommit.Trailers)
+	gitCommits, err := gitContract.QueryCommitsByTrailer(transactionContext, "Fixes", "#123")
+	require.NoError(t, err)
+	require.Empty(t, gitCommits)
+	gitCommits, err = gitContract.QueryCommitsByTrailer(transactionContext, "Fixes", "#124")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1"}, commitHashes(gitCommits))
+
+	// Null removes an optional field
+	require.NoError(t, gitContract.PatchGitCommit(transactionContext, "hash1", `{"filesChanged": null, "authorTimestamp": null}`))
+	gitCommit, err = gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Zero(t, gitCommit.FilesChanged)
+	require.Empty(t, gitCommit.AuthorTimestamp)
+	require.Equal(t, 10, gitCommit.Insertions)
+}
+
+func TestUpdateGitCommit(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	setTxTime(chaincodeStub, time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Intial commit", "Alcie"))
+
+	setTxTime(chaincodeStub, time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, gitContract.UpdateGitCommit(transactionContext, "hash1", "Initial commit", "Alice"))
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, "hash1", gitCommit.CommitHash)
+	require.Equal(t, "repo1", gitCommit.Repository)
+	require.Equal(t, "Initial commit", gitCommit.CommitMessage)
+	require.Equal(t, "Alice", gitCommit.Author)
+	require.Equal(t, 1, gitCommit.VersionNumber)
+	require.Equal(t, "2026-01-01T12:00:00Z", gitCommit.Timestamp)
+
+	err = gitContract.UpdateGitCommit(transactionContext, "hash2", "Added feature", "Bob")
+	require.EqualError(t, err, "the commit hash2 does not exist")
+}
+
+func TestPatchGitCommitRejectsProtectedFields(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+
+	for patch, message := range map[string]string{
+		`{"commitHash": "hash2"}`:          "the commit field commitHash cannot be changed",
+		`{"Repository": "repo2"}`:          "the commit field repository cannot be changed",
+		`{"versionNumber": 7}`:             "the commit field versionNumber cannot be changed",
+		`{"buildStatus": "passed"}`:        "the commit field buildStatus cannot be patched, it is set by its own contract functions",
+		`{"author": null}`:                 "the commit field author is required and cannot be removed",
+		`{"labels": ["bug"]}`:              `unknown commit field "labels"`,
+		`{"insertions": -1}`:               "commit stats must not be negative, got -1 insertions, 0 deletions and 0 files changed",
+		`{"commitTimestamp": "yesterday"}`: `invalid commit timestamp "yesterday", must be RFC3339`,
+		`["commitMessage"]`:                "failed to parse commit patch, must be a JSON object",
+		`{"filesChanged": "three"}`:        "failed to apply commit patch",
+	} {
+		err := gitContract.PatchGitCommit(transactionContext, "hash1", patch)
+		require.ErrorContains(t, err, message, patch)
+	}
+
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, "hash1", gitCommit.CommitHash)
+	require.Equal(t, "Alice", gitCommit.Author)
+	require.Empty(t, gitCommit.BuildStatus)
+}
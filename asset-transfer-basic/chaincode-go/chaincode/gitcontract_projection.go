@@ -0,0 +1,105 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// gitCommitFieldNames returns the JSON names of the GitCommit fields.
+func gitCommitFieldNames() map[string]bool {
+	names := make(map[string]bool)
+	commitType := reflect.TypeOf(GitCommit{})
+	for i := 0; i < commitType.NumField(); i++ {
+		name := strings.Split(commitType.Field(i).Tag.Get("json"), ",")[0]
+		names[name] = true
+	}
+	return names
+}
+
+// parseProjection turns a comma-separated list of GitCommit field names into a set of JSON names. Names match
+// case-insensitively, so the PascalCase names used before schema version 3 keep working. Unknown names are
+// rejected when strict is set and ignored otherwise.
+func parseProjection(fields string, strict bool) (map[string]bool, error) {
+	known := make(map[string]string)
+	for name := range gitCommitFieldNames() {
+		known[strings.ToLower(name)] = name
+	}
+	projection := make(map[string]bool)
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		name, ok := known[strings.ToLower(field)]
+		if !ok {
+			if strict {
+				return nil, fmt.Errorf("unknown commit field %q", field)
+			}
+			continue
+		}
+		projection[name] = true
+	}
+	if len(projection) == 0 {
+		return nil, fmt.Errorf("no known commit fields selected in %q", fields)
+	}
+
+	return projection, nil
+}
+
+// projectGitCommit returns the selected fields of a commit, keyed by their JSON names.
+func projectGitCommit(gitCommit *GitCommit, projection map[string]bool) (map[string]interface{}, error) {
+	gitCommitJSON, err := json.Marshal(gitCommit)
+	if err != nil {
+		return nil, err
+	}
+	var allFields map[string]interface{}
+	err = json.Unmarshal(gitCommitJSON, &allFields)
+	if err != nil {
+		return nil, err
+	}
+
+	projected := make(map[string]interface{})
+	for field, value := range allFields {
+		if projection[field] {
+			projected[field] = value
+		}
+	}
+	return projected, nil
+}
+
+// GetGitCommitsProjection returns only the selected fields of the commits of a repository, or of every
+// commit when repository is empty, to keep list responses small. fields is a comma-separated list of
+// GitCommit field names such as "commitHash,author,versionNumber"; unknown names are rejected when
+// strict is set and ignored otherwise.
+func (s *SmartContract) GetGitCommitsProjection(ctx contractapi.TransactionContextInterface, repository string, fields string, strict bool) ([]map[string]interface{}, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	projection, err := parseProjection(fields, strict)
+	if err != nil {
+		return nil, err
+	}
+
+	gitCommits, err := s.listGitCommits(ctx, func(gitCommit *GitCommit) bool {
+		return repository == "" || gitCommit.Repository == repository
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	projected := []map[string]interface{}{}
+	for _, gitCommit := range gitCommits {
+		fieldValues, err := projectGitCommit(gitCommit, projection)
+		if err != nil {
+			return nil, err
+		}
+		projected = append(projected, fieldValues)
+	}
+
+	return projected, nil
+}
@@ -0,0 +1,37 @@
+package chaincode_test
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetGitCommitsProjection(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo2", "Added feature", "Bob"))
+
+	projected, err := gitContract.GetGitCommitsProjection(transactionContext, "repo1", "commitHash, author,versionNumber", true)
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{
+		{"commitHash": "hash1", "author": "Alice", "versionNumber": float64(1)},
+	}, projected)
+
+	// The PascalCase names of older records still select the same fields
+	projected, err = gitContract.GetGitCommitsProjection(transactionContext, "repo1", "CommitHash,Author", true)
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{{"commitHash": "hash1", "author": "Alice"}}, projected)
+
+	projected, err = gitContract.GetGitCommitsProjection(transactionContext, "", "commitHash,Files", false)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []map[string]interface{}{{"commitHash": "hash1"}, {"commitHash": "hash2"}}, projected)
+
+	_, err = gitContract.GetGitCommitsProjection(transactionContext, "", "commitHash,Files", true)
+	require.EqualError(t, err, `unknown commit field "Files"`)
+
+	_, err = gitContract.GetGitCommitsProjection(transactionContext, "", "Files", false)
+	require.EqualError(t, err, `no known commit fields selected in "Files"`)
+}
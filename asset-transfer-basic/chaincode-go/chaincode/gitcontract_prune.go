@@ -0,0 +1,226 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const pruneAuditObjectType = "pruneAudit"
+
+// ProtectedCommit is a commit that was old enough to prune but had to be kept
+type ProtectedCommit struct {
+	CommitHash string `json:"CommitHash"`
+	Reason     string `json:"Reason"`
+}
+
+// PruneAudit records one run of PruneCommitsBefore
+type PruneAudit struct {
+	Repository string             `json:"Repository"`
+	Before     string             `json:"Before"`
+	Keep       int                `json:"Keep"`
+	Pruned     []string           `json:"Pruned"`
+	Protected  []*ProtectedCommit `json:"Protected"`
+	PrunedBy   string             `json:"PrunedBy"`
+	TxID       string             `json:"TxID"`
+	Timestamp  string             `json:"Timestamp"`
+}
+
+// PruneCommitsBefore deletes the commits of a repository whose commit time is before the given RFC3339 time,
+// always keeping the latest keep commits. Pinned commits, commits still referenced by a kept commit or by a
+// push, and commits flagged with a security level other than none, are kept as well. Every run is recorded as a PruneAudit,
+// which is also returned. Only the admin organization may prune commits.
+func (s *SmartContract) PruneCommitsBefore(ctx contractapi.TransactionContextInterface, repository string, before string, keep int) (*PruneAudit, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	beforeTime, err := time.Parse(time.RFC3339, before)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time %q, must be RFC3339: %v", before, err)
+	}
+	if keep < 0 {
+		return nil, fmt.Errorf("the number of commits to keep must not be negative, got %d", keep)
+	}
+	err = s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = s.checkRepositoryNotArchived(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	gitCommits, err := s.getRepositoryCommits(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	pushTransactions, err := s.getRepositoryPushTransactions(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	pushed := make(map[string]bool)
+	for _, pushTx := range pushTransactions {
+		pushed[pushTx.CommitHash] = true
+	}
+
+	// gitCommits is sorted oldest first, so the retention floor covers its tail
+	candidates := make(map[string]bool)
+	for i, gitCommit := range gitCommits {
+		if i < len(gitCommits)-keep && commitTime(gitCommit).Before(beforeTime) {
+			candidates[gitCommit.CommitHash] = true
+		}
+	}
+
+	audit := &PruneAudit{Repository: repository, Before: before, Keep: keep, Pruned: []string{}, Protected: []*ProtectedCommit{}}
+	protect := func(commitHash, reason string) {
+		delete(candidates, commitHash)
+		audit.Protected = append(audit.Protected, &ProtectedCommit{CommitHash: commitHash, Reason: reason})
+	}
+	for _, gitCommit := range gitCommits {
+		if !candidates[gitCommit.CommitHash] {
+			continue
+		}
+		if gitCommit.Pinned {
+			protect(gitCommit.CommitHash, "pinned")
+		} else if pushed[gitCommit.CommitHash] {
+			protect(gitCommit.CommitHash, "pushed")
+		} else if gitCommit.SecurityLevel != "" && gitCommit.SecurityLevel != SecurityLevelNone {
+			protect(gitCommit.CommitHash, "security level "+gitCommit.SecurityLevel)
+		}
+	}
+	// A parent must stay while any of its children stays, and protecting it may in turn protect its own parents
+	for changed := true; changed; {
+		changed = false
+		for _, child := range gitCommits {
+			if candidates[child.CommitHash] {
+				continue
+			}
+			for _, parentHash := range child.ParentHashes {
+				if candidates[parentHash] {
+					protect(parentHash, "parent of "+child.CommitHash)
+					changed = true
+				}
+			}
+		}
+	}
+
+	var pruned []*GitCommit
+	for _, gitCommit := range gitCommits {
+		if candidates[gitCommit.CommitHash] {
+			pruned = append(pruned, gitCommit)
+			audit.Pruned = append(audit.Pruned, gitCommit.CommitHash)
+		}
+	}
+	err = s.removeGitCommits(ctx, pruned)
+	if err != nil {
+		return nil, err
+	}
+
+	audit.PrunedBy, err = ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MSPID: %v", err)
+	}
+	audit.TxID = ctx.GetStub().GetTxID()
+	audit.Timestamp, err = txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	auditKey, err := ctx.GetStub().CreateCompositeKey(pruneAuditObjectType, []string{repository, audit.Timestamp, audit.TxID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create composite key: %v", err)
+	}
+	auditJSON, err := json.Marshal(audit)
+	if err != nil {
+		return nil, err
+	}
+	err = ctx.GetStub().PutState(auditKey, auditJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to put to world state: %v", err)
+	}
+
+	return audit, nil
+}
+
+// GetPruneHistory returns the PruneAudit records of a repository, oldest first.
+func (s *SmartContract) GetPruneHistory(ctx contractapi.TransactionContextInterface, repository string) ([]*PruneAudit, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(pruneAuditObjectType, []string{repository})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	audits := []*PruneAudit{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var audit PruneAudit
+		err = json.Unmarshal(queryResponse.Value, &audit)
+		if err != nil {
+			return nil, err
+		}
+		audits = append(audits, &audit)
+	}
+
+	return audits, nil
+}
+
+// PinCommit marks a commit as permanently retained, so that PruneCommitsBefore never removes it however old it
+// is, as for a first release or a security fix. Only the admin organization may pin commits.
+func (s *SmartContract) PinCommit(ctx contractapi.TransactionContextInterface, commitHash string) error {
+	return s.setPinned(ctx, commitHash, true)
+}
+
+// UnpinCommit lets retention pruning remove a pinned commit again. Only the admin organization may unpin commits.
+func (s *SmartContract) UnpinCommit(ctx contractapi.TransactionContextInterface, commitHash string) error {
+	return s.setPinned(ctx, commitHash, false)
+}
+
+func (s *SmartContract) setPinned(ctx contractapi.TransactionContextInterface, commitHash string, pinned bool) error {
+	err := s.requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	gitCommit, err := s.ReadGitCommit(ctx, commitHash)
+	if err != nil {
+		return err
+	}
+	if gitCommit.Pinned == pinned {
+		if pinned {
+			return fmt.Errorf("the commit %s is already pinned", commitHash)
+		}
+		return fmt.Errorf("the commit %s is not pinned", commitHash)
+	}
+
+	gitCommit.Pinned = pinned
+	return s.putGitCommit(ctx, gitCommit)
+}
+
+// GetPinnedCommits returns the pinned commits of a repository sorted by commit time.
+func (s *SmartContract) GetPinnedCommits(ctx contractapi.TransactionContextInterface, repository string) ([]*GitCommit, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	gitCommits, err := s.listRepositoryCommits(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	pinned := []*GitCommit{}
+	for _, gitCommit := range gitCommits {
+		if gitCommit.Pinned {
+			pinned = append(pinned, gitCommit)
+		}
+	}
+	return pinned, nil
+}
@@ -0,0 +1,126 @@
+package chaincode_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneCommitsBefore(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, commitHash := range []string{"hash1", "hash2", "hash3", "hash4", "hash5"} {
+		setTxTime(chaincodeStub, start.Add(time.Duration(i+1)*time.Hour))
+		require.NoError(t, gitContract.CreateGitCommit(transactionContext, commitHash, "repo1", "Change "+commitHash, "Alice"))
+	}
+	setTxTime(chaincodeStub, start.Add(6*time.Hour))
+	require.NoError(t, gitContract.CreateMergeCommit(transactionContext, "merge1", "repo1", "hash4", "hash5", "Merge branch", "Alice"))
+	setTxTime(chaincodeStub, start.Add(7*time.Hour))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash6", "repo1", "Change hash6", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "other1", "repo2", "Elsewhere", "Bob"))
+
+	_, err := gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash2")
+	require.NoError(t, err)
+	require.NoError(t, gitContract.FlagSecurityIssue(transactionContext, "hash3", "high", ""))
+
+	cutoff := start.Add(5*time.Hour + 30*time.Minute).Format(time.RFC3339)
+	chaincodeStub.GetTxIDReturns("tx1")
+	audit, err := gitContract.PruneCommitsBefore(transactionContext, "repo1", cutoff, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1"}, audit.Pruned)
+	require.Equal(t, []*chaincode.ProtectedCommit{
+		{CommitHash: "hash2", Reason: "pushed"},
+		{CommitHash: "hash3", Reason: "security level high"},
+		{CommitHash: "hash4", Reason: "parent of merge1"},
+		{CommitHash: "hash5", Reason: "parent of merge1"},
+	}, audit.Protected)
+
+	gitCommits, err := gitContract.GetUnpushedCommits(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash3", "hash4", "hash5", "merge1", "hash6"}, commitHashes(gitCommits))
+
+	// Everything is past the cutoff now, but the latest commit is kept as the retention floor
+	chaincodeStub.GetTxIDReturns("tx2")
+	audit, err = gitContract.PruneCommitsBefore(transactionContext, "repo1", start.Add(24*time.Hour).Format(time.RFC3339), 1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash4", "hash5", "merge1"}, audit.Pruned)
+	gitCommits, err = gitContract.GetUnpushedCommits(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash3", "hash6"}, commitHashes(gitCommits))
+
+	exists, err := gitContract.GitCommitExists(transactionContext, "other1")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	history, err := gitContract.GetPruneHistory(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.Equal(t, []string{"hash1"}, history[0].Pruned)
+	require.Equal(t, adminMSPID, history[0].PrunedBy)
+	require.Equal(t, 1, history[1].Keep)
+}
+
+func TestPruneCommitsBeforeValidation(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	_, err := gitContract.PruneCommitsBefore(transactionContext, "repo1", "last year", 1)
+	require.ErrorContains(t, err, `invalid time "last year", must be RFC3339`)
+
+	_, err = gitContract.PruneCommitsBefore(transactionContext, "repo1", "2026-01-01T00:00:00Z", -1)
+	require.EqualError(t, err, "the number of commits to keep must not be negative, got -1")
+
+	setClientMSPID(transactionContext, "Org2MSP")
+	_, err = gitContract.PruneCommitsBefore(transactionContext, "repo1", "2026-01-01T00:00:00Z", 1)
+	require.EqualError(t, err, "client from org Org2MSP is not authorized to perform this operation")
+}
+
+func TestPinnedCommitsSurvivePruning(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, commitHash := range []string{"hash1", "hash2", "hash3"} {
+		setTxTime(chaincodeStub, start.Add(time.Duration(i+1)*time.Hour))
+		require.NoError(t, gitContract.CreateGitCommit(transactionContext, commitHash, "repo1", "Change "+commitHash, "Alice"))
+	}
+
+	setClientMSPID(transactionContext, "Org2MSP")
+	err := gitContract.PinCommit(transactionContext, "hash1")
+	require.EqualError(t, err, "client from org Org2MSP is not authorized to perform this operation")
+	setClientMSPID(transactionContext, adminMSPID)
+
+	require.NoError(t, gitContract.PinCommit(transactionContext, "hash1"))
+	err = gitContract.PinCommit(transactionContext, "hash1")
+	require.EqualError(t, err, "the commit hash1 is already pinned")
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.True(t, gitCommit.Pinned)
+
+	pinned, err := gitContract.GetPinnedCommits(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1"}, commitHashes(pinned))
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	audit, err := gitContract.PruneCommitsBefore(transactionContext, "repo1", start.Add(24*time.Hour).Format(time.RFC3339), 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash2", "hash3"}, audit.Pruned)
+	require.Equal(t, []*chaincode.ProtectedCommit{{CommitHash: "hash1", Reason: "pinned"}}, audit.Protected)
+
+	// Once unpinned, the commit is pruned like any other
+	require.NoError(t, gitContract.UnpinCommit(transactionContext, "hash1"))
+	err = gitContract.UnpinCommit(transactionContext, "hash1")
+	require.EqualError(t, err, "the commit hash1 is not pinned")
+	chaincodeStub.GetTxIDReturns("tx2")
+	audit, err = gitContract.PruneCommitsBefore(transactionContext, "repo1", start.Add(24*time.Hour).Format(time.RFC3339), 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1"}, audit.Pruned)
+
+	pinned, err = gitContract.GetPinnedCommits(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Empty(t, pinned)
+}
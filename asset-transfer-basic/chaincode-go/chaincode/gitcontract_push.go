@@ -0,0 +1,326 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// defaultPushMessageTemplate renders the build message of a push when the PushMessageTemplate setting is empty.
+// A push without annotations gets the message HandleGitPush always returned.
+const defaultPushMessageTemplate = "{{if .ApprovedBy}}Approved by {{.ApprovedBy}}{{else}}All testing is done and audit processes approved{{end}}, " +
+	"move to build stage{{if .Message}}: {{.Message}}{{end}}. Git clone link: {{.RemoteURL}}"
+
+// pushTransactionKey returns the world state key of a push. The zero-padded version makes range scans
+// return a repository's pushes in version order, and the transaction ID keeps keys unique.
+func pushTransactionKey(repository string, version int, txID string) string {
+	return fmt.Sprintf("%s%s_%010d_%s", pushKeyPrefix, repository, version, txID)
+}
+
+// GetPushTransactionsByRepository returns the push transactions of a repository in version order.
+func (s *SmartContract) GetPushTransactionsByRepository(ctx contractapi.TransactionContextInterface, repository string) ([]*PushTransaction, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	pushTransactions, err := s.getRepositoryPushTransactions(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	if pushTransactions == nil {
+		pushTransactions = []*PushTransaction{}
+	}
+
+	return pushTransactions, nil
+}
+
+// MigratePushTransactionKeys rewrites push transactions stored under the legacy PUSH_<repo>_<timestamp>
+// keys into the version ordered key scheme and returns the number of records moved. The timestamp of
+// the legacy key takes the place of the transaction ID so that migrated keys stay unique.
+// Only the admin organization may run the migration.
+func (s *SmartContract) MigratePushTransactionKeys(ctx contractapi.TransactionContextInterface) (int, error) {
+	err := s.requireAdmin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByRange(pushKeyPrefix, pushKeyPrefix+"~")
+	if err != nil {
+		return 0, err
+	}
+	defer resultsIterator.Close()
+
+	type migration struct {
+		legacyKey string
+		newKey    string
+		value     []byte
+	}
+	var migrations []migration
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return 0, err
+		}
+
+		var pushTx PushTransaction
+		err = json.Unmarshal(queryResponse.Value, &pushTx)
+		if err != nil {
+			return 0, err
+		}
+
+		repositoryPrefix := fmt.Sprintf("%s%s_", pushKeyPrefix, pushTx.Repository)
+		if strings.HasPrefix(queryResponse.Key, pushTransactionKey(pushTx.Repository, pushTx.Version, "")) {
+			continue
+		}
+		legacySuffix := strings.TrimPrefix(queryResponse.Key, repositoryPrefix)
+		migrations = append(migrations, migration{
+			legacyKey: queryResponse.Key,
+			newKey:    pushTransactionKey(pushTx.Repository, pushTx.Version, legacySuffix),
+			value:     queryResponse.Value,
+		})
+	}
+
+	for _, m := range migrations {
+		err = ctx.GetStub().PutState(m.newKey, m.value)
+		if err != nil {
+			return 0, err
+		}
+		err = ctx.GetStub().DelState(m.legacyKey)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(migrations), nil
+}
+
+// normalizeRemoteURL reduces the spellings of one remote to a single form for matching: surrounding space,
+// trailing slashes and a trailing .git are dropped and the scheme and host of URLs are lower cased.
+// HandleGitPush records the URL as given, so both sides of a comparison are normalized.
+func normalizeRemoteURL(remoteURL string) string {
+	normalized := strings.TrimSpace(remoteURL)
+	normalized = strings.TrimRight(normalized, "/")
+	normalized = strings.TrimSuffix(normalized, ".git")
+	normalized = strings.TrimRight(normalized, "/")
+
+	parsed, err := url.Parse(normalized)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		// Not a URL, for example an scp-like git@host:path remote or a local path
+		return normalized
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	return parsed.String()
+}
+
+// GetCommitsByRemote returns the commits that were pushed to a remote, each once, sorted by commit time.
+// The remote is matched after normalization, so https://host/repo.git and https://host/repo/ are the same.
+func (s *SmartContract) GetCommitsByRemote(ctx contractapi.TransactionContextInterface, remoteURL string) ([]*GitCommit, error) {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	remote := normalizeRemoteURL(remoteURL)
+
+	resultsIterator, err := ctx.GetStub().GetStateByRange(pushKeyPrefix, pushKeyPrefix+"~")
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	gitCommits := []*GitCommit{}
+	seen := make(map[string]bool)
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var pushTx PushTransaction
+		err = json.Unmarshal(queryResponse.Value, &pushTx)
+		if err != nil {
+			return nil, err
+		}
+		// Hashes are only unique within a repository once commits are repository scoped
+		seenKey := pushTx.Repository + "\x00" + pushTx.CommitHash
+		if seen[seenKey] || normalizeRemoteURL(pushTx.RemoteURL) != remote {
+			continue
+		}
+		seen[seenKey] = true
+
+		gitCommit, err := s.ReadGitCommitInRepository(ctx, pushTx.Repository, pushTx.CommitHash)
+		if err != nil {
+			return nil, err
+		}
+		gitCommits = append(gitCommits, gitCommit)
+		err = checkResultLimit(config, len(gitCommits))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sortGitCommits(gitCommits)
+	return gitCommits, nil
+}
+
+// HandleAnnotatedGitPush records a push like HandleGitPush, together with a message, such as the build target,
+// and the approver of the push. Either may be empty. While the repository has access grants, approvedBy must
+// be the organization of the submitting client.
+func (s *SmartContract) HandleAnnotatedGitPush(ctx contractapi.TransactionContextInterface, repository, remoteURL, commitHash, pushMessage, approvedBy string) (string, error) {
+	return s.handleGitPush(ctx, repository, remoteURL, commitHash, pushMessage, approvedBy, "")
+}
+
+// HandleDeploymentPush records a push like HandleAnnotatedGitPush, together with the environment it was
+// deployed to. When the AllowedEnvironments setting is not empty, the environment must be one of them.
+func (s *SmartContract) HandleDeploymentPush(ctx contractapi.TransactionContextInterface, repository, remoteURL, commitHash, environment, pushMessage, approvedBy string) (string, error) {
+	return s.handleGitPush(ctx, repository, remoteURL, commitHash, pushMessage, approvedBy, environment)
+}
+
+// GetPushesByEnvironment returns the pushes of a repository deployed to an environment in version order, so
+// the last one is what the environment runs now.
+func (s *SmartContract) GetPushesByEnvironment(ctx contractapi.TransactionContextInterface, repository, environment string) ([]*PushTransaction, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	if environment == "" {
+		return nil, fmt.Errorf("the environment must not be empty")
+	}
+	pushTransactions, err := s.getRepositoryPushTransactions(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	deployments := []*PushTransaction{}
+	for _, pushTx := range pushTransactions {
+		if pushTx.Environment == environment {
+			deployments = append(deployments, pushTx)
+		}
+	}
+
+	return deployments, nil
+}
+
+// GetCurrentDeployment returns the latest push of a repository deployed to an environment, which is what the
+// environment runs now.
+func (s *SmartContract) GetCurrentDeployment(ctx contractapi.TransactionContextInterface, repository, environment string) (*PushTransaction, error) {
+	deployments, err := s.GetPushesByEnvironment(ctx, repository, environment)
+	if err != nil {
+		return nil, err
+	}
+	if len(deployments) == 0 {
+		return nil, fmt.Errorf("the repository %s has not been deployed to %s", repository, environment)
+	}
+
+	return deployments[len(deployments)-1], nil
+}
+
+// GetVersionGaps returns the versions of a repository, in order, that no push records, such as the versions
+// of lost pushes. A repository starts at version 1 before its first push, so the check starts at version 2;
+// a fork starts at the version of its source, which is not recorded, so its check starts at its first push.
+// Versions taken by IncrementVersionNumber without a push show up as gaps too.
+func (s *SmartContract) GetVersionGaps(ctx contractapi.TransactionContextInterface, repository string) ([]int, error) {
+	repoVersion, err := s.GetRepositoryVersion(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	pushTransactions, err := s.getRepositoryPushTransactions(ctx, repoVersion.Repository)
+	if err != nil {
+		return nil, err
+	}
+
+	pushed := make(map[int]bool, len(pushTransactions))
+	for _, pushTx := range pushTransactions {
+		pushed[pushTx.Version] = true
+	}
+	firstVersion := 2
+	if repoVersion.ForkedFrom != "" {
+		if len(pushTransactions) == 0 {
+			return []int{}, nil
+		}
+		// Pushes are in version order
+		firstVersion = pushTransactions[0].Version
+	}
+
+	gaps := []int{}
+	for version := firstVersion; version <= repoVersion.VersionNumber; version++ {
+		if !pushed[version] {
+			gaps = append(gaps, version)
+		}
+	}
+	return gaps, nil
+}
+
+// sanitizePushText applies sanitizeText to the annotations of a push using the contract settings, and checks
+// the environment against the AllowedEnvironments setting.
+func sanitizePushText(config *ContractConfig, pushMessage, approvedBy, environment string) (string, string, string, error) {
+	validation := newValidationError(config)
+	pushMessage, fieldError := sanitizeText("Message", "push message", pushMessage, true, config.StripControlCharacters)
+	validation.add(fieldError)
+	approvedBy, fieldError = sanitizeText("ApprovedBy", "approver", approvedBy, false, config.StripControlCharacters)
+	validation.add(fieldError)
+	environment, fieldError = sanitizeText("Environment", "environment", environment, false, config.StripControlCharacters)
+	validation.add(fieldError)
+	if fieldError == nil && environment != "" && len(config.AllowedEnvironments) > 0 {
+		allowed := false
+		for _, allowedEnvironment := range config.AllowedEnvironments {
+			allowed = allowed || environment == allowedEnvironment
+		}
+		if !allowed {
+			validation.add(&FieldError{Field: "Environment", Rule: "allowedEnvironment", Message: fmt.Sprintf("the environment %s is not one of %s", environment, strings.Join(config.AllowedEnvironments, ", ")), Value: environment})
+		}
+	}
+	err := validation.errorOrNil()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return pushMessage, approvedBy, environment, nil
+}
+
+// checkApprovedBy returns an error when a repository has access grants and approvedBy is set to anything but
+// the organization of the submitting client, so that nobody can record an approval on behalf of another.
+func (s *SmartContract) checkApprovedBy(ctx contractapi.TransactionContextInterface, repository, approvedBy string) error {
+	if approvedBy == "" {
+		return nil
+	}
+	mspIDs, err := s.GetRepositoryAccess(ctx, repository)
+	if err != nil {
+		return err
+	}
+	if len(mspIDs) == 0 {
+		return nil
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get MSPID: %v", err)
+	}
+	if approvedBy != clientMSPID {
+		return fmt.Errorf("client from org %v cannot record an approval by %s", clientMSPID, approvedBy)
+	}
+	return nil
+}
+
+// renderPushMessage executes the PushMessageTemplate setting with a recorded push.
+func renderPushMessage(config *ContractConfig, pushTx *PushTransaction) (string, error) {
+	text := config.PushMessageTemplate
+	if text == "" {
+		text = defaultPushMessageTemplate
+	}
+	pushTemplate, err := template.New("push").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse PushMessageTemplate: %v", err)
+	}
+
+	var message strings.Builder
+	err = pushTemplate.Execute(&message, pushTx)
+	if err != nil {
+		return "", fmt.Errorf("failed to render push message: %v", err)
+	}
+	return message.String(), nil
+}
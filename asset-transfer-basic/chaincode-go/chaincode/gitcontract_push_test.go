@@ -0,0 +1,321 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func pushVersions(pushTransactions []*chaincode.PushTransaction) []int {
+	versions := []int{}
+	for _, pushTx := range pushTransactions {
+		versions = append(versions, pushTx.Version)
+	}
+	return versions
+}
+
+func TestPushTransactionsAreVersionOrdered(t *testing.T) {
+	transactionContext, chaincodeStub, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1_other", "Unrelated", "Bob"))
+	for i := 0; i < 11; i++ {
+		chaincodeStub.GetTxIDReturns(fmt.Sprintf("tx%d", i))
+		_, err := gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+		require.NoError(t, err)
+	}
+	_, err := gitContract.HandleGitPush(transactionContext, "repo1_other", "https://example.com/repo1_other.git", "hash2")
+	require.NoError(t, err)
+
+	require.Contains(t, world.state, "PUSH_repo1_0000000012_tx10")
+
+	pushTransactions, err := gitContract.GetPushTransactionsByRepository(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, pushVersions(pushTransactions))
+	require.Equal(t, "tx10", pushTransactions[10].TxID)
+
+	pushTransactions, err = gitContract.GetAllPushTransactions(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 2}, pushVersions(pushTransactions))
+
+	pushTransactions, err = gitContract.GetPushTransactionsByRepository(transactionContext, "repo2")
+	require.NoError(t, err)
+	require.Empty(t, pushTransactions)
+}
+
+func TestMigratePushTransactionKeys(t *testing.T) {
+	transactionContext, _, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	legacyPushes := map[string]chaincode.PushTransaction{
+		"PUSH_repo1_2023-06-01T12:00:00Z": {Repository: "repo1", Version: 10, CommitHash: "hash2"},
+		"PUSH_repo1_2023-05-01T12:00:00Z": {Repository: "repo1", Version: 9, CommitHash: "hash1"},
+	}
+	for key, pushTx := range legacyPushes {
+		pushTxJSON, err := json.Marshal(pushTx)
+		require.NoError(t, err)
+		world.state[key] = pushTxJSON
+	}
+
+	migrated, err := gitContract.MigratePushTransactionKeys(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, 2, migrated)
+	require.NotContains(t, world.state, "PUSH_repo1_2023-06-01T12:00:00Z")
+	require.Contains(t, world.state, "PUSH_repo1_0000000010_2023-06-01T12:00:00Z")
+	require.Contains(t, world.state, "PUSH_repo1_0000000009_2023-05-01T12:00:00Z")
+
+	pushTransactions, err := gitContract.GetPushTransactionsByRepository(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []int{9, 10}, pushVersions(pushTransactions))
+
+	migrated, err = gitContract.MigratePushTransactionKeys(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, 0, migrated)
+
+	setClientMSPID(transactionContext, "Org2MSP")
+	_, err = gitContract.MigratePushTransactionKeys(transactionContext)
+	require.EqualError(t, err, "client from org Org2MSP is not authorized to perform this operation")
+}
+
+func TestGetCommitsByRemote(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo2", "Unrelated", "Carol"))
+
+	pushes := []struct{ repository, remoteURL, commitHash string }{
+		{"repo1", "https://example.com/repo1.git", "hash1"},
+		{"repo1", "https://mirror.example.com/repo1", "hash1"},
+		{"repo1", "HTTPS://Example.com/repo1/", "hash2"},
+		{"repo1", "https://example.com/repo1", "hash2"},
+		{"repo2", "https://mirror.example.com/repo1.git", "hash3"},
+	}
+	for i, push := range pushes {
+		chaincodeStub.GetTxIDReturns(fmt.Sprintf("tx%d", i))
+		_, err := gitContract.HandleGitPush(transactionContext, push.repository, push.remoteURL, push.commitHash)
+		require.NoError(t, err)
+	}
+
+	gitCommits, err := gitContract.GetCommitsByRemote(transactionContext, "https://example.com/repo1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1", "hash2"}, commitHashes(gitCommits))
+
+	gitCommits, err = gitContract.GetCommitsByRemote(transactionContext, " https://MIRROR.example.com/repo1.git/ ")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1", "hash3"}, commitHashes(gitCommits))
+
+	gitCommits, err = gitContract.GetCommitsByRemote(transactionContext, "https://example.com/repo2")
+	require.NoError(t, err)
+	require.Empty(t, gitCommits)
+}
+
+func TestHandleAnnotatedGitPush(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	chaincodeStub.GetTxIDReturns("tx1")
+	message, err := gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.NoError(t, err)
+	require.Equal(t, "All testing is done and audit processes approved, move to build stage. Git clone link: https://example.com/repo1.git", message)
+
+	chaincodeStub.GetTxIDReturns("tx2")
+	message, err = gitContract.HandleAnnotatedGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1", "deploy to staging", "Org1MSP")
+	require.NoError(t, err)
+	require.Equal(t, "Approved by Org1MSP, move to build stage: deploy to staging. Git clone link: https://example.com/repo1.git", message)
+
+	pushTransactions, err := gitContract.GetPushTransactionsByRepository(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Len(t, pushTransactions, 2)
+	require.Empty(t, pushTransactions[0].Message)
+	require.Empty(t, pushTransactions[0].ApprovedBy)
+	require.Equal(t, "deploy to staging", pushTransactions[1].Message)
+	require.Equal(t, "Org1MSP", pushTransactions[1].ApprovedBy)
+
+	// Without access grants any approver may be recorded
+	chaincodeStub.GetTxIDReturns("tx3")
+	_, err = gitContract.HandleAnnotatedGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1", "", "Org2MSP")
+	require.NoError(t, err)
+
+	_, err = gitContract.HandleAnnotatedGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1", "deploy\x07", "")
+	require.EqualError(t, err, "the push message contains control character U+0007 at offset 6")
+}
+
+func TestHandleAnnotatedGitPushChecksApprover(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.GrantRepositoryAccess(transactionContext, "repo1", adminMSPID))
+
+	_, err := gitContract.HandleAnnotatedGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1", "", "Org2MSP")
+	require.EqualError(t, err, "client from org Org1MSP cannot record an approval by Org2MSP")
+
+	chaincodeStub.GetTxIDReturns("tx1")
+	_, err = gitContract.HandleAnnotatedGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1", "", adminMSPID)
+	require.NoError(t, err)
+}
+
+func TestPushMessageTemplate(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	err := gitContract.SetContractConfig(transactionContext, `{"PushMessageTemplate": "{{if .Version}"}`)
+	require.ErrorContains(t, err, "failed to parse PushMessageTemplate")
+
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"PushMessageTemplate": "Build {{.Repository}} v{{.Version}} at {{.CommitHash}}{{with .ApprovedBy}}, approved by {{.}}{{end}}"}`))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	message, err := gitContract.HandleAnnotatedGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1", "", "Org1MSP")
+	require.NoError(t, err)
+	require.Equal(t, "Build repo1 v2 at hash1, approved by Org1MSP", message)
+}
+
+func TestGetPushesByEnvironment(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo2", "Initial commit", "Carol"))
+	for i, push := range []struct{ repository, commitHash, environment string }{
+		{"repo1", "hash1", "staging"},
+		{"repo1", "hash1", "prod"},
+		{"repo1", "hash2", "staging"},
+		{"repo1", "hash2", ""},
+		{"repo2", "hash3", "prod"},
+	} {
+		chaincodeStub.GetTxIDReturns(fmt.Sprintf("tx%d", i))
+		_, err := gitContract.HandleDeploymentPush(transactionContext, push.repository, "https://example.com/"+push.repository+".git", push.commitHash, push.environment, "", "")
+		require.NoError(t, err)
+	}
+
+	deployments, err := gitContract.GetPushesByEnvironment(transactionContext, "repo1", "staging")
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 4}, pushVersions(deployments))
+	require.Equal(t, "hash2", deployments[1].CommitHash)
+
+	deployments, err = gitContract.GetPushesByEnvironment(transactionContext, "repo1", "prod")
+	require.NoError(t, err)
+	require.Len(t, deployments, 1)
+	require.Equal(t, "hash1", deployments[0].CommitHash)
+	require.Equal(t, "prod", deployments[0].Environment)
+
+	deployments, err = gitContract.GetPushesByEnvironment(transactionContext, "repo1", "dev")
+	require.NoError(t, err)
+	require.Empty(t, deployments)
+
+	_, err = gitContract.GetPushesByEnvironment(transactionContext, "repo1", "")
+	require.EqualError(t, err, "the environment must not be empty")
+}
+
+func TestAllowedEnvironments(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+
+	// Any environment is accepted until an allowlist is configured
+	chaincodeStub.GetTxIDReturns("tx1")
+	_, err := gitContract.HandleDeploymentPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1", "qa-box-7", "", "")
+	require.NoError(t, err)
+
+	err = gitContract.SetContractConfig(transactionContext, `{"AllowedEnvironments": ["dev", ""]}`)
+	require.EqualError(t, err, "AllowedEnvironments must not contain an empty environment")
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"AllowedEnvironments": ["dev", "staging", "prod"]}`))
+
+	_, err = gitContract.HandleDeploymentPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1", "qa-box-7", "", "")
+	require.EqualError(t, err, "the environment qa-box-7 is not one of dev, staging, prod")
+
+	chaincodeStub.GetTxIDReturns("tx2")
+	_, err = gitContract.HandleDeploymentPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1", "prod", "release 1.0", "")
+	require.NoError(t, err)
+	deployments, err := gitContract.GetPushesByEnvironment(transactionContext, "repo1", "prod")
+	require.NoError(t, err)
+	require.Len(t, deployments, 1)
+	require.Equal(t, "release 1.0", deployments[0].Message)
+}
+
+func TestGetCurrentDeployment(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	for _, commitHash := range []string{"hash1", "hash2", "hash3"} {
+		require.NoError(t, gitContract.CreateGitCommit(transactionContext, commitHash, "repo1", "Change", "Alice"))
+	}
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash4", "repo2", "Initial commit", "Bob"))
+	for i, push := range []struct{ repository, commitHash, environment string }{
+		{"repo1", "hash1", "staging"},
+		{"repo1", "hash1", "prod"},
+		{"repo1", "hash2", "staging"},
+		{"repo1", "hash3", "staging"},
+		{"repo1", "hash2", "prod"},
+		{"repo2", "hash4", "prod"},
+	} {
+		chaincodeStub.GetTxIDReturns(fmt.Sprintf("tx%d", i))
+		_, err := gitContract.HandleDeploymentPush(transactionContext, push.repository, "https://example.com/"+push.repository+".git", push.commitHash, push.environment, "", "")
+		require.NoError(t, err)
+	}
+
+	current, err := gitContract.GetCurrentDeployment(transactionContext, "repo1", "prod")
+	require.NoError(t, err)
+	require.Equal(t, "hash2", current.CommitHash)
+	require.Equal(t, 6, current.Version)
+
+	current, err = gitContract.GetCurrentDeployment(transactionContext, "repo1", "staging")
+	require.NoError(t, err)
+	require.Equal(t, "hash3", current.CommitHash)
+
+	current, err = gitContract.GetCurrentDeployment(transactionContext, "repo2", "prod")
+	require.NoError(t, err)
+	require.Equal(t, "hash4", current.CommitHash)
+
+	_, err = gitContract.GetCurrentDeployment(transactionContext, "repo2", "staging")
+	require.EqualError(t, err, "the repository repo2 has not been deployed to staging")
+}
+
+func TestGetVersionGaps(t *testing.T) {
+	transactionContext, _, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	gaps, err := gitContract.GetVersionGaps(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Empty(t, gaps)
+
+	for _, version := range []int{1, 2, 4} {
+		pushTxJSON, err := json.Marshal(chaincode.PushTransaction{Repository: "repo1", Version: version, CommitHash: "hash1"})
+		require.NoError(t, err)
+		world.state[fmt.Sprintf("PUSH_repo1_%010d_tx%d", version, version)] = pushTxJSON
+	}
+	for i := 0; i < 3; i++ {
+		_, err = gitContract.IncrementVersionNumber(transactionContext, "repo1")
+		require.NoError(t, err)
+	}
+	gaps, err = gitContract.GetVersionGaps(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []int{3}, gaps)
+
+	// A version taken without a push is a gap as well
+	_, err = gitContract.IncrementVersionNumber(transactionContext, "repo1")
+	require.NoError(t, err)
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.NoError(t, err)
+	gaps, err = gitContract.GetVersionGaps(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []int{3, 5}, gaps)
+
+	// A fork is checked from its first push
+	require.NoError(t, gitContract.ForkRepository(transactionContext, "repo1", "fork1"))
+	gaps, err = gitContract.GetVersionGaps(transactionContext, "fork1")
+	require.NoError(t, err)
+	require.Empty(t, gaps)
+
+	_, err = gitContract.GetVersionGaps(transactionContext, "repo2")
+	require.EqualError(t, err, "the repository repo2 does not have a version number")
+}
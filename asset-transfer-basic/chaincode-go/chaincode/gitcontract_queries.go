@@ -0,0 +1,354 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+const (
+	versionKeyPrefix = "VERSION_"
+	pushKeyPrefix    = "PUSH_"
+)
+
+// isCommitKey reports whether a plain world state key holds a GitCommit rather than a version, push, blob or
+// snapshot record.
+func isCommitKey(key string) bool {
+	return !strings.HasPrefix(key, versionKeyPrefix) && !strings.HasPrefix(key, pushKeyPrefix) && !strings.HasPrefix(key, blobKeyPrefix) &&
+		!strings.HasPrefix(key, snapshotKeyPrefix)
+}
+
+// forEachGitCommit calls fn with every GitCommit in the world state, in key order, and stops at the first error.
+func (s *SmartContract) forEachGitCommit(ctx contractapi.TransactionContextInterface, config *ContractConfig, fn func(*GitCommit) error) error {
+	return s.forEachRepositoryCommit(ctx, config, "", fn)
+}
+
+// forEachRepositoryCommit is forEachGitCommit limited to one repository, or to none when repository is empty.
+// Repository scoped commits are read from the repository's key range; otherwise every commit is scanned.
+func (s *SmartContract) forEachRepositoryCommit(ctx contractapi.TransactionContextInterface, config *ContractConfig, repository string, fn func(*GitCommit) error) error {
+	var resultsIterator shim.StateQueryIteratorInterface
+	var err error
+	if config.RepositoryScopedCommits {
+		attributes := []string{}
+		if repository != "" {
+			attributes = append(attributes, repository)
+		}
+		resultsIterator, err = ctx.GetStub().GetStateByPartialCompositeKey(scopedCommitObjectType, attributes)
+	} else {
+		resultsIterator, err = ctx.GetStub().GetStateByRange("", "")
+	}
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+		if !config.RepositoryScopedCommits && !isCommitKey(queryResponse.Key) {
+			continue
+		}
+
+		var gitCommit GitCommit
+		err = json.Unmarshal(queryResponse.Value, &gitCommit)
+		if err != nil {
+			return err
+		}
+		if repository != "" && gitCommit.Repository != repository {
+			continue
+		}
+		err = s.resolveMessageBlob(ctx, &gitCommit)
+		if err != nil {
+			return err
+		}
+		err = fn(&gitCommit)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getGitCommits returns the GitCommits accepted by match, sorted by commitTime.
+func (s *SmartContract) getGitCommits(ctx contractapi.TransactionContextInterface, match func(*GitCommit) bool) ([]*GitCommit, error) {
+	return s.collectGitCommits(ctx, match, false)
+}
+
+// listGitCommits is getGitCommits for the unpaginated list queries: it fails once more commits match than
+// the MaxQueryResults setting allows. Transactions that work through every commit use getGitCommits instead,
+// so that they keep working on repositories past the cap.
+func (s *SmartContract) listGitCommits(ctx contractapi.TransactionContextInterface, match func(*GitCommit) bool) ([]*GitCommit, error) {
+	return s.collectGitCommits(ctx, match, true)
+}
+
+func (s *SmartContract) collectGitCommits(ctx contractapi.TransactionContextInterface, match func(*GitCommit) bool, capped bool) ([]*GitCommit, error) {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	gitCommits := []*GitCommit{}
+	err = s.forEachGitCommit(ctx, config, func(gitCommit *GitCommit) error {
+		if !match(gitCommit) {
+			return nil
+		}
+		gitCommits = append(gitCommits, gitCommit)
+		if capped {
+			return checkResultLimit(config, len(gitCommits))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortGitCommits(gitCommits)
+	return gitCommits, nil
+}
+
+// getRepositoryCommits returns the GitCommits of a repository sorted by timestamp.
+func (s *SmartContract) getRepositoryCommits(ctx contractapi.TransactionContextInterface, repository string) ([]*GitCommit, error) {
+	return s.collectRepositoryCommits(ctx, repository, false)
+}
+
+// listRepositoryCommits is getRepositoryCommits for the unpaginated list queries, capped like listGitCommits.
+func (s *SmartContract) listRepositoryCommits(ctx contractapi.TransactionContextInterface, repository string) ([]*GitCommit, error) {
+	return s.collectRepositoryCommits(ctx, repository, true)
+}
+
+func (s *SmartContract) collectRepositoryCommits(ctx contractapi.TransactionContextInterface, repository string, capped bool) ([]*GitCommit, error) {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	gitCommits := []*GitCommit{}
+	err = s.forEachRepositoryCommit(ctx, config, repository, func(gitCommit *GitCommit) error {
+		gitCommits = append(gitCommits, gitCommit)
+		if capped {
+			return checkResultLimit(config, len(gitCommits))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortGitCommits(gitCommits)
+	return gitCommits, nil
+}
+
+// QueryCommitsByRepository returns the commits of a repository sorted by commit time. Once commits are
+// repository scoped it reads only the repository's key range, which is much cheaper than listing every commit
+// with GetAllGitCommits; before that it still has to scan all commits.
+func (s *SmartContract) QueryCommitsByRepository(ctx contractapi.TransactionContextInterface, repository string) ([]*GitCommit, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	if repository == "" {
+		return nil, fmt.Errorf("the repository name must not be empty")
+	}
+	return s.listRepositoryCommits(ctx, repository)
+}
+
+// GetCommitsByRepository returns the commits of a repository sorted by commit time, leaving out the version
+// and push records kept alongside them. It is QueryCommitsByRepository under the name clients ask for.
+func (s *SmartContract) GetCommitsByRepository(ctx contractapi.TransactionContextInterface, repository string) ([]*GitCommit, error) {
+	return s.QueryCommitsByRepository(ctx, repository)
+}
+
+// getRepositoryPushTransactions returns the push transactions recorded for a repository.
+func (s *SmartContract) getRepositoryPushTransactions(ctx contractapi.TransactionContextInterface, repository string) ([]*PushTransaction, error) {
+	prefix := fmt.Sprintf("%s%s_", pushKeyPrefix, repository)
+	resultsIterator, err := ctx.GetStub().GetStateByRange(prefix, prefix+"~")
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var pushTransactions []*PushTransaction
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var pushTx PushTransaction
+		err = json.Unmarshal(queryResponse.Value, &pushTx)
+		if err != nil {
+			return nil, err
+		}
+		// The prefix of "repo" also matches pushes of "repo_other", so filter on the record itself
+		if pushTx.Repository == repository {
+			pushTransactions = append(pushTransactions, &pushTx)
+		}
+	}
+
+	return pushTransactions, nil
+}
+
+// GetUnpushedCommits returns the commits of a repository that have never been part of a push.
+func (s *SmartContract) GetUnpushedCommits(ctx contractapi.TransactionContextInterface, repository string) ([]*GitCommit, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	pushTransactions, err := s.getRepositoryPushTransactions(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	pushed := make(map[string]bool)
+	for _, pushTx := range pushTransactions {
+		pushed[pushTx.CommitHash] = true
+	}
+
+	gitCommits, err := s.listRepositoryCommits(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	unpushed := []*GitCommit{}
+	for _, gitCommit := range gitCommits {
+		if !pushed[gitCommit.CommitHash] {
+			unpushed = append(unpushed, gitCommit)
+		}
+	}
+
+	return unpushed, nil
+}
+
+// GetCommitAtTime returns the latest commit of a repository whose commit time is at or before the given
+// RFC3339 time, i.e. the head of the repository as of that moment.
+func (s *SmartContract) GetCommitAtTime(ctx contractapi.TransactionContextInterface, repository string, at string) (*GitCommit, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	atTime, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time %q, must be RFC3339: %v", at, err)
+	}
+
+	gitCommits, err := s.getRepositoryCommits(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	var head *GitCommit
+	for _, gitCommit := range gitCommits {
+		if commitTime(gitCommit).After(atTime) {
+			break
+		}
+		head = gitCommit
+	}
+	if head == nil {
+		return nil, fmt.Errorf("the repository %s has no commit at or before %s", repository, at)
+	}
+
+	return head, nil
+}
+
+// PaginatedGitCommits holds one page of GitCommits and the bookmark of the next page
+type PaginatedGitCommits struct {
+	Records             []*GitCommit `json:"records"`
+	FetchedRecordsCount int32        `json:"fetchedRecordsCount"`
+	Bookmark            string       `json:"bookmark"`
+}
+
+// PaginatedPushTransactions holds one page of PushTransactions and the bookmark of the next page
+type PaginatedPushTransactions struct {
+	Records             []*PushTransaction `json:"records"`
+	FetchedRecordsCount int32              `json:"fetchedRecordsCount"`
+	Bookmark            string             `json:"bookmark"`
+}
+
+// GetAllGitCommitsWithPagination returns one page of GitCommits in key order, starting at bookmark.
+// Version and push records share the key range, so a page may hold fewer commits than were fetched.
+// Paginated range queries are only valid for read only transactions.
+func (s *SmartContract) GetAllGitCommitsWithPagination(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*PaginatedGitCommits, error) {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var resultsIterator shim.StateQueryIteratorInterface
+	var responseMetadata *peer.QueryResponseMetadata
+	if config.RepositoryScopedCommits {
+		resultsIterator, responseMetadata, err = ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(scopedCommitObjectType, []string{}, int32(pageSize), bookmark)
+	} else {
+		resultsIterator, responseMetadata, err = ctx.GetStub().GetStateByRangeWithPagination("", "", int32(pageSize), bookmark)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	gitCommits := []*GitCommit{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if !config.RepositoryScopedCommits && !isCommitKey(queryResponse.Key) {
+			continue
+		}
+
+		var gitCommit GitCommit
+		err = json.Unmarshal(queryResponse.Value, &gitCommit)
+		if err != nil {
+			return nil, err
+		}
+		err = s.resolveMessageBlob(ctx, &gitCommit)
+		if err != nil {
+			return nil, err
+		}
+		gitCommits = append(gitCommits, &gitCommit)
+	}
+
+	return &PaginatedGitCommits{
+		Records:             gitCommits,
+		FetchedRecordsCount: responseMetadata.FetchedRecordsCount,
+		Bookmark:            responseMetadata.Bookmark,
+	}, nil
+}
+
+// GetAllPushTransactionsWithPagination returns one page of PushTransactions in key order, starting at bookmark.
+// Paginated range queries are only valid for read only transactions.
+func (s *SmartContract) GetAllPushTransactionsWithPagination(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*PaginatedPushTransactions, error) {
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetStateByRangeWithPagination(pushKeyPrefix, pushKeyPrefix+"~", int32(pageSize), bookmark)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	pushTransactions := []*PushTransaction{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var pushTx PushTransaction
+		err = json.Unmarshal(queryResponse.Value, &pushTx)
+		if err != nil {
+			return nil, err
+		}
+		pushTransactions = append(pushTransactions, &pushTx)
+	}
+
+	return &PaginatedPushTransactions{
+		Records:             pushTransactions,
+		FetchedRecordsCount: responseMetadata.FetchedRecordsCount,
+		Bookmark:            responseMetadata.Bookmark,
+	}, nil
+}
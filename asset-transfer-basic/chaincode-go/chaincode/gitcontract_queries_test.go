@@ -0,0 +1,236 @@
+package chaincode_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func commitHashes(gitCommits []*chaincode.GitCommit) []string {
+	hashes := []string{}
+	for _, gitCommit := range gitCommits {
+		hashes = append(hashes, gitCommit.CommitHash)
+	}
+	return hashes
+}
+
+func TestGetUnpushedCommits(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo1_other", "Unrelated", "Carol"))
+
+	unpushed, err := gitContract.GetUnpushedCommits(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"hash1", "hash2"}, commitHashes(unpushed))
+
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.NoError(t, err)
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1_other", "https://example.com/repo1_other.git", "hash3")
+	require.NoError(t, err)
+
+	unpushed, err = gitContract.GetUnpushedCommits(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash2"}, commitHashes(unpushed))
+
+	unpushed, err = gitContract.GetUnpushedCommits(transactionContext, "repo1_other")
+	require.NoError(t, err)
+	require.Empty(t, unpushed)
+}
+
+func TestGetAllGitCommitsSkipsOtherRecords(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"MessageBlobMinSize": 20}`))
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo2", "Added a feature with a long message", "Bob"))
+	// Seed version, push, blob and snapshot records next to the commits
+	_, err := gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.NoError(t, err)
+	_, err = gitContract.CreateRepositorySnapshot(transactionContext, "repo1", "before-release")
+	require.NoError(t, err)
+
+	gitCommits, err := gitContract.GetAllGitCommits(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1", "hash2"}, commitHashes(gitCommits))
+	require.Equal(t, "Added a feature with a long message", gitCommits[1].CommitMessage)
+}
+
+func TestListQueriesEnforceMaxQueryResults(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"MaxQueryResults": 2}`))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+
+	_, err := gitContract.GetAllGitCommits(transactionContext)
+	require.NoError(t, err)
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo2", "Added feature", "Bob"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo3", "Fixed bug", "Carol"))
+	_, err = gitContract.GetAllGitCommits(transactionContext)
+	require.EqualError(t, err, "result set too large (more than 2 records), use pagination")
+
+	for i, repository := range []string{"repo1", "repo2", "repo3"} {
+		_, err = gitContract.HandleGitPush(transactionContext, repository, "https://example.com/"+repository+".git", fmt.Sprintf("hash%d", i+1))
+		require.NoError(t, err)
+	}
+	_, err = gitContract.GetAllPushTransactions(transactionContext)
+	require.EqualError(t, err, "result set too large (more than 2 records), use pagination")
+
+	page, err := gitContract.GetAllPushTransactionsWithPagination(transactionContext, 2, "")
+	require.NoError(t, err)
+	require.Len(t, page.Records, 2)
+	require.NotEmpty(t, page.Bookmark)
+	page, err = gitContract.GetAllPushTransactionsWithPagination(transactionContext, 2, page.Bookmark)
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1)
+	require.Empty(t, page.Bookmark)
+
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"MaxQueryResults": 0}`))
+	pushTransactions, err := gitContract.GetAllPushTransactions(transactionContext)
+	require.NoError(t, err)
+	require.Len(t, pushTransactions, 3)
+}
+
+func TestMaxQueryResultsLeavesTransactionsAlone(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"MaxQueryResults": 2}`))
+	for i, commitHash := range []string{"hash1", "hash2", "hash3", "hash4"} {
+		setTxTime(chaincodeStub, start.Add(time.Duration(i+1)*time.Hour))
+		require.NoError(t, gitContract.CreateGitCommit(transactionContext, commitHash, "repo1", "Change "+commitHash, "Alice"))
+	}
+	_, err := gitContract.QueryCommitsByRepository(transactionContext, "repo1")
+	require.EqualError(t, err, "result set too large (more than 2 records), use pagination")
+
+	// Transactions that work through the whole repository are not list queries and are not capped
+	references, err := gitContract.GetCommitReferences(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Empty(t, references)
+	report, err := gitContract.ValidateRepositoryDAG(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.True(t, report.Valid)
+	audit, err := gitContract.PruneCommitsBefore(transactionContext, "repo1", start.Add(24*time.Hour).Format(time.RFC3339), 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1", "hash2"}, audit.Pruned)
+
+	gitCommits, err := gitContract.QueryCommitsByRepository(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash3", "hash4"}, commitHashes(gitCommits))
+}
+
+func TestGetAllGitCommitsWithPagination(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo1", "Fixed bug", "Carol"))
+
+	var gitCommits []*chaincode.GitCommit
+	bookmark := ""
+	for {
+		page, err := gitContract.GetAllGitCommitsWithPagination(transactionContext, 2, bookmark)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page.Records), 2)
+		gitCommits = append(gitCommits, page.Records...)
+		if page.Bookmark == "" {
+			break
+		}
+		bookmark = page.Bookmark
+	}
+	require.Equal(t, []string{"hash1", "hash2", "hash3"}, commitHashes(gitCommits))
+}
+
+func TestGetCommitAtTime(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo2", "Unrelated", "Bob"))
+
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	commitTime, err := time.Parse(time.RFC3339, gitCommit.Timestamp)
+	require.NoError(t, err)
+
+	// A commit made exactly at the queried time is part of the state as of that time
+	head, err := gitContract.GetCommitAtTime(transactionContext, "repo1", gitCommit.Timestamp)
+	require.NoError(t, err)
+	require.Equal(t, "hash1", head.CommitHash)
+
+	head, err = gitContract.GetCommitAtTime(transactionContext, "repo1", commitTime.Add(time.Hour).Format(time.RFC3339))
+	require.NoError(t, err)
+	require.Equal(t, "hash1", head.CommitHash)
+
+	before := commitTime.Add(-time.Second).Format(time.RFC3339)
+	_, err = gitContract.GetCommitAtTime(transactionContext, "repo1", before)
+	require.EqualError(t, err, "the repository repo1 has no commit at or before "+before)
+
+	_, err = gitContract.GetCommitAtTime(transactionContext, "repo3", gitCommit.Timestamp)
+	require.EqualError(t, err, "the repository repo3 has no commit at or before "+gitCommit.Timestamp)
+
+	_, err = gitContract.GetCommitAtTime(transactionContext, "repo1", "yesterday")
+	require.ErrorContains(t, err, `invalid time "yesterday", must be RFC3339`)
+}
+
+func TestQueryCommitsByRepository(t *testing.T) {
+	for _, scoped := range []bool{false, true} {
+		transactionContext, chaincodeStub, _ := prepWorldState()
+		gitContract := chaincode.SmartContract{}
+		if scoped {
+			_, err := gitContract.MigrateToRepositoryScopedCommits(transactionContext)
+			require.NoError(t, err)
+		}
+		start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		setTxTime(chaincodeStub, start.Add(time.Hour))
+		require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+		setTxTime(chaincodeStub, start)
+		require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+		require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo10", "Initial commit", "Carol"))
+
+		gitCommits, err := gitContract.QueryCommitsByRepository(transactionContext, "repo1")
+		require.NoError(t, err)
+		require.Equal(t, []string{"hash1", "hash2"}, commitHashes(gitCommits), "scoped: %v", scoped)
+
+		gitCommits, err = gitContract.QueryCommitsByRepository(transactionContext, "repo2")
+		require.NoError(t, err)
+		require.Empty(t, gitCommits)
+
+		_, err = gitContract.QueryCommitsByRepository(transactionContext, "")
+		require.EqualError(t, err, "the repository name must not be empty")
+	}
+}
+
+func TestGetCommitsByRepository(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	setTxTime(chaincodeStub, start.Add(time.Hour))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+	setTxTime(chaincodeStub, start)
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo2", "Initial commit", "Carol"))
+	_, err := gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash2")
+	require.NoError(t, err)
+
+	// The version and push records of the repository are skipped
+	gitCommits, err := gitContract.GetCommitsByRepository(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1", "hash2"}, commitHashes(gitCommits))
+
+	gitCommits, err = gitContract.GetCommitsByRepository(transactionContext, "repo3")
+	require.NoError(t, err)
+	require.Empty(t, gitCommits)
+}
@@ -0,0 +1,595 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// checkRepositoryNotArchived returns an error when the repository has been archived.
+// Repositories without a version record are not archived.
+func (s *SmartContract) checkRepositoryNotArchived(ctx contractapi.TransactionContextInterface, repository string) error {
+	repoVersionJSON, err := ctx.GetStub().GetState(versionKeyPrefix + repository)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if repoVersionJSON == nil {
+		return nil
+	}
+
+	var repoVersion RepositoryVersion
+	err = json.Unmarshal(repoVersionJSON, &repoVersion)
+	if err != nil {
+		return err
+	}
+	if repoVersion.Archived {
+		return fmt.Errorf("the repository %s is archived", repository)
+	}
+
+	return nil
+}
+
+// ArchiveRepository marks a repository as archived. Archived repositories reject new commits and pushes,
+// while their history stays readable. Like unarchiving, archiving is reserved to the admin organization.
+func (s *SmartContract) ArchiveRepository(ctx contractapi.TransactionContextInterface, repository string) error {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return err
+	}
+	err = s.requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	repoVersion, err := s.GetRepositoryVersion(ctx, repository)
+	if err != nil {
+		return err
+	}
+	if repoVersion.Archived {
+		return fmt.Errorf("the repository %s is already archived", repository)
+	}
+
+	repoVersion.Archived = true
+	return s.SetRepositoryVersion(ctx, repoVersion)
+}
+
+// UnarchiveRepository makes an archived repository writable again. Only the admin organization may unarchive.
+func (s *SmartContract) UnarchiveRepository(ctx contractapi.TransactionContextInterface, repository string) error {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return err
+	}
+	err = s.requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	repoVersion, err := s.GetRepositoryVersion(ctx, repository)
+	if err != nil {
+		return err
+	}
+	if !repoVersion.Archived {
+		return fmt.Errorf("the repository %s is not archived", repository)
+	}
+
+	repoVersion.Archived = false
+	return s.SetRepositoryVersion(ctx, repoVersion)
+}
+
+// ListRepositories returns the version record, including the archived status, of every repository.
+func (s *SmartContract) ListRepositories(ctx contractapi.TransactionContextInterface) ([]*RepositoryVersion, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByRange(versionKeyPrefix, versionKeyPrefix+"~")
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	repoVersions := []*RepositoryVersion{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var repoVersion RepositoryVersion
+		err = json.Unmarshal(queryResponse.Value, &repoVersion)
+		if err != nil {
+			return nil, err
+		}
+		repoVersions = append(repoVersions, &repoVersion)
+	}
+
+	return repoVersions, nil
+}
+
+const registeredRepositoryObjectType = "registeredRepository"
+
+// checkRepositoryRegistered returns an error when the contract only accepts registered repositories and
+// the repository was never registered.
+func (s *SmartContract) checkRepositoryRegistered(ctx contractapi.TransactionContextInterface, config *ContractConfig, repository string) error {
+	if !config.RequireRegisteredRepositories {
+		return nil
+	}
+
+	registrationKey, err := ctx.GetStub().CreateCompositeKey(registeredRepositoryObjectType, []string{repository})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	registration, err := ctx.GetStub().GetState(registrationKey)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if registration == nil {
+		return fmt.Errorf("the repository %s is not registered", repository)
+	}
+
+	return nil
+}
+
+// RegisterRepository adds a repository to the set that may receive commits and pushes when
+// RequireRegisteredRepositories is enabled, and creates its version record if it has none.
+// Only the admin organization may register repositories.
+func (s *SmartContract) RegisterRepository(ctx contractapi.TransactionContextInterface, repository string) error {
+	err := s.requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	if repository == "" {
+		return fmt.Errorf("the repository name must not be empty")
+	}
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return err
+	}
+	displayName := repositoryDisplayName(config, repository)
+	repository = canonicalRepositoryName(config, repository)
+	err = checkKeyParts(config, repository, "")
+	if err != nil {
+		return err
+	}
+
+	registrationKey, err := ctx.GetStub().CreateCompositeKey(registeredRepositoryObjectType, []string{repository})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	registration, err := ctx.GetStub().GetState(registrationKey)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if registration != nil {
+		return fmt.Errorf("the repository %s is already registered", repository)
+	}
+
+	repoVersionJSON, err := ctx.GetStub().GetState(versionKeyPrefix + repository)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if repoVersionJSON == nil {
+		err = s.SetRepositoryVersion(ctx, &RepositoryVersion{Repository: repository, VersionNumber: 1, DisplayName: displayName})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Composite key values cannot be empty, so store a single null byte
+	return ctx.GetStub().PutState(registrationKey, []byte{0x00})
+}
+
+// ListRegisteredRepositories returns the names of the registered repositories in alphabetical order.
+func (s *SmartContract) ListRegisteredRepositories(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(registeredRepositoryObjectType, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	repositories := []string{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		repositories = append(repositories, compositeKeyParts[0])
+	}
+	sort.Strings(repositories)
+
+	return repositories, nil
+}
+
+const forkIndexName = "fork~source~fork"
+
+// ForkRepository creates the repository fork as a fork of source. The fork starts at the version source has
+// reached and records ForkedFrom; its commits start out empty, since commits are stored per repository and
+// history before the fork stays readable in source. The fork must be new. When
+// RequireRegisteredRepositories is set, the fork still has to be registered before it accepts commits.
+func (s *SmartContract) ForkRepository(ctx contractapi.TransactionContextInterface, source string, fork string) error {
+	if fork == "" {
+		return fmt.Errorf("the repository name must not be empty")
+	}
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return err
+	}
+	displayName := repositoryDisplayName(config, fork)
+	source = canonicalRepositoryName(config, source)
+	fork = canonicalRepositoryName(config, fork)
+	err = checkKeyParts(config, fork, "")
+	if err != nil {
+		return err
+	}
+	if source == fork {
+		return fmt.Errorf("the repository %s cannot be a fork of itself", source)
+	}
+
+	sourceVersion, err := s.GetRepositoryVersion(ctx, source)
+	if err != nil {
+		return err
+	}
+	forkVersionJSON, err := ctx.GetStub().GetState(versionKeyPrefix + fork)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if forkVersionJSON != nil {
+		return fmt.Errorf("the repository %s already exists", fork)
+	}
+
+	err = s.SetRepositoryVersion(ctx, &RepositoryVersion{Repository: fork, VersionNumber: sourceVersion.VersionNumber, ForkedFrom: source, DisplayName: displayName})
+	if err != nil {
+		return err
+	}
+	indexKey, err := ctx.GetStub().CreateCompositeKey(forkIndexName, []string{source, fork})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	// Composite key values cannot be empty, so store a single null byte
+	return ctx.GetStub().PutState(indexKey, []byte{0x00})
+}
+
+// GetForks returns the version records of the direct forks of a repository in alphabetical order.
+func (s *SmartContract) GetForks(ctx contractapi.TransactionContextInterface, repository string) ([]*RepositoryVersion, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(forkIndexName, []string{repository})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	forks := []*RepositoryVersion{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		forkVersion, err := s.GetRepositoryVersion(ctx, compositeKeyParts[1])
+		if err != nil {
+			return nil, err
+		}
+		forks = append(forks, forkVersion)
+	}
+
+	return forks, nil
+}
+
+// VersionReconciliation compares the version counter of a repository with the versions its commits carry
+type VersionReconciliation struct {
+	Repository string `json:"Repository"`
+	// StoredVersion is the counter before any repair; zero when the repository has no version record
+	StoredVersion    int `json:"StoredVersion"`
+	MaxCommitVersion int `json:"MaxCommitVersion"`
+	// MaxVersionCommit is the hash of a commit carrying MaxCommitVersion
+	MaxVersionCommit string `json:"MaxVersionCommit,omitempty"`
+	// Drift is how far the counter is behind MaxCommitVersion; a counter ahead of every commit is normal
+	Drift    int  `json:"Drift"`
+	Repaired bool `json:"Repaired"`
+}
+
+// ReconcileRepositoryVersion scans the commits of a repository for the highest VersionNumber and reports
+// whether the stored version counter has fallen behind it, as after an interrupted import or a manual
+// SetRepositoryVersion. A counter behind its commits would hand out version numbers that are already taken.
+// With repair, the counter is raised to the highest commit version; only the admin organization may repair.
+func (s *SmartContract) ReconcileRepositoryVersion(ctx contractapi.TransactionContextInterface, repository string, repair bool) (*VersionReconciliation, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	if repair {
+		err := s.requireAdmin(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	repoVersionJSON, err := ctx.GetStub().GetState(versionKeyPrefix + repository)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	repoVersion := &RepositoryVersion{Repository: repository}
+	if repoVersionJSON != nil {
+		err = json.Unmarshal(repoVersionJSON, repoVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	gitCommits, err := s.getRepositoryCommits(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	if repoVersionJSON == nil && len(gitCommits) == 0 {
+		return nil, fmt.Errorf("the repository %s does not have a version number", repository)
+	}
+
+	reconciliation := &VersionReconciliation{Repository: repository, StoredVersion: repoVersion.VersionNumber}
+	for _, gitCommit := range gitCommits {
+		if gitCommit.VersionNumber > reconciliation.MaxCommitVersion {
+			reconciliation.MaxCommitVersion = gitCommit.VersionNumber
+			reconciliation.MaxVersionCommit = gitCommit.CommitHash
+		}
+	}
+	if reconciliation.MaxCommitVersion > reconciliation.StoredVersion {
+		reconciliation.Drift = reconciliation.MaxCommitVersion - reconciliation.StoredVersion
+	}
+
+	if repair && reconciliation.Drift > 0 {
+		repoVersion.VersionNumber = reconciliation.MaxCommitVersion
+		err = s.SetRepositoryVersion(ctx, repoVersion)
+		if err != nil {
+			return nil, err
+		}
+		reconciliation.Repaired = true
+	}
+
+	return reconciliation, nil
+}
+
+// canonicalRepositoryName returns the name a repository is stored and looked up under: the name without
+// surrounding whitespace, so that "repo1 " is not recorded as a second repository. With the
+// CanonicalRepositoryNames setting it is also lowercased and loses a trailing ".git".
+func canonicalRepositoryName(config *ContractConfig, repository string) string {
+	canonical := strings.TrimSpace(repository)
+	if !config.CanonicalRepositoryNames {
+		return canonical
+	}
+	canonical = strings.ToLower(canonical)
+	return strings.TrimSpace(strings.TrimSuffix(canonical, ".git"))
+}
+
+// repositoryDisplayName returns the spelling a repository was given, trimmed, when canonicalization changes
+// it beyond that, so that it can be kept next to the canonical name.
+func repositoryDisplayName(config *ContractConfig, repository string) string {
+	displayName := strings.TrimSpace(repository)
+	if displayName == canonicalRepositoryName(config, repository) {
+		return ""
+	}
+	return displayName
+}
+
+// canonicalRepository is canonicalRepositoryName for callers that have not loaded the contract settings.
+func (s *SmartContract) canonicalRepository(ctx contractapi.TransactionContextInterface, repository string) (string, error) {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+	return canonicalRepositoryName(config, repository), nil
+}
+
+// MergeRepositories moves the commits and pushes of the repository from into the repository into, to
+// consolidate a repository that was recorded under several spellings before CanonicalRepositoryNames was
+// switched on. from is taken as given, into is canonicalized. into keeps the higher of the two version
+// numbers and the version record of from is removed; access grants, registrations, forks, prune audits and
+// tombstones of from are left as they are. Only the admin organization may merge repositories.
+func (s *SmartContract) MergeRepositories(ctx contractapi.TransactionContextInterface, from string, into string) error {
+	err := s.requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return err
+	}
+	into = canonicalRepositoryName(config, into)
+	if into == "" {
+		return fmt.Errorf("the repository name must not be empty")
+	}
+	if from == into {
+		return fmt.Errorf("the repository %s cannot be merged into itself", from)
+	}
+
+	fromVersionJSON, err := ctx.GetStub().GetState(versionKeyPrefix + from)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if fromVersionJSON == nil {
+		return fmt.Errorf("the repository %s does not have a version number", from)
+	}
+	var fromVersion RepositoryVersion
+	err = json.Unmarshal(fromVersionJSON, &fromVersion)
+	if err != nil {
+		return err
+	}
+
+	gitCommits, err := s.getRepositoryCommits(ctx, from)
+	if err != nil {
+		return err
+	}
+	for _, gitCommit := range gitCommits {
+		err = s.moveGitCommit(ctx, config, gitCommit, into)
+		if err != nil {
+			return err
+		}
+	}
+	err = s.movePushTransactions(ctx, from, into)
+	if err != nil {
+		return err
+	}
+
+	intoVersionJSON, err := ctx.GetStub().GetState(versionKeyPrefix + into)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	intoVersion := fromVersion
+	intoVersion.Repository = into
+	if intoVersionJSON != nil {
+		intoVersion = RepositoryVersion{}
+		err = json.Unmarshal(intoVersionJSON, &intoVersion)
+		if err != nil {
+			return err
+		}
+		if fromVersion.VersionNumber > intoVersion.VersionNumber {
+			intoVersion.VersionNumber = fromVersion.VersionNumber
+		}
+	}
+	err = s.SetRepositoryVersion(ctx, &intoVersion)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().DelState(versionKeyPrefix + from)
+	if err != nil {
+		return fmt.Errorf("failed to delete state: %v", err)
+	}
+
+	return nil
+}
+
+// moveGitCommit stores a commit under another repository and rebuilds its index entries. When commits are
+// repository scoped the commit keeps its hash, which must not exist in the other repository yet.
+func (s *SmartContract) moveGitCommit(ctx contractapi.TransactionContextInterface, config *ContractConfig, gitCommit *GitCommit, repository string) error {
+	keys, err := s.commitIndexKeys(ctx, config, gitCommit)
+	if err != nil {
+		return err
+	}
+	if config.RepositoryScopedCommits {
+		targetKey, err := s.gitCommitKey(ctx, config, repository, gitCommit.CommitHash)
+		if err != nil {
+			return err
+		}
+		existingJSON, err := ctx.GetStub().GetState(targetKey)
+		if err != nil {
+			return fmt.Errorf("failed to read from world state: %v", err)
+		}
+		if existingJSON != nil {
+			return fmt.Errorf("the commit %s exists in both %s and %s", gitCommit.CommitHash, gitCommit.Repository, repository)
+		}
+		commitKey, err := s.gitCommitKey(ctx, config, gitCommit.Repository, gitCommit.CommitHash)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, commitKey)
+	}
+	for _, key := range keys {
+		err = ctx.GetStub().DelState(key)
+		if err != nil {
+			return fmt.Errorf("failed to delete state: %v", err)
+		}
+	}
+
+	gitCommit.Repository = repository
+	err = s.putGitCommit(ctx, gitCommit)
+	if err != nil {
+		return err
+	}
+	if config.RepositoryScopedCommits {
+		err = s.indexCommitRepository(ctx, gitCommit)
+		if err != nil {
+			return err
+		}
+	}
+	err = s.indexTrailers(ctx, gitCommit)
+	if err != nil {
+		return err
+	}
+	err = s.indexIssueRefs(ctx, gitCommit)
+	if err != nil {
+		return err
+	}
+	err = s.indexCommitType(ctx, config, gitCommit)
+	if err != nil {
+		return err
+	}
+	err = s.indexCommitMessage(ctx, gitCommit)
+	if err != nil {
+		return err
+	}
+	if gitCommit.SecurityLevel != "" {
+		securityKey, err := s.securityLevelIndexKey(ctx, gitCommit)
+		if err != nil {
+			return err
+		}
+		// Composite key values cannot be empty, so store a single null byte
+		err = ctx.GetStub().PutState(securityKey, []byte{0x00})
+		if err != nil {
+			return fmt.Errorf("failed to put to world state: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// movePushTransactions re-keys the push transactions of the repository from under the repository into,
+// keeping the version and transaction part of their keys.
+func (s *SmartContract) movePushTransactions(ctx contractapi.TransactionContextInterface, from string, into string) error {
+	prefix := fmt.Sprintf("%s%s_", pushKeyPrefix, from)
+	resultsIterator, err := ctx.GetStub().GetStateByRange(prefix, prefix+"~")
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	moved := map[string]*PushTransaction{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+
+		var pushTx PushTransaction
+		err = json.Unmarshal(queryResponse.Value, &pushTx)
+		if err != nil {
+			return err
+		}
+		// The prefix of "repo" also matches pushes of "repo_other", so filter on the record itself
+		if pushTx.Repository == from {
+			moved[queryResponse.Key] = &pushTx
+		}
+	}
+
+	keys := make([]string, 0, len(moved))
+	for key := range moved {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		pushTx := moved[key]
+		pushTx.Repository = into
+		pushTxJSON, err := json.Marshal(pushTx)
+		if err != nil {
+			return err
+		}
+		err = ctx.GetStub().PutState(fmt.Sprintf("%s%s_%s", pushKeyPrefix, into, strings.TrimPrefix(key, prefix)), pushTxJSON)
+		if err != nil {
+			return fmt.Errorf("failed to put to world state: %v", err)
+		}
+		err = ctx.GetStub().DelState(key)
+		if err != nil {
+			return fmt.Errorf("failed to delete state: %v", err)
+		}
+	}
+
+	return nil
+}
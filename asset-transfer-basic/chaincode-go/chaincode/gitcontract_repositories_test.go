@@ -0,0 +1,308 @@
+package chaincode_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveRepository(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	setClientMSPID(transactionContext, "Org2MSP")
+	err := gitContract.ArchiveRepository(transactionContext, "repo1")
+	require.EqualError(t, err, "client from org Org2MSP is not authorized to perform this operation")
+
+	setClientMSPID(transactionContext, adminMSPID)
+	require.NoError(t, gitContract.ArchiveRepository(transactionContext, "repo1"))
+	err = gitContract.ArchiveRepository(transactionContext, "repo1")
+	require.EqualError(t, err, "the repository repo1 is already archived")
+
+	err = gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob")
+	require.EqualError(t, err, "the repository repo1 is archived")
+
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.EqualError(t, err, "the repository repo1 is archived")
+
+	repoVersion, err := gitContract.GetRepositoryVersion(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, 1, repoVersion.VersionNumber)
+
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, "repo1", gitCommit.Repository)
+
+	repositories, err := gitContract.ListRepositories(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, []*chaincode.RepositoryVersion{{Repository: "repo1", VersionNumber: 1, Archived: true}}, repositories)
+
+	setClientMSPID(transactionContext, "Org2MSP")
+	err = gitContract.UnarchiveRepository(transactionContext, "repo1")
+	require.EqualError(t, err, "client from org Org2MSP is not authorized to perform this operation")
+
+	setClientMSPID(transactionContext, adminMSPID)
+	require.NoError(t, gitContract.UnarchiveRepository(transactionContext, "repo1"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+
+	err = gitContract.UnarchiveRepository(transactionContext, "repo1")
+	require.EqualError(t, err, "the repository repo1 is not archived")
+}
+
+func TestArchiveUnknownRepository(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	err := gitContract.ArchiveRepository(transactionContext, "repo1")
+	require.EqualError(t, err, "the repository repo1 does not have a version number")
+}
+
+func TestUnregisteredRepositoriesAutoCreatedByDefault(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	_, err := gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.NoError(t, err)
+
+	registered, err := gitContract.ListRegisteredRepositories(transactionContext)
+	require.NoError(t, err)
+	require.Empty(t, registered)
+}
+
+func TestRequireRegisteredRepositories(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"RequireRegisteredRepositories": true}`))
+
+	err := gitContract.CreateGitCommit(transactionContext, "hash2", "repo2", "Initial commit", "Bob")
+	require.EqualError(t, err, "the repository repo2 is not registered")
+	_, err = gitContract.GetRepositoryVersion(transactionContext, "repo2")
+	require.EqualError(t, err, "the repository repo2 does not have a version number")
+
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.EqualError(t, err, "the repository repo1 is not registered")
+
+	setClientMSPID(transactionContext, "Org2MSP")
+	err = gitContract.RegisterRepository(transactionContext, "repo2")
+	require.EqualError(t, err, "client from org Org2MSP is not authorized to perform this operation")
+	setClientMSPID(transactionContext, adminMSPID)
+
+	require.NoError(t, gitContract.RegisterRepository(transactionContext, "repo2"))
+	require.NoError(t, gitContract.RegisterRepository(transactionContext, "repo1"))
+	err = gitContract.RegisterRepository(transactionContext, "repo1")
+	require.EqualError(t, err, "the repository repo1 is already registered")
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo2", "Initial commit", "Bob"))
+	_, err = gitContract.HandleGitPush(transactionContext, "repo2", "https://example.com/repo2.git", "hash2")
+	require.NoError(t, err)
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.NoError(t, err)
+
+	// Registering an existing repository keeps its version history
+	repoVersion, err := gitContract.GetRepositoryVersion(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, 2, repoVersion.VersionNumber)
+
+	registered, err := gitContract.ListRegisteredRepositories(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, []string{"repo1", "repo2"}, registered)
+}
+
+func TestForkRepository(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	_, err := gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.NoError(t, err)
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "other", "Unrelated", "Bob"))
+
+	require.NoError(t, gitContract.ForkRepository(transactionContext, "repo1", "fork1"))
+	forkVersion, err := gitContract.GetRepositoryVersion(transactionContext, "fork1")
+	require.NoError(t, err)
+	require.Equal(t, &chaincode.RepositoryVersion{Repository: "fork1", VersionNumber: 2, ForkedFrom: "repo1"}, forkVersion)
+
+	err = gitContract.ForkRepository(transactionContext, "repo1", "fork1")
+	require.EqualError(t, err, "the repository fork1 already exists")
+	err = gitContract.ForkRepository(transactionContext, "repo1", "other")
+	require.EqualError(t, err, "the repository other already exists")
+	err = gitContract.ForkRepository(transactionContext, "missing", "fork2")
+	require.EqualError(t, err, "the repository missing does not have a version number")
+	err = gitContract.ForkRepository(transactionContext, "repo1", "repo1")
+	require.EqualError(t, err, "the repository repo1 cannot be a fork of itself")
+
+	// The fork versions independently of its source from here on
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "fork1", "Fork the project", "Carol"))
+	_, err = gitContract.HandleGitPush(transactionContext, "fork1", "https://example.com/fork1.git", "hash3")
+	require.NoError(t, err)
+	forkVersion, err = gitContract.GetRepositoryVersion(transactionContext, "fork1")
+	require.NoError(t, err)
+	require.Equal(t, 3, forkVersion.VersionNumber)
+	sourceVersion, err := gitContract.GetRepositoryVersion(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, 2, sourceVersion.VersionNumber)
+
+	require.NoError(t, gitContract.ForkRepository(transactionContext, "repo1", "a-fork"))
+	require.NoError(t, gitContract.ForkRepository(transactionContext, "fork1", "nested"))
+	forks, err := gitContract.GetForks(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Len(t, forks, 2)
+	require.Equal(t, "a-fork", forks[0].Repository)
+	require.Equal(t, "fork1", forks[1].Repository)
+	require.Equal(t, "repo1", forks[1].ForkedFrom)
+
+	forks, err = gitContract.GetForks(transactionContext, "other")
+	require.NoError(t, err)
+	require.Empty(t, forks)
+}
+
+func TestReconcileRepositoryVersion(t *testing.T) {
+	transactionContext, chaincodeStub, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	for i, commitHash := range []string{"hash1", "hash2", "hash3"} {
+		require.NoError(t, gitContract.CreateGitCommit(transactionContext, commitHash, "repo1", "Change", "Alice"))
+		chaincodeStub.GetTxIDReturns(fmt.Sprintf("tx%d", i))
+		_, err := gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", commitHash)
+		require.NoError(t, err)
+	}
+
+	reconciliation, err := gitContract.ReconcileRepositoryVersion(transactionContext, "repo1", false)
+	require.NoError(t, err)
+	require.Equal(t, &chaincode.VersionReconciliation{Repository: "repo1", StoredVersion: 4, MaxCommitVersion: 3, MaxVersionCommit: "hash3"}, reconciliation)
+
+	// An interrupted import leaves the counter behind the commits
+	require.NoError(t, gitContract.SetRepositoryVersion(transactionContext, &chaincode.RepositoryVersion{Repository: "repo1", VersionNumber: 1}))
+	reconciliation, err = gitContract.ReconcileRepositoryVersion(transactionContext, "repo1", false)
+	require.NoError(t, err)
+	require.Equal(t, &chaincode.VersionReconciliation{Repository: "repo1", StoredVersion: 1, MaxCommitVersion: 3, MaxVersionCommit: "hash3", Drift: 2}, reconciliation)
+	repoVersion, err := gitContract.GetRepositoryVersion(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, 1, repoVersion.VersionNumber)
+
+	setClientMSPID(transactionContext, "Org2MSP")
+	_, err = gitContract.ReconcileRepositoryVersion(transactionContext, "repo1", true)
+	require.EqualError(t, err, "client from org Org2MSP is not authorized to perform this operation")
+
+	setClientMSPID(transactionContext, adminMSPID)
+	reconciliation, err = gitContract.ReconcileRepositoryVersion(transactionContext, "repo1", true)
+	require.NoError(t, err)
+	require.Equal(t, 2, reconciliation.Drift)
+	require.True(t, reconciliation.Repaired)
+	repoVersion, err = gitContract.GetRepositoryVersion(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, 3, repoVersion.VersionNumber)
+
+	// A lost version record is recreated from the commits
+	delete(world.state, "VERSION_repo1")
+	reconciliation, err = gitContract.ReconcileRepositoryVersion(transactionContext, "repo1", true)
+	require.NoError(t, err)
+	require.Equal(t, 0, reconciliation.StoredVersion)
+	require.Equal(t, 3, reconciliation.Drift)
+	repoVersion, err = gitContract.GetRepositoryVersion(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, 3, repoVersion.VersionNumber)
+
+	_, err = gitContract.ReconcileRepositoryVersion(transactionContext, "missing", false)
+	require.EqualError(t, err, "the repository missing does not have a version number")
+}
+
+func TestCanonicalRepositoryNames(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"CanonicalRepositoryNames": true}`))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "MyRepo", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", " myrepo.git", "Added feature", "Bob"))
+	chaincodeStub.GetTxIDReturns("tx1")
+	_, err := gitContract.HandleGitPush(transactionContext, "MYREPO.git", "https://example.com/myrepo.git", "hash2")
+	require.NoError(t, err)
+
+	repoVersion, err := gitContract.GetRepositoryVersion(transactionContext, "myrepo")
+	require.NoError(t, err)
+	require.Equal(t, &chaincode.RepositoryVersion{Repository: "myrepo", VersionNumber: 2, DisplayName: "MyRepo"}, repoVersion)
+
+	for _, name := range []string{"MyRepo", "myrepo", "myrepo.git", " MyRepo.GIT "} {
+		gitCommits, err := gitContract.QueryCommitsByRepository(transactionContext, name)
+		require.NoError(t, err)
+		require.Equal(t, []string{"hash1", "hash2"}, commitHashes(gitCommits), name)
+		pushTransactions, err := gitContract.GetPushTransactionsByRepository(transactionContext, name)
+		require.NoError(t, err)
+		require.Len(t, pushTransactions, 1, name)
+	}
+
+	repositories, err := gitContract.ListRepositories(transactionContext)
+	require.NoError(t, err)
+	require.Len(t, repositories, 1)
+}
+
+func TestCanonicalRepositoryNamesDisabled(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "MyRepo", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "myrepo.git", "Added feature", "Bob"))
+
+	gitCommits, err := gitContract.QueryCommitsByRepository(transactionContext, "MyRepo")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1"}, commitHashes(gitCommits))
+	_, err = gitContract.GetRepositoryVersion(transactionContext, "myrepo")
+	require.EqualError(t, err, "the repository myrepo does not have a version number")
+
+	repositories, err := gitContract.ListRepositories(transactionContext)
+	require.NoError(t, err)
+	require.Len(t, repositories, 2)
+}
+
+func TestMergeRepositories(t *testing.T) {
+	transactionContext, chaincodeStub, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	// History recorded before canonicalization was switched on is spread over three spellings
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "MyRepo", "Initial commit for JIRA-1", "Alice"))
+	chaincodeStub.GetTxIDReturns("tx1")
+	_, err := gitContract.HandleGitPush(transactionContext, "MyRepo", "https://example.com/myrepo.git", "hash1")
+	require.NoError(t, err)
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "myrepo.git", "Added feature", "Bob"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "myrepo", "Fixed bug", "Carol"))
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"CanonicalRepositoryNames": true}`))
+
+	setClientMSPID(transactionContext, "Org2MSP")
+	err = gitContract.MergeRepositories(transactionContext, "MyRepo", "myrepo")
+	require.EqualError(t, err, "client from org Org2MSP is not authorized to perform this operation")
+	setClientMSPID(transactionContext, adminMSPID)
+
+	require.NoError(t, gitContract.MergeRepositories(transactionContext, "MyRepo", "myrepo"))
+	require.NoError(t, gitContract.MergeRepositories(transactionContext, "myrepo.git", "MyRepo"))
+	err = gitContract.MergeRepositories(transactionContext, "myrepo", "MyRepo")
+	require.EqualError(t, err, "the repository myrepo cannot be merged into itself")
+	err = gitContract.MergeRepositories(transactionContext, "MyRepo", "myrepo")
+	require.EqualError(t, err, "the repository MyRepo does not have a version number")
+
+	gitCommits, err := gitContract.QueryCommitsByRepository(transactionContext, "MyRepo")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1", "hash2", "hash3"}, commitHashes(gitCommits))
+	for _, gitCommit := range gitCommits {
+		require.Equal(t, "myrepo", gitCommit.Repository)
+	}
+	issueCommits, err := gitContract.GetCommitsByIssue(transactionContext, "JIRA-1")
+	require.NoError(t, err)
+	require.Equal(t, "myrepo", issueCommits[0].Repository)
+
+	pushTransactions, err := gitContract.GetPushTransactionsByRepository(transactionContext, "myrepo")
+	require.NoError(t, err)
+	require.Len(t, pushTransactions, 1)
+	require.Equal(t, "myrepo", pushTransactions[0].Repository)
+
+	repoVersion, err := gitContract.GetRepositoryVersion(transactionContext, "myrepo")
+	require.NoError(t, err)
+	require.Equal(t, 2, repoVersion.VersionNumber)
+	require.NotContains(t, world.state, "VERSION_MyRepo")
+	require.NotContains(t, world.state, "VERSION_myrepo.git")
+}
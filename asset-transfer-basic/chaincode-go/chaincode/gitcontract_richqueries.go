@@ -0,0 +1,134 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Rich queries run a CouchDB Mango query against the JSON of the world state, so they need CouchDB as the
+// state database; on LevelDB they fail. Selectors on fields without an index scan every document, so the
+// fields queried here are indexed by the definitions under META-INF/statedb/couchdb/indexes, which are
+// packaged with the chaincode and created when it is installed.
+
+// QueryCommits returns the commits matching a CouchDB Mango query, such as
+// {"selector": {"repository": "repo1", "insertions": {"$gt": 100}}, "sort": [{"author": "asc"}]}, in the
+// order CouchDB returns them. Field names are the JSON names of GitCommit. Documents that match but are
+// not commits, such as push transactions, are left out.
+func (s *SmartContract) QueryCommits(ctx contractapi.TransactionContextInterface, queryString string) ([]*GitCommit, error) {
+	var query map[string]interface{}
+	err := json.Unmarshal([]byte(queryString), &query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query, must be a JSON object: %v", err)
+	}
+	if _, ok := query["selector"].(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("the query must have a selector object")
+	}
+	return s.queryCommits(ctx, queryString)
+}
+
+// QueryCommitsByAuthor returns the commits of an author across all repositories sorted by commit time, using
+// the author index. Commits stored before schema version 3 keep the field as Author, which the query cannot
+// match, so until MigrateAllCommits has run it also looks for those, without an index, and fails while the
+// author has any, telling the caller to run MigrateAllCommits first. Once it has run the query uses the index
+// alone.
+func (s *SmartContract) QueryCommitsByAuthor(ctx contractapi.TransactionContextInterface, author string) ([]*GitCommit, error) {
+	author = strings.TrimSpace(author)
+	if author == "" {
+		return nil, fmt.Errorf("the author must not be empty")
+	}
+	queryJSON, err := json.Marshal(map[string]interface{}{
+		"selector":  map[string]string{"author": author},
+		"use_index": []string{"_design/indexAuthorDoc", "indexAuthor"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	gitCommits, err := s.queryCommits(ctx, string(queryJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if config.MigratedSchemaVersion < currentSchemaVersion {
+		outdatedJSON, err := json.Marshal(map[string]interface{}{
+			"selector": map[string]string{"Author": author},
+		})
+		if err != nil {
+			return nil, err
+		}
+		outdated, err := s.queryCommits(ctx, string(outdatedJSON))
+		if err != nil {
+			return nil, err
+		}
+		if len(outdated) > 0 {
+			return nil, fmt.Errorf("the author query cannot match commits of %s stored before schema version %d; run MigrateAllCommits first", author, currentSchemaVersion)
+		}
+	}
+
+	sortGitCommits(gitCommits)
+	return gitCommits, nil
+}
+
+// queryCommits runs a rich query and returns the commits among its results.
+func (s *SmartContract) queryCommits(ctx contractapi.TransactionContextInterface, queryString string) ([]*GitCommit, error) {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run rich query, which needs CouchDB as the state database: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	gitCommits := []*GitCommit{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		isCommit, err := s.isCommitRecordKey(ctx, config, queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		if !isCommit {
+			continue
+		}
+
+		var gitCommit GitCommit
+		err = json.Unmarshal(queryResponse.Value, &gitCommit)
+		if err != nil {
+			return nil, err
+		}
+		err = s.resolveMessageBlob(ctx, &gitCommit)
+		if err != nil {
+			return nil, err
+		}
+		gitCommits = append(gitCommits, &gitCommit)
+		err = checkResultLimit(config, len(gitCommits))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return gitCommits, nil
+}
+
+// isCommitRecordKey reports whether a key found by a rich query holds a GitCommit: a repository scoped commit
+// key once commits are scoped, else a plain key outside the version, push, blob and snapshot prefixes.
+func (s *SmartContract) isCommitRecordKey(ctx contractapi.TransactionContextInterface, config *ContractConfig, key string) (bool, error) {
+	// Composite keys start with a null byte
+	if !strings.HasPrefix(key, "\x00") {
+		return !config.RepositoryScopedCommits && isCommitKey(key), nil
+	}
+	objectType, _, err := ctx.GetStub().SplitCompositeKey(key)
+	if err != nil {
+		return false, err
+	}
+	return config.RepositoryScopedCommits && objectType == scopedCommitObjectType, nil
+}
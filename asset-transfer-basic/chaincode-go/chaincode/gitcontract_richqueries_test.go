@@ -0,0 +1,125 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRichQueries answers rich queries from the world state, supporting only selectors that match fields
+// with equal strings, which is enough to tell which documents a query returns.
+func stubRichQueries(chaincodeStub *mocks.ChaincodeStub, world *worldState) {
+	chaincodeStub.GetQueryResultStub = func(queryString string) (shim.StateQueryIteratorInterface, error) {
+		var query struct {
+			Selector map[string]string `json:"selector"`
+		}
+		err := json.Unmarshal([]byte(queryString), &query)
+		if err != nil {
+			return nil, err
+		}
+		return world.iterator(func(key string) bool {
+			var document map[string]interface{}
+			if json.Unmarshal(world.committed[key], &document) != nil {
+				return false
+			}
+			for field, value := range query.Selector {
+				if document[field] != value {
+					return false
+				}
+			}
+			return true
+		}), nil
+	}
+}
+
+func TestQueryCommitsByAuthor(t *testing.T) {
+	transactionContext, chaincodeStub, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	stubRichQueries(chaincodeStub, world)
+
+	require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "hash1", "repo1", "Added feature", "Alice", "", "2026-01-02T00:00:00Z"))
+	require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "hash2", "repo2", "Initial commit", "Alice", "", "2026-01-01T00:00:00Z"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo1", "Fixed bug", "Bob"))
+
+	gitCommits, err := gitContract.QueryCommitsByAuthor(transactionContext, " Alice ")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash2", "hash1"}, commitHashes(gitCommits))
+	var query map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(chaincodeStub.GetQueryResultArgsForCall(0)), &query))
+	require.Equal(t, map[string]interface{}{"author": "Alice"}, query["selector"])
+
+	_, err = gitContract.QueryCommitsByAuthor(transactionContext, "")
+	require.EqualError(t, err, "the author must not be empty")
+}
+
+func TestQueryCommitsByAuthorBeforeMigration(t *testing.T) {
+	transactionContext, chaincodeStub, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	stubRichQueries(chaincodeStub, world)
+
+	require.NoError(t, gitContract.CreateGitCommitWithDates(transactionContext, "hash1", "repo1", "Added feature", "Alice", "", "2026-01-02T00:00:00Z"))
+	putV1Commit(t, world, "hash2", "2026-01-01T00:00:00Z")
+
+	// The stored Author field of the old commit does not match the selector on author
+	_, err := gitContract.QueryCommitsByAuthor(transactionContext, "Alice")
+	require.EqualError(t, err, "the author query cannot match commits of Alice stored before schema version 3; run MigrateAllCommits first")
+
+	_, err = gitContract.MigrateAllCommits(transactionContext)
+	require.NoError(t, err)
+	config, err := gitContract.GetContractConfig(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, 3, config.MigratedSchemaVersion)
+
+	// Once migrated, only the indexed query runs
+	queries := chaincodeStub.GetQueryResultCallCount()
+	gitCommits, err := gitContract.QueryCommitsByAuthor(transactionContext, "Alice")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash2", "hash1"}, commitHashes(gitCommits))
+	require.Equal(t, queries+1, chaincodeStub.GetQueryResultCallCount())
+	require.JSONEq(t, `{"selector": {"author": "Alice"}, "use_index": ["_design/indexAuthorDoc", "indexAuthor"]}`, chaincodeStub.GetQueryResultArgsForCall(queries))
+
+	err = gitContract.SetContractConfig(transactionContext, `{"MigratedSchemaVersion": 0}`)
+	require.EqualError(t, err, "MigratedSchemaVersion can only be set by MigrateAllCommits")
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"UniqueCommitMessages": true}`))
+	config, err = gitContract.GetContractConfig(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, 3, config.MigratedSchemaVersion)
+}
+
+func TestQueryCommits(t *testing.T) {
+	transactionContext, chaincodeStub, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	stubRichQueries(chaincodeStub, world)
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo2", "Added feature", "Bob"))
+	_, err := gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.NoError(t, err)
+
+	// The push transaction matches the selector too, but is not a commit
+	gitCommits, err := gitContract.QueryCommits(transactionContext, `{"selector": {"repository": "repo1"}}`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1"}, commitHashes(gitCommits))
+
+	// So do scoped commits once migrated
+	_, err = gitContract.MigrateToRepositoryScopedCommits(transactionContext)
+	require.NoError(t, err)
+	gitCommits, err = gitContract.QueryCommits(transactionContext, `{"selector": {"repository": "repo1"}}`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1"}, commitHashes(gitCommits))
+
+	_, err = gitContract.QueryCommits(transactionContext, `{"repository": "repo1"}`)
+	require.EqualError(t, err, "the query must have a selector object")
+	_, err = gitContract.QueryCommits(transactionContext, `repository = repo1`)
+	require.ErrorContains(t, err, "failed to parse query, must be a JSON object")
+
+	chaincodeStub.GetQueryResultReturns(nil, fmt.Errorf("ExecuteQuery not supported for leveldb"))
+	chaincodeStub.GetQueryResultStub = nil
+	_, err = gitContract.QueryCommits(transactionContext, `{"selector": {"repository": "repo1"}}`)
+	require.EqualError(t, err, "failed to run rich query, which needs CouchDB as the state database: ExecuteQuery not supported for leveldb")
+}
@@ -0,0 +1,121 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// currentSchemaVersion is the GitCommit layout written by this contract. Records without a SchemaVersion
+// predate versioning and count as version 1.
+//
+// Version 2 added RecordedTimestamp, which version 1 records take from their Timestamp.
+// Version 3 writes camelCase JSON names; older records decode unchanged and are rewritten on migration.
+const currentSchemaVersion = 3
+
+// schemaVersion returns the schema version a stored GitCommit was written with.
+func schemaVersion(gitCommit *GitCommit) int {
+	if gitCommit.SchemaVersion == 0 {
+		return 1
+	}
+	return gitCommit.SchemaVersion
+}
+
+// upgradeGitCommit fills the fields an older record lacks with their defaults and stamps it with the current
+// schema version. It reports whether the record changed.
+func upgradeGitCommit(gitCommit *GitCommit) bool {
+	if schemaVersion(gitCommit) >= currentSchemaVersion {
+		return false
+	}
+	if gitCommit.RecordedTimestamp == "" {
+		gitCommit.RecordedTimestamp = gitCommit.Timestamp
+	}
+	gitCommit.SchemaVersion = currentSchemaVersion
+	return true
+}
+
+// GetSchemaVersion returns the GitCommit schema version this contract writes.
+func (s *SmartContract) GetSchemaVersion(ctx contractapi.TransactionContextInterface) (int, error) {
+	return currentSchemaVersion, nil
+}
+
+// MigrateCommit upgrades a stored commit to the current schema version, filling missing fields with their
+// defaults. Commits already at the current version are left untouched. Only the admin organization may
+// migrate commits.
+func (s *SmartContract) MigrateCommit(ctx contractapi.TransactionContextInterface, commitHash string) error {
+	err := s.requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	gitCommit, err := s.ReadGitCommit(ctx, commitHash)
+	if err != nil {
+		return err
+	}
+	if !upgradeGitCommit(gitCommit) {
+		return nil
+	}
+
+	return s.putGitCommit(ctx, gitCommit)
+}
+
+// MigrateAllCommits upgrades every stored commit that is behind the current schema version and returns the
+// number of upgraded commits. Only the admin organization may migrate commits.
+func (s *SmartContract) MigrateAllCommits(ctx contractapi.TransactionContextInterface) (int, error) {
+	err := s.requireAdmin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var resultsIterator shim.StateQueryIteratorInterface
+	if config.RepositoryScopedCommits {
+		resultsIterator, err = ctx.GetStub().GetStateByPartialCompositeKey(scopedCommitObjectType, []string{})
+	} else {
+		resultsIterator, err = ctx.GetStub().GetStateByRange("", "")
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer resultsIterator.Close()
+
+	var outdated []*GitCommit
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return 0, err
+		}
+		if !config.RepositoryScopedCommits && !isCommitKey(queryResponse.Key) {
+			continue
+		}
+
+		var gitCommit GitCommit
+		err = json.Unmarshal(queryResponse.Value, &gitCommit)
+		if err != nil {
+			return 0, err
+		}
+		if upgradeGitCommit(&gitCommit) {
+			outdated = append(outdated, &gitCommit)
+		}
+	}
+
+	for _, gitCommit := range outdated {
+		err = s.putGitCommit(ctx, gitCommit)
+		if err != nil {
+			return 0, fmt.Errorf("failed to migrate commit %s: %v", gitCommit.CommitHash, err)
+		}
+	}
+
+	if config.MigratedSchemaVersion != currentSchemaVersion {
+		config.MigratedSchemaVersion = currentSchemaVersion
+		err = s.putContractConfig(ctx, config)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(outdated), nil
+}
@@ -0,0 +1,147 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+// putV1Commit stores a commit the way contracts before schema versioning wrote it.
+func putV1Commit(t *testing.T, world *worldState, commitHash string, timestamp string) {
+	v1JSON, err := json.Marshal(map[string]interface{}{
+		"CommitHash":    commitHash,
+		"Repository":    "repo1",
+		"CommitMessage": "Initial commit",
+		"Author":        "Alice",
+		"VersionNumber": 1,
+		"Timestamp":     timestamp,
+	})
+	require.NoError(t, err)
+	world.state[commitHash] = v1JSON
+}
+
+func TestGetSchemaVersion(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	version, err := gitContract.GetSchemaVersion(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, 3, version)
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, version, gitCommit.SchemaVersion)
+}
+
+func TestMigrateCommit(t *testing.T) {
+	transactionContext, _, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	putV1Commit(t, world, "hash1", "2023-06-01T12:00:00Z")
+
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Zero(t, gitCommit.SchemaVersion)
+	require.Empty(t, gitCommit.RecordedTimestamp)
+
+	setClientMSPID(transactionContext, "Org2MSP")
+	err = gitContract.MigrateCommit(transactionContext, "hash1")
+	require.EqualError(t, err, "client from org Org2MSP is not authorized to perform this operation")
+
+	setClientMSPID(transactionContext, adminMSPID)
+	require.NoError(t, gitContract.MigrateCommit(transactionContext, "hash1"))
+	gitCommit, err = gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, 3, gitCommit.SchemaVersion)
+	require.Equal(t, "2023-06-01T12:00:00Z", gitCommit.RecordedTimestamp)
+	require.Equal(t, "Initial commit", gitCommit.CommitMessage)
+
+	err = gitContract.MigrateCommit(transactionContext, "hash2")
+	require.EqualError(t, err, "the commit hash2 does not exist")
+}
+
+func TestMigrateAllCommits(t *testing.T) {
+	transactionContext, _, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	putV1Commit(t, world, "hash1", "2023-06-01T12:00:00Z")
+	putV1Commit(t, world, "hash2", "2023-06-02T12:00:00Z")
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo1", "Fixed bug", "Carol"))
+
+	migrated, err := gitContract.MigrateAllCommits(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, 2, migrated)
+
+	for _, commitHash := range []string{"hash1", "hash2", "hash3"} {
+		gitCommit, err := gitContract.ReadGitCommit(transactionContext, commitHash)
+		require.NoError(t, err)
+		require.Equal(t, 3, gitCommit.SchemaVersion)
+		require.NotEmpty(t, gitCommit.RecordedTimestamp)
+	}
+
+	migrated, err = gitContract.MigrateAllCommits(transactionContext)
+	require.NoError(t, err)
+	require.Zero(t, migrated)
+}
+
+func TestGitCommitJSONNames(t *testing.T) {
+	gitCommit := &chaincode.GitCommit{
+		CommitHash:    "hash1",
+		Repository:    "repo1",
+		CommitMessage: "Initial commit",
+		Author:        "Alice",
+		VersionNumber: 1,
+		Timestamp:     "2026-01-01T12:00:00Z",
+		ParentHashes:  []string{"hash0"},
+		SchemaVersion: 3,
+	}
+	gitCommitJSON, err := json.Marshal(gitCommit)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"commitHash": "hash1", "repository": "repo1", "commitMessage": "Initial commit", "author": "Alice", "versionNumber": 1, "timestamp": "2026-01-01T12:00:00Z", "parentHashes": ["hash0"], "schemaVersion": 3}`, string(gitCommitJSON))
+
+	var decoded chaincode.GitCommit
+	require.NoError(t, json.Unmarshal(gitCommitJSON, &decoded))
+	require.Equal(t, gitCommit, &decoded)
+
+	// Records written before schema version 3 use PascalCase names
+	decoded = chaincode.GitCommit{}
+	require.NoError(t, json.Unmarshal([]byte(`{"CommitHash": "hash1", "Repository": "repo1", "CommitMessage": "Initial commit", "Author": "Alice", "VersionNumber": 1, "Timestamp": "2026-01-01T12:00:00Z", "ParentHashes": ["hash0"], "SchemaVersion": 3}`), &decoded))
+	require.Equal(t, gitCommit, &decoded)
+}
+
+func TestPushTransactionJSONNames(t *testing.T) {
+	pushTx := &chaincode.PushTransaction{
+		Repository:  "repo1",
+		RemoteURL:   "https://example.com/repo1.git",
+		Timestamp:   "2026-01-01T12:00:00Z",
+		Version:     2,
+		CommitHash:  "hash1",
+		Environment: "prod",
+	}
+	pushTxJSON, err := json.Marshal(pushTx)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"repository": "repo1", "remoteURL": "https://example.com/repo1.git", "timestamp": "2026-01-01T12:00:00Z", "version": 2, "commitHash": "hash1", "environment": "prod"}`, string(pushTxJSON))
+
+	var decoded chaincode.PushTransaction
+	require.NoError(t, json.Unmarshal(pushTxJSON, &decoded))
+	require.Equal(t, pushTx, &decoded)
+
+	// Pushes were recorded with a PascalCase CommitHash
+	decoded = chaincode.PushTransaction{}
+	require.NoError(t, json.Unmarshal([]byte(`{"repository": "repo1", "remoteURL": "https://example.com/repo1.git", "timestamp": "2026-01-01T12:00:00Z", "version": 2, "CommitHash": "hash1", "environment": "prod"}`), &decoded))
+	require.Equal(t, pushTx, &decoded)
+}
+
+func TestMigrateCommitRewritesJSONNames(t *testing.T) {
+	transactionContext, _, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	putV1Commit(t, world, "hash1", "2023-06-01T12:00:00Z")
+
+	require.NoError(t, gitContract.MigrateCommit(transactionContext, "hash1"))
+	var stored map[string]interface{}
+	require.NoError(t, json.Unmarshal(world.state["hash1"], &stored))
+	require.Equal(t, "hash1", stored["commitHash"])
+	require.Equal(t, "Alice", stored["author"])
+	require.NotContains(t, stored, "CommitHash")
+}
@@ -0,0 +1,241 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	scopedCommitObjectType    = "commit"
+	commitRepositoryIndexName = "hash~repository"
+)
+
+// gitCommitKey returns the world state key of a commit: its hash, or a composite key of repository and hash
+// once commits are repository scoped.
+func (s *SmartContract) gitCommitKey(ctx contractapi.TransactionContextInterface, config *ContractConfig, repository string, commitHash string) (string, error) {
+	if !config.RepositoryScopedCommits {
+		return commitHash, nil
+	}
+
+	commitKey, err := ctx.GetStub().CreateCompositeKey(scopedCommitObjectType, []string{repository, commitHash})
+	if err != nil {
+		return "", fmt.Errorf("failed to create composite key: %v", err)
+	}
+	return commitKey, nil
+}
+
+// commitRepositories returns the repositories holding a commit with the given hash, in alphabetical order.
+// It is only meaningful once commits are repository scoped.
+func (s *SmartContract) commitRepositories(ctx contractapi.TransactionContextInterface, commitHash string) ([]string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(commitRepositoryIndexName, []string{commitHash})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var repositories []string
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		repositories = append(repositories, compositeKeyParts[1])
+	}
+
+	return repositories, nil
+}
+
+// indexCommitRepository records which repository holds a repository scoped commit, so that it can still be
+// found by hash alone.
+func (s *SmartContract) indexCommitRepository(ctx contractapi.TransactionContextInterface, gitCommit *GitCommit) error {
+	indexKey, err := ctx.GetStub().CreateCompositeKey(commitRepositoryIndexName, []string{gitCommit.CommitHash, gitCommit.Repository})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	// Composite key values cannot be empty, so store a single null byte
+	return ctx.GetStub().PutState(indexKey, []byte{0x00})
+}
+
+// ReadGitCommitInRepository returns the commit with the given hash in a repository. Unlike ReadGitCommit it
+// stays unambiguous when commits are repository scoped and the same hash exists in several repositories.
+func (s *SmartContract) ReadGitCommitInRepository(ctx contractapi.TransactionContextInterface, repository string, commitHash string) (*GitCommit, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	commitHash = strings.TrimSpace(commitHash)
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	commitKey, err := s.gitCommitKey(ctx, config, repository, commitHash)
+	if err != nil {
+		return nil, err
+	}
+
+	gitCommitJSON, err := ctx.GetStub().GetState(commitKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if gitCommitJSON == nil {
+		return nil, s.missingCommitError(ctx, config, repository, commitHash, fmt.Errorf("the commit %s does not exist in repository %s", commitHash, repository))
+	}
+
+	var gitCommit GitCommit
+	err = json.Unmarshal(gitCommitJSON, &gitCommit)
+	if err != nil {
+		return nil, err
+	}
+	if gitCommit.Repository != repository {
+		return nil, s.missingCommitError(ctx, config, repository, commitHash, fmt.Errorf("the commit %s does not exist in repository %s", commitHash, repository))
+	}
+	err = s.resolveMessageBlob(ctx, &gitCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gitCommit, nil
+}
+
+// DuplicateCommitHash is a commit hash recorded in more than one repository
+type DuplicateCommitHash struct {
+	CommitHash string `json:"CommitHash"`
+	// Repositories holds the repositories of the hash in alphabetical order
+	Repositories []string `json:"Repositories"`
+}
+
+// FindDuplicateCommitHashes returns the commit hashes recorded in more than one repository, ordered by hash.
+// A shared hash usually means shared history, such as a mirror or fork, but may also be a commit pushed to the
+// wrong repository. Only repository scoped commits can share a hash, so the result is empty before
+// MigrateToRepositoryScopedCommits.
+func (s *SmartContract) FindDuplicateCommitHashes(ctx contractapi.TransactionContextInterface) ([]*DuplicateCommitHash, error) {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	duplicates := []*DuplicateCommitHash{}
+	if !config.RepositoryScopedCommits {
+		return duplicates, nil
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(commitRepositoryIndexName, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	// The index is ordered by hash, so the repositories of a hash are adjacent
+	var current *DuplicateCommitHash
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		commitHash, repository := compositeKeyParts[0], compositeKeyParts[1]
+		if current == nil || current.CommitHash != commitHash {
+			current = &DuplicateCommitHash{CommitHash: commitHash}
+		}
+		current.Repositories = append(current.Repositories, repository)
+		if len(current.Repositories) == 2 {
+			duplicates = append(duplicates, current)
+			err = checkResultLimit(config, len(duplicates))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return duplicates, nil
+}
+
+// MigrateToRepositoryScopedCommits moves every commit from its hash key to a key scoped by its repository and
+// then enables the RepositoryScopedCommits setting, so that the same hash can be created in several
+// repositories, as happens with mirrors and forks. It returns the number of migrated commits. Only the
+// admin organization may run the migration, and it cannot be undone.
+func (s *SmartContract) MigrateToRepositoryScopedCommits(ctx contractapi.TransactionContextInterface) (int, error) {
+	err := s.requireAdmin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if config.RepositoryScopedCommits {
+		return 0, fmt.Errorf("commits are already repository scoped")
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return 0, err
+	}
+	defer resultsIterator.Close()
+
+	type migration struct {
+		oldKey    string
+		gitCommit *GitCommit
+		value     []byte
+	}
+	var migrations []migration
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return 0, err
+		}
+		if !isCommitKey(queryResponse.Key) {
+			continue
+		}
+
+		var gitCommit GitCommit
+		err = json.Unmarshal(queryResponse.Value, &gitCommit)
+		if err != nil {
+			return 0, err
+		}
+		migrations = append(migrations, migration{oldKey: queryResponse.Key, gitCommit: &gitCommit, value: queryResponse.Value})
+	}
+
+	config.RepositoryScopedCommits = true
+	for _, m := range migrations {
+		newKey, err := s.gitCommitKey(ctx, config, m.gitCommit.Repository, m.gitCommit.CommitHash)
+		if err != nil {
+			return 0, err
+		}
+		err = ctx.GetStub().PutState(newKey, m.value)
+		if err != nil {
+			return 0, fmt.Errorf("failed to put to world state: %v", err)
+		}
+		err = s.indexCommitRepository(ctx, m.gitCommit)
+		if err != nil {
+			return 0, err
+		}
+		err = ctx.GetStub().DelState(m.oldKey)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete state: %v", err)
+		}
+	}
+
+	err = s.putContractConfig(ctx, config)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(migrations), nil
+}
+
+// ambiguousCommitError is returned when a hash alone does not identify a repository scoped commit.
+func ambiguousCommitError(commitHash string, repositories []string) error {
+	return fmt.Errorf("the commit %s exists in repositories %s, use ReadGitCommitInRepository", commitHash, strings.Join(repositories, ", "))
+}
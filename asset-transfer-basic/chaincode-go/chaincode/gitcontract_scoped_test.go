@@ -0,0 +1,158 @@
+package chaincode_test
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobalCommitHashesByDefault(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	err := gitContract.CreateGitCommit(transactionContext, "hash1", "fork1", "Initial commit", "Alice")
+	require.EqualError(t, err, "the commit hash1 already exists")
+
+	gitCommit, err := gitContract.ReadGitCommitInRepository(transactionContext, "repo1", "hash1")
+	require.NoError(t, err)
+	require.Equal(t, "repo1", gitCommit.Repository)
+	_, err = gitContract.ReadGitCommitInRepository(transactionContext, "fork1", "hash1")
+	require.EqualError(t, err, "the commit hash1 does not exist in repository fork1")
+
+	err = gitContract.SetContractConfig(transactionContext, `{"RepositoryScopedCommits": true}`)
+	require.EqualError(t, err, "RepositoryScopedCommits can only be enabled by MigrateToRepositoryScopedCommits")
+}
+
+func TestMigrateToRepositoryScopedCommits(t *testing.T) {
+	transactionContext, _, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Fix crash\n\nFixes: #123", "Bob"))
+
+	setClientMSPID(transactionContext, "Org2MSP")
+	_, err := gitContract.MigrateToRepositoryScopedCommits(transactionContext)
+	require.EqualError(t, err, "client from org Org2MSP is not authorized to perform this operation")
+	setClientMSPID(transactionContext, adminMSPID)
+
+	migrated, err := gitContract.MigrateToRepositoryScopedCommits(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, 2, migrated)
+	require.NotContains(t, world.state, "hash1")
+	require.NotContains(t, world.state, "hash2")
+
+	_, err = gitContract.MigrateToRepositoryScopedCommits(transactionContext)
+	require.EqualError(t, err, "commits are already repository scoped")
+
+	config, err := gitContract.GetContractConfig(transactionContext)
+	require.NoError(t, err)
+	require.True(t, config.RepositoryScopedCommits)
+
+	// Settings changed later keep the scoped mode
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"MaxQueryResults": 50}`))
+	config, err = gitContract.GetContractConfig(transactionContext)
+	require.NoError(t, err)
+	require.True(t, config.RepositoryScopedCommits)
+	err = gitContract.SetContractConfig(transactionContext, `{"RepositoryScopedCommits": false}`)
+	require.EqualError(t, err, "RepositoryScopedCommits can only be enabled by MigrateToRepositoryScopedCommits")
+
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, "Initial commit", gitCommit.CommitMessage)
+
+	gitCommits, err := gitContract.QueryCommitsByTrailer(transactionContext, "Fixes", "#123")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash2"}, commitHashes(gitCommits))
+}
+
+func TestSameHashInDifferentRepositories(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	_, err := gitContract.MigrateToRepositoryScopedCommits(transactionContext)
+	require.NoError(t, err)
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "fork1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "fork1", "Fork only\n\nFixes: #7", "Bob"))
+	err = gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice")
+	require.EqualError(t, err, "the commit hash1 already exists")
+
+	_, err = gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.EqualError(t, err, "the commit hash1 exists in repositories fork1, repo1, use ReadGitCommitInRepository")
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash2")
+	require.NoError(t, err)
+	require.Equal(t, "fork1", gitCommit.Repository)
+	_, err = gitContract.ReadGitCommit(transactionContext, "hash3")
+	require.EqualError(t, err, "the commit hash3 does not exist")
+
+	gitCommit, err = gitContract.ReadGitCommitInRepository(transactionContext, "fork1", "hash1")
+	require.NoError(t, err)
+	require.Equal(t, "fork1", gitCommit.Repository)
+
+	exists, err := gitContract.GitCommitExists(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.True(t, exists)
+	existing, err := gitContract.GitCommitsExist(transactionContext, "hash1,hash3")
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"hash1": true, "hash3": false}, existing)
+
+	_, err = gitContract.HandleGitPush(transactionContext, "fork1", "https://example.com/fork1.git", "hash1")
+	require.NoError(t, err)
+	gitCommit, err = gitContract.ReadGitCommitInRepository(transactionContext, "fork1", "hash1")
+	require.NoError(t, err)
+	require.Equal(t, chaincode.BuildStatusPending, gitCommit.BuildStatus)
+	gitCommit, err = gitContract.ReadGitCommitInRepository(transactionContext, "repo1", "hash1")
+	require.NoError(t, err)
+	require.Empty(t, gitCommit.BuildStatus)
+
+	require.NoError(t, gitContract.CreateMergeCommit(transactionContext, "hash4", "fork1", "hash1", "hash2", "Merge", "Carol"))
+
+	gitCommits, err := gitContract.GetAllGitCommits(transactionContext)
+	require.NoError(t, err)
+	require.Len(t, gitCommits, 4)
+
+	unpushed, err := gitContract.GetUnpushedCommits(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1"}, commitHashes(unpushed))
+
+	page, err := gitContract.GetAllGitCommitsWithPagination(transactionContext, 3, "")
+	require.NoError(t, err)
+	require.Len(t, page.Records, 3)
+	require.NotEmpty(t, page.Bookmark)
+	page, err = gitContract.GetAllGitCommitsWithPagination(transactionContext, 3, page.Bookmark)
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1)
+	require.Empty(t, page.Bookmark)
+
+	gitCommits, err = gitContract.QueryCommitsByTrailer(transactionContext, "Fixes", "#7")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash2"}, commitHashes(gitCommits))
+}
+
+func TestFindDuplicateCommitHashes(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	duplicates, err := gitContract.FindDuplicateCommitHashes(transactionContext)
+	require.NoError(t, err)
+	require.Empty(t, duplicates)
+
+	_, err = gitContract.MigrateToRepositoryScopedCommits(transactionContext)
+	require.NoError(t, err)
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "mirror", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "fork1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo2", "Wrong repository", "Carol"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo1", "Wrong repository", "Carol"))
+
+	duplicates, err = gitContract.FindDuplicateCommitHashes(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, []*chaincode.DuplicateCommitHash{
+		{CommitHash: "hash1", Repositories: []string{"fork1", "mirror", "repo1"}},
+		{CommitHash: "hash3", Repositories: []string{"repo1", "repo2"}},
+	}, duplicates)
+}
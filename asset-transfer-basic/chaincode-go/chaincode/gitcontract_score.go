@@ -0,0 +1,88 @@
+package chaincode
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ScoredCommit is a commit together with its score
+type ScoredCommit struct {
+	Score  int        `json:"Score"`
+	Commit *GitCommit `json:"Commit"`
+}
+
+// coAuthorCount returns the number of Co-authored-by trailers of a commit. Trailer keys are matched
+// case-insensitively, as git does.
+func coAuthorCount(gitCommit *GitCommit) int {
+	count := 0
+	for key, values := range gitCommit.Trailers {
+		if strings.EqualFold(key, "Co-authored-by") {
+			count += len(values)
+		}
+	}
+	return count
+}
+
+// commitScore weighs the files changed, lines changed, co-authors and merge status of a commit.
+func commitScore(weights ScoreWeights, gitCommit *GitCommit) int {
+	score := gitCommit.FilesChanged*weights.FileChanged +
+		(gitCommit.Insertions+gitCommit.Deletions)*weights.LineChanged +
+		coAuthorCount(gitCommit)*weights.CoAuthor
+	if gitCommit.IsMerge {
+		score += weights.Merge
+	}
+	return score
+}
+
+// ComputeCommitScore returns the importance score of a commit using the ScoreWeights setting.
+func (s *SmartContract) ComputeCommitScore(ctx contractapi.TransactionContextInterface, commitHash string) (int, error) {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return 0, err
+	}
+	gitCommit, err := s.ReadGitCommit(ctx, commitHash)
+	if err != nil {
+		return 0, err
+	}
+
+	return commitScore(config.ScoreWeights, gitCommit), nil
+}
+
+// GetTopCommits returns up to limit commits of a repository with the highest scores, highest first. Commits
+// with equal scores are in commit time order, then hash order.
+func (s *SmartContract) GetTopCommits(ctx contractapi.TransactionContextInterface, repository string, limit int) ([]*ScoredCommit, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("the limit must be positive, got %d", limit)
+	}
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gitCommits, err := s.getRepositoryCommits(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	scored := make([]*ScoredCommit, len(gitCommits))
+	for i, gitCommit := range gitCommits {
+		scored[i] = &ScoredCommit{Score: commitScore(config.ScoreWeights, gitCommit), Commit: gitCommit}
+	}
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		return commitBefore(scored[i].Commit, scored[j].Commit)
+	})
+	if len(scored) > limit {
+		scored = scored[:limit]
+	}
+
+	return scored, nil
+}
@@ -0,0 +1,70 @@
+package chaincode_test
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeCommitScore(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	message := "Add feature\n\nCo-authored-by: Bob <bob@example.com>\nco-authored-by: Carol <carol@example.com>"
+	require.NoError(t, gitContract.CreateGitCommitWithStats(transactionContext, "hash1", "repo1", message, "Alice", 30, 12, 3))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Fix typo", "Bob"))
+	require.NoError(t, gitContract.CreateMergeCommit(transactionContext, "merge1", "repo1", "hash1", "hash2", "Merge branch", "Alice"))
+
+	// 3 files * 10 + 42 lines * 1 + 2 co-authors * 25
+	score, err := gitContract.ComputeCommitScore(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, 122, score)
+
+	score, err = gitContract.ComputeCommitScore(transactionContext, "hash2")
+	require.NoError(t, err)
+	require.Zero(t, score)
+
+	score, err = gitContract.ComputeCommitScore(transactionContext, "merge1")
+	require.NoError(t, err)
+	require.Equal(t, 50, score)
+
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"ScoreWeights": {"LineChanged": 2, "Merge": 0}}`))
+	score, err = gitContract.ComputeCommitScore(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, 164, score)
+	score, err = gitContract.ComputeCommitScore(transactionContext, "merge1")
+	require.NoError(t, err)
+	require.Zero(t, score)
+
+	err = gitContract.SetContractConfig(transactionContext, `{"ScoreWeights": {"CoAuthor": -1}}`)
+	require.EqualError(t, err, "ScoreWeights must not be negative")
+
+	_, err = gitContract.ComputeCommitScore(transactionContext, "hash3")
+	require.EqualError(t, err, "the commit hash3 does not exist")
+}
+
+func TestGetTopCommits(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommitWithStats(transactionContext, "hash1", "repo1", "Small change", "Alice", 1, 0, 1))
+	require.NoError(t, gitContract.CreateGitCommitWithStats(transactionContext, "hash2", "repo1", "Large change", "Bob", 200, 50, 8))
+	require.NoError(t, gitContract.CreateGitCommitWithStats(transactionContext, "hash3", "repo1", "Another small change", "Carol", 1, 0, 1))
+	require.NoError(t, gitContract.CreateGitCommitWithStats(transactionContext, "hash4", "repo2", "Huge change", "Dave", 5000, 0, 90))
+
+	top, err := gitContract.GetTopCommits(transactionContext, "repo1", 2)
+	require.NoError(t, err)
+	require.Len(t, top, 2)
+	require.Equal(t, "hash2", top[0].Commit.CommitHash)
+	require.Equal(t, 330, top[0].Score)
+	// Equal scores keep commit order
+	require.Equal(t, "hash1", top[1].Commit.CommitHash)
+
+	top, err = gitContract.GetTopCommits(transactionContext, "repo1", 10)
+	require.NoError(t, err)
+	require.Len(t, top, 3)
+
+	_, err = gitContract.GetTopCommits(transactionContext, "repo1", 0)
+	require.EqualError(t, err, "the limit must be positive, got 0")
+}
@@ -0,0 +1,169 @@
+package chaincode
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Security levels a commit can be flagged with, from least to most severe
+const (
+	SecurityLevelNone     = "none"
+	SecurityLevelLow      = "low"
+	SecurityLevelMedium   = "medium"
+	SecurityLevelHigh     = "high"
+	SecurityLevelCritical = "critical"
+)
+
+const (
+	securityLevelIndexName = "securityLevel~hash~repository"
+	// securityTeamAttribute marks clients of any organization as members of the security team when its
+	// value in their enrollment certificate is "true"
+	securityTeamAttribute = "gitSecurityTeam"
+)
+
+// validSecurityLevel returns a failure unless level is one of the security levels.
+func validSecurityLevel(level string) *FieldError {
+	switch level {
+	case SecurityLevelNone, SecurityLevelLow, SecurityLevelMedium, SecurityLevelHigh, SecurityLevelCritical:
+		return nil
+	}
+	message := fmt.Sprintf("invalid security level %q, must be one of %s, %s, %s, %s or %s", level, SecurityLevelNone, SecurityLevelLow, SecurityLevelMedium, SecurityLevelHigh, SecurityLevelCritical)
+	return &FieldError{Field: "SecurityLevel", Rule: "oneOf", Message: message, Value: level}
+}
+
+// requireSecurityTeam returns an error unless the client belongs to the security team organization, which
+// defaults to the admin organization, or carries the security team attribute.
+func (s *SmartContract) requireSecurityTeam(ctx contractapi.TransactionContextInterface) error {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get MSPID: %v", err)
+	}
+	securityMSPID := config.SecurityTeamMSPID
+	if securityMSPID == "" {
+		securityMSPID = config.AdminMSPID
+	}
+	if clientMSPID == securityMSPID {
+		return nil
+	}
+	if ctx.GetClientIdentity().AssertAttributeValue(securityTeamAttribute, "true") == nil {
+		return nil
+	}
+
+	return fmt.Errorf("client from org %v is not a member of the security team", clientMSPID)
+}
+
+// securityLevelIndexKey returns the security level index key of a commit.
+func (s *SmartContract) securityLevelIndexKey(ctx contractapi.TransactionContextInterface, gitCommit *GitCommit) (string, error) {
+	indexKey, err := ctx.GetStub().CreateCompositeKey(securityLevelIndexName, []string{gitCommit.SecurityLevel, gitCommit.CommitHash, gitCommit.Repository})
+	if err != nil {
+		return "", fmt.Errorf("failed to create composite key: %v", err)
+	}
+	return indexKey, nil
+}
+
+// FlagSecurityIssue sets the security level of a commit, optionally with a link to the advisory describing
+// the issue. Flagging a commit again replaces its level and advisory. Only the security team may flag commits.
+func (s *SmartContract) FlagSecurityIssue(ctx contractapi.TransactionContextInterface, commitHash string, level string, advisoryURL string) error {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return err
+	}
+	validation := newValidationError(config)
+	validation.add(validSecurityLevel(level))
+	if advisoryURL != "" {
+		parsed, err := url.Parse(advisoryURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			validation.add(&FieldError{Field: "AdvisoryURL", Rule: "httpURL", Message: fmt.Sprintf("invalid advisory URL %q, must be an http or https URL", advisoryURL), Value: advisoryURL})
+		}
+	}
+	err = validation.errorOrNil()
+	if err != nil {
+		return err
+	}
+	err = s.requireSecurityTeam(ctx)
+	if err != nil {
+		return err
+	}
+
+	gitCommit, err := s.ReadGitCommit(ctx, commitHash)
+	if err != nil {
+		return err
+	}
+	if gitCommit.SecurityLevel != "" {
+		oldIndexKey, err := s.securityLevelIndexKey(ctx, gitCommit)
+		if err != nil {
+			return err
+		}
+		err = ctx.GetStub().DelState(oldIndexKey)
+		if err != nil {
+			return fmt.Errorf("failed to delete state: %v", err)
+		}
+	}
+
+	gitCommit.SecurityLevel = level
+	gitCommit.AdvisoryURL = advisoryURL
+	err = s.putGitCommit(ctx, gitCommit)
+	if err != nil {
+		return err
+	}
+
+	indexKey, err := s.securityLevelIndexKey(ctx, gitCommit)
+	if err != nil {
+		return err
+	}
+	// Composite key values cannot be empty, so store a single null byte
+	return ctx.GetStub().PutState(indexKey, []byte{0x00})
+}
+
+// GetCommitsBySecurityLevel returns the commits flagged with the given security level, sorted by commit time.
+// Commits that were never flagged are not returned for any level.
+func (s *SmartContract) GetCommitsBySecurityLevel(ctx contractapi.TransactionContextInterface, level string) ([]*GitCommit, error) {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	validation := newValidationError(config)
+	validation.add(validSecurityLevel(level))
+	err = validation.errorOrNil()
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(securityLevelIndexName, []string{level})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	gitCommits := []*GitCommit{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		gitCommit, err := s.ReadGitCommitInRepository(ctx, compositeKeyParts[2], compositeKeyParts[1])
+		if err != nil {
+			return nil, err
+		}
+		gitCommits = append(gitCommits, gitCommit)
+		err = checkResultLimit(config, len(gitCommits))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sortGitCommits(gitCommits)
+	return gitCommits, nil
+}
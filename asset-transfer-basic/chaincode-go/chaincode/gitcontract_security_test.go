@@ -0,0 +1,89 @@
+package chaincode_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+// setSecurityTeamClient makes subsequent calls come from a client of the given organization that does or
+// does not carry the security team attribute.
+func setSecurityTeamClient(transactionContext *mocks.TransactionContext, mspID string, member bool) {
+	clientIdentity := &mocks.ClientIdentity{}
+	clientIdentity.GetMSPIDReturns(mspID, nil)
+	if !member {
+		clientIdentity.AssertAttributeValueReturns(fmt.Errorf("attribute gitSecurityTeam not found"))
+	}
+	transactionContext.GetClientIdentityReturns(clientIdentity)
+}
+
+func TestFlagSecurityIssue(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+
+	err := gitContract.FlagSecurityIssue(transactionContext, "hash1", "severe", "")
+	require.EqualError(t, err, `invalid security level "severe", must be one of none, low, medium, high or critical`)
+	err = gitContract.FlagSecurityIssue(transactionContext, "hash1", "High", "")
+	require.ErrorContains(t, err, `invalid security level "High"`)
+	err = gitContract.FlagSecurityIssue(transactionContext, "hash1", "high", "javascript:alert(1)")
+	require.EqualError(t, err, `invalid advisory URL "javascript:alert(1)", must be an http or https URL`)
+
+	setSecurityTeamClient(transactionContext, "Org2MSP", false)
+	err = gitContract.FlagSecurityIssue(transactionContext, "hash1", "high", "")
+	require.EqualError(t, err, "client from org Org2MSP is not a member of the security team")
+
+	setSecurityTeamClient(transactionContext, "Org2MSP", true)
+	require.NoError(t, gitContract.FlagSecurityIssue(transactionContext, "hash1", "high", "https://example.com/GHSA-1234"))
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, chaincode.SecurityLevelHigh, gitCommit.SecurityLevel)
+	require.Equal(t, "https://example.com/GHSA-1234", gitCommit.AdvisoryURL)
+
+	setSecurityTeamClient(transactionContext, adminMSPID, false)
+	err = gitContract.FlagSecurityIssue(transactionContext, "hash2", "low", "")
+	require.EqualError(t, err, "the commit hash2 does not exist")
+
+	setClientMSPID(transactionContext, adminMSPID)
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"AdminMSPID":"Org1MSP","SecurityTeamMSPID":"Org3MSP"}`))
+	setSecurityTeamClient(transactionContext, adminMSPID, false)
+	err = gitContract.FlagSecurityIssue(transactionContext, "hash1", "low", "")
+	require.EqualError(t, err, "client from org Org1MSP is not a member of the security team")
+	setSecurityTeamClient(transactionContext, "Org3MSP", false)
+	require.NoError(t, gitContract.FlagSecurityIssue(transactionContext, "hash1", "low", ""))
+}
+
+func TestGetCommitsBySecurityLevel(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo2", "Fixed bug", "Carol"))
+
+	require.NoError(t, gitContract.FlagSecurityIssue(transactionContext, "hash1", "critical", ""))
+	require.NoError(t, gitContract.FlagSecurityIssue(transactionContext, "hash2", "high", ""))
+	require.NoError(t, gitContract.FlagSecurityIssue(transactionContext, "hash3", "critical", ""))
+
+	gitCommits, err := gitContract.GetCommitsBySecurityLevel(transactionContext, "critical")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1", "hash3"}, commitHashes(gitCommits))
+
+	// Reflagging moves the commit to its new level
+	require.NoError(t, gitContract.FlagSecurityIssue(transactionContext, "hash1", "none", ""))
+	gitCommits, err = gitContract.GetCommitsBySecurityLevel(transactionContext, "critical")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash3"}, commitHashes(gitCommits))
+	gitCommits, err = gitContract.GetCommitsBySecurityLevel(transactionContext, "none")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1"}, commitHashes(gitCommits))
+
+	gitCommits, err = gitContract.GetCommitsBySecurityLevel(transactionContext, "medium")
+	require.NoError(t, err)
+	require.Empty(t, gitCommits)
+
+	_, err = gitContract.GetCommitsBySecurityLevel(transactionContext, "urgent")
+	require.ErrorContains(t, err, `invalid security level "urgent"`)
+}
@@ -0,0 +1,99 @@
+package chaincode
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// submitterContent returns the canonical content a submitter signs for a commit: the JSON array of its hash,
+// repository, message and author. Clients sign the SHA-256 digest of it with their enrollment key.
+func submitterContent(commitHash string, repository string, commitMessage string, author string) ([]byte, error) {
+	return json.Marshal([]string{commitHash, repository, commitMessage, author})
+}
+
+// verifySubmitterSignature checks the stored submitter signature of a commit against its content and the
+// stored submitter certificate.
+func verifySubmitterSignature(gitCommit *GitCommit) error {
+	signature, err := base64.StdEncoding.DecodeString(gitCommit.SubmitterSignature)
+	if err != nil {
+		return fmt.Errorf("invalid submitter signature, must be base64: %v", err)
+	}
+	block, _ := pem.Decode([]byte(gitCommit.SubmitterCertificate))
+	if block == nil {
+		return fmt.Errorf("invalid submitter certificate, must be PEM encoded")
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid submitter certificate: %v", err)
+	}
+	content, err := submitterContent(gitCommit.CommitHash, gitCommit.Repository, gitCommit.CommitMessage, gitCommit.Author)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(content)
+	var verified bool
+	switch publicKey := certificate.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		verified = ecdsa.VerifyASN1(publicKey, digest[:], signature)
+	case ed25519.PublicKey:
+		verified = ed25519.Verify(publicKey, digest[:], signature)
+	default:
+		return fmt.Errorf("unsupported submitter key type %T", publicKey)
+	}
+	if !verified {
+		return fmt.Errorf("signature does not match the submitter certificate")
+	}
+
+	return nil
+}
+
+// CreateSignedGitCommit issues a new GitCommit like CreateGitCommit, along with the submitter's signature
+// over its content as returned by submitterContent. The signature must verify against the certificate of the
+// submitting client, which is stored with the commit so that VerifySubmitterSignature can check it later.
+func (s *SmartContract) CreateSignedGitCommit(ctx contractapi.TransactionContextInterface, commitHash string, repository string, commitMessage string, author string, signature string) error {
+	certificate, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return fmt.Errorf("failed to get client certificate: %v", err)
+	}
+	submitter, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client ID: %v", err)
+	}
+
+	gitCommit, err := s.newGitCommit(ctx, commitHash, repository, commitMessage, author)
+	if err != nil {
+		return err
+	}
+	gitCommit.SubmittedBy = submitter
+	gitCommit.SubmitterCertificate = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}))
+	gitCommit.SubmitterSignature = signature
+	err = verifySubmitterSignature(gitCommit)
+	if err != nil {
+		return fmt.Errorf("the submitter signature of commit %s is not valid: %v", commitHash, err)
+	}
+
+	return s.addGitCommit(ctx, gitCommit)
+}
+
+// VerifySubmitterSignature reports whether the stored content of a signed commit still matches the signature
+// its submitter made when creating it.
+func (s *SmartContract) VerifySubmitterSignature(ctx contractapi.TransactionContextInterface, commitHash string) (bool, error) {
+	gitCommit, err := s.ReadGitCommit(ctx, commitHash)
+	if err != nil {
+		return false, err
+	}
+	if gitCommit.SubmitterSignature == "" {
+		return false, fmt.Errorf("the commit %s has no submitter signature", commitHash)
+	}
+
+	return verifySubmitterSignature(gitCommit) == nil, nil
+}
@@ -0,0 +1,111 @@
+package chaincode_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+// newSubmitter returns a signing key and makes subsequent calls come from a client holding its certificate.
+func newSubmitter(t *testing.T, transactionContext *mocks.TransactionContext) *ecdsa.PrivateKey {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "User1@org1.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certificateDER, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	require.NoError(t, err)
+	certificate, err := x509.ParseCertificate(certificateDER)
+	require.NoError(t, err)
+
+	clientIdentity := &mocks.ClientIdentity{}
+	clientIdentity.GetMSPIDReturns(adminMSPID, nil)
+	clientIdentity.GetIDReturns("x509::CN=User1@org1.example.com", nil)
+	clientIdentity.GetX509CertificateReturns(certificate, nil)
+	transactionContext.GetClientIdentityReturns(clientIdentity)
+	return privateKey
+}
+
+// signCommit signs the canonical content of a commit the way the client does.
+func signCommit(t *testing.T, privateKey *ecdsa.PrivateKey, commitHash, repository, commitMessage, author string) string {
+	content, err := json.Marshal([]string{commitHash, repository, commitMessage, author})
+	require.NoError(t, err)
+	digest := sha256.Sum256(content)
+	signature, err := ecdsa.SignASN1(rand.Reader, privateKey, digest[:])
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func TestCreateSignedGitCommit(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	privateKey := newSubmitter(t, transactionContext)
+
+	signature := signCommit(t, privateKey, "hash1", "repo1", "Initial commit", "Alice")
+	require.NoError(t, gitContract.CreateSignedGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice", signature))
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, "x509::CN=User1@org1.example.com", gitCommit.SubmittedBy)
+	require.Contains(t, gitCommit.SubmitterCertificate, "-----BEGIN CERTIFICATE-----")
+	require.Equal(t, signature, gitCommit.SubmitterSignature)
+
+	// A signature over different content is rejected
+	signature = signCommit(t, privateKey, "hash2", "repo1", "Added feature", "Bob")
+	err = gitContract.CreateSignedGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Mallory", signature)
+	require.EqualError(t, err, "the submitter signature of commit hash2 is not valid: signature does not match the submitter certificate")
+
+	// A valid signature made with another client's key is rejected
+	newSubmitter(t, transactionContext)
+	err = gitContract.CreateSignedGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob", signature)
+	require.ErrorContains(t, err, "signature does not match the submitter certificate")
+
+	err = gitContract.CreateSignedGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob", "not base64!")
+	require.ErrorContains(t, err, "invalid submitter signature, must be base64")
+
+	exists, err := gitContract.GitCommitExists(transactionContext, "hash2")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestVerifySubmitterSignature(t *testing.T) {
+	transactionContext, _, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	privateKey := newSubmitter(t, transactionContext)
+
+	signature := signCommit(t, privateKey, "hash1", "repo1", "Initial commit", "Alice")
+	require.NoError(t, gitContract.CreateSignedGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice", signature))
+	verified, err := gitContract.VerifySubmitterSignature(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.True(t, verified)
+
+	// Tamper with the stored message behind the contract's back
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	gitCommit.CommitMessage = "Initial commit with a backdoor"
+	tampered, err := json.Marshal(gitCommit)
+	require.NoError(t, err)
+	world.state["hash1"] = tampered
+
+	verified, err = gitContract.VerifySubmitterSignature(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.False(t, verified)
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+	_, err = gitContract.VerifySubmitterSignature(transactionContext, "hash2")
+	require.EqualError(t, err, "the commit hash2 has no submitter signature")
+}
@@ -0,0 +1,282 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const snapshotKeyPrefix = "SNAPSHOT_"
+
+// SnapshotCommit is a commit of a repository snapshot with the version it was recorded at.
+type SnapshotCommit struct {
+	CommitHash    string `json:"CommitHash"`
+	VersionNumber int    `json:"VersionNumber"`
+}
+
+// SnapshotRef is a named pointer to a commit in a repository snapshot: a tag, or the head of a remote, which
+// is the commit of the latest push to it.
+type SnapshotRef struct {
+	Name       string `json:"Name"`
+	CommitHash string `json:"CommitHash"`
+}
+
+// RepositorySnapshot is a manifest of the state of a repository at one point in time. Commits are sorted by
+// hash and refs by name, so that equal states give equal manifests. Digest is the SHA-256 of the manifest
+// content, everything but the name, digest and creation details, so two snapshots of the same state have the
+// same digest.
+type RepositorySnapshot struct {
+	Repository    string            `json:"Repository"`
+	Name          string            `json:"Name"`
+	VersionNumber int               `json:"VersionNumber"`
+	Commits       []*SnapshotCommit `json:"Commits"`
+	Heads         []*SnapshotRef    `json:"Heads"`
+	Tags          []*SnapshotRef    `json:"Tags"`
+	Digest        string            `json:"Digest"`
+	CreatedAt     string            `json:"CreatedAt"`
+	CreatedBy     string            `json:"CreatedBy"`
+}
+
+// snapshotContent is the part of a RepositorySnapshot its digest covers
+type snapshotContent struct {
+	Repository    string            `json:"Repository"`
+	VersionNumber int               `json:"VersionNumber"`
+	Commits       []*SnapshotCommit `json:"Commits"`
+	Heads         []*SnapshotRef    `json:"Heads"`
+	Tags          []*SnapshotRef    `json:"Tags"`
+}
+
+// snapshotDigest returns the hex encoded SHA-256 of the content of a snapshot.
+func snapshotDigest(snapshot *RepositorySnapshot) (string, error) {
+	content, err := json.Marshal(snapshotContent{
+		Repository:    snapshot.Repository,
+		VersionNumber: snapshot.VersionNumber,
+		Commits:       snapshot.Commits,
+		Heads:         snapshot.Heads,
+		Tags:          snapshot.Tags,
+	})
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256(content)
+	return hex.EncodeToString(digest[:]), nil
+}
+
+// CreateRepositorySnapshot records the current commits, version, remote heads and tags of a repository under
+// a name and returns the snapshot. A name can only be used once per repository.
+func (s *SmartContract) CreateRepositorySnapshot(ctx contractapi.TransactionContextInterface, repository string, name string) (*RepositorySnapshot, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("the snapshot name must not be empty")
+	}
+	err = s.checkRepositoryWriteAccess(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	snapshotKey := snapshotKeyPrefix + repository + "_" + name
+	existing, err := ctx.GetStub().GetState(snapshotKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("the snapshot %s already exists in repository %s", name, repository)
+	}
+
+	repoVersion, err := s.GetRepositoryVersion(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := RepositorySnapshot{
+		Repository:    repository,
+		Name:          name,
+		VersionNumber: repoVersion.VersionNumber,
+		Commits:       []*SnapshotCommit{},
+		Heads:         []*SnapshotRef{},
+		Tags:          []*SnapshotRef{},
+	}
+
+	gitCommits, err := s.getRepositoryCommits(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	for _, gitCommit := range gitCommits {
+		snapshot.Commits = append(snapshot.Commits, &SnapshotCommit{CommitHash: gitCommit.CommitHash, VersionNumber: gitCommit.VersionNumber})
+	}
+	sort.Slice(snapshot.Commits, func(i, j int) bool {
+		return snapshot.Commits[i].CommitHash < snapshot.Commits[j].CommitHash
+	})
+
+	// Pushes come in version order, so the last push to each remote is its head
+	pushTransactions, err := s.getRepositoryPushTransactions(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	heads := make(map[string]string)
+	for _, pushTx := range pushTransactions {
+		heads[pushTx.RemoteURL] = pushTx.CommitHash
+	}
+	for remoteURL, commitHash := range heads {
+		snapshot.Heads = append(snapshot.Heads, &SnapshotRef{Name: remoteURL, CommitHash: commitHash})
+	}
+	sort.Slice(snapshot.Heads, func(i, j int) bool {
+		return snapshot.Heads[i].Name < snapshot.Heads[j].Name
+	})
+
+	tags, err := s.GetTags(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		snapshot.Tags = append(snapshot.Tags, &SnapshotRef{Name: tag.Name, CommitHash: tag.CommitHash})
+	}
+
+	snapshot.Digest, err = snapshotDigest(&snapshot)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.CreatedBy, err = ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MSPID: %v", err)
+	}
+	snapshot.CreatedAt, err = txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshotJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ctx.GetStub().PutState(snapshotKey, snapshotJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to put to world state: %v", err)
+	}
+	return &snapshot, nil
+}
+
+// GetSnapshot returns the snapshot of a repository with given name.
+func (s *SmartContract) GetSnapshot(ctx contractapi.TransactionContextInterface, repository string, name string) (*RepositorySnapshot, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	snapshotJSON, err := ctx.GetStub().GetState(snapshotKeyPrefix + repository + "_" + name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if snapshotJSON == nil {
+		return nil, fmt.Errorf("the snapshot %s does not exist in repository %s", name, repository)
+	}
+
+	var snapshot RepositorySnapshot
+	err = json.Unmarshal(snapshotJSON, &snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// SnapshotRefChange is a ref that points to a different commit in the second of two snapshots. From is empty
+// for a ref that was added, To for one that was removed.
+type SnapshotRefChange struct {
+	Name string `json:"Name"`
+	From string `json:"From"`
+	To   string `json:"To"`
+}
+
+// SnapshotComparison lists what changed in a repository from one snapshot to another.
+type SnapshotComparison struct {
+	Repository     string               `json:"Repository"`
+	From           string               `json:"From"`
+	To             string               `json:"To"`
+	Identical      bool                 `json:"Identical"`
+	FromVersion    int                  `json:"FromVersion"`
+	ToVersion      int                  `json:"ToVersion"`
+	AddedCommits   []string             `json:"AddedCommits"`
+	RemovedCommits []string             `json:"RemovedCommits"`
+	ChangedHeads   []*SnapshotRefChange `json:"ChangedHeads"`
+	ChangedTags    []*SnapshotRefChange `json:"ChangedTags"`
+}
+
+// CompareSnapshots reports the commits added and removed, and the heads and tags moved, between the snapshots
+// nameA and nameB of a repository. The snapshots are identical when their digests are equal.
+func (s *SmartContract) CompareSnapshots(ctx contractapi.TransactionContextInterface, repository string, nameA string, nameB string) (*SnapshotComparison, error) {
+	snapshotA, err := s.GetSnapshot(ctx, repository, nameA)
+	if err != nil {
+		return nil, err
+	}
+	snapshotB, err := s.GetSnapshot(ctx, repository, nameB)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := SnapshotComparison{
+		Repository:     snapshotA.Repository,
+		From:           snapshotA.Name,
+		To:             snapshotB.Name,
+		Identical:      snapshotA.Digest == snapshotB.Digest,
+		FromVersion:    snapshotA.VersionNumber,
+		ToVersion:      snapshotB.VersionNumber,
+		AddedCommits:   []string{},
+		RemovedCommits: []string{},
+		ChangedHeads:   compareSnapshotRefs(snapshotA.Heads, snapshotB.Heads),
+		ChangedTags:    compareSnapshotRefs(snapshotA.Tags, snapshotB.Tags),
+	}
+
+	commitsA := make(map[string]bool, len(snapshotA.Commits))
+	for _, commit := range snapshotA.Commits {
+		commitsA[commit.CommitHash] = true
+	}
+	commitsB := make(map[string]bool, len(snapshotB.Commits))
+	for _, commit := range snapshotB.Commits {
+		commitsB[commit.CommitHash] = true
+		if !commitsA[commit.CommitHash] {
+			comparison.AddedCommits = append(comparison.AddedCommits, commit.CommitHash)
+		}
+	}
+	for _, commit := range snapshotA.Commits {
+		if !commitsB[commit.CommitHash] {
+			comparison.RemovedCommits = append(comparison.RemovedCommits, commit.CommitHash)
+		}
+	}
+
+	return &comparison, nil
+}
+
+// compareSnapshotRefs returns the refs that were added, removed or point to another commit in b, by name.
+func compareSnapshotRefs(a, b []*SnapshotRef) []*SnapshotRefChange {
+	refsA := make(map[string]string, len(a))
+	for _, ref := range a {
+		refsA[ref.Name] = ref.CommitHash
+	}
+	refsB := make(map[string]string, len(b))
+	for _, ref := range b {
+		refsB[ref.Name] = ref.CommitHash
+	}
+
+	changes := []*SnapshotRefChange{}
+	for name, from := range refsA {
+		if to := refsB[name]; to != from {
+			changes = append(changes, &SnapshotRefChange{Name: name, From: from, To: to})
+		}
+	}
+	for name, to := range refsB {
+		if _, ok := refsA[name]; !ok {
+			changes = append(changes, &SnapshotRefChange{Name: name, To: to})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
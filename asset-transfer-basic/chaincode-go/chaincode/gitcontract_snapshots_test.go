@@ -0,0 +1,115 @@
+package chaincode_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareSnapshotsAroundNewCommit(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	setTxTime(chaincodeStub, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+	_, err := gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash2")
+	require.NoError(t, err)
+	require.NoError(t, gitContract.CreateTag(transactionContext, "repo1", "v1.0", "hash1"))
+
+	before, err := gitContract.CreateRepositorySnapshot(transactionContext, "repo1", "before")
+	require.NoError(t, err)
+	require.Equal(t, "repo1", before.Repository)
+	require.Equal(t, 2, before.VersionNumber)
+	require.Equal(t, []*chaincode.SnapshotCommit{{CommitHash: "hash1", VersionNumber: 1}, {CommitHash: "hash2", VersionNumber: 1}}, before.Commits)
+	require.Equal(t, []*chaincode.SnapshotRef{{Name: "https://example.com/repo1.git", CommitHash: "hash2"}}, before.Heads)
+	require.Equal(t, []*chaincode.SnapshotRef{{Name: "v1.0", CommitHash: "hash1"}}, before.Tags)
+	require.Len(t, before.Digest, 64)
+	require.Equal(t, "2026-03-01T12:00:00Z", before.CreatedAt)
+	require.Equal(t, adminMSPID, before.CreatedBy)
+
+	stored, err := gitContract.GetSnapshot(transactionContext, "repo1", "before")
+	require.NoError(t, err)
+	require.Equal(t, before, stored)
+
+	// A snapshot of the same state has the same digest
+	same, err := gitContract.CreateRepositorySnapshot(transactionContext, "repo1", "same")
+	require.NoError(t, err)
+	require.Equal(t, before.Digest, same.Digest)
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo1", "Fixed bug", "Carol"))
+	after, err := gitContract.CreateRepositorySnapshot(transactionContext, "repo1", "after")
+	require.NoError(t, err)
+	require.NotEqual(t, before.Digest, after.Digest)
+
+	comparison, err := gitContract.CompareSnapshots(transactionContext, "repo1", "before", "after")
+	require.NoError(t, err)
+	require.Equal(t, &chaincode.SnapshotComparison{
+		Repository:     "repo1",
+		From:           "before",
+		To:             "after",
+		FromVersion:    2,
+		ToVersion:      2,
+		AddedCommits:   []string{"hash3"},
+		RemovedCommits: []string{},
+		ChangedHeads:   []*chaincode.SnapshotRefChange{},
+		ChangedTags:    []*chaincode.SnapshotRefChange{},
+	}, comparison)
+
+	comparison, err = gitContract.CompareSnapshots(transactionContext, "repo1", "before", "same")
+	require.NoError(t, err)
+	require.True(t, comparison.Identical)
+	require.Empty(t, comparison.AddedCommits)
+
+	// Snapshot records are not commits
+	gitCommits, err := gitContract.QueryCommitsByRepository(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1", "hash2", "hash3"}, commitHashes(gitCommits))
+}
+
+func TestCompareSnapshotsReportsMovedRefs(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+	_, err := gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.NoError(t, err)
+	_, err = gitContract.CreateRepositorySnapshot(transactionContext, "repo1", "a")
+	require.NoError(t, err)
+
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash2")
+	require.NoError(t, err)
+	require.NoError(t, gitContract.CreateTag(transactionContext, "repo1", "v1.0", "hash2"))
+	_, err = gitContract.CreateRepositorySnapshot(transactionContext, "repo1", "b")
+	require.NoError(t, err)
+
+	comparison, err := gitContract.CompareSnapshots(transactionContext, "repo1", "a", "b")
+	require.NoError(t, err)
+	require.False(t, comparison.Identical)
+	require.Equal(t, 2, comparison.FromVersion)
+	require.Equal(t, 3, comparison.ToVersion)
+	require.Empty(t, comparison.AddedCommits)
+	require.Equal(t, []*chaincode.SnapshotRefChange{{Name: "https://example.com/repo1.git", From: "hash1", To: "hash2"}}, comparison.ChangedHeads)
+	require.Equal(t, []*chaincode.SnapshotRefChange{{Name: "v1.0", To: "hash2"}}, comparison.ChangedTags)
+}
+
+func TestCreateRepositorySnapshotErrors(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	_, err := gitContract.CreateRepositorySnapshot(transactionContext, "repo1", "s1")
+	require.NoError(t, err)
+
+	_, err = gitContract.CreateRepositorySnapshot(transactionContext, "repo1", "s1")
+	require.EqualError(t, err, "the snapshot s1 already exists in repository repo1")
+	_, err = gitContract.CreateRepositorySnapshot(transactionContext, "repo1", " ")
+	require.EqualError(t, err, "the snapshot name must not be empty")
+	_, err = gitContract.CreateRepositorySnapshot(transactionContext, "repo2", "s1")
+	require.EqualError(t, err, "the repository repo2 does not have a version number")
+	_, err = gitContract.CompareSnapshots(transactionContext, "repo1", "s1", "s2")
+	require.EqualError(t, err, "the snapshot s2 does not exist in repository repo1")
+}
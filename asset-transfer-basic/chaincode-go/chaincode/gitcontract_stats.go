@@ -0,0 +1,76 @@
+package chaincode
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RepositoryChurn sums the size of the commits a repository received in a time window
+type RepositoryChurn struct {
+	Repository   string `json:"Repository"`
+	Start        string `json:"Start"`
+	End          string `json:"End"`
+	Commits      int    `json:"Commits"`
+	Insertions   int    `json:"Insertions"`
+	Deletions    int    `json:"Deletions"`
+	FilesChanged int    `json:"FilesChanged"`
+}
+
+// CreateGitCommitWithStats issues a new GitCommit like CreateGitCommit and records its size as reported by
+// git show --shortstat. Commits created without stats count as zero.
+func (s *SmartContract) CreateGitCommitWithStats(ctx contractapi.TransactionContextInterface, commitHash string, repository string, commitMessage string, author string, insertions int, deletions int, filesChanged int) error {
+	if insertions < 0 || deletions < 0 || filesChanged < 0 {
+		return fmt.Errorf("commit stats must not be negative, got %d insertions, %d deletions and %d files changed", insertions, deletions, filesChanged)
+	}
+
+	gitCommit, err := s.newGitCommit(ctx, commitHash, repository, commitMessage, author)
+	if err != nil {
+		return err
+	}
+	gitCommit.Insertions = insertions
+	gitCommit.Deletions = deletions
+	gitCommit.FilesChanged = filesChanged
+
+	return s.addGitCommit(ctx, gitCommit)
+}
+
+// GetRepositoryChurn sums the insertions, deletions and changed files of the commits a repository received
+// between the start and end RFC3339 times, both inclusive.
+func (s *SmartContract) GetRepositoryChurn(ctx contractapi.TransactionContextInterface, repository string, start string, end string) (*RepositoryChurn, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	startTime, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start time %q, must be RFC3339: %v", start, err)
+	}
+	endTime, err := time.Parse(time.RFC3339, end)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end time %q, must be RFC3339: %v", end, err)
+	}
+	if startTime.After(endTime) {
+		return nil, fmt.Errorf("the start time %s is after the end time %s", start, end)
+	}
+
+	gitCommits, err := s.getRepositoryCommits(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	churn := &RepositoryChurn{Repository: repository, Start: start, End: end}
+	for _, gitCommit := range gitCommits {
+		at := commitTime(gitCommit)
+		if at.Before(startTime) || at.After(endTime) {
+			continue
+		}
+		churn.Commits++
+		churn.Insertions += gitCommit.Insertions
+		churn.Deletions += gitCommit.Deletions
+		churn.FilesChanged += gitCommit.FilesChanged
+	}
+
+	return churn, nil
+}
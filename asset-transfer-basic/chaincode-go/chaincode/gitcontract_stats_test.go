@@ -0,0 +1,69 @@
+package chaincode_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateGitCommitWithStats(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommitWithStats(transactionContext, "hash1", "repo1", "Initial commit", "Alice", 120, 4, 3))
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, 120, gitCommit.Insertions)
+	require.Equal(t, 4, gitCommit.Deletions)
+	require.Equal(t, 3, gitCommit.FilesChanged)
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+	gitCommit, err = gitContract.ReadGitCommit(transactionContext, "hash2")
+	require.NoError(t, err)
+	require.Zero(t, gitCommit.Insertions)
+	require.Zero(t, gitCommit.Deletions)
+	require.Zero(t, gitCommit.FilesChanged)
+
+	err = gitContract.CreateGitCommitWithStats(transactionContext, "hash3", "repo1", "Fixed bug", "Carol", 1, -1, 1)
+	require.EqualError(t, err, "commit stats must not be negative, got 1 insertions, -1 deletions and 1 files changed")
+	exists, err := gitContract.GitCommitExists(transactionContext, "hash3")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestGetRepositoryChurn(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommitWithStats(transactionContext, "hash1", "repo1", "Initial commit", "Alice", 120, 4, 3))
+	require.NoError(t, gitContract.CreateGitCommitWithStats(transactionContext, "hash2", "repo1", "Added feature", "Bob", 30, 10, 2))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo1", "Fixed typo", "Carol"))
+	require.NoError(t, gitContract.CreateGitCommitWithStats(transactionContext, "hash4", "repo2", "Unrelated", "Dave", 500, 500, 50))
+
+	now := time.Now().UTC()
+	start := now.Add(-time.Hour).Format(time.RFC3339)
+	end := now.Add(time.Hour).Format(time.RFC3339)
+	churn, err := gitContract.GetRepositoryChurn(transactionContext, "repo1", start, end)
+	require.NoError(t, err)
+	require.Equal(t, &chaincode.RepositoryChurn{Repository: "repo1", Start: start, End: end, Commits: 3, Insertions: 150, Deletions: 14, FilesChanged: 5}, churn)
+
+	// The window is inclusive, so a window of a single instant covers a commit made at that instant
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	churn, err = gitContract.GetRepositoryChurn(transactionContext, "repo2", gitCommit.Timestamp, end)
+	require.NoError(t, err)
+	require.Equal(t, 1, churn.Commits)
+
+	earlier := now.Add(-2 * time.Hour).Format(time.RFC3339)
+	churn, err = gitContract.GetRepositoryChurn(transactionContext, "repo1", earlier, start)
+	require.NoError(t, err)
+	require.Zero(t, churn.Commits)
+	require.Zero(t, churn.Insertions)
+
+	_, err = gitContract.GetRepositoryChurn(transactionContext, "repo1", end, start)
+	require.EqualError(t, err, "the start time "+end+" is after the end time "+start)
+	_, err = gitContract.GetRepositoryChurn(transactionContext, "repo1", "last week", end)
+	require.ErrorContains(t, err, `invalid start time "last week", must be RFC3339`)
+}
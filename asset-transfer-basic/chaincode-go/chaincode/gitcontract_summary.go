@@ -0,0 +1,95 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// LedgerSummary holds totals across the whole ledger
+type LedgerSummary struct {
+	Repositories    int `json:"Repositories"`
+	Commits         int `json:"Commits"`
+	Pushes          int `json:"Pushes"`
+	DistinctAuthors int `json:"DistinctAuthors"`
+	// MostRecentlyActiveRepository is the repository that last received a commit or push, at LastActivity
+	MostRecentlyActiveRepository string `json:"MostRecentlyActiveRepository,omitempty"`
+	LastActivity                 string `json:"LastActivity,omitempty"`
+}
+
+// GetLedgerSummary returns the number of repositories, commits, pushes and distinct authors on the ledger and
+// the repository with the latest recorded commit or push. It makes one pass over the version, push and commit
+// partitions and keeps only the counters, so unlike the listing queries it is not bound by MaxQueryResults.
+func (s *SmartContract) GetLedgerSummary(ctx contractapi.TransactionContextInterface) (*LedgerSummary, error) {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &LedgerSummary{}
+	repositories := make(map[string]bool)
+	authors := make(map[string]bool)
+	var lastActivity time.Time
+	recordActivity := func(repository string, timestamp string) {
+		at, err := time.Parse(time.RFC3339, timestamp)
+		if err == nil && at.After(lastActivity) {
+			lastActivity = at
+			summary.MostRecentlyActiveRepository = repository
+			summary.LastActivity = timestamp
+		}
+	}
+
+	// Repositories without commits, such as registered but unused ones, still have a version record
+	resultsIterator, err := ctx.GetStub().GetStateByRange(versionKeyPrefix, versionKeyPrefix+"~")
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		repositories[queryResponse.Key[len(versionKeyPrefix):]] = true
+	}
+
+	pushIterator, err := ctx.GetStub().GetStateByRange(pushKeyPrefix, pushKeyPrefix+"~")
+	if err != nil {
+		return nil, err
+	}
+	defer pushIterator.Close()
+	for pushIterator.HasNext() {
+		queryResponse, err := pushIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var pushTx PushTransaction
+		err = json.Unmarshal(queryResponse.Value, &pushTx)
+		if err != nil {
+			return nil, err
+		}
+		summary.Pushes++
+		recordActivity(pushTx.Repository, pushTx.Timestamp)
+	}
+
+	err = s.forEachGitCommit(ctx, config, func(gitCommit *GitCommit) error {
+		summary.Commits++
+		repositories[gitCommit.Repository] = true
+		authors[gitCommit.Author] = true
+		recorded := gitCommit.RecordedTimestamp
+		if recorded == "" {
+			recorded = gitCommit.Timestamp
+		}
+		recordActivity(gitCommit.Repository, recorded)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summary.Repositories = len(repositories)
+	summary.DistinctAuthors = len(authors)
+	return summary, nil
+}
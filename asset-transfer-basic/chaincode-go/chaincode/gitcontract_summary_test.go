@@ -0,0 +1,47 @@
+package chaincode_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLedgerSummary(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	summary, err := gitContract.GetLedgerSummary(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, &chaincode.LedgerSummary{}, summary)
+
+	setTxTime(chaincodeStub, start)
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo2", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.RegisterRepository(transactionContext, "repo3"))
+
+	setTxTime(chaincodeStub, start.Add(time.Hour))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash4", "repo2", "Fixed bug", "Carol"))
+
+	setTxTime(chaincodeStub, start.Add(2*time.Hour))
+	chaincodeStub.GetTxIDReturns("tx1")
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash2")
+	require.NoError(t, err)
+	chaincodeStub.GetTxIDReturns("tx2")
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash2")
+	require.NoError(t, err)
+
+	summary, err = gitContract.GetLedgerSummary(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, &chaincode.LedgerSummary{
+		Repositories:                 3,
+		Commits:                      4,
+		Pushes:                       2,
+		DistinctAuthors:              3,
+		MostRecentlyActiveRepository: "repo1",
+		LastActivity:                 "2026-01-01T02:00:00Z",
+	}, summary)
+}
@@ -0,0 +1,122 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const tagObjectType = "tag"
+
+// GitTag names a commit of a repository, like a lightweight git tag. Tags cannot be moved once created.
+type GitTag struct {
+	Repository string `json:"Repository"`
+	Name       string `json:"Name"`
+	CommitHash string `json:"CommitHash"`
+	CreatedAt  string `json:"CreatedAt"`
+	CreatedBy  string `json:"CreatedBy"`
+}
+
+// checkTagName returns an error unless a tag name is a single word, as git allows no whitespace in ref names.
+func checkTagName(tagName string) error {
+	if tagName == "" {
+		return fmt.Errorf("the tag name must not be empty")
+	}
+	if strings.IndexFunc(tagName, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) >= 0 {
+		return fmt.Errorf("the tag name %q must not contain whitespace or control characters", tagName)
+	}
+	return nil
+}
+
+// CreateTag tags a commit of a repository. A tag name may only be used once per repository, and the commit
+// must exist in the repository.
+func (s *SmartContract) CreateTag(ctx contractapi.TransactionContextInterface, repository, tagName, commitHash string) error {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return err
+	}
+	tagName = strings.TrimSpace(tagName)
+	err = checkTagName(tagName)
+	if err != nil {
+		return err
+	}
+	err = s.checkRepositoryWriteAccess(ctx, repository)
+	if err != nil {
+		return err
+	}
+	gitCommit, err := s.ReadGitCommitInRepository(ctx, repository, commitHash)
+	if err != nil {
+		return err
+	}
+
+	tagKey, err := ctx.GetStub().CreateCompositeKey(tagObjectType, []string{repository, tagName})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	existing, err := ctx.GetStub().GetState(tagKey)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("the tag %s already exists in repository %s", tagName, repository)
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get MSPID: %v", err)
+	}
+	createdAt, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	tag := GitTag{
+		Repository: repository,
+		Name:       tagName,
+		CommitHash: gitCommit.CommitHash,
+		CreatedAt:  createdAt,
+		CreatedBy:  clientMSPID,
+	}
+	tagJSON, err := json.Marshal(tag)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutState(tagKey, tagJSON)
+	if err != nil {
+		return fmt.Errorf("failed to put to world state: %v", err)
+	}
+	return nil
+}
+
+// GetTags returns the tags of a repository sorted by name.
+func (s *SmartContract) GetTags(ctx contractapi.TransactionContextInterface, repository string) ([]*GitTag, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(tagObjectType, []string{repository})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	tags := []*GitTag{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var tag GitTag
+		err = json.Unmarshal(queryResponse.Value, &tag)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, &tag)
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,73 @@
+package chaincode_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTag(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	setTxTime(chaincodeStub, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo2", "Initial commit", "Carol"))
+
+	require.NoError(t, gitContract.CreateTag(transactionContext, "repo1", "v1.1", "hash2"))
+	require.NoError(t, gitContract.CreateTag(transactionContext, "repo1", " v1.0 ", " hash1"))
+	// The same name may be used in another repository
+	require.NoError(t, gitContract.CreateTag(transactionContext, "repo2", "v1.0", "hash3"))
+
+	tags, err := gitContract.GetTags(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []*chaincode.GitTag{
+		{Repository: "repo1", Name: "v1.0", CommitHash: "hash1", CreatedAt: "2026-03-01T12:00:00Z", CreatedBy: adminMSPID},
+		{Repository: "repo1", Name: "v1.1", CommitHash: "hash2", CreatedAt: "2026-03-01T12:00:00Z", CreatedBy: adminMSPID},
+	}, tags)
+
+	err = gitContract.CreateTag(transactionContext, "repo1", "v1.0", "hash2")
+	require.EqualError(t, err, "the tag v1.0 already exists in repository repo1")
+
+	err = gitContract.CreateTag(transactionContext, "repo1", "v2.0", "hash3")
+	require.EqualError(t, err, "the commit hash3 does not exist in repository repo1")
+
+	err = gitContract.CreateTag(transactionContext, "repo1", "", "hash1")
+	require.EqualError(t, err, "the tag name must not be empty")
+	err = gitContract.CreateTag(transactionContext, "repo1", "release one", "hash1")
+	require.EqualError(t, err, `the tag name "release one" must not contain whitespace or control characters`)
+
+	tags, err = gitContract.GetTags(transactionContext, "repo3")
+	require.NoError(t, err)
+	require.Empty(t, tags)
+}
+
+func TestCreateTagRequiresWriteAccess(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.GrantRepositoryAccess(transactionContext, "repo1", adminMSPID))
+
+	setClientMSPID(transactionContext, "Org2MSP")
+	err := gitContract.CreateTag(transactionContext, "repo1", "v1.0", "hash1")
+	require.EqualError(t, err, "client from org Org2MSP is not allowed to write to repository repo1")
+}
+
+func TestDeleteGitCommitBlockedByTag(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateTag(transactionContext, "repo1", "v1.0", "hash1"))
+
+	references, err := gitContract.GetCommitReferences(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, []*chaincode.CommitReference{{Kind: chaincode.ReferenceTag, Repository: "repo1", Reference: "v1.0"}}, references)
+
+	err = gitContract.DeleteGitCommit(transactionContext, "hash1", false, chaincode.DeletePurge, "")
+	require.EqualError(t, err, "the commit hash1 is still referenced by tag v1.0")
+}
@@ -0,0 +1,68 @@
+package chaincode
+
+import (
+	"sort"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// VersionMilestone is one version of a repository in its timeline
+type VersionMilestone struct {
+	Version int `json:"Version"`
+	// Push is the push that advanced the repository to this version, if one was recorded
+	Push       *PushTransaction `json:"Push,omitempty"`
+	CommitHash string           `json:"CommitHash,omitempty"`
+	Timestamp  string           `json:"Timestamp,omitempty"`
+	// Initial marks the version a repository starts at, which no push produces
+	Initial bool `json:"Initial,omitempty"`
+	// Gap marks a version that was reached without a recorded push, for example by IncrementVersionNumber
+	Gap bool `json:"Gap,omitempty"`
+}
+
+// GetRepositoryTimeline returns one milestone per version of a repository, from its initial version up to the
+// current one, built from the version counter and the recorded pushes. A version reached by several pushes,
+// as can happen with migrated legacy pushes, has one milestone per push.
+func (s *SmartContract) GetRepositoryTimeline(ctx contractapi.TransactionContextInterface, repository string) ([]*VersionMilestone, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	repoVersion, err := s.GetRepositoryVersion(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	pushTransactions, err := s.getRepositoryPushTransactions(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := repoVersion.VersionNumber
+	pushesByVersion := make(map[int][]*PushTransaction)
+	for _, pushTx := range pushTransactions {
+		pushesByVersion[pushTx.Version] = append(pushesByVersion[pushTx.Version], pushTx)
+		if pushTx.Version > latest {
+			latest = pushTx.Version
+		}
+	}
+
+	timeline := []*VersionMilestone{}
+	for version := 1; version <= latest; version++ {
+		pushes := pushesByVersion[version]
+		if len(pushes) == 0 {
+			timeline = append(timeline, &VersionMilestone{Version: version, Initial: version == 1, Gap: version > 1})
+			continue
+		}
+		// Within a version, pushes come back ordered by transaction ID rather than time, which then breaks ties
+		sort.SliceStable(pushes, func(i, j int) bool {
+			if pushes[i].Timestamp != pushes[j].Timestamp {
+				return pushes[i].Timestamp < pushes[j].Timestamp
+			}
+			return pushes[i].TxID < pushes[j].TxID
+		})
+		for _, pushTx := range pushes {
+			timeline = append(timeline, &VersionMilestone{Version: version, Push: pushTx, CommitHash: pushTx.CommitHash, Timestamp: pushTx.Timestamp})
+		}
+	}
+
+	return timeline, nil
+}
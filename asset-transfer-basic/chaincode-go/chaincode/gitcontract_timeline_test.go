@@ -0,0 +1,47 @@
+package chaincode_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRepositoryTimeline(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Added feature", "Bob"))
+
+	setTxTime(chaincodeStub, start.Add(time.Hour))
+	chaincodeStub.GetTxIDReturns("tx1")
+	_, err := gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash1")
+	require.NoError(t, err)
+	_, err = gitContract.IncrementVersionNumber(transactionContext, "repo1")
+	require.NoError(t, err)
+	setTxTime(chaincodeStub, start.Add(2*time.Hour))
+	chaincodeStub.GetTxIDReturns("tx2")
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", "hash2")
+	require.NoError(t, err)
+
+	timeline, err := gitContract.GetRepositoryTimeline(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Len(t, timeline, 4)
+
+	require.Equal(t, &chaincode.VersionMilestone{Version: 1, Initial: true}, timeline[0])
+	require.Equal(t, 2, timeline[1].Version)
+	require.Equal(t, "hash1", timeline[1].CommitHash)
+	require.Equal(t, "2026-01-01T01:00:00Z", timeline[1].Timestamp)
+	require.Equal(t, "https://example.com/repo1.git", timeline[1].Push.RemoteURL)
+	require.False(t, timeline[1].Gap)
+	require.Equal(t, &chaincode.VersionMilestone{Version: 3, Gap: true}, timeline[2])
+	require.Equal(t, 4, timeline[3].Version)
+	require.Equal(t, "hash2", timeline[3].CommitHash)
+
+	timeline, err = gitContract.GetRepositoryTimeline(transactionContext, "repo2")
+	require.EqualError(t, err, "the repository repo2 does not have a version number")
+	require.Nil(t, timeline)
+}
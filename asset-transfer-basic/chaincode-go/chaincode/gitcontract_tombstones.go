@@ -0,0 +1,161 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const tombstoneObjectType = "tombstone"
+
+// tombstoneKey returns the key of a commit's tombstone. Like commit keys, it includes the repository only when
+// commits are repository scoped.
+func tombstoneKey(ctx contractapi.TransactionContextInterface, config *ContractConfig, repository, commitHash string) (string, error) {
+	attributes := []string{commitHash}
+	if config.RepositoryScopedCommits {
+		attributes = append(attributes, repository)
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(tombstoneObjectType, attributes)
+	if err != nil {
+		return "", fmt.Errorf("failed to create composite key: %v", err)
+	}
+	return key, nil
+}
+
+// CommitTombstone is left in place of a commit deleted by DeleteGitCommit in the tombstone mode
+type CommitTombstone struct {
+	CommitHash string `json:"CommitHash"`
+	Repository string `json:"Repository"`
+	Reason     string `json:"Reason"`
+	// DeletedBy is the organization of the client that deleted the commit
+	DeletedBy string `json:"DeletedBy"`
+	DeletedAt string `json:"DeletedAt"`
+	IsDeleted bool   `json:"IsDeleted"`
+}
+
+// CommitDeletedError is returned by reads of a commit that was deleted with a tombstone, in place of the error
+// for a commit that never existed.
+type CommitDeletedError struct {
+	Tombstone *CommitTombstone
+}
+
+func (e *CommitDeletedError) Error() string {
+	tombstone := e.Tombstone
+	message := fmt.Sprintf("the commit %s was deleted from repository %s by %s at %s", tombstone.CommitHash, tombstone.Repository, tombstone.DeletedBy, tombstone.DeletedAt)
+	if tombstone.Reason != "" {
+		message += ": " + tombstone.Reason
+	}
+	return message
+}
+
+// putTombstone stores the tombstone of a commit that was just removed, recording the organization of the
+// client and the transaction time.
+func (s *SmartContract) putTombstone(ctx contractapi.TransactionContextInterface, config *ContractConfig, gitCommit *GitCommit, reason string) error {
+	tombstone := &CommitTombstone{CommitHash: gitCommit.CommitHash, Repository: gitCommit.Repository, Reason: reason, IsDeleted: true}
+	var err error
+	tombstone.DeletedBy, err = ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get MSPID: %v", err)
+	}
+	tombstone.DeletedAt, err = txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	key, err := tombstoneKey(ctx, config, tombstone.Repository, tombstone.CommitHash)
+	if err != nil {
+		return err
+	}
+	tombstoneJSON, err := json.Marshal(tombstone)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(key, tombstoneJSON)
+	if err != nil {
+		return fmt.Errorf("failed to put to world state: %v", err)
+	}
+	return nil
+}
+
+// GetTombstones returns the tombstones of the commits deleted from a repository, ordered by commit hash.
+// Tombstones are keyed by commit hash, so this scans the tombstones of all repositories.
+func (s *SmartContract) GetTombstones(ctx contractapi.TransactionContextInterface, repository string) ([]*CommitTombstone, error) {
+	repository, err := s.canonicalRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	tombstones, err := s.getTombstones(ctx, []string{})
+	if err != nil {
+		return nil, err
+	}
+
+	matching := []*CommitTombstone{}
+	for _, tombstone := range tombstones {
+		if tombstone.Repository == repository {
+			matching = append(matching, tombstone)
+		}
+	}
+	return matching, nil
+}
+
+// getTombstones returns the tombstones whose key starts with the given attributes.
+func (s *SmartContract) getTombstones(ctx contractapi.TransactionContextInterface, attributes []string) ([]*CommitTombstone, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(tombstoneObjectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var tombstones []*CommitTombstone
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var tombstone CommitTombstone
+		err = json.Unmarshal(queryResponse.Value, &tombstone)
+		if err != nil {
+			return nil, err
+		}
+		tombstones = append(tombstones, &tombstone)
+	}
+	return tombstones, nil
+}
+
+// missingCommitError returns the error for a commit that is not in the world state: a CommitDeletedError if it
+// was deleted with a tombstone, else notFound. An empty repository matches a tombstone in any repository.
+func (s *SmartContract) missingCommitError(ctx contractapi.TransactionContextInterface, config *ContractConfig, repository, commitHash string, notFound error) error {
+	var tombstones []*CommitTombstone
+	if config.RepositoryScopedCommits {
+		var err error
+		tombstones, err = s.getTombstones(ctx, []string{commitHash})
+		if err != nil {
+			return err
+		}
+	} else {
+		key, err := tombstoneKey(ctx, config, repository, commitHash)
+		if err != nil {
+			return err
+		}
+		tombstoneJSON, err := ctx.GetStub().GetState(key)
+		if err != nil {
+			return fmt.Errorf("failed to read from world state: %v", err)
+		}
+		if tombstoneJSON != nil {
+			var tombstone CommitTombstone
+			err = json.Unmarshal(tombstoneJSON, &tombstone)
+			if err != nil {
+				return err
+			}
+			tombstones = append(tombstones, &tombstone)
+		}
+	}
+
+	for _, tombstone := range tombstones {
+		if repository == "" || tombstone.Repository == repository {
+			return &CommitDeletedError{Tombstone: tombstone}
+		}
+	}
+	return notFound
+}
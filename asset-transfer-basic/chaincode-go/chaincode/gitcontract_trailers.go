@@ -0,0 +1,155 @@
+package chaincode
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// trailerIndexName names the trailer index. Entries also carry the repository after the hash, which
+// entries written before repository scoped commits lack.
+const trailerIndexName = "trailer~key~value~hash"
+
+// trailerPattern matches a "Key: value" trailer line such as "Signed-off-by: Alice <alice@example.com>"
+var trailerPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*):[ \t]*(.*)$`)
+
+// parseTrailers returns the trailers of a commit message, keyed as written and with the values of repeated
+// keys in message order. Like git, it only considers the last paragraph, and only when that paragraph is
+// not the subject and every line in it is a trailer or an indented continuation of one.
+func parseTrailers(commitMessage string) map[string][]string {
+	lines := strings.Split(strings.TrimRight(commitMessage, " \t\r\n"), "\n")
+
+	start := -1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.TrimSpace(lines[i]) == "" {
+			start = i + 1
+			break
+		}
+	}
+	if start <= 0 {
+		return nil
+	}
+
+	type trailer struct {
+		key   string
+		value string
+	}
+	var trailers []trailer
+	for _, line := range lines[start:] {
+		line = strings.TrimRight(line, " \t\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(trailers) > 0 {
+			last := &trailers[len(trailers)-1]
+			last.value = strings.TrimSpace(last.value + " " + strings.TrimSpace(line))
+			continue
+		}
+		match := trailerPattern.FindStringSubmatch(line)
+		if match == nil {
+			return nil
+		}
+		trailers = append(trailers, trailer{key: match[1], value: match[2]})
+	}
+
+	parsed := make(map[string][]string)
+	for _, t := range trailers {
+		if t.value != "" {
+			parsed[t.key] = append(parsed[t.key], t.value)
+		}
+	}
+	if len(parsed) == 0 {
+		return nil
+	}
+	return parsed
+}
+
+// trailerIndexKeys returns the trailer index keys of a commit. Keys are indexed in lower case because git
+// matches trailer keys case-insensitively. Legacy keys lack the repository attribute.
+func (s *SmartContract) trailerIndexKeys(ctx contractapi.TransactionContextInterface, gitCommit *GitCommit, legacy bool) ([]string, error) {
+	keys := make([]string, 0, len(gitCommit.Trailers))
+	for key := range gitCommit.Trailers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var indexKeys []string
+	for _, key := range keys {
+		for _, value := range gitCommit.Trailers[key] {
+			attributes := []string{strings.ToLower(key), value, gitCommit.CommitHash}
+			if !legacy {
+				attributes = append(attributes, gitCommit.Repository)
+			}
+			indexKey, err := ctx.GetStub().CreateCompositeKey(trailerIndexName, attributes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create composite key: %v", err)
+			}
+			indexKeys = append(indexKeys, indexKey)
+		}
+	}
+
+	return indexKeys, nil
+}
+
+// indexTrailers adds a trailer index entry for every trailer of a commit.
+func (s *SmartContract) indexTrailers(ctx contractapi.TransactionContextInterface, gitCommit *GitCommit) error {
+	indexKeys, err := s.trailerIndexKeys(ctx, gitCommit, false)
+	if err != nil {
+		return err
+	}
+
+	for _, indexKey := range indexKeys {
+		// Composite key values cannot be empty, so store a single null byte
+		err = ctx.GetStub().PutState(indexKey, []byte{0x00})
+		if err != nil {
+			return fmt.Errorf("failed to put to world state: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// QueryCommitsByTrailer returns the commits carrying a trailer with the given key and value, for example
+// all commits with "Fixes: #123", sorted by timestamp. The key is matched case-insensitively.
+func (s *SmartContract) QueryCommitsByTrailer(ctx contractapi.TransactionContextInterface, key string, value string) ([]*GitCommit, error) {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(trailerIndexName, []string{strings.ToLower(key), value})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	gitCommits := []*GitCommit{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		var gitCommit *GitCommit
+		if len(compositeKeyParts) > 3 {
+			gitCommit, err = s.ReadGitCommitInRepository(ctx, compositeKeyParts[3], compositeKeyParts[2])
+		} else {
+			gitCommit, err = s.ReadGitCommit(ctx, compositeKeyParts[2])
+		}
+		if err != nil {
+			return nil, err
+		}
+		gitCommits = append(gitCommits, gitCommit)
+		err = checkResultLimit(config, len(gitCommits))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sortGitCommits(gitCommits)
+	return gitCommits, nil
+}
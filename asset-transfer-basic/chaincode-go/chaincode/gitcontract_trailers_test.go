@@ -0,0 +1,70 @@
+package chaincode_test
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateGitCommitParsesTrailers(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	message := "Fix crash on empty input\n\nFixes: missing nil check\nin the parser\n\nFixes: #123\nFixes: #124\nReviewed-by: Bob\nSigned-off-by: Alice <alice@example.com>\n  and Carol\n"
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", message, "Alice"))
+
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		"Fixes":         {"#123", "#124"},
+		"Reviewed-by":   {"Bob"},
+		"Signed-off-by": {"Alice <alice@example.com> and Carol"},
+	}, gitCommit.Trailers)
+}
+
+func TestCreateGitCommitWithoutTrailers(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	for hash, message := range map[string]string{
+		"hash1": "Fixes: #123",
+		"hash2": "Fix crash\n\nFixes: #123\nSee the bug tracker for details",
+		"hash3": "Fix crash\n\nFixes: #123\n\nMore details below the trailers",
+	} {
+		require.NoError(t, gitContract.CreateGitCommit(transactionContext, hash, "repo1", message, "Alice"))
+		gitCommit, err := gitContract.ReadGitCommit(transactionContext, hash)
+		require.NoError(t, err)
+		require.Nil(t, gitCommit.Trailers, message)
+	}
+
+	gitCommits, err := gitContract.QueryCommitsByTrailer(transactionContext, "Fixes", "#123")
+	require.NoError(t, err)
+	require.Empty(t, gitCommits)
+}
+
+func TestQueryCommitsByTrailer(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Fix crash\n\nFixes: #123", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Fix crash again\n\nfixes: #123\nFixes: #124", "Bob"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo2", "Add feature\n\nReviewed-by: Carol", "Carol"))
+	require.NoError(t, gitContract.CreateMergeCommit(transactionContext, "hash4", "repo1", "hash1", "hash2", "Merge fixes\n\nFixes: #124", "Dave"))
+
+	gitCommits, err := gitContract.QueryCommitsByTrailer(transactionContext, "Fixes", "#123")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"hash1", "hash2"}, commitHashes(gitCommits))
+
+	gitCommits, err = gitContract.QueryCommitsByTrailer(transactionContext, "FIXES", "#124")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"hash2", "hash4"}, commitHashes(gitCommits))
+
+	gitCommits, err = gitContract.QueryCommitsByTrailer(transactionContext, "Fixes", "#12")
+	require.NoError(t, err)
+	require.Empty(t, gitCommits)
+
+	gitCommits, err = gitContract.QueryCommitsByTrailer(transactionContext, "Reviewed-by", "Carol")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash3"}, commitHashes(gitCommits))
+}
@@ -0,0 +1,217 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	releaseTrainObjectType = "releaseTrain"
+	trainCommitIndexName   = "train~name~repository~hash"
+	commitTrainIndexName   = "commitTrain~repository~hash~name"
+)
+
+// ReleaseTrain groups commits of several repositories that are released together.
+type ReleaseTrain struct {
+	Name        string `json:"Name"`
+	Description string `json:"Description"`
+	CreatedAt   string `json:"CreatedAt"`
+	CreatedBy   string `json:"CreatedBy"`
+}
+
+// CreateReleaseTrain creates an empty release train. Train names are unique across all repositories.
+func (s *SmartContract) CreateReleaseTrain(ctx contractapi.TransactionContextInterface, name string, description string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("the release train name must not be empty")
+	}
+	trainKey, err := ctx.GetStub().CreateCompositeKey(releaseTrainObjectType, []string{name})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	existing, err := ctx.GetStub().GetState(trainKey)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("the release train %s already exists", name)
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get MSPID: %v", err)
+	}
+	createdAt, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	train := ReleaseTrain{
+		Name:        name,
+		Description: description,
+		CreatedAt:   createdAt,
+		CreatedBy:   clientMSPID,
+	}
+	trainJSON, err := json.Marshal(train)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutState(trainKey, trainJSON)
+	if err != nil {
+		return fmt.Errorf("failed to put to world state: %v", err)
+	}
+	return nil
+}
+
+// ReadReleaseTrain returns the release train stored in the world state with given name.
+func (s *SmartContract) ReadReleaseTrain(ctx contractapi.TransactionContextInterface, name string) (*ReleaseTrain, error) {
+	name = strings.TrimSpace(name)
+	trainKey, err := ctx.GetStub().CreateCompositeKey(releaseTrainObjectType, []string{name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create composite key: %v", err)
+	}
+	trainJSON, err := ctx.GetStub().GetState(trainKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if trainJSON == nil {
+		return nil, fmt.Errorf("the release train %s does not exist", name)
+	}
+
+	var train ReleaseTrain
+	err = json.Unmarshal(trainJSON, &train)
+	if err != nil {
+		return nil, err
+	}
+	return &train, nil
+}
+
+// AddCommitToTrain adds a commit to a release train. The client needs write access to the repository of the
+// commit, and a commit can only be added to a train once.
+func (s *SmartContract) AddCommitToTrain(ctx contractapi.TransactionContextInterface, trainName string, commitHash string) error {
+	train, err := s.ReadReleaseTrain(ctx, trainName)
+	if err != nil {
+		return err
+	}
+	gitCommit, err := s.ReadGitCommit(ctx, commitHash)
+	if err != nil {
+		return err
+	}
+	err = s.checkRepositoryWriteAccess(ctx, gitCommit.Repository)
+	if err != nil {
+		return err
+	}
+
+	memberKey, err := ctx.GetStub().CreateCompositeKey(trainCommitIndexName, []string{train.Name, gitCommit.Repository, gitCommit.CommitHash})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	existing, err := ctx.GetStub().GetState(memberKey)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("the commit %s is already in release train %s", gitCommit.CommitHash, train.Name)
+	}
+	reverseKey, err := ctx.GetStub().CreateCompositeKey(commitTrainIndexName, []string{gitCommit.Repository, gitCommit.CommitHash, train.Name})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+
+	// Composite key values cannot be empty, so store a single null byte
+	for _, key := range []string{memberKey, reverseKey} {
+		err = ctx.GetStub().PutState(key, []byte{0x00})
+		if err != nil {
+			return fmt.Errorf("failed to put to world state: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetTrainCommits returns the commits of a release train, grouped by repository and in commit time order,
+// then hash order, within each repository.
+func (s *SmartContract) GetTrainCommits(ctx contractapi.TransactionContextInterface, trainName string) ([]*GitCommit, error) {
+	train, err := s.ReadReleaseTrain(ctx, trainName)
+	if err != nil {
+		return nil, err
+	}
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(trainCommitIndexName, []string{train.Name})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	gitCommits := []*GitCommit{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		gitCommit, err := s.ReadGitCommitInRepository(ctx, keyParts[1], keyParts[2])
+		if err != nil {
+			return nil, err
+		}
+		gitCommits = append(gitCommits, gitCommit)
+	}
+
+	sort.Slice(gitCommits, func(i, j int) bool {
+		if gitCommits[i].Repository != gitCommits[j].Repository {
+			return gitCommits[i].Repository < gitCommits[j].Repository
+		}
+		return commitBefore(gitCommits[i], gitCommits[j])
+	})
+	return gitCommits, nil
+}
+
+// trainMembershipKeys returns the keys recording the release train memberships of a commit, so that a removed
+// commit leaves its trains.
+func (s *SmartContract) trainMembershipKeys(ctx contractapi.TransactionContextInterface, gitCommit *GitCommit) ([]string, error) {
+	trainNames, err := s.getCommitTrains(ctx, gitCommit)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, trainName := range trainNames {
+		memberKey, err := ctx.GetStub().CreateCompositeKey(trainCommitIndexName, []string{trainName, gitCommit.Repository, gitCommit.CommitHash})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create composite key: %v", err)
+		}
+		reverseKey, err := ctx.GetStub().CreateCompositeKey(commitTrainIndexName, []string{gitCommit.Repository, gitCommit.CommitHash, trainName})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create composite key: %v", err)
+		}
+		keys = append(keys, memberKey, reverseKey)
+	}
+	return keys, nil
+}
+
+// getCommitTrains returns the names of the release trains a commit belongs to.
+func (s *SmartContract) getCommitTrains(ctx contractapi.TransactionContextInterface, gitCommit *GitCommit) ([]string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(commitTrainIndexName, []string{gitCommit.Repository, gitCommit.CommitHash})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	trainNames := []string{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		trainNames = append(trainNames, keyParts[2])
+	}
+	return trainNames, nil
+}
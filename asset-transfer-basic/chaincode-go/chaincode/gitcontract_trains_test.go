@@ -0,0 +1,82 @@
+package chaincode_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseTrain(t *testing.T) {
+	transactionContext, chaincodeStub, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+	setTxTime(chaincodeStub, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo2", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Initial commit", "Bob"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo1", "Added feature", "Carol"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash4", "repo1", "Not released", "Dave"))
+
+	require.NoError(t, gitContract.CreateReleaseTrain(transactionContext, " 2026.03 ", "March release"))
+	train, err := gitContract.ReadReleaseTrain(transactionContext, "2026.03")
+	require.NoError(t, err)
+	require.Equal(t, &chaincode.ReleaseTrain{Name: "2026.03", Description: "March release", CreatedAt: "2026-03-01T12:00:00Z", CreatedBy: adminMSPID}, train)
+
+	require.NoError(t, gitContract.AddCommitToTrain(transactionContext, "2026.03", "hash3"))
+	require.NoError(t, gitContract.AddCommitToTrain(transactionContext, "2026.03", "hash1"))
+	require.NoError(t, gitContract.AddCommitToTrain(transactionContext, "2026.03", "hash2"))
+
+	gitCommits, err := gitContract.GetTrainCommits(transactionContext, "2026.03")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash2", "hash3", "hash1"}, commitHashes(gitCommits))
+
+	err = gitContract.AddCommitToTrain(transactionContext, "2026.03", "hash1")
+	require.EqualError(t, err, "the commit hash1 is already in release train 2026.03")
+	err = gitContract.AddCommitToTrain(transactionContext, "2026.03", "hash9")
+	require.EqualError(t, err, "the commit hash9 does not exist")
+	err = gitContract.AddCommitToTrain(transactionContext, "2026.04", "hash4")
+	require.EqualError(t, err, "the release train 2026.04 does not exist")
+	_, err = gitContract.GetTrainCommits(transactionContext, "2026.04")
+	require.EqualError(t, err, "the release train 2026.04 does not exist")
+
+	err = gitContract.CreateReleaseTrain(transactionContext, "2026.03", "Again")
+	require.EqualError(t, err, "the release train 2026.03 already exists")
+	err = gitContract.CreateReleaseTrain(transactionContext, " ", "")
+	require.EqualError(t, err, "the release train name must not be empty")
+}
+
+func TestAddCommitToTrainRequiresWriteAccess(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.GrantRepositoryAccess(transactionContext, "repo1", adminMSPID))
+	require.NoError(t, gitContract.CreateReleaseTrain(transactionContext, "2026.03", ""))
+
+	setClientMSPID(transactionContext, "Org2MSP")
+	err := gitContract.AddCommitToTrain(transactionContext, "2026.03", "hash1")
+	require.EqualError(t, err, "client from org Org2MSP is not allowed to write to repository repo1")
+}
+
+func TestDeleteGitCommitBlockedByReleaseTrain(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateReleaseTrain(transactionContext, "2026.03", ""))
+	require.NoError(t, gitContract.AddCommitToTrain(transactionContext, "2026.03", "hash1"))
+
+	references, err := gitContract.GetCommitReferences(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, []*chaincode.CommitReference{{Kind: chaincode.ReferenceTrain, Repository: "repo1", Reference: "2026.03"}}, references)
+
+	err = gitContract.DeleteGitCommit(transactionContext, "hash1", false, chaincode.DeletePurge, "")
+	require.EqualError(t, err, "the commit hash1 is still referenced by release train 2026.03")
+
+	// A forced delete takes the commit out of the train
+	require.NoError(t, gitContract.ForceDeleteGitCommit(transactionContext, "hash1"))
+	gitCommits, err := gitContract.GetTrainCommits(transactionContext, "2026.03")
+	require.NoError(t, err)
+	require.Empty(t, gitCommits)
+}
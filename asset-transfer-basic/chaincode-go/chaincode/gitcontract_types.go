@@ -0,0 +1,130 @@
+package chaincode
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const commitTypeIndexName = "type~type~repository~hash"
+
+// defaultConventionalCommitTypes are the commit types indexed when the ConventionalCommitTypes setting is empty,
+// those of the Angular convention that Conventional Commits grew out of
+var defaultConventionalCommitTypes = []string{"build", "chore", "ci", "docs", "feat", "fix", "perf", "refactor", "revert", "style", "test"}
+
+// conventionalCommitPattern matches the header of a Conventional Commits message, such as "feat: ...",
+// "fix(parser): ..." or "refactor!: ...", and captures its type
+var conventionalCommitPattern = regexp.MustCompile(`^([A-Za-z]+)(?:\([^()\r\n]*\))?!?:\s`)
+
+// parseCommitType returns the lower cased Conventional Commits type of a commit message, or "" when the
+// message does not start with one. Types are not case sensitive, so "Fix:" and "fix:" are the same type.
+func parseCommitType(commitMessage string) string {
+	match := conventionalCommitPattern.FindStringSubmatch(commitMessage)
+	if match == nil {
+		return ""
+	}
+	return strings.ToLower(match[1])
+}
+
+// conventionalCommitTypes returns the commit types allowed by the contract settings.
+func conventionalCommitTypes(config *ContractConfig) []string {
+	if len(config.ConventionalCommitTypes) == 0 {
+		return defaultConventionalCommitTypes
+	}
+	return config.ConventionalCommitTypes
+}
+
+// allowedCommitType reports whether the contract settings allow a commit type.
+func allowedCommitType(config *ContractConfig, commitType string) bool {
+	for _, allowed := range conventionalCommitTypes(config) {
+		if commitType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// commitTypeIndexKey returns the commit type index key of a commit, or "" when its message has no type.
+// The key does not depend on the allowed types, so that it is found for removal after they changed.
+func (s *SmartContract) commitTypeIndexKey(ctx contractapi.TransactionContextInterface, gitCommit *GitCommit) (string, error) {
+	commitType := parseCommitType(gitCommit.CommitMessage)
+	if commitType == "" {
+		return "", nil
+	}
+	indexKey, err := ctx.GetStub().CreateCompositeKey(commitTypeIndexName, []string{commitType, gitCommit.Repository, gitCommit.CommitHash})
+	if err != nil {
+		return "", fmt.Errorf("failed to create composite key: %v", err)
+	}
+	return indexKey, nil
+}
+
+// indexCommitType adds the commit type index entry of a commit whose message has an allowed type. Messages
+// with other types, such as "WIP: ...", are treated as not conventional.
+func (s *SmartContract) indexCommitType(ctx contractapi.TransactionContextInterface, config *ContractConfig, gitCommit *GitCommit) error {
+	if !allowedCommitType(config, parseCommitType(gitCommit.CommitMessage)) {
+		return nil
+	}
+	indexKey, err := s.commitTypeIndexKey(ctx, gitCommit)
+	if err != nil {
+		return err
+	}
+
+	// Composite key values cannot be empty, so store a single null byte
+	err = ctx.GetStub().PutState(indexKey, []byte{0x00})
+	if err != nil {
+		return fmt.Errorf("failed to put to world state: %v", err)
+	}
+	return nil
+}
+
+// GetCommitsByType returns the commits of a repository whose message starts with the Conventional Commits
+// type commitType, with or without a scope, as in "feat: ..." or "feat(api): ...", sorted by commit time.
+// The type must be one of the ConventionalCommitTypes setting. Commits created before the type index was
+// added are not found.
+func (s *SmartContract) GetCommitsByType(ctx contractapi.TransactionContextInterface, repository string, commitType string) ([]*GitCommit, error) {
+	config, err := s.GetContractConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	repository = canonicalRepositoryName(config, repository)
+	if repository == "" {
+		return nil, fmt.Errorf("the repository name must not be empty")
+	}
+	commitType = strings.ToLower(strings.TrimSpace(commitType))
+	if !allowedCommitType(config, commitType) {
+		return nil, fmt.Errorf("the commit type %q is not one of %s", commitType, strings.Join(conventionalCommitTypes(config), ", "))
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(commitTypeIndexName, []string{commitType, repository})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	gitCommits := []*GitCommit{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		gitCommit, err := s.ReadGitCommitInRepository(ctx, compositeKeyParts[1], compositeKeyParts[2])
+		if err != nil {
+			return nil, err
+		}
+		gitCommits = append(gitCommits, gitCommit)
+		err = checkResultLimit(config, len(gitCommits))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sortGitCommits(gitCommits)
+	return gitCommits, nil
+}
@@ -0,0 +1,72 @@
+package chaincode_test
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCommitsByType(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "feat: add login", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "feat(api)!: drop v1 endpoints", "Bob"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo1", "Fix(parser): handle empty input", "Carol"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash4", "repo1", "Add README\n\nfeat: not a header", "Dave"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash5", "repo1", "feature: unknown type", "Erin"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash6", "repo1", "feat:missing space", "Frank"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash7", "repo2", "feat: other repository", "Grace"))
+
+	gitCommits, err := gitContract.GetCommitsByType(transactionContext, "repo1", "feat")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"hash1", "hash2"}, commitHashes(gitCommits))
+
+	// Types are not case sensitive
+	gitCommits, err = gitContract.GetCommitsByType(transactionContext, "repo1", "FIX")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash3"}, commitHashes(gitCommits))
+
+	gitCommits, err = gitContract.GetCommitsByType(transactionContext, "repo1", "docs")
+	require.NoError(t, err)
+	require.Empty(t, gitCommits)
+
+	_, err = gitContract.GetCommitsByType(transactionContext, "repo1", "feature")
+	require.EqualError(t, err, `the commit type "feature" is not one of build, chore, ci, docs, feat, fix, perf, refactor, revert, style, test`)
+
+	// Deleting a commit removes it from the index
+	require.NoError(t, gitContract.DeleteGitCommit(transactionContext, "hash1", false, chaincode.DeletePurge, ""))
+	gitCommits, err = gitContract.GetCommitsByType(transactionContext, "repo1", "feat")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash2"}, commitHashes(gitCommits))
+}
+
+func TestConventionalCommitTypesSetting(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"ConventionalCommitTypes": ["feat", "fix", "feature"]}`))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "feature: custom type", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "chore: no longer allowed", "Bob"))
+
+	gitCommits, err := gitContract.GetCommitsByType(transactionContext, "repo1", "feature")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1"}, commitHashes(gitCommits))
+	_, err = gitContract.GetCommitsByType(transactionContext, "repo1", "chore")
+	require.EqualError(t, err, `the commit type "chore" is not one of feat, fix, feature`)
+
+	// Re-typing a commit moves it in the index
+	require.NoError(t, gitContract.PatchGitCommit(transactionContext, "hash1", `{"commitMessage": "fix: typed again"}`))
+	gitCommits, err = gitContract.GetCommitsByType(transactionContext, "repo1", "feature")
+	require.NoError(t, err)
+	require.Empty(t, gitCommits)
+	gitCommits, err = gitContract.GetCommitsByType(transactionContext, "repo1", "fix")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1"}, commitHashes(gitCommits))
+
+	err = gitContract.SetContractConfig(transactionContext, `{"ConventionalCommitTypes": ["Feat"]}`)
+	require.EqualError(t, err, `ConventionalCommitTypes must be lower case letters only, got "Feat"`)
+	err = gitContract.SetContractConfig(transactionContext, `{"ConventionalCommitTypes": [""]}`)
+	require.EqualError(t, err, `ConventionalCommitTypes must be lower case letters only, got ""`)
+}
@@ -0,0 +1,141 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError describes one input field that failed validation
+type FieldError struct {
+	// Field is the name of the GitCommit field or contract argument that failed
+	Field string `json:"field"`
+	// Rule names the check that failed, such as "utf8" or "rfc3339"
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Value   string `json:"value"`
+}
+
+// ValidationError collects every input field of a call that failed validation. With the
+// StructuredValidationErrors setting its message is a JSON object of the form {"validationErrors": [...]},
+// which clients can map back to their input fields; otherwise it is the failure messages joined by "; ".
+type ValidationError struct {
+	Errors     []*FieldError `json:"validationErrors"`
+	structured bool
+}
+
+func newValidationError(config *ContractConfig) *ValidationError {
+	return &ValidationError{Errors: []*FieldError{}, structured: config.StructuredValidationErrors}
+}
+
+func (e *ValidationError) Error() string {
+	if e.structured {
+		errorJSON, err := json.Marshal(e)
+		if err == nil {
+			return string(errorJSON)
+		}
+	}
+
+	messages := make([]string, len(e.Errors))
+	for i, fieldError := range e.Errors {
+		messages[i] = fieldError.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// add records a failure when fieldError is not nil.
+func (e *ValidationError) add(fieldError *FieldError) {
+	if fieldError != nil {
+		e.Errors = append(e.Errors, fieldError)
+	}
+}
+
+// errorOrNil returns e if it holds any failures, so that callers can return it as an error.
+func (e *ValidationError) errorOrNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// isASCIIControl reports whether r is an ASCII control character.
+func isASCIIControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
+
+// sanitizeText validates a free-text field before it is stored. Invalid UTF-8 is always rejected,
+// while ASCII control characters are either stripped or rejected depending on strip. Multi-line
+// fields such as commit messages keep their newlines and tabs. label names the field in messages.
+func sanitizeText(field, label, value string, multiline, strip bool) (string, *FieldError) {
+	if !utf8.ValidString(value) {
+		return "", &FieldError{Field: field, Rule: "utf8", Message: fmt.Sprintf("the %s contains invalid UTF-8", label), Value: value}
+	}
+
+	var sanitized strings.Builder
+	for i, r := range value {
+		if !isASCIIControl(r) || (multiline && (r == '\n' || r == '\t')) {
+			sanitized.WriteRune(r)
+			continue
+		}
+		if !strip {
+			return "", &FieldError{Field: field, Rule: "controlCharacters", Message: fmt.Sprintf("the %s contains control character %U at offset %d", label, r, i), Value: value}
+		}
+	}
+
+	return sanitized.String(), nil
+}
+
+// validKeyPart rejects a value that is used to build world state keys if it contains a character the key
+// schemes reserve: the NUL byte separating composite key attributes, or U+10FFFF, which ends the range of a
+// partial composite key query. Either would make keys split or match wrongly without any error.
+func validKeyPart(field, label, value string) *FieldError {
+	for i, r := range value {
+		if r == 0 || r == utf8.MaxRune {
+			return &FieldError{Field: field, Rule: "reservedCharacter", Message: fmt.Sprintf("the %s contains reserved key delimiter %U at offset %d", label, r, i), Value: value}
+		}
+	}
+	return nil
+}
+
+// checkKeyParts validates the repository name and, unless it is empty, the commit hash of a call before they
+// are used in keys. Without repository scoped commits a hash is itself a key, so it must not start like the
+// keys of version and push records either.
+func checkKeyParts(config *ContractConfig, repository, commitHash string) error {
+	validation := newValidationError(config)
+	validation.add(validKeyPart("Repository", "repository name", repository))
+	if commitHash != "" {
+		fieldError := validKeyPart("CommitHash", "commit hash", commitHash)
+		if fieldError == nil && !config.RepositoryScopedCommits && !isCommitKey(commitHash) {
+			fieldError = &FieldError{Field: "CommitHash", Rule: "reservedPrefix", Message: fmt.Sprintf("the commit hash %s must not start with %s, %s or %s, which are reserved for version, push and blob records", commitHash, versionKeyPrefix, pushKeyPrefix, blobKeyPrefix), Value: commitHash}
+		}
+		validation.add(fieldError)
+	}
+	return validation.errorOrNil()
+}
+
+// requiredField returns a field error when a value that identifies a record is empty. Callers trim the value
+// first, so that whitespace alone does not count.
+func requiredField(field, label, value string) *FieldError {
+	if value != "" {
+		return nil
+	}
+	return &FieldError{Field: field, Rule: "required", Message: fmt.Sprintf("the %s must not be empty", label), Value: value}
+}
+
+// sanitizeCommitText applies sanitizeText to the free-text fields of a commit using the contract settings,
+// after trimming the surrounding whitespace of the author.
+// Failures of both fields are reported together.
+func sanitizeCommitText(config *ContractConfig, commitMessage, author string) (string, string, error) {
+	validation := newValidationError(config)
+	commitMessage, fieldError := sanitizeText("CommitMessage", "commit message", commitMessage, true, config.StripControlCharacters)
+	validation.add(fieldError)
+	author, fieldError = sanitizeText("Author", "author", strings.TrimSpace(author), false, config.StripControlCharacters)
+	validation.add(fieldError)
+	err := validation.errorOrNil()
+	if err != nil {
+		return "", "", err
+	}
+
+	return commitMessage, author, nil
+}
@@ -0,0 +1,181 @@
+package chaincode_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateGitCommitRejectsControlCharacters(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	err := gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial\x00commit", "Alice")
+	require.EqualError(t, err, "the commit message contains control character U+0000 at offset 7")
+
+	err = gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Al\tice")
+	require.EqualError(t, err, "the author contains control character U+0009 at offset 2")
+
+	err = gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial \xff commit", "Alice")
+	require.EqualError(t, err, "the commit message contains invalid UTF-8")
+
+	err = gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Al\xc3ice")
+	require.EqualError(t, err, "the author contains invalid UTF-8")
+
+	exists, err := gitContract.GitCommitExists(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestCreateGitCommitKeepsMultiLineMessages(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	message := "Add feature\n\n\tDetails of the change\nSigned-off-by: Alice"
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", message, "Alice"))
+
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, message, gitCommit.CommitMessage)
+}
+
+func TestCreateGitCommitStripsControlCharacters(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"StripControlCharacters": true}`))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial\x00 commit\x1b\nbody", "Al\tice\x7f"))
+
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, "Initial commit\nbody", gitCommit.CommitMessage)
+	require.Equal(t, "Alice", gitCommit.Author)
+
+	err = gitContract.CreateGitCommit(transactionContext, "hash2", "repo1", "Initial \xff commit", "Alice")
+	require.EqualError(t, err, "the commit message contains invalid UTF-8")
+}
+
+func TestStructuredValidationErrors(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	// Without the setting, the failures of several fields are joined into one plain message
+	err := gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial\x00commit", "Al\xc3ice")
+	require.EqualError(t, err, "the commit message contains control character U+0000 at offset 7; the author contains invalid UTF-8")
+
+	require.NoError(t, gitContract.SetContractConfig(transactionContext, `{"StructuredValidationErrors": true}`))
+	err = gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial\x00commit", "Al\tice")
+	require.JSONEq(t, `{"validationErrors": [
+		{"field": "CommitMessage", "rule": "controlCharacters", "message": "the commit message contains control character U+0000 at offset 7", "value": "Initial\u0000commit"},
+		{"field": "Author", "rule": "controlCharacters", "message": "the author contains control character U+0009 at offset 2", "value": "Al\tice"}
+	]}`, err.Error())
+
+	var validationError *chaincode.ValidationError
+	require.ErrorAs(t, err, &validationError)
+	require.Len(t, validationError.Errors, 2)
+
+	err = gitContract.CreateGitCommitWithDates(transactionContext, "hash1", "repo1", "Initial commit", "Alice", "yesterday", "2026-01-01")
+	require.ErrorAs(t, err, &validationError)
+	require.Equal(t, []string{"AuthorTimestamp", "CommitTimestamp"}, []string{validationError.Errors[0].Field, validationError.Errors[1].Field})
+	require.Equal(t, "rfc3339", validationError.Errors[0].Rule)
+	require.Equal(t, "2026-01-01", validationError.Errors[1].Value)
+	require.True(t, strings.HasPrefix(err.Error(), `{"validationErrors":[`))
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	err = gitContract.FlagSecurityIssue(transactionContext, "hash1", "severe", "ftp://example.com/advisory")
+	require.ErrorAs(t, err, &validationError)
+	require.Equal(t, "SecurityLevel", validationError.Errors[0].Field)
+	require.Equal(t, "oneOf", validationError.Errors[0].Rule)
+	require.Equal(t, "AdvisoryURL", validationError.Errors[1].Field)
+
+	// Failures that are not about the input stay plain
+	err = gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice")
+	require.EqualError(t, err, "the commit hash1 already exists")
+}
+
+func TestReservedKeyCharacters(t *testing.T) {
+	transactionContext, _, world := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	err := gitContract.CreateGitCommit(transactionContext, "hash\x001", "repo1", "Initial commit", "Alice")
+	require.EqualError(t, err, "the commit hash contains reserved key delimiter U+0000 at offset 4")
+
+	err = gitContract.CreateGitCommit(transactionContext, "hash1", "repo\U0010FFFF", "Initial commit", "Alice")
+	require.EqualError(t, err, "the repository name contains reserved key delimiter U+10FFFF at offset 4")
+
+	err = gitContract.CreateGitCommit(transactionContext, "VERSION_repo1", "repo1", "Initial commit", "Alice")
+	require.EqualError(t, err, "the commit hash VERSION_repo1 must not start with VERSION_, PUSH_ or BLOB_, which are reserved for version, push and blob records")
+
+	err = gitContract.CreateGitCommit(transactionContext, "PUSH_repo1", "re\x00po1", "Initial commit", "Alice")
+	require.EqualError(t, err, "the repository name contains reserved key delimiter U+0000 at offset 2; the commit hash PUSH_repo1 must not start with VERSION_, PUSH_ or BLOB_, which are reserved for version, push and blob records")
+	require.Empty(t, world.state)
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1\x00", "https://example.com/repo1.git", "hash1")
+	require.EqualError(t, err, "the repository name contains reserved key delimiter U+0000 at offset 5")
+
+	err = gitContract.RegisterRepository(transactionContext, "repo\x002")
+	require.EqualError(t, err, "the repository name contains reserved key delimiter U+0000 at offset 4")
+
+	_, err = gitContract.MigrateToRepositoryScopedCommits(transactionContext)
+	require.NoError(t, err)
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "PUSH_1", "repo1", "Scoped keys cannot collide", "Alice"))
+}
+
+func TestSurroundingWhitespaceIsTrimmed(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, " hash1\t", " repo1 ", "Initial commit", " Alice "))
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, "hash1", gitCommit.CommitHash)
+	require.Equal(t, "repo1", gitCommit.Repository)
+	require.Equal(t, "Alice", gitCommit.Author)
+
+	// " repo1 " and "repo1" are one repository
+	err = gitContract.CreateGitCommit(transactionContext, "hash1 ", "repo1", "Initial commit", "Alice")
+	require.EqualError(t, err, "the commit hash1 already exists")
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash2", "repo1 ", "Added feature", "Bob"))
+	gitCommits, err := gitContract.QueryCommitsByRepository(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1", "hash2"}, commitHashes(gitCommits))
+	gitCommits, err = gitContract.QueryCommitsByRepository(transactionContext, "  repo1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1", "hash2"}, commitHashes(gitCommits))
+
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1 ", "https://example.com/repo1.git", " hash2")
+	require.NoError(t, err)
+	pushTransactions, err := gitContract.GetPushTransactionsByRepository(transactionContext, " repo1")
+	require.NoError(t, err)
+	require.Len(t, pushTransactions, 1)
+	require.Equal(t, "repo1", pushTransactions[0].Repository)
+	require.Equal(t, "hash2", pushTransactions[0].CommitHash)
+	repoVersion, err := gitContract.GetRepositoryVersion(transactionContext, " repo1 ")
+	require.NoError(t, err)
+	require.Equal(t, 2, repoVersion.VersionNumber)
+
+	exists, err := gitContract.GitCommitExists(transactionContext, "hash2 ")
+	require.NoError(t, err)
+	require.True(t, exists)
+}
+
+func TestBlankIdentifiersAreRejected(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	err := gitContract.CreateGitCommit(transactionContext, " ", "repo1", "Initial commit", "Alice")
+	require.EqualError(t, err, "the commit hash must not be empty")
+	err = gitContract.CreateGitCommit(transactionContext, "hash1", "\t", "Initial commit", "Alice")
+	require.EqualError(t, err, "the repository name must not be empty")
+	err = gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "  ")
+	require.EqualError(t, err, "the author must not be empty")
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	_, err = gitContract.HandleGitPush(transactionContext, "repo1", "https://example.com/repo1.git", " ")
+	require.EqualError(t, err, "the commit hash must not be empty")
+	_, err = gitContract.HandleGitPush(transactionContext, " ", "https://example.com/repo1.git", "hash1")
+	require.EqualError(t, err, "the repository name must not be empty")
+}
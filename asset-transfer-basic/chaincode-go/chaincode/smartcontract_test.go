@@ -3,8 +3,11 @@ package chaincode_test
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
@@ -28,157 +31,245 @@ type stateQueryIterator interface {
 	shim.StateQueryIteratorInterface
 }
 
+//go:generate counterfeiter -o mocks/historyqueryiterator.go -fake-name HistoryQueryIterator . historyQueryIterator
+type historyQueryIterator interface {
+	shim.HistoryQueryIteratorInterface
+}
+
+//go:generate counterfeiter -o mocks/clientidentity.go -fake-name ClientIdentity . clientIdentity
+type clientIdentity interface {
+	cid.ClientIdentity
+}
+
+// readOnlyPrefixes and readOnlySuffixes name the functions that only read the world state, which must all be
+// tagged as evaluate so that clients following the metadata do not order empty transactions.
+var (
+	readOnlyPrefixes = []string{"Get", "Read", "List", "Query", "Validate", "Compute", "Verify"}
+	readOnlySuffixes = []string{"Exists", "Exist"}
+)
+
+func TestGetEvaluateTransactions(t *testing.T) {
+	gitContract := &chaincode.SmartContract{}
+	evaluate := map[string]bool{}
+	for _, name := range gitContract.GetEvaluateTransactions() {
+		require.False(t, evaluate[name], "%s is listed twice", name)
+		evaluate[name] = true
+	}
+
+	// Methods promoted from contractapi.Contract are not transactions
+	contractMethods := map[string]bool{}
+	contractType := reflect.TypeOf(&contractapi.Contract{})
+	for i := 0; i < contractType.NumMethod(); i++ {
+		contractMethods[contractType.Method(i).Name] = true
+	}
+
+	transactions := map[string]bool{}
+	smartContractType := reflect.TypeOf(gitContract)
+	for i := 0; i < smartContractType.NumMethod(); i++ {
+		name := smartContractType.Method(i).Name
+		if contractMethods[name] || name == "GetEvaluateTransactions" {
+			continue
+		}
+		transactions[name] = true
+
+		readOnly := false
+		for _, prefix := range readOnlyPrefixes {
+			readOnly = readOnly || strings.HasPrefix(name, prefix)
+		}
+		for _, suffix := range readOnlySuffixes {
+			readOnly = readOnly || strings.HasSuffix(name, suffix)
+		}
+		if readOnly {
+			require.True(t, evaluate[name], "%s only reads the world state but is not in GetEvaluateTransactions", name)
+		}
+	}
+
+	for name := range evaluate {
+		require.True(t, transactions[name], "GetEvaluateTransactions lists %s, which is not a transaction", name)
+	}
+}
+
 func TestInitLedger(t *testing.T) {
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
 
-	assetTransfer := chaincode.SmartContract{}
-	err := assetTransfer.InitLedger(transactionContext)
+	gitContract := chaincode.SmartContract{}
+	err := gitContract.InitLedger(transactionContext)
 	require.NoError(t, err)
 
 	chaincodeStub.PutStateReturns(fmt.Errorf("failed inserting key"))
-	err = assetTransfer.InitLedger(transactionContext)
+	err = gitContract.InitLedger(transactionContext)
 	require.EqualError(t, err, "failed to put to world state. failed inserting key")
 }
 
-func TestCreateAsset(t *testing.T) {
+func TestCreateGitCommit(t *testing.T) {
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	chaincodeStub.GetStateByPartialCompositeKeyReturns(&mocks.StateQueryIterator{}, nil)
 
-	assetTransfer := chaincode.SmartContract{}
-	err := assetTransfer.CreateAsset(transactionContext, "", "", 0, "", 0)
+	gitContract := chaincode.SmartContract{}
+	err := gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice")
 	require.NoError(t, err)
 
 	chaincodeStub.GetStateReturns([]byte{}, nil)
-	err = assetTransfer.CreateAsset(transactionContext, "asset1", "", 0, "", 0)
-	require.EqualError(t, err, "the asset asset1 already exists")
+	err = gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice")
+	require.EqualError(t, err, "the commit hash1 already exists")
 
-	chaincodeStub.GetStateReturns(nil, fmt.Errorf("unable to retrieve asset"))
-	err = assetTransfer.CreateAsset(transactionContext, "asset1", "", 0, "", 0)
-	require.EqualError(t, err, "failed to read from world state: unable to retrieve asset")
+	chaincodeStub.GetStateReturns(nil, fmt.Errorf("unable to retrieve commit"))
+	err = gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice")
+	require.EqualError(t, err, "failed to read from world state: unable to retrieve commit")
 }
 
-func TestReadAsset(t *testing.T) {
+func TestReadGitCommit(t *testing.T) {
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
 
-	expectedAsset := &chaincode.Asset{ID: "asset1"}
-	bytes, err := json.Marshal(expectedAsset)
+	expectedCommit := &chaincode.GitCommit{CommitHash: "hash1"}
+	bytes, err := json.Marshal(expectedCommit)
 	require.NoError(t, err)
 
 	chaincodeStub.GetStateReturns(bytes, nil)
-	assetTransfer := chaincode.SmartContract{}
-	asset, err := assetTransfer.ReadAsset(transactionContext, "")
+	gitContract := chaincode.SmartContract{}
+	gitCommit, err := gitContract.ReadGitCommit(transactionContext, "")
 	require.NoError(t, err)
-	require.Equal(t, expectedAsset, asset)
+	require.Equal(t, expectedCommit, gitCommit)
 
-	chaincodeStub.GetStateReturns(nil, fmt.Errorf("unable to retrieve asset"))
-	_, err = assetTransfer.ReadAsset(transactionContext, "")
-	require.EqualError(t, err, "failed to read from world state: unable to retrieve asset")
+	chaincodeStub.GetStateReturns(nil, fmt.Errorf("unable to retrieve commit"))
+	_, err = gitContract.ReadGitCommit(transactionContext, "")
+	require.EqualError(t, err, "failed to read from world state: unable to retrieve commit")
 
 	chaincodeStub.GetStateReturns(nil, nil)
-	asset, err = assetTransfer.ReadAsset(transactionContext, "asset1")
-	require.EqualError(t, err, "the asset asset1 does not exist")
-	require.Nil(t, asset)
+	gitCommit, err = gitContract.ReadGitCommit(transactionContext, "hash1")
+	require.EqualError(t, err, "the commit hash1 does not exist")
+	require.Nil(t, gitCommit)
 }
 
-func TestUpdateAsset(t *testing.T) {
+func TestGitCommitExists(t *testing.T) {
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
 
-	expectedAsset := &chaincode.Asset{ID: "asset1"}
-	bytes, err := json.Marshal(expectedAsset)
-	require.NoError(t, err)
-
-	chaincodeStub.GetStateReturns(bytes, nil)
-	assetTransfer := chaincode.SmartContract{}
-	err = assetTransfer.UpdateAsset(transactionContext, "", "", 0, "", 0)
+	gitContract := chaincode.SmartContract{}
+	chaincodeStub.GetStateReturns([]byte{}, nil)
+	exists, err := gitContract.GitCommitExists(transactionContext, "hash1")
 	require.NoError(t, err)
+	require.True(t, exists)
 
 	chaincodeStub.GetStateReturns(nil, nil)
-	err = assetTransfer.UpdateAsset(transactionContext, "asset1", "", 0, "", 0)
-	require.EqualError(t, err, "the asset asset1 does not exist")
+	exists, err = gitContract.GitCommitExists(transactionContext, "hash1")
+	require.NoError(t, err)
+	require.False(t, exists)
 
-	chaincodeStub.GetStateReturns(nil, fmt.Errorf("unable to retrieve asset"))
-	err = assetTransfer.UpdateAsset(transactionContext, "asset1", "", 0, "", 0)
-	require.EqualError(t, err, "failed to read from world state: unable to retrieve asset")
+	chaincodeStub.GetStateReturns(nil, fmt.Errorf("unable to retrieve commit"))
+	_, err = gitContract.GitCommitExists(transactionContext, "hash1")
+	require.EqualError(t, err, "failed to read from world state: unable to retrieve commit")
 }
 
-func TestDeleteAsset(t *testing.T) {
-	chaincodeStub := &mocks.ChaincodeStub{}
-	transactionContext := &mocks.TransactionContext{}
-	transactionContext.GetStubReturns(chaincodeStub)
+func TestGitCommitsExist(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
 
-	asset := &chaincode.Asset{ID: "asset1"}
-	bytes, err := json.Marshal(asset)
-	require.NoError(t, err)
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo1", "Fixed bug", "Carol"))
 
-	chaincodeStub.GetStateReturns(bytes, nil)
-	chaincodeStub.DelStateReturns(nil)
-	assetTransfer := chaincode.SmartContract{}
-	err = assetTransfer.DeleteAsset(transactionContext, "")
+	exists, err := gitContract.GitCommitsExist(transactionContext, "hash1, hash2,hash3,,VERSION_repo1")
 	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"hash1": true, "hash2": false, "hash3": true, "VERSION_repo1": false}, exists)
 
-	chaincodeStub.GetStateReturns(nil, nil)
-	err = assetTransfer.DeleteAsset(transactionContext, "asset1")
-	require.EqualError(t, err, "the asset asset1 does not exist")
-
-	chaincodeStub.GetStateReturns(nil, fmt.Errorf("unable to retrieve asset"))
-	err = assetTransfer.DeleteAsset(transactionContext, "")
-	require.EqualError(t, err, "failed to read from world state: unable to retrieve asset")
-}
+	hashes := make([]string, 501)
+	for i := range hashes {
+		hashes[i] = fmt.Sprintf("hash%d", i)
+	}
+	_, err = gitContract.GitCommitsExist(transactionContext, strings.Join(hashes, ","))
+	require.EqualError(t, err, "too many commit hashes (501), at most 500 can be checked per call")
 
-func TestTransferAsset(t *testing.T) {
-	chaincodeStub := &mocks.ChaincodeStub{}
-	transactionContext := &mocks.TransactionContext{}
-	transactionContext.GetStubReturns(chaincodeStub)
-
-	asset := &chaincode.Asset{ID: "asset1"}
-	bytes, err := json.Marshal(asset)
-	require.NoError(t, err)
-
-	chaincodeStub.GetStateReturns(bytes, nil)
-	assetTransfer := chaincode.SmartContract{}
-	_, err = assetTransfer.TransferAsset(transactionContext, "", "")
+	exists, err = gitContract.GitCommitsExist(transactionContext, strings.Join(hashes[:500], ","))
 	require.NoError(t, err)
-
-	chaincodeStub.GetStateReturns(nil, fmt.Errorf("unable to retrieve asset"))
-	_, err = assetTransfer.TransferAsset(transactionContext, "", "")
-	require.EqualError(t, err, "failed to read from world state: unable to retrieve asset")
+	require.Len(t, exists, 500)
+	require.True(t, exists["hash1"])
 }
 
-func TestGetAllAssets(t *testing.T) {
-	asset := &chaincode.Asset{ID: "asset1"}
-	bytes, err := json.Marshal(asset)
+func TestGetAllGitCommits(t *testing.T) {
+	gitCommit := &chaincode.GitCommit{CommitHash: "hash1"}
+	bytes, err := json.Marshal(gitCommit)
 	require.NoError(t, err)
 
 	iterator := &mocks.StateQueryIterator{}
 	iterator.HasNextReturnsOnCall(0, true)
 	iterator.HasNextReturnsOnCall(1, false)
-	iterator.NextReturns(&queryresult.KV{Value: bytes}, nil)
+	iterator.NextReturns(&queryresult.KV{Key: "hash1", Value: bytes}, nil)
 
 	chaincodeStub := &mocks.ChaincodeStub{}
 	transactionContext := &mocks.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
 
 	chaincodeStub.GetStateByRangeReturns(iterator, nil)
-	assetTransfer := &chaincode.SmartContract{}
-	assets, err := assetTransfer.GetAllAssets(transactionContext)
+	gitContract := &chaincode.SmartContract{}
+	gitCommits, err := gitContract.GetAllGitCommits(transactionContext)
 	require.NoError(t, err)
-	require.Equal(t, []*chaincode.Asset{asset}, assets)
+	require.Equal(t, []*chaincode.GitCommit{gitCommit}, gitCommits)
 
 	iterator.HasNextReturns(true)
 	iterator.NextReturns(nil, fmt.Errorf("failed retrieving next item"))
-	assets, err = assetTransfer.GetAllAssets(transactionContext)
+	gitCommits, err = gitContract.GetAllGitCommits(transactionContext)
 	require.EqualError(t, err, "failed retrieving next item")
-	require.Nil(t, assets)
+	require.Nil(t, gitCommits)
+
+	chaincodeStub.GetStateByRangeReturns(nil, fmt.Errorf("failed retrieving all commits"))
+	gitCommits, err = gitContract.GetAllGitCommits(transactionContext)
+	require.EqualError(t, err, "failed retrieving all commits")
+	require.Nil(t, gitCommits)
+}
+
+func TestIncrementVersionNumberCAS(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+
+	version, err := gitContract.IncrementVersionNumberCAS(transactionContext, "repo1", 1)
+	require.NoError(t, err)
+	require.Equal(t, 2, version)
+
+	_, err = gitContract.IncrementVersionNumberCAS(transactionContext, "repo1", 1)
+	require.EqualError(t, err, "version conflict on repository repo1: expected version 1, current version is 2")
+	var conflict *chaincode.VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	require.Equal(t, 2, conflict.CurrentVersion)
+
+	repoVersion, err := gitContract.GetRepositoryVersion(transactionContext, "repo1")
+	require.NoError(t, err)
+	require.Equal(t, 2, repoVersion.VersionNumber)
+
+	_, err = gitContract.IncrementVersionNumberCAS(transactionContext, "repo2", 1)
+	require.EqualError(t, err, "the repository repo2 does not have a version number")
+}
 
-	chaincodeStub.GetStateByRangeReturns(nil, fmt.Errorf("failed retrieving all assets"))
-	assets, err = assetTransfer.GetAllAssets(transactionContext)
-	require.EqualError(t, err, "failed retrieving all assets")
-	require.Nil(t, assets)
+func TestReadGitCommits(t *testing.T) {
+	transactionContext, _, _ := prepWorldState()
+	gitContract := chaincode.SmartContract{}
+
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash1", "repo1", "Initial commit", "Alice"))
+	require.NoError(t, gitContract.CreateGitCommit(transactionContext, "hash3", "repo1", "Fixed bug", "Carol"))
+
+	batch, err := gitContract.ReadGitCommits(transactionContext, "hash3, hash2,hash1,,hash3,VERSION_repo1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash3", "hash1"}, commitHashes(batch.Commits))
+	require.Equal(t, "Fixed bug", batch.Commits[0].CommitMessage)
+	require.Equal(t, []string{"hash2", "VERSION_repo1"}, batch.NotFound)
+
+	batch, err = gitContract.ReadGitCommits(transactionContext, "")
+	require.NoError(t, err)
+	require.Empty(t, batch.Commits)
+	require.Empty(t, batch.NotFound)
+
+	hashes := make([]string, 101)
+	for i := range hashes {
+		hashes[i] = fmt.Sprintf("hash%d", i)
+	}
+	_, err = gitContract.ReadGitCommits(transactionContext, strings.Join(hashes, ","))
+	require.EqualError(t, err, "too many commit hashes (101), at most 100 can be read per call")
 }
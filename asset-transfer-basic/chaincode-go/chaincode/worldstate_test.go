@@ -0,0 +1,290 @@
+package chaincode_test
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode/mocks"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+/*
+worldState backs the counterfeiter ChaincodeStub with an in-memory key/value
+store so that tests can exercise functions which read back what they wrote,
+scan ranges or walk composite key indexes. Every write is also kept in the
+history of its key, stamped with the stub's transaction ID and time.
+
+Like the peer, reads only see the state committed before the transaction
+started: a write shows up in state straight away, for tests to inspect, but
+GetState and the queries serve committed until the next transaction. Each
+call into the contract from a test is a transaction of its own; see begin.
+*/
+type worldState struct {
+	state     map[string][]byte
+	committed map[string][]byte
+	history   map[string][]*queryresult.KeyModification
+	pending   []pendingModification
+	stub      *mocks.ChaincodeStub
+
+	// callSite and entry identify the contract call of the current transaction
+	callSite uintptr
+	entry    string
+}
+
+// pendingModification is a write of the current transaction, which joins the history of its key on commit.
+type pendingModification struct {
+	key          string
+	modification *queryresult.KeyModification
+}
+
+const adminMSPID = "Org1MSP"
+
+// prepWorldState returns mocks whose stub reads and writes an empty in-memory world state.
+// The client identity belongs to the default admin organization and transactions are stamped with the current time.
+func prepWorldState() (*mocks.TransactionContext, *mocks.ChaincodeStub, *worldState) {
+	chaincodeStub := &mocks.ChaincodeStub{}
+	world := &worldState{state: map[string][]byte{}, committed: map[string][]byte{}, history: map[string][]*queryresult.KeyModification{}, stub: chaincodeStub}
+
+	chaincodeStub.GetStateStub = world.getState
+	chaincodeStub.PutStateStub = world.putState
+	chaincodeStub.DelStateStub = world.delState
+	chaincodeStub.GetStateByRangeStub = world.getStateByRange
+	chaincodeStub.GetStateByRangeWithPaginationStub = world.getStateByRangeWithPagination
+	chaincodeStub.CreateCompositeKeyStub = createCompositeKey
+	chaincodeStub.SplitCompositeKeyStub = splitCompositeKey
+	chaincodeStub.GetStateByPartialCompositeKeyStub = world.getStateByPartialCompositeKey
+	chaincodeStub.GetStateByPartialCompositeKeyWithPaginationStub = world.getStateByPartialCompositeKeyWithPagination
+	chaincodeStub.GetHistoryForKeyStub = world.getHistoryForKey
+	setTxTime(chaincodeStub, time.Now())
+
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	setClientMSPID(transactionContext, adminMSPID)
+	return transactionContext, chaincodeStub, world
+}
+
+// setTxTime makes subsequent transactions carry the given timestamp.
+func setTxTime(chaincodeStub *mocks.ChaincodeStub, at time.Time) {
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(at), nil)
+}
+
+// setClientMSPID makes subsequent calls appear to come from a client of the given organization.
+func setClientMSPID(transactionContext *mocks.TransactionContext, mspID string) {
+	clientIdentity := &mocks.ClientIdentity{}
+	clientIdentity.GetMSPIDReturns(mspID, nil)
+	transactionContext.GetClientIdentityReturns(clientIdentity)
+}
+
+const chaincodePackage = "github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode."
+
+/*
+begin commits the writes of the previous transaction when the stub is used by
+a new one. The stub does not know when a transaction ends, so begin tells from
+the stack: a transaction is a call into the chaincode package from a test, and
+a new one starts when the call comes from another line of a test, or when the
+stub is reached through the very same frames as the first call of the current
+transaction, as when a test calls the contract in a loop. A contract that
+reaches the stub through the same frames twice, in a loop of its own, commits
+early, which at worst lets it read its own writes as tests did before.
+*/
+func (w *worldState) begin() {
+	callSite, entry := contractCall()
+	if callSite != 0 && callSite == w.callSite && entry != w.entry {
+		return
+	}
+	w.callSite, w.entry = callSite, entry
+
+	w.committed = make(map[string][]byte, len(w.state))
+	for key, value := range w.state {
+		w.committed[key] = value
+	}
+	for _, pending := range w.pending {
+		w.history[pending.key] = append(w.history[pending.key], pending.modification)
+	}
+	w.pending = nil
+}
+
+// contractCall returns the test line that called into the chaincode package and the frames from there to the
+// stub, or zero when the stub is used outside of a contract call.
+func contractCall() (uintptr, string) {
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(3, pcs)]
+	frames := runtime.CallersFrames(pcs)
+	inContract := false
+	for i := 0; ; i++ {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, chaincodePackage) && !strings.HasSuffix(frame.File, "_test.go") {
+			inContract = true
+		} else if inContract && strings.HasSuffix(frame.File, "_test.go") {
+			return pcs[i], fmt.Sprint(pcs[:i+1])
+		}
+		if !more {
+			return 0, ""
+		}
+	}
+}
+
+func (w *worldState) getState(key string) ([]byte, error) {
+	w.begin()
+	return w.committed[key], nil
+}
+
+func (w *worldState) putState(key string, value []byte) error {
+	w.begin()
+	w.state[key] = value
+	w.record(key, value, false)
+	return nil
+}
+
+func (w *worldState) delState(key string) error {
+	w.begin()
+	delete(w.state, key)
+	w.record(key, nil, true)
+	return nil
+}
+
+func (w *worldState) record(key string, value []byte, isDelete bool) {
+	timestamp, _ := w.stub.GetTxTimestamp()
+	modification := &queryresult.KeyModification{TxId: w.stub.GetTxID(), Value: value, Timestamp: timestamp, IsDelete: isDelete}
+	w.pending = append(w.pending, pendingModification{key: key, modification: modification})
+}
+
+// getHistoryForKey returns the committed changes of a key newest first, as the peer does.
+func (w *worldState) getHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	w.begin()
+	var modifications []*queryresult.KeyModification
+	for i := len(w.history[key]) - 1; i >= 0; i-- {
+		modifications = append(modifications, w.history[key][i])
+	}
+
+	iterator := &mocks.HistoryQueryIterator{}
+	iterator.HasNextStub = func() bool {
+		return len(modifications) > 0
+	}
+	iterator.NextStub = func() (*queryresult.KeyModification, error) {
+		modification := modifications[0]
+		modifications = modifications[1:]
+		return modification, nil
+	}
+	return iterator, nil
+}
+
+func (w *worldState) getStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	w.begin()
+	keys := map[string]bool{}
+	for _, key := range w.keys(startKey, endKey) {
+		keys[key] = true
+	}
+	return w.iterator(func(key string) bool {
+		return keys[key]
+	}), nil
+}
+
+// keys returns the sorted committed keys of a range. Like the peer, an empty start key skips the composite key
+// namespace.
+func (w *worldState) keys(startKey, endKey string) []string {
+	if startKey == "" {
+		startKey = "\x01"
+	}
+	if endKey == "" {
+		endKey = string(utf8.MaxRune)
+	}
+
+	var keys []string
+	for key := range w.committed {
+		if key >= startKey && key < endKey {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// getStateByRangeWithPagination uses the first key of the next page as the bookmark.
+func (w *worldState) getStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	w.begin()
+	if bookmark != "" {
+		startKey = bookmark
+	}
+	keys := w.keys(startKey, endKey)
+
+	metadata := &peer.QueryResponseMetadata{}
+	if len(keys) > int(pageSize) {
+		metadata.Bookmark = keys[pageSize]
+		keys = keys[:pageSize]
+	}
+	metadata.FetchedRecordsCount = int32(len(keys))
+
+	page := map[string]bool{}
+	for _, key := range keys {
+		page[key] = true
+	}
+	return w.iterator(func(key string) bool {
+		return page[key]
+	}), metadata, nil
+}
+
+func (w *worldState) getStateByPartialCompositeKey(objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+	prefix, err := createCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return w.iterator(func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	}), nil
+}
+
+func (w *worldState) getStateByPartialCompositeKeyWithPagination(objectType string, attributes []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	prefix, err := createCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if bookmark == "" {
+		bookmark = prefix
+	}
+	return w.getStateByRangeWithPagination(bookmark, prefix+string(utf8.MaxRune), pageSize, "")
+}
+
+// iterator walks the committed keys that match, in order.
+func (w *worldState) iterator(match func(key string) bool) *mocks.StateQueryIterator {
+	w.begin()
+	committed := w.committed
+	var keys []string
+	for key := range committed {
+		if match(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	iterator := &mocks.StateQueryIterator{}
+	iterator.HasNextStub = func() bool {
+		return len(keys) > 0
+	}
+	iterator.NextStub = func() (*queryresult.KV, error) {
+		key := keys[0]
+		keys = keys[1:]
+		return &queryresult.KV{Key: key, Value: committed[key]}, nil
+	}
+	return iterator
+}
+
+func createCompositeKey(objectType string, attributes []string) (string, error) {
+	key := "\x00" + objectType + "\x00"
+	for _, attribute := range attributes {
+		key += attribute + "\x00"
+	}
+	return key, nil
+}
+
+func splitCompositeKey(compositeKey string) (string, []string, error) {
+	parts := strings.Split(strings.TrimPrefix(compositeKey, "\x00"), "\x00")
+	return parts[0], parts[1 : len(parts)-1], nil
+}